@@ -3,6 +3,7 @@ package main
 import (
 	"moodtracker/internal/api"
 	"moodtracker/internal/config"
+	"strings"
 )
 
 func main() {
@@ -18,6 +19,61 @@ func main() {
 	cfg.Limiter.RPS = c.RateLimiter.RPS
 	cfg.Limiter.Burst = c.RateLimiter.Burst
 	cfg.Limiter.Enabled = c.RateLimiter.Enabled
+	cfg.Security.SecretKey = c.Security.SecretKey
+	cfg.Security.PasswordResetTTL = c.Security.PasswordResetTTL
+	cfg.Security.EmailChangeTTL = c.Security.EmailChangeTTL
+	cfg.Security.AccessTokenTTL = c.Security.AccessTokenTTL
+	cfg.Security.RefreshTokenTTL = c.Security.RefreshTokenTTL
+	cfg.Security.JWTIssuer = c.Security.JWTIssuer
+	cfg.Security.JWTAudience = c.Security.JWTAudience
+	cfg.Security.JWTAlgorithm = c.Security.JWTAlgorithm
+	cfg.Security.JWTKeys = c.Security.JWTKeys
+	cfg.Security.JWTActiveKeyID = c.Security.JWTActiveKeyID
+	cfg.Password.Algorithm = c.Password.Algorithm
+	cfg.Password.BcryptCost = c.Password.BcryptCost
+	cfg.Password.Argon2Memory = c.Password.Argon2Memory
+	cfg.Password.Argon2Iterations = c.Password.Argon2Iterations
+	cfg.Password.Argon2Parallelism = c.Password.Argon2Parallelism
+	cfg.Password.MinEntropy = c.Password.MinEntropy
+	cfg.Password.BreachCorpus = c.Password.BreachCorpus
+	cfg.MFA.Issuer = c.MFA.Issuer
+	cfg.MFA.TokenTTL = c.MFA.TokenTTL
+	cfg.OIDC.Issuer = c.OIDC.Issuer
+	cfg.OIDC.ClientID = c.OIDC.ClientID
+	cfg.OIDC.ClientSecret = c.OIDC.ClientSecret
+	cfg.OIDC.RedirectURL = c.OIDC.RedirectURL
+	cfg.OIDC.Scopes = strings.Fields(c.OIDC.Scopes)
+	cfg.OIDC.StateTTL = c.OIDC.StateTTL
+	cfg.Activation.CodeTTL = c.Activation.CodeTTL
+	cfg.Activation.MaxAttempts = c.Activation.MaxAttempts
+	cfg.Login.BackoffAfter = c.Login.BackoffAfter
+	cfg.Login.BackoffBase = c.Login.BackoffBase
+	cfg.Login.LockoutAfter = c.Login.LockoutAfter
+	cfg.Login.LockoutDuration = c.Login.LockoutDuration
+	cfg.Mood.Scale = c.Mood.Scale
+	cfg.Trash.RetentionDays = c.Trash.RetentionDays
+	cfg.Trash.PurgeInterval = c.Trash.PurgeInterval
+	cfg.Attachments.Driver = c.Attachments.Driver
+	cfg.Attachments.Dir = c.Attachments.Dir
+	cfg.Attachments.MaxSize = c.Attachments.MaxSize
+	cfg.Attachments.AllowedTypes = strings.Fields(c.Attachments.AllowedTypes)
+	cfg.Attachments.URLTTL = c.Attachments.URLTTL
+	cfg.Attachments.S3.Endpoint = c.Attachments.S3Endpoint
+	cfg.Attachments.S3.Region = c.Attachments.S3Region
+	cfg.Attachments.S3.Bucket = c.Attachments.S3Bucket
+	cfg.Attachments.S3.AccessKey = c.Attachments.S3AccessKey
+	cfg.Attachments.S3.SecretKey = c.Attachments.S3SecretKey
+	cfg.Attachments.S3.PathStyle = c.Attachments.S3PathStyle
+	cfg.Reminders.Interval = c.Reminders.Interval
+	cfg.Reminders.BatchSize = c.Reminders.BatchSize
+	cfg.Reminders.Channels = strings.Fields(c.Reminders.Channels)
+	cfg.Mailer.Driver = c.Mailer.Driver
+	cfg.Mailer.Host = c.Mailer.Host
+	cfg.Mailer.Port = c.Mailer.Port
+	cfg.Mailer.Username = c.Mailer.Username
+	cfg.Mailer.Password = c.Mailer.Password
+	cfg.Mailer.Sender = c.Mailer.Sender
+	cfg.Mailer.FileDir = c.Mailer.FileDir
 
 	app := api.NewApp(cfg)
 	err := app.Server()
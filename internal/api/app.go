@@ -4,8 +4,15 @@ import (
 	"context"
 	"database/sql"
 	"expvar"
+	"moodtracker/internal/blob"
 	"moodtracker/internal/config"
+	"moodtracker/internal/hashing"
 	"moodtracker/internal/jsonlog"
+	"moodtracker/internal/mailer"
+	"moodtracker/internal/models"
+	"moodtracker/internal/notify"
+	"moodtracker/internal/passwords"
+	"moodtracker/internal/signing"
 	"os"
 	"runtime"
 	"sync"
@@ -13,10 +20,15 @@ import (
 )
 
 type application struct {
-	config config.Config
-	Logger jsonlog.Logger
-	wg     sync.WaitGroup
-	db     *sql.DB
+	config   config.Config
+	Logger   jsonlog.Logger
+	wg       sync.WaitGroup
+	db       *sql.DB
+	mailer   mailer.Mailer
+	store    blob.Store
+	channels notify.Channels
+	keys     *signing.KeySet
+	policy   *passwords.Policy
 }
 
 const version = "1.0.0"
@@ -31,6 +43,45 @@ func NewApp(cfg config.Config) *application {
 
 	logger.PrintInfo("database connection pool established", nil)
 
+	m, err := mailer.New(cfg, logger)
+	if err != nil {
+		logger.PrintFatal(err, nil)
+	}
+
+	store, err := blob.New(cfg)
+	if err != nil {
+		logger.PrintFatal(err, nil)
+	}
+
+	channels, err := notify.New(cfg, m)
+	if err != nil {
+		logger.PrintFatal(err, nil)
+	}
+
+	keys, err := signing.New(cfg)
+	if err != nil {
+		logger.PrintFatal(err, nil)
+	}
+
+	if err := hashing.Configure(cfg); err != nil {
+		logger.PrintFatal(err, nil)
+	}
+
+	policy, err := passwords.NewPolicy(cfg)
+	if err != nil {
+		logger.PrintFatal(err, nil)
+	}
+
+	moodScale, err := models.ParseMoodScale(cfg.Mood.Scale)
+	if err != nil {
+		logger.PrintFatal(err, nil)
+	}
+	models.SetMoodScale(moodScale)
+
+	if err := checkStoredMoods(db, moodScale); err != nil {
+		logger.PrintFatal(err, nil)
+	}
+
 	expvar.NewString("version").Set(version)
 
 	expvar.Publish("goroutines", expvar.Func(func() any {
@@ -45,9 +96,14 @@ func NewApp(cfg config.Config) *application {
 		return time.Now().Unix()
 	}))
 	return &application{
-		config: cfg,
-		Logger: logger,
-		db:     db,
+		config:   cfg,
+		Logger:   logger,
+		db:       db,
+		mailer:   m,
+		store:    store,
+		channels: channels,
+		keys:     keys,
+		policy:   policy,
 	}
 }
 
@@ -76,3 +132,23 @@ func openDB(cfg config.Config) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// checkStoredMoods makes sure every mood saved in day logs and their
+// revisions has a level in the configured scale.
+func checkStoredMoods(db *sql.DB, scale models.MoodScale) error {
+	query := `
+		SELECT coalesce(greatest(
+			(SELECT max(mood_label) FROM day_logs),
+			(SELECT max(mood_label) FROM day_log_revisions)
+		), 0)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var highest models.MoodLabel
+	if err := db.QueryRowContext(ctx, query).Scan(&highest); err != nil {
+		return err
+	}
+
+	return scale.CheckStored(highest)
+}
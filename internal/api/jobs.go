@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"moodtracker/internal/repositories"
+	"moodtracker/internal/services"
+	"strconv"
+	"time"
+)
+
+// startJobs runs the periodic maintenance tasks until ctx is cancelled. They
+// are tracked by app.wg so shutdown waits for a run in progress.
+func (app *application) startJobs(ctx context.Context) {
+	r := repositories.NewRepository(app.Logger, app.db)
+
+	if app.config.Trash.RetentionDays > 0 && app.config.Trash.PurgeInterval > 0 {
+		trash := services.NewTrashService(r.Trash, r.DayLog, r.Tag, r.Attachment, app.store, app.db, app.config)
+
+		app.every(ctx, app.config.Trash.PurgeInterval, func() {
+			app.purgeTrash(trash)
+		})
+	}
+
+	if app.config.Reminders.Interval > 0 {
+		reminders := services.NewReminderService(r.Reminder, r.User, app.channels, app.db, app.config)
+
+		app.every(ctx, app.config.Reminders.Interval, func() {
+			app.sendReminders(ctx, reminders)
+		})
+	}
+}
+
+func (app *application) every(ctx context.Context, interval time.Duration, fn func()) {
+	app.wg.Add(1)
+
+	go func() {
+		defer app.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			fn()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (app *application) purgeTrash(trash services.TrashService) {
+	daylogs, tags, attachments, err := trash.Purge(time.Now())
+	if err != nil {
+		app.Logger.PrintError(err, map[string]string{"job": "trash_purge"})
+		return
+	}
+
+	if daylogs > 0 || tags > 0 || attachments > 0 {
+		app.Logger.PrintInfo("trash purged", map[string]string{
+			"day_logs":    strconv.FormatInt(daylogs, 10),
+			"tags":        strconv.FormatInt(tags, 10),
+			"attachments": strconv.FormatInt(attachments, 10),
+		})
+	}
+}
+
+func (app *application) sendReminders(ctx context.Context, reminders services.ReminderService) {
+	sent, skipped, err := reminders.Dispatch(ctx, time.Now())
+	if err != nil {
+		app.Logger.PrintError(err, map[string]string{"job": "reminders"})
+	}
+
+	if sent > 0 || skipped > 0 {
+		app.Logger.PrintInfo("reminders dispatched", map[string]string{
+			"sent":    strconv.Itoa(sent),
+			"skipped": strconv.Itoa(skipped),
+		})
+	}
+}
@@ -20,6 +20,12 @@ func (app *application) Server() error {
 		app.db,
 		app.Logger,
 		app.config,
+		app.mailer,
+		app.store,
+		app.channels,
+		app.keys,
+		app.policy,
+		&app.wg,
 	)
 
 	srv := &http.Server{
@@ -31,6 +37,11 @@ func (app *application) Server() error {
 		WriteTimeout: 30 * time.Second,
 	}
 
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	app.startJobs(jobsCtx)
+
 	shutdownError := make(chan error)
 
 	go func() {
@@ -52,6 +63,8 @@ func (app *application) Server() error {
 
 		defer app.db.Close()
 
+		stopJobs()
+
 		app.Logger.PrintInfo("completing background tasks", map[string]string{
 			"addr": srv.Addr,
 		})
@@ -0,0 +1,58 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"moodtracker/internal/config"
+	"path"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Store keeps the content of attachments. Keys are slash separated paths made
+// of the ids of the owner, the log and the attachment.
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+func New(cfg config.Config) (Store, error) {
+	switch cfg.Attachments.Driver {
+	case "local", "":
+		return NewLocalStore(cfg.Attachments.Dir)
+	case "s3":
+		return NewS3Store(S3Config{
+			Endpoint:  cfg.Attachments.S3.Endpoint,
+			Region:    cfg.Attachments.S3.Region,
+			Bucket:    cfg.Attachments.S3.Bucket,
+			AccessKey: cfg.Attachments.S3.AccessKey,
+			SecretKey: cfg.Attachments.S3.SecretKey,
+			PathStyle: cfg.Attachments.S3.PathStyle,
+		}, nil)
+	default:
+		return nil, fmt.Errorf("unknown blob store driver %q", cfg.Attachments.Driver)
+	}
+}
+
+// validKey rejects keys that could escape the store root once joined to a
+// path or URL.
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key {
+		return ErrInvalidKey
+	}
+
+	for _, part := range strings.Split(key, "/") {
+		if part == "." || part == ".." {
+			return ErrInvalidKey
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,298 @@
+package blob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testAccessKey = "AKIDEXAMPLE"
+	testSecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	testRegion    = "us-east-1"
+)
+
+// fakeS3 is an in-memory stand-in for an S3 server such as MinIO. It checks
+// the SigV4 signature of every request the way the server would and answers
+// 403 when it does not match.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	f := &fakeS3{
+		objects: map[string][]byte{},
+		types:   map[string]string{},
+	}
+
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	return f, srv
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := verifySignature(r); err != nil {
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, "<Error><Code>SignatureDoesNotMatch</Code></Error>")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = body
+		f.types[r.URL.Path] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "<Error><Code>NoSuchKey</Code></Error>")
+			return
+		}
+		w.Write(body)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// verifySignature recomputes the signature from the request as received,
+// following the AWS Signature Version 4 documentation.
+func verifySignature(r *http.Request) error {
+	auth := r.Header.Get("Authorization")
+	prefix := "AWS4-HMAC-SHA256 "
+	if !strings.HasPrefix(auth, prefix) {
+		return errors.New("missing AWS4-HMAC-SHA256 authorization")
+	}
+
+	fields := map[string]string{}
+	for _, part := range strings.Split(strings.TrimPrefix(auth, prefix), ", ") {
+		name, value, _ := strings.Cut(part, "=")
+		fields[name] = value
+	}
+
+	credential := strings.Split(fields["Credential"], "/")
+	if len(credential) != 5 || credential[0] != testAccessKey || credential[2] != testRegion ||
+		credential[3] != "s3" || credential[4] != "aws4_request" {
+		return errors.New("bad credential scope " + fields["Credential"])
+	}
+
+	amzDate := r.Header.Get("X-Amz-Date")
+	if !strings.HasPrefix(amzDate, credential[1]) {
+		return errors.New("x-amz-date does not match the credential date")
+	}
+
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash == "" {
+		return errors.New("missing x-amz-content-sha256")
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, name := range strings.Split(fields["SignedHeaders"], ";") {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		r.URL.RawQuery,
+		canonicalHeaders.String(),
+		fields["SignedHeaders"],
+		payloadHash,
+	}, "\n")
+
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		strings.Join(credential[1:], "/"),
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := []byte("AWS4" + testSecretKey)
+	for _, part := range []string{credential[1], testRegion, "s3", "aws4_request"} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign))
+	if want := hex.EncodeToString(mac.Sum(nil)); fields["Signature"] != want {
+		return errors.New("signature does not match")
+	}
+
+	return nil
+}
+
+func newTestS3Store(t *testing.T, endpoint string) *s3Store {
+	t.Helper()
+
+	store, err := NewS3Store(S3Config{
+		Endpoint:  endpoint,
+		Region:    testRegion,
+		Bucket:    "moodtracker",
+		AccessKey: testAccessKey,
+		SecretKey: testSecretKey,
+		PathStyle: true,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.now = func() time.Time {
+		return time.Date(2026, 2, 10, 21, 3, 0, 0, time.UTC)
+	}
+
+	return store
+}
+
+func TestS3StoreRoundTrip(t *testing.T) {
+	fake, srv := newFakeS3(t)
+	store := newTestS3Store(t, srv.URL)
+	ctx := context.Background()
+	key := "user/log/voice note.m4a"
+
+	err := store.Put(ctx, key, strings.NewReader("hello"), 5, "audio/mp4")
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	path := "/moodtracker/user/log/voice note.m4a"
+	if got := string(fake.objects[path]); got != "hello" {
+		t.Fatalf("stored %q at %s, want %q", got, path, "hello")
+	}
+	if got := fake.types[path]; got != "audio/mp4" {
+		t.Errorf("stored content type %q, want audio/mp4", got)
+	}
+
+	rc, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	body, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(body) != "hello" {
+		t.Errorf("Get returned %q, want %q", body, "hello")
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete returned %v, want ErrNotFound", err)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("Delete of a missing key returned %v, want nil", err)
+	}
+}
+
+func TestS3StoreSignatureHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		if err := verifySignature(r); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	store := newTestS3Store(t, srv.URL)
+
+	if err := store.Delete(context.Background(), "user/log/file"); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := got.Get("X-Amz-Date"); v != "20260210T210300Z" {
+		t.Errorf("X-Amz-Date = %q, want 20260210T210300Z", v)
+	}
+
+	if v := got.Get("X-Amz-Content-Sha256"); v != emptyPayloadHash {
+		t.Errorf("X-Amz-Content-Sha256 = %q, want the empty payload hash", v)
+	}
+
+	wantPrefix := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20260210/us-east-1/s3/aws4_request, " +
+		"SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="
+	if v := got.Get("Authorization"); !strings.HasPrefix(v, wantPrefix) {
+		t.Errorf("Authorization = %q, want prefix %q", v, wantPrefix)
+	}
+
+	if err := store.Put(context.Background(), "user/log/file", strings.NewReader("x"), 1, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := got.Get("X-Amz-Content-Sha256"); v != unsignedPayload {
+		t.Errorf("Put X-Amz-Content-Sha256 = %q, want %s", v, unsignedPayload)
+	}
+}
+
+func TestS3StoreRejectsBadSignature(t *testing.T) {
+	_, srv := newFakeS3(t)
+	store := newTestS3Store(t, srv.URL)
+	store.cfg.SecretKey = "wrong"
+
+	err := store.Put(context.Background(), "user/log/file", strings.NewReader("x"), 1, "image/png")
+	if err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("Put with a wrong secret returned %v, want a 403 error", err)
+	}
+
+	if !strings.Contains(err.Error(), "403") {
+		t.Errorf("error %q does not mention the 403 status", err)
+	}
+}
+
+func TestSigningKeyDerivation(t *testing.T) {
+	// Example from the AWS Signature Version 4 documentation.
+	key := hmacSHA256([]byte("AWS4"+testSecretKey), "20120215")
+	key = hmacSHA256(key, "us-east-1")
+	key = hmacSHA256(key, "iam")
+	key = hmacSHA256(key, "aws4_request")
+
+	want := "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d"
+	if got := hex.EncodeToString(key); got != want {
+		t.Errorf("signing key = %s, want %s", got, want)
+	}
+}
+
+func TestURIEncode(t *testing.T) {
+	tests := []struct {
+		in          string
+		encodeSlash bool
+		want        string
+	}{
+		{"user/log/file", false, "user/log/file"},
+		{"a b/c", false, "a%20b/c"},
+		{"a/b", true, "a%2Fb"},
+		{"ação~-_.", false, "a%C3%A7%C3%A3o~-_."},
+		{"a+b=c", false, "a%2Bb%3Dc"},
+	}
+
+	for _, tt := range tests {
+		if got := uriEncode(tt.in, tt.encodeSlash); got != tt.want {
+			t.Errorf("uriEncode(%q, %v) = %q, want %q", tt.in, tt.encodeSlash, got, tt.want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"log"
+	"time"
 
 	"github.com/joeshaw/envdecode"
 )
@@ -24,7 +25,83 @@ type Config struct {
 		TrustedOrigins []string
 	}
 	Security struct {
-		SecretKey string
+		SecretKey        string
+		PasswordResetTTL time.Duration
+		EmailChangeTTL   time.Duration
+		AccessTokenTTL   time.Duration
+		RefreshTokenTTL  time.Duration
+		JWTIssuer        string
+		JWTAudience      string
+		JWTAlgorithm     string
+		JWTKeys          string
+		JWTActiveKeyID   string
+	}
+	Activation struct {
+		CodeTTL     time.Duration
+		MaxAttempts int
+	}
+	Password struct {
+		Algorithm         string
+		BcryptCost        int
+		Argon2Memory      uint32
+		Argon2Iterations  uint32
+		Argon2Parallelism uint8
+		MinEntropy        float64
+		BreachCorpus      string
+	}
+	Login struct {
+		BackoffAfter    int
+		BackoffBase     time.Duration
+		LockoutAfter    int
+		LockoutDuration time.Duration
+	}
+	MFA struct {
+		Issuer   string
+		TokenTTL time.Duration
+	}
+	OIDC struct {
+		Issuer       string
+		ClientID     string
+		ClientSecret string
+		RedirectURL  string
+		Scopes       []string
+		StateTTL     time.Duration
+	}
+	Mood struct {
+		Scale string
+	}
+	Trash struct {
+		RetentionDays int
+		PurgeInterval time.Duration
+	}
+	Attachments struct {
+		Driver       string
+		Dir          string
+		MaxSize      int64
+		AllowedTypes []string
+		URLTTL       time.Duration
+		S3           struct {
+			Endpoint  string
+			Region    string
+			Bucket    string
+			AccessKey string
+			SecretKey string
+			PathStyle bool
+		}
+	}
+	Reminders struct {
+		Interval  time.Duration
+		BatchSize int
+		Channels  []string
+	}
+	Mailer struct {
+		Driver   string
+		Host     string
+		Port     int
+		Username string
+		Password string
+		Sender   string
+		FileDir  string
 	}
 }
 
@@ -33,6 +110,16 @@ type Conf struct {
 	DB          ConfDB
 	RateLimiter ConfRL
 	Security    ConfSecurity
+	Mailer      ConfMailer
+	Activation  ConfActivation
+	MFA         ConfMFA
+	OIDC        ConfOIDC
+	Login       ConfLogin
+	Password    ConfPassword
+	Mood        ConfMood
+	Trash       ConfTrash
+	Attachments ConfAttachments
+	Reminders   ConfReminders
 }
 
 type ConfServer struct {
@@ -54,7 +141,91 @@ type ConfRL struct {
 }
 
 type ConfSecurity struct {
-	SecretKey string `env:"SECRET_KEY,required"`
+	SecretKey        string        `env:"SECRET_KEY,required"`
+	PasswordResetTTL time.Duration `env:"PASSWORD_RESET_TTL,default=45m"`
+	EmailChangeTTL   time.Duration `env:"EMAIL_CHANGE_TTL,default=1h"`
+	AccessTokenTTL   time.Duration `env:"ACCESS_TOKEN_TTL,default=15m"`
+	RefreshTokenTTL  time.Duration `env:"REFRESH_TOKEN_TTL,default=720h"`
+	JWTIssuer        string        `env:"JWT_ISSUER,default=moodtracker"`
+	JWTAudience      string        `env:"JWT_AUDIENCE,default=moodtracker-api"`
+	JWTAlgorithm     string        `env:"JWT_ALGORITHM,default=HS256"`
+	JWTKeys          string        `env:"JWT_KEYS,default="`
+	JWTActiveKeyID   string        `env:"JWT_ACTIVE_KID,default="`
+}
+
+type ConfActivation struct {
+	CodeTTL     time.Duration `env:"ACTIVATION_CODE_TTL,default=15m"`
+	MaxAttempts int           `env:"ACTIVATION_MAX_ATTEMPTS,default=5"`
+}
+
+type ConfLogin struct {
+	BackoffAfter    int           `env:"LOGIN_BACKOFF_AFTER,default=3"`
+	BackoffBase     time.Duration `env:"LOGIN_BACKOFF_BASE,default=1s"`
+	LockoutAfter    int           `env:"LOGIN_LOCKOUT_AFTER,default=10"`
+	LockoutDuration time.Duration `env:"LOGIN_LOCKOUT_DURATION,default=15m"`
+}
+
+type ConfPassword struct {
+	Algorithm         string  `env:"PASSWORD_HASH_ALGORITHM,default=argon2id"`
+	BcryptCost        int     `env:"PASSWORD_BCRYPT_COST,default=12"`
+	Argon2Memory      uint32  `env:"PASSWORD_ARGON2_MEMORY,default=65536"`
+	Argon2Iterations  uint32  `env:"PASSWORD_ARGON2_ITERATIONS,default=3"`
+	Argon2Parallelism uint8   `env:"PASSWORD_ARGON2_PARALLELISM,default=2"`
+	MinEntropy        float64 `env:"PASSWORD_MIN_ENTROPY,default=40"`
+	BreachCorpus      string  `env:"PASSWORD_BREACH_CORPUS,default="`
+}
+
+type ConfMFA struct {
+	Issuer   string        `env:"TOTP_ISSUER,default=MoodTracker"`
+	TokenTTL time.Duration `env:"MFA_TOKEN_TTL,default=5m"`
+}
+
+type ConfOIDC struct {
+	Issuer       string        `env:"OIDC_ISSUER,default="`
+	ClientID     string        `env:"OIDC_CLIENT_ID,default="`
+	ClientSecret string        `env:"OIDC_CLIENT_SECRET,default="`
+	RedirectURL  string        `env:"OIDC_REDIRECT_URL,default="`
+	Scopes       string        `env:"OIDC_SCOPES,default=openid email profile"`
+	StateTTL     time.Duration `env:"OIDC_STATE_TTL,default=10m"`
+}
+
+type ConfMood struct {
+	Scale string `env:"MOOD_SCALE,default="`
+}
+
+type ConfTrash struct {
+	RetentionDays int           `env:"TRASH_RETENTION_DAYS,default=30"`
+	PurgeInterval time.Duration `env:"TRASH_PURGE_INTERVAL,default=1h"`
+}
+
+type ConfAttachments struct {
+	Driver       string        `env:"ATTACHMENTS_DRIVER,default=local"`
+	Dir          string        `env:"ATTACHMENTS_DIR,default=attachments"`
+	MaxSize      int64         `env:"ATTACHMENTS_MAX_SIZE,default=10485760"`
+	AllowedTypes string        `env:"ATTACHMENTS_ALLOWED_TYPES,default=image/jpeg image/png image/gif image/webp audio/mpeg audio/mp4 audio/ogg audio/webm audio/wav"`
+	URLTTL       time.Duration `env:"ATTACHMENTS_URL_TTL,default=15m"`
+	S3Endpoint   string        `env:"S3_ENDPOINT,default="`
+	S3Region     string        `env:"S3_REGION,default=us-east-1"`
+	S3Bucket     string        `env:"S3_BUCKET,default="`
+	S3AccessKey  string        `env:"S3_ACCESS_KEY,default="`
+	S3SecretKey  string        `env:"S3_SECRET_KEY,default="`
+	S3PathStyle  bool          `env:"S3_PATH_STYLE,default=true"`
+}
+
+type ConfReminders struct {
+	Interval  time.Duration `env:"REMINDER_INTERVAL,default=1m"`
+	BatchSize int           `env:"REMINDER_BATCH_SIZE,default=100"`
+	Channels  string        `env:"REMINDER_CHANNELS,default=email webhook"`
+}
+
+type ConfMailer struct {
+	Driver   string `env:"MAILER_DRIVER,required"`
+	Host     string `env:"SMTP_HOST,default=localhost"`
+	Port     int    `env:"SMTP_PORT,default=25"`
+	Username string `env:"SMTP_USERNAME,default="`
+	Password string `env:"SMTP_PASSWORD,default="`
+	Sender   string `env:"SMTP_SENDER,default=MoodTracker <no-reply@moodtracker.local>"`
+	FileDir  string `env:"MAILER_FILE_DIR,default=mails"`
 }
 
 func New() *Conf {
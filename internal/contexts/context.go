@@ -8,7 +8,11 @@ import (
 
 type contextKey string
 
-const userContextKey = contextKey("user")
+const (
+	userContextKey   = contextKey("user")
+	claimsContextKey = contextKey("claims")
+	tokenContextKey  = contextKey("access_token")
+)
 
 func ContextSetUser(r *http.Request, user *models.User) *http.Request {
 	ctx := context.WithValue(r.Context(), userContextKey, user)
@@ -22,3 +26,28 @@ func ContextGetUser(r *http.Request) *models.User {
 	}
 	return user
 }
+
+func ContextSetClaims(r *http.Request, claims *models.AccessClaims) *http.Request {
+	ctx := context.WithValue(r.Context(), claimsContextKey, claims)
+	return r.WithContext(ctx)
+}
+
+func ContextGetClaims(r *http.Request) *models.AccessClaims {
+	claims, ok := r.Context().Value(claimsContextKey).(*models.AccessClaims)
+	if !ok {
+		panic("missing claims value in request context")
+	}
+	return claims
+}
+
+func ContextSetAccessToken(r *http.Request, token *models.PersonalAccessToken) *http.Request {
+	ctx := context.WithValue(r.Context(), tokenContextKey, token)
+	return r.WithContext(ctx)
+}
+
+// ContextGetAccessToken returns nil when the request was not authenticated
+// with a personal access token.
+func ContextGetAccessToken(r *http.Request) *models.PersonalAccessToken {
+	token, _ := r.Context().Value(tokenContextKey).(*models.PersonalAccessToken)
+	return token
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"moodtracker/internal/contexts"
+	"moodtracker/internal/models"
+	"moodtracker/internal/services"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"net/http"
+	"time"
+)
+
+type accessTokenHandler struct {
+	accessToken services.AccessTokenService
+	errRsp      e.ErrorHandlerInterface
+}
+
+type AccessTokenHandler interface {
+	GetAll(w http.ResponseWriter, r *http.Request)
+	Create(w http.ResponseWriter, r *http.Request)
+	Revoke(w http.ResponseWriter, r *http.Request)
+}
+
+func NewAccessTokenHandler(
+	accessToken services.AccessTokenService,
+	errRsp e.ErrorHandlerInterface,
+) *accessTokenHandler {
+	return &accessTokenHandler{
+		accessToken: accessToken,
+		errRsp:      errRsp,
+	}
+}
+
+func (h *accessTokenHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	user := contexts.ContextGetUser(r)
+	tokens, err := h.accessToken.GetAllByUserID(user.ID)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, nil)
+		return
+	}
+
+	dtos := make([]*models.PersonalAccessTokenDTO, 0, len(tokens))
+	for _, t := range tokens {
+		dtos = append(dtos, t.ToDTO())
+	}
+
+	respond(w, r, http.StatusOK, utils.Envelope{"access_tokens": dtos}, nil, h.errRsp)
+}
+
+func (h *accessTokenHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	err := utils.ReadJSON(w, r, &input)
+	if err != nil {
+		h.errRsp.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	user := contexts.ContextGetUser(r)
+	token := models.GeneratePersonalAccessToken(user.ID, input.Name, input.Scopes, input.ExpiresAt)
+
+	err = h.accessToken.Create(token, v)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	respond(w, r, http.StatusCreated, utils.Envelope{"access_token": token.ToDTO()}, nil, h.errRsp)
+}
+
+func (h *accessTokenHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseUUID(w, r, h.errRsp)
+	if !ok {
+		return
+	}
+
+	user := contexts.ContextGetUser(r)
+	err := h.accessToken.Revoke(id, user.ID)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, nil)
+		return
+	}
+
+	respond(w, r, http.StatusNoContent, nil, nil, h.errRsp)
+}
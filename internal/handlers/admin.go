@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"moodtracker/internal/models"
+	"moodtracker/internal/models/filters"
+	"moodtracker/internal/services"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type adminHandler struct {
+	admin  services.AdminService
+	errRsp e.ErrorHandlerInterface
+}
+
+type AdminHandler interface {
+	ListUsers(w http.ResponseWriter, r *http.Request)
+	ActivateUser(w http.ResponseWriter, r *http.Request)
+	DeactivateUser(w http.ResponseWriter, r *http.Request)
+	RestoreUser(w http.ResponseWriter, r *http.Request)
+}
+
+func NewAdminHandler(
+	admin services.AdminService,
+	errRsp e.ErrorHandlerInterface,
+) *adminHandler {
+	return &adminHandler{
+		admin:  admin,
+		errRsp: errRsp,
+	}
+}
+
+func (h *adminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		search    string
+		activated *bool
+		deleted   *bool
+		filters.Filters
+	}
+
+	v := validator.New()
+
+	input.search = utils.ReadStringParam(r, "q", "")
+	input.activated = utils.ReadBoolParam(r, "activated", v)
+	input.deleted = utils.ReadBoolParam(r, "deleted", v)
+
+	input.Filters.Page = utils.ReadIntParam(r, "page", 1, v)
+	input.Filters.PageSize = utils.ReadIntParam(r, "page_size", 20, v)
+	input.Filters.Sort = utils.ReadStringParam(r, "sort", "-created_at")
+	input.Filters.SortSafelist = []string{"name", "email", "created_at", "-name", "-email", "-created_at"}
+
+	if !v.Valid() {
+		h.errRsp.HandlerError(w, r, e.ErrInvalidData, v)
+		return
+	}
+
+	users, metadata, err := h.admin.ListUsers(
+		input.search,
+		input.activated,
+		input.deleted,
+		input.Filters,
+		v,
+	)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	dtos := make([]*models.UserAdminDTO, 0, len(users))
+	for _, u := range users {
+		dtos = append(dtos, u.ToAdminDTO())
+	}
+
+	respond(w, r, http.StatusOK, utils.Envelope{"users": dtos, "metadata": metadata}, nil, h.errRsp)
+}
+
+func (h *adminHandler) ActivateUser(w http.ResponseWriter, r *http.Request) {
+	h.changeUser(w, r, h.admin.ActivateUser)
+}
+
+func (h *adminHandler) DeactivateUser(w http.ResponseWriter, r *http.Request) {
+	h.changeUser(w, r, h.admin.DeactivateUser)
+}
+
+func (h *adminHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	h.changeUser(w, r, h.admin.RestoreUser)
+}
+
+func (h *adminHandler) changeUser(
+	w http.ResponseWriter,
+	r *http.Request,
+	fn func(id uuid.UUID) (*models.User, error),
+) {
+	id, ok := parseUUID(w, r, h.errRsp)
+	if !ok {
+		return
+	}
+
+	user, err := fn(id)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, nil)
+		return
+	}
+
+	respond(w, r, http.StatusOK, utils.Envelope{"user": user.ToAdminDTO()}, nil, h.errRsp)
+}
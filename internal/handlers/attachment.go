@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"moodtracker/internal/contexts"
+	"moodtracker/internal/models"
+	"moodtracker/internal/services"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// transferTimeout replaces the server read and write timeouts for uploads and
+// downloads, which take longer than a JSON request.
+const transferTimeout = 5 * time.Minute
+
+type attachmentHandler struct {
+	attachment services.AttachmentService
+	errRsp     e.ErrorHandlerInterface
+}
+
+type AttachmentHandler interface {
+	GetAll(w http.ResponseWriter, r *http.Request)
+	FindByID(w http.ResponseWriter, r *http.Request)
+	Upload(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+	Content(w http.ResponseWriter, r *http.Request)
+}
+
+func NewAttachmentHandler(
+	attachment services.AttachmentService,
+	errRsp e.ErrorHandlerInterface,
+) *attachmentHandler {
+	return &attachmentHandler{
+		attachment: attachment,
+		errRsp:     errRsp,
+	}
+}
+
+func (h *attachmentHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	logID, ok := parseUUID(w, r, h.errRsp)
+	if !ok {
+		return
+	}
+
+	user := contexts.ContextGetUser(r)
+	attachments, err := h.attachment.GetAll(logID, user.ID)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, nil)
+		return
+	}
+
+	dtos := make([]*models.AttachmentDTO, 0, len(attachments))
+	for _, a := range attachments {
+		dtos = append(dtos, h.toDTO(a))
+	}
+
+	respond(w, r, http.StatusOK, utils.Envelope{"attachments": dtos}, nil, h.errRsp)
+}
+
+func (h *attachmentHandler) FindByID(w http.ResponseWriter, r *http.Request) {
+	logID, id, ok := h.parseIDs(w, r)
+	if !ok {
+		return
+	}
+
+	user := contexts.ContextGetUser(r)
+	attachment, err := h.attachment.FindByID(id, logID, user.ID)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, nil)
+		return
+	}
+
+	respond(w, r, http.StatusOK, utils.Envelope{"attachment": h.toDTO(attachment)}, nil, h.errRsp)
+}
+
+func (h *attachmentHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	logID, ok := parseUUID(w, r, h.errRsp)
+	if !ok {
+		return
+	}
+
+	// The body is spooled to disk, so make sure the log is the caller's first.
+	user := contexts.ContextGetUser(r)
+	if err := h.attachment.CheckLog(logID, user.ID); err != nil {
+		h.errRsp.HandlerError(w, r, err, nil)
+		return
+	}
+
+	http.NewResponseController(w).SetReadDeadline(time.Now().Add(transferTimeout))
+
+	upload, err := utils.ReadMultipartFile(w, r, "file", h.attachment.MaxSize())
+	if errors.Is(err, utils.ErrUploadTooLarge) {
+		h.errRsp.FailedValidationResponse(w, r, map[string]string{
+			"file": fmt.Sprintf("must not be larger than %d bytes", h.attachment.MaxSize()),
+		})
+		return
+	}
+	if err != nil {
+		h.errRsp.BadRequestResponse(w, r, err)
+		return
+	}
+	defer upload.Close()
+
+	v := validator.New()
+	attachment, err := h.attachment.Save(logID, user.ID, upload, v)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/day_logs/%s/attachments/%s", logID, attachment.ID))
+
+	respond(w, r, http.StatusCreated, utils.Envelope{"attachment": h.toDTO(attachment)}, headers, h.errRsp)
+}
+
+func (h *attachmentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	logID, id, ok := h.parseIDs(w, r)
+	if !ok {
+		return
+	}
+
+	user := contexts.ContextGetUser(r)
+	if err := h.attachment.Delete(id, logID, user.ID); err != nil {
+		h.errRsp.HandlerError(w, r, err, nil)
+		return
+	}
+
+	respond(w, r, http.StatusNoContent, nil, nil, h.errRsp)
+}
+
+// Content serves the file behind a signed URL. It needs no authentication, so
+// the URL can be used directly as the source of an <img> or <audio> tag.
+func (h *attachmentHandler) Content(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseUUID(w, r, h.errRsp)
+	if !ok {
+		return
+	}
+
+	attachment, content, err := h.attachment.Open(
+		id,
+		utils.ReadStringParam(r, "expires", ""),
+		utils.ReadStringParam(r, "signature", ""),
+	)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, nil)
+		return
+	}
+	defer content.Close()
+
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(transferTimeout))
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", attachment.FileName))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, content); err != nil {
+		h.errRsp.LogError(r, err)
+	}
+}
+
+func (h *attachmentHandler) parseIDs(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	logID, ok := parseUUID(w, r, h.errRsp)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	s, err := utils.ReadStringPathVariable(r, "attachmentID")
+	if err != nil {
+		h.errRsp.BadRequestResponse(w, r, err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(s)
+	if err != nil {
+		h.errRsp.BadRequestResponse(w, r, err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return logID, id, true
+}
+
+func (h *attachmentHandler) toDTO(a *models.Attachment) *models.AttachmentDTO {
+	dto := a.ToDTO()
+	dto.URL, dto.URLExpiresAt = h.attachment.SignedURL(a)
+	return dto
+}
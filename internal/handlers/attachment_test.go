@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"moodtracker/internal/contexts"
+	"moodtracker/internal/models"
+	"moodtracker/internal/services"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+type fakeAttachmentService struct {
+	services.AttachmentService
+	logErr error
+	saved  bool
+}
+
+func (s *fakeAttachmentService) MaxSize() int64 {
+	return 1 << 20
+}
+
+func (s *fakeAttachmentService) CheckLog(logID, userID uuid.UUID) error {
+	return s.logErr
+}
+
+func (s *fakeAttachmentService) Save(
+	logID, userID uuid.UUID,
+	upload *utils.Upload,
+	v *validator.Validator,
+) (*models.Attachment, error) {
+	s.saved = true
+	return &models.Attachment{ID: uuid.New(), LogID: logID, FileName: upload.FileName}, nil
+}
+
+func (s *fakeAttachmentService) SignedURL(a *models.Attachment) (string, time.Time) {
+	return "/v1/attachments/" + a.ID.String(), time.Now().Add(time.Hour)
+}
+
+// countingReader records how much of the request body was read.
+type countingReader struct {
+	r    io.Reader
+	read int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += n
+	return n, err
+}
+
+func TestAttachmentUploadChecksTheLogFirst(t *testing.T) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "walk.png")
+	part.Write([]byte("\x89PNG\r\n\x1a\n"))
+	form.Close()
+
+	tests := []struct {
+		name   string
+		logErr error
+		status int
+		read   bool
+	}{
+		{"someone else's log", e.ErrRecordNotFound, http.StatusNotFound, false},
+		{"own log", nil, http.StatusCreated, true},
+	}
+
+	for _, tt := range tests {
+		service := &fakeAttachmentService{logErr: tt.logErr}
+		h := NewAttachmentHandler(service, newTestErrorHandler())
+
+		logID := uuid.New()
+		reader := &countingReader{r: bytes.NewReader(body.Bytes())}
+
+		r := httptest.NewRequest(http.MethodPost, "/v1/day_logs/"+logID.String()+"/attachments", reader)
+		r.Header.Set("Content-Type", form.FormDataContentType())
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", logID.String())
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+		r = contexts.ContextSetUser(r, &models.User{ID: uuid.New()})
+
+		w := httptest.NewRecorder()
+		h.Upload(w, r)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, w.Code, tt.status, w.Body)
+		}
+		if read := reader.read > 0; read != tt.read {
+			t.Errorf("%s: body read = %v, want %v", tt.name, read, tt.read)
+		}
+		if service.saved != tt.read {
+			t.Errorf("%s: saved = %v, want %v", tt.name, service.saved, tt.read)
+		}
+	}
+}
@@ -1,10 +1,16 @@
 package handlers
 
 import (
+	"crypto/subtle"
+	"fmt"
+	"moodtracker/internal/contexts"
+	"moodtracker/internal/models"
+	"moodtracker/internal/models/filters"
 	"moodtracker/internal/services"
 	"moodtracker/utils"
 	"moodtracker/utils/errors"
 	"moodtracker/utils/validator"
+	"net"
 	"net/http"
 )
 
@@ -15,6 +21,14 @@ type AuthHandler struct {
 
 type AuthHandlerInterface interface {
 	LoginHandler(w http.ResponseWriter, r *http.Request)
+	VerifyMFAHandler(w http.ResponseWriter, r *http.Request)
+	OIDCLoginHandler(w http.ResponseWriter, r *http.Request)
+	OIDCCallbackHandler(w http.ResponseWriter, r *http.Request)
+	RefreshHandler(w http.ResponseWriter, r *http.Request)
+	LogoutHandler(w http.ResponseWriter, r *http.Request)
+	LogoutAllHandler(w http.ResponseWriter, r *http.Request)
+	JWKSHandler(w http.ResponseWriter, r *http.Request)
+	SignInsHandler(w http.ResponseWriter, r *http.Request)
 }
 
 func NewAuthHandler(authService services.AuthServiceInterface, errResp errors.ErrorHandlerInterface) *AuthHandler {
@@ -37,15 +51,223 @@ func (h *AuthHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	v := validator.New()
-	token, err := h.auth.Login(v, input.Email, input.Password)
+	result, err := h.auth.Login(v, input.Email, input.Password, clientInfo(r))
 
 	if err != nil {
 		h.errorHandler.HandlerError(w, r, err, v)
 		return
 	}
 
-	err = utils.WriteJSON(w, http.StatusCreated, utils.Envelope{"authentication_token": token}, nil)
+	h.respondLogin(w, r, result)
+}
+
+// oidcStateCookie binds a pending sign-in to the browser that started it.
+const oidcStateCookie = "oidc_state"
+
+func (h *AuthHandler) OIDCLoginHandler(w http.ResponseWriter, r *http.Request) {
+	authURL, state, err := h.auth.StartOIDC()
+	if err != nil {
+		h.errorHandler.HandlerError(w, r, err, nil)
+		return
+	}
+
+	// Lax, not Strict: the provider sends the browser back to the callback
+	// with a cross-site redirect.
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    models.OIDCStateBinding(state.Plaintext),
+		Path:     "/v1/auth/oidc",
+		Expires:  state.Expiry,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	respond(w, r, http.StatusOK, utils.Envelope{"authorization_url": authURL}, nil, h.errorHandler)
+}
+
+func (h *AuthHandler) OIDCCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	if providerErr := utils.ReadStringParam(r, "error", ""); providerErr != "" {
+		description := utils.ReadStringParam(r, "error_description", providerErr)
+		h.errorHandler.BadRequestResponse(w, r, fmt.Errorf("identity provider: %s", description))
+		return
+	}
+
+	v := validator.New()
+	state := utils.ReadStringParam(r, "state", "")
+
+	cookie, err := r.Cookie(oidcStateCookie)
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: "/v1/auth/oidc", MaxAge: -1})
+
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(models.OIDCStateBinding(state))) != 1 {
+		v.AddError("state", "does not belong to the browser that started the sign-in")
+		h.errorHandler.HandlerError(w, r, errors.ErrInvalidData, v)
+		return
+	}
+
+	result, err := h.auth.CompleteOIDC(
+		v,
+		utils.ReadStringParam(r, "code", ""),
+		state,
+		clientInfo(r),
+	)
+	if err != nil {
+		h.errorHandler.HandlerError(w, r, err, v)
+		return
+	}
+
+	h.respondLogin(w, r, result)
+}
+
+func (h *AuthHandler) respondLogin(w http.ResponseWriter, r *http.Request, result *models.LoginResult) {
+	if result.MFAToken != nil {
+		respond(w, r, http.StatusOK, utils.Envelope{
+			"mfa_required":   true,
+			"mfa_token":      result.MFAToken.Plaintext,
+			"mfa_expires_at": result.MFAToken.Expiry,
+		}, nil, h.errorHandler)
+		return
+	}
+
+	respond(w, r, http.StatusCreated, tokensEnvelope(result.Tokens), nil, h.errorHandler)
+}
+
+func (h *AuthHandler) VerifyMFAHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		MFAToken     string `json:"mfa_token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	err := utils.ReadJSON(w, r, &input)
+	if err != nil {
+		h.errorHandler.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	tokens, err := h.auth.VerifyMFA(v, input.MFAToken, input.Code, input.RecoveryCode, clientInfo(r))
+	if err != nil {
+		h.errorHandler.HandlerError(w, r, err, v)
+		return
+	}
+
+	respond(w, r, http.StatusCreated, tokensEnvelope(tokens), nil, h.errorHandler)
+}
+
+func (h *AuthHandler) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	err := utils.ReadJSON(w, r, &input)
+	if err != nil {
+		h.errorHandler.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	tokens, err := h.auth.Refresh(v, input.RefreshToken)
+	if err != nil {
+		h.errorHandler.HandlerError(w, r, err, v)
+		return
+	}
+
+	respond(w, r, http.StatusCreated, tokensEnvelope(tokens), nil, h.errorHandler)
+}
+
+func (h *AuthHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	if r.ContentLength != 0 {
+		err := utils.ReadJSON(w, r, &input)
+		if err != nil {
+			h.errorHandler.BadRequestResponse(w, r, err)
+			return
+		}
+	}
+
+	v := validator.New()
+	user := contexts.ContextGetUser(r)
+	claims := contexts.ContextGetClaims(r)
+	err := h.auth.Logout(v, user, claims, input.RefreshToken)
+	if err != nil {
+		h.errorHandler.HandlerError(w, r, err, v)
+		return
+	}
+
+	respond(w, r, http.StatusNoContent, nil, nil, h.errorHandler)
+}
+
+func (h *AuthHandler) LogoutAllHandler(w http.ResponseWriter, r *http.Request) {
+	user := contexts.ContextGetUser(r)
+	err := h.auth.LogoutAll(user)
 	if err != nil {
-		h.errorHandler.ServerErrorResponse(w, r, err)
+		h.errorHandler.HandlerError(w, r, err, nil)
+		return
+	}
+
+	respond(w, r, http.StatusNoContent, nil, nil, h.errorHandler)
+}
+
+func (h *AuthHandler) JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	headers := make(http.Header)
+	headers.Set("Cache-Control", "public, max-age=300")
+
+	respond(w, r, http.StatusOK, utils.Envelope{"keys": h.auth.JWKS()}, headers, h.errorHandler)
+}
+
+func (h *AuthHandler) SignInsHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		filters.Filters
+	}
+
+	v := validator.New()
+
+	input.Filters.Page = utils.ReadIntParam(r, "page", 1, v)
+	input.Filters.PageSize = utils.ReadIntParam(r, "page_size", 20, v)
+	input.Filters.Sort = "-created_at"
+	input.Filters.SortSafelist = []string{"-created_at"}
+
+	if !v.Valid() {
+		h.errorHandler.HandlerError(w, r, errors.ErrInvalidData, v)
+		return
+	}
+
+	user := contexts.ContextGetUser(r)
+	events, metadata, err := h.auth.GetAuthEvents(user.ID, input.Filters, v)
+	if err != nil {
+		h.errorHandler.HandlerError(w, r, err, v)
+		return
+	}
+
+	dtos := make([]*models.AuthEventDTO, 0, len(events))
+	for _, event := range events {
+		dtos = append(dtos, event.ToDTO())
+	}
+
+	respond(w, r, http.StatusOK, utils.Envelope{"sign_ins": dtos, "metadata": metadata}, nil, h.errorHandler)
+}
+
+func clientInfo(r *http.Request) models.ClientInfo {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	return models.ClientInfo{
+		IP:        ip,
+		UserAgent: r.UserAgent(),
+	}
+}
+
+func tokensEnvelope(tokens *models.AuthTokens) utils.Envelope {
+	return utils.Envelope{
+		"authentication_token": tokens.AccessToken,
+		"expires_at":           tokens.AccessTokenExpiry,
+		"refresh_token":        tokens.RefreshToken,
+		"refresh_expires_at":   tokens.RefreshTokenExpiry,
 	}
 }
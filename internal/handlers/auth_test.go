@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"moodtracker/internal/models"
+	"moodtracker/internal/services"
+	"moodtracker/utils/validator"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeAuthService struct {
+	services.AuthServiceInterface
+	state     *models.OIDCState
+	completed []string
+}
+
+func (s *fakeAuthService) StartOIDC() (string, *models.OIDCState, error) {
+	return "https://sso.example.com/authorize?state=" + s.state.Plaintext, s.state, nil
+}
+
+func (s *fakeAuthService) CompleteOIDC(
+	v *validator.Validator,
+	code,
+	state string,
+	client models.ClientInfo,
+) (*models.LoginResult, error) {
+	s.completed = append(s.completed, state)
+	return &models.LoginResult{Tokens: &models.AuthTokens{AccessToken: "access"}}, nil
+}
+
+func TestOIDCLoginSetsStateCookie(t *testing.T) {
+	state := models.GenerateOIDCState("verifier", 10*time.Minute)
+	h := NewAuthHandler(&fakeAuthService{state: state}, newTestErrorHandler())
+
+	w := httptest.NewRecorder()
+	h.OIDCLoginHandler(w, httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/login", nil))
+
+	cookies := w.Result().Cookies()
+	if w.Code != http.StatusOK || len(cookies) != 1 {
+		t.Fatalf("status = %d, cookies = %v", w.Code, cookies)
+	}
+
+	c := cookies[0]
+	if c.Name != oidcStateCookie || c.Value != models.OIDCStateBinding(state.Plaintext) {
+		t.Errorf("cookie %s=%s does not bind the state", c.Name, c.Value)
+	}
+	if c.Value == state.Plaintext {
+		t.Error("cookie holds the state itself")
+	}
+	if !c.HttpOnly || c.SameSite != http.SameSiteLaxMode || c.Path != "/v1/auth/oidc" {
+		t.Errorf("cookie HttpOnly = %v, SameSite = %v, Path = %q", c.HttpOnly, c.SameSite, c.Path)
+	}
+	if !c.Expires.Equal(state.Expiry.Truncate(time.Second)) {
+		t.Errorf("cookie expires at %v, want %v", c.Expires, state.Expiry)
+	}
+}
+
+func TestOIDCCallbackStateCookie(t *testing.T) {
+	state := models.GenerateOIDCState("verifier", 10*time.Minute)
+	other := models.GenerateOIDCState("verifier", 10*time.Minute)
+
+	tests := []struct {
+		name     string
+		cookie   string
+		status   int
+		complete bool
+	}{
+		{"no cookie", "", http.StatusUnprocessableEntity, false},
+		{"cookie of another sign-in", models.OIDCStateBinding(other.Plaintext), http.StatusUnprocessableEntity, false},
+		{"state as the cookie", state.Plaintext, http.StatusUnprocessableEntity, false},
+		{"matching cookie", models.OIDCStateBinding(state.Plaintext), http.StatusCreated, true},
+	}
+
+	for _, tt := range tests {
+		service := &fakeAuthService{state: state}
+		h := NewAuthHandler(service, newTestErrorHandler())
+
+		r := httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/callback?code=abc&state="+state.Plaintext, nil)
+		if tt.cookie != "" {
+			r.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: tt.cookie})
+		}
+
+		w := httptest.NewRecorder()
+		h.OIDCCallbackHandler(w, r)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.status)
+		}
+		if completed := len(service.completed) > 0; completed != tt.complete {
+			t.Errorf("%s: CompleteOIDC called = %v, want %v", tt.name, completed, tt.complete)
+		}
+
+		cookies := w.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Name != oidcStateCookie || cookies[0].MaxAge >= 0 {
+			t.Errorf("%s: state cookie was not cleared: %v", tt.name, cookies)
+		}
+	}
+}
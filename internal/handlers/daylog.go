@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	goerrors "errors"
+	"fmt"
 	"moodtracker/internal/contexts"
 	"moodtracker/internal/models"
+	"moodtracker/internal/models/filters"
 	"moodtracker/internal/services"
 	"moodtracker/utils"
 	e "moodtracker/utils/errors"
@@ -29,13 +33,82 @@ func NewDaylogHandler(
 }
 
 type DaylogHandler interface {
+	GetAll(w http.ResponseWriter, r *http.Request)
 	GetAllByYear(w http.ResponseWriter, r *http.Request)
+	GetMoodScale(w http.ResponseWriter, r *http.Request)
+	GetDailySummaries(w http.ResponseWriter, r *http.Request)
+	GetRevisions(w http.ResponseWriter, r *http.Request)
+	DiffRevisions(w http.ResponseWriter, r *http.Request)
+	RevertToRevision(w http.ResponseWriter, r *http.Request)
 	GenericHandlerInterface[
 		models.Daylog,
 		models.DaylogDTO,
 	]
 }
 
+func (h *daylogHandlers) GetAll(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		text      string
+		tags      []string
+		moodLabel *models.MoodLabel
+		startDate *time.Time
+		endDate   *time.Time
+		filters.Filters
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	input.text = utils.ReadStringParam(r, "text", "")
+	input.tags = utils.ReadCSVParam(r, "tags", []string{})
+
+	if s := utils.ReadStringParam(r, "mood_label", ""); s != "" {
+		label := models.ParseMoodLabel(s)
+		input.moodLabel = &label
+	}
+
+	input.startDate = utils.ReadDate(qs, "start_date", time.DateOnly)
+	v.Check(qs.Get("start_date") == "" || input.startDate != nil, "start_date", "must be a valid date (YYYY-MM-DD)")
+	input.endDate = utils.ReadDate(qs, "end_date", time.DateOnly)
+	v.Check(qs.Get("end_date") == "" || input.endDate != nil, "end_date", "must be a valid date (YYYY-MM-DD)")
+
+	input.Filters.Page = utils.ReadIntParam(r, "page", 1, v)
+	input.Filters.PageSize = utils.ReadIntParam(r, "page_size", 20, v)
+	input.Filters.Sort = utils.ReadStringParam(r, "sort", "-date")
+	input.Filters.SortSafelist = []string{
+		"date", "mood_label", "logged_at", "created_at",
+		"-date", "-mood_label", "-logged_at", "-created_at",
+	}
+
+	if !v.Valid() {
+		h.errRsp.HandlerError(w, r, e.ErrInvalidData, v)
+		return
+	}
+
+	user := contexts.ContextGetUser(r)
+	datas, metadata, err := h.daylog.GetAll(
+		input.text,
+		input.tags,
+		input.moodLabel,
+		input.startDate,
+		input.endDate,
+		user.ID,
+		input.Filters,
+		v,
+	)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	dtos := make([]*models.DaylogDTO, 0, len(datas))
+	for _, m := range datas {
+		dtos = append(dtos, m.ToDTO())
+	}
+
+	respond(w, r, http.StatusOK, utils.Envelope{"day_logs": dtos, "metadata": metadata}, nil, h.errRsp)
+}
+
 func (h *daylogHandlers) GetAllByYear(w http.ResponseWriter, r *http.Request) {
 	v := validator.New()
 	year := utils.ReadIntParam(r, "year", time.Now().Year(), v)
@@ -58,5 +131,159 @@ func (h *daylogHandlers) GetAllByYear(w http.ResponseWriter, r *http.Request) {
 		dtos = append(dtos, dto)
 	}
 
-	respond(w, r, http.StatusOK, utils.Envelope{"day_logs": dtos}, nil, h.errRsp)
+	respond(w, r, http.StatusOK, utils.Envelope{"day_logs": dtos}, listValidators(datas), h.errRsp)
+}
+
+// listValidators derives a weak ETag from the id and ETag of every log, so
+// renaming a tag or tracker or deleting a log also changes it.
+func listValidators(logs []*models.Daylog) http.Header {
+	hash := sha256.New()
+
+	for _, d := range logs {
+		fmt.Fprintf(hash, "%s:%s;", d.ID, d.ETag())
+	}
+
+	return validators(fmt.Sprintf(`W/"%x"`, hash.Sum(nil)[:16]), time.Time{})
+}
+
+func (h *daylogHandlers) GetDailySummaries(w http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+	qs := r.URL.Query()
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	endDate := utils.ReadDate(qs, "end_date", time.DateOnly)
+	v.Check(qs.Get("end_date") == "" || endDate != nil, "end_date", "must be a valid date (YYYY-MM-DD)")
+	if endDate == nil {
+		endDate = &today
+	}
+
+	startDate := utils.ReadDate(qs, "start_date", time.DateOnly)
+	v.Check(qs.Get("start_date") == "" || startDate != nil, "start_date", "must be a valid date (YYYY-MM-DD)")
+	if startDate == nil {
+		start := endDate.AddDate(0, 0, -29)
+		startDate = &start
+	}
+
+	if !v.Valid() {
+		h.errRsp.HandlerError(w, r, e.ErrInvalidData, v)
+		return
+	}
+
+	user := contexts.ContextGetUser(r)
+	summaries, err := h.daylog.GetDailySummaries(*startDate, *endDate, user.ID, v)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	respond(w, r, http.StatusOK, utils.Envelope{"daily_summaries": summaries}, nil, h.errRsp)
+}
+
+func (h *daylogHandlers) GetMoodScale(w http.ResponseWriter, r *http.Request) {
+	respond(w, r, http.StatusOK, utils.Envelope{"mood_scale": models.CurrentMoodScale()}, nil, h.errRsp)
+}
+
+func (h *daylogHandlers) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseUUID(w, r, h.errRsp)
+	if !ok {
+		return
+	}
+
+	var f filters.Filters
+
+	v := validator.New()
+	f.Page = utils.ReadIntParam(r, "page", 1, v)
+	f.PageSize = utils.ReadIntParam(r, "page_size", 20, v)
+	f.Sort = utils.ReadStringParam(r, "sort", "-version")
+	f.SortSafelist = []string{"version", "-version"}
+
+	if !v.Valid() {
+		h.errRsp.HandlerError(w, r, e.ErrInvalidData, v)
+		return
+	}
+
+	user := contexts.ContextGetUser(r)
+	revisions, metadata, err := h.daylog.GetRevisions(id, user.ID, f, v)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	dtos := make([]*models.DaylogRevisionDTO, 0, len(revisions))
+	for _, rev := range revisions {
+		dtos = append(dtos, rev.ToDTO())
+	}
+
+	respond(w, r, http.StatusOK, utils.Envelope{"revisions": dtos, "metadata": metadata}, nil, h.errRsp)
+}
+
+func (h *daylogHandlers) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseUUID(w, r, h.errRsp)
+	if !ok {
+		return
+	}
+
+	v := validator.New()
+	from := utils.ReadIntParam(r, "from", 0, v)
+	to := utils.ReadIntParam(r, "to", 0, v)
+
+	if !v.Valid() {
+		h.errRsp.HandlerError(w, r, e.ErrInvalidData, v)
+		return
+	}
+
+	user := contexts.ContextGetUser(r)
+	diff, err := h.daylog.DiffRevisions(id, user.ID, from, to, v)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	respond(w, r, http.StatusOK, utils.Envelope{"diff": diff}, nil, h.errRsp)
+}
+
+func (h *daylogHandlers) RevertToRevision(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseUUID(w, r, h.errRsp)
+	if !ok {
+		return
+	}
+
+	version, err := utils.ReadIntPathVariable(r, "version")
+	if err != nil {
+		h.errRsp.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	user := contexts.ContextGetUser(r)
+
+	current, err := h.daylog.FindByID(id, user.ID)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	sent, ok := ifMatch(r, current.ETag())
+	if sent && !ok {
+		h.errRsp.PreconditionFailedResponse(w, r)
+		return
+	}
+
+	daylog, err := h.daylog.RevertToRevision(current, int(version), user.ID, v)
+	if err != nil {
+		if sent && goerrors.Is(err, e.ErrEditConflict) {
+			err = e.ErrPreconditionFailed
+		}
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	respond(
+		w, r,
+		http.StatusOK,
+		utils.Envelope{"daylog": daylog.ToDTO()},
+		validators(daylog.ETag(), daylog.LastModified()),
+		h.errRsp,
+	)
 }
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"moodtracker/internal/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestListValidators(t *testing.T) {
+	created := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	updated := created.Add(48 * time.Hour)
+	tracker := uuid.MustParse("33333333-3333-3333-3333-333333333333")
+
+	logs := func() []*models.Daylog {
+		return []*models.Daylog{
+			{ID: uuid.MustParse("11111111-1111-1111-1111-111111111111"), Tags: []string{"work", "gym"},
+				BaseModel: models.BaseModel{Version: 1, CreatedAt: created}},
+			{ID: uuid.MustParse("22222222-2222-2222-2222-222222222222"), Tags: []string{"sleep"},
+				Trackers:  []*models.TrackerValue{{TrackerID: tracker, Name: "Steps", Unit: "steps"}},
+				BaseModel: models.BaseModel{Version: 2, CreatedAt: created, UpdatedAt: &updated}},
+		}
+	}
+
+	base := listValidators(logs())
+
+	if got := base.Get("Last-Modified"); got != "" {
+		t.Errorf("Last-Modified = %q, want none", got)
+	}
+	if etag := base.Get("ETag"); len(etag) != len(`W/""`)+32 || etag[:3] != `W/"` {
+		t.Errorf("ETag = %q, want a weak tag of 16 hex bytes", etag)
+	}
+
+	tests := []struct {
+		name    string
+		change  func([]*models.Daylog) []*models.Daylog
+		changed bool
+	}{
+		{"same logs", func(l []*models.Daylog) []*models.Daylog { return l }, false},
+		{"tags in another order", func(l []*models.Daylog) []*models.Daylog {
+			l[0].Tags = []string{"gym", "work"}
+			return l
+		}, false},
+		{"tag renamed", func(l []*models.Daylog) []*models.Daylog {
+			l[0].Tags = []string{"work", "running"}
+			return l
+		}, true},
+		{"tracker renamed", func(l []*models.Daylog) []*models.Daylog {
+			l[1].Trackers = []*models.TrackerValue{{TrackerID: tracker, Name: "Steps", Unit: "k"}}
+			return l
+		}, true},
+		{"new version", func(l []*models.Daylog) []*models.Daylog {
+			l[1].Version++
+			return l
+		}, true},
+		{"log deleted", func(l []*models.Daylog) []*models.Daylog { return l[:1] }, true},
+	}
+
+	for _, tt := range tests {
+		got := listValidators(tt.change(logs())).Get("ETag")
+		if changed := got != base.Get("ETag"); changed != tt.changed {
+			t.Errorf("%s: ETag changed = %v, want %v", tt.name, changed, tt.changed)
+		}
+	}
+
+	empty := listValidators(nil)
+	if empty.Get("ETag") == "" || empty.Get("Last-Modified") != "" {
+		t.Errorf("empty list validators = %v", empty)
+	}
+}
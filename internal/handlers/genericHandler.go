@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"encoding/json"
+	goerrors "errors"
 	"moodtracker/internal/contexts"
 	"moodtracker/internal/models"
 	"moodtracker/internal/services"
@@ -8,6 +10,7 @@ import (
 	"moodtracker/utils/errors"
 	"moodtracker/utils/validator"
 	"net/http"
+	"time"
 )
 
 type genericHandler[
@@ -25,6 +28,7 @@ type GenericHandlerInterface[
 	FindByID(w http.ResponseWriter, r *http.Request)
 	Save(w http.ResponseWriter, r *http.Request)
 	Update(w http.ResponseWriter, r *http.Request)
+	Patch(w http.ResponseWriter, r *http.Request)
 	Delete(w http.ResponseWriter, r *http.Request)
 }
 
@@ -58,7 +62,7 @@ func (h *genericHandler[T, D]) FindByID(w http.ResponseWriter, r *http.Request)
 		w, r,
 		http.StatusOK,
 		utils.Envelope{utils.GetTypeName(model): (*model).ToDTO()},
-		nil,
+		validators((*model).ETag(), (*model).LastModified()),
 		h.errRsp,
 	)
 }
@@ -79,7 +83,13 @@ func (h *genericHandler[T, D]) Save(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respond(w, r, http.StatusCreated, utils.Envelope{utils.GetTypeName(model): (*model).ToDTO()}, nil, h.errRsp)
+	respond(
+		w, r,
+		http.StatusCreated,
+		utils.Envelope{utils.GetTypeName(model): (*model).ToDTO()},
+		validators((*model).ETag(), time.Time{}),
+		h.errRsp,
+	)
 }
 
 func (h *genericHandler[T, D]) Update(w http.ResponseWriter, r *http.Request) {
@@ -93,12 +103,123 @@ func (h *genericHandler[T, D]) Update(w http.ResponseWriter, r *http.Request) {
 	user := contexts.ContextGetUser(r)
 	model := dto.ToModel()
 
+	sent, ok := ifMatch(r, (*model).ETag())
+	if sent && !ok {
+		h.errRsp.PreconditionFailedResponse(w, r)
+		return
+	}
+
+	if err := h.service.Update(model, user.ID, v); err != nil {
+		if sent && goerrors.Is(err, errors.ErrEditConflict) {
+			err = errors.ErrPreconditionFailed
+		}
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	respond(
+		w, r,
+		http.StatusOK,
+		utils.Envelope{utils.GetTypeName(model): (*model).ToDTO()},
+		validators((*model).ETag(), time.Time{}),
+		h.errRsp,
+	)
+}
+
+// Patch applies an RFC 7396 merge patch to the record in the URL. The record
+// version must be sent in If-Match; id and version can't be patched.
+func (h *genericHandler[T, D]) Patch(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseUUID(w, r, h.errRsp)
+	if !ok {
+		return
+	}
+
+	if r.Header.Get("If-Match") == "" {
+		h.errRsp.PreconditionRequiredResponse(w, r)
+		return
+	}
+
+	var patch map[string]any
+	if err := utils.ReadJSON(w, r, &patch); err != nil {
+		h.errRsp.BadRequestResponse(w, r, err)
+		return
+	}
+	if patch == nil {
+		h.errRsp.BadRequestResponse(w, r, goerrors.New("body must be a JSON object"))
+		return
+	}
+
+	v := validator.New()
+	user := contexts.ContextGetUser(r)
+
+	current, err := h.service.FindByID(id, user.ID)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, nil)
+		return
+	}
+
+	target, err := toJSONObject((*current).ToDTO())
+	if err != nil {
+		h.errRsp.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	if _, ok := ifMatch(r, (*current).ETag()); !ok {
+		h.errRsp.PreconditionFailedResponse(w, r)
+		return
+	}
+
+	delete(patch, "id")
+	delete(patch, "version")
+
+	// The DTOs read a missing list as "keep the current items", so removing a
+	// list is sent on as emptying it.
+	for key, value := range patch {
+		if _, isList := target[key].([]any); isList && value == nil {
+			patch[key] = []any{}
+		}
+	}
+
+	merged, err := json.Marshal(utils.MergePatch(target, patch))
+	if err != nil {
+		h.errRsp.ServerErrorResponse(w, r, err)
+		return
+	}
+
+	var dto D
+	if err := utils.DecodeJSON(merged, &dto); err != nil {
+		h.errRsp.BadRequestResponse(w, r, err)
+		return
+	}
+
+	model := dto.ToModel()
+
 	if err := h.service.Update(model, user.ID, v); err != nil {
+		if goerrors.Is(err, errors.ErrEditConflict) {
+			err = errors.ErrPreconditionFailed
+		}
 		h.errRsp.HandlerError(w, r, err, v)
 		return
 	}
 
-	respond(w, r, http.StatusOK, utils.Envelope{utils.GetTypeName(model): (*model).ToDTO()}, nil, h.errRsp)
+	respond(
+		w, r,
+		http.StatusOK,
+		utils.Envelope{utils.GetTypeName(model): (*model).ToDTO()},
+		validators((*model).ETag(), time.Time{}),
+		h.errRsp,
+	)
+}
+
+func toJSONObject(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var object map[string]any
+	err = json.Unmarshal(data, &object)
+	return object, err
 }
 
 func (h *genericHandler[T, D]) Delete(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"moodtracker/internal/contexts"
+	"moodtracker/internal/jsonlog"
+	"moodtracker/internal/models"
+	"moodtracker/internal/services"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+type fakeGenericService[T models.ModelInterface[D], D any] struct {
+	services.GenericServiceInterface[T, D]
+	record  *T
+	updated *T
+	err     error
+}
+
+func (s *fakeGenericService[T, D]) FindByID(id, userID uuid.UUID) (*T, error) {
+	if s.record == nil {
+		return nil, e.ErrRecordNotFound
+	}
+	record := *s.record
+	return &record, nil
+}
+
+func (s *fakeGenericService[T, D]) Update(entity *T, userID uuid.UUID, v *validator.Validator) error {
+	s.updated = entity
+	return s.err
+}
+
+func newTestErrorHandler() e.ErrorHandlerInterface {
+	return e.NewErrorHandler(jsonlog.New(io.Discard, jsonlog.LevelOff))
+}
+
+// newTestRequest builds a request for the record id made by an authenticated
+// user, as the router would pass it to a handler.
+func newTestRequest(method string, id uuid.UUID, body string, headers map[string]string) *http.Request {
+	r := httptest.NewRequest(method, "/v1/records/"+id.String(), strings.NewReader(body))
+	for name, value := range headers {
+		r.Header.Set(name, value)
+	}
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id.String())
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	return contexts.ContextSetUser(r, &models.User{ID: uuid.New()})
+}
+
+func TestGenericHandlerPatch(t *testing.T) {
+	id := uuid.New()
+
+	tests := []struct {
+		name      string
+		ifMatch   string
+		body      string
+		missing   bool
+		updateErr error
+		status    int
+		want      string
+	}{
+		{"no If-Match", "", `{"name":"gym"}`, false, nil, http.StatusPreconditionRequired, ""},
+		{"stale version", `"2"`, `{"name":"gym"}`, false, nil, http.StatusPreconditionFailed, ""},
+		{"not a version", `"abc"`, `{"name":"gym"}`, false, nil, http.StatusPreconditionFailed, ""},
+		{"null body", `"3"`, `null`, false, nil, http.StatusBadRequest, ""},
+		{"array body", `"3"`, `[{"name":"gym"}]`, false, nil, http.StatusBadRequest, ""},
+		{"unknown member", `"3"`, `{"colour":"red"}`, false, nil, http.StatusBadRequest, ""},
+		{"unknown record", `"3"`, `{"name":"gym"}`, true, nil, http.StatusNotFound, ""},
+		{"patched", `"3"`, `{"name":"gym"}`, false, nil, http.StatusOK, "gym"},
+		{"weak entity tag", `W/"3"`, `{"name":"gym"}`, false, nil, http.StatusOK, "gym"},
+		{"empty patch", `"3"`, `{}`, false, nil, http.StatusOK, "work"},
+		{"id and version are kept", `"3"`, `{"name":"gym","id":"` + uuid.NewString() + `","version":9}`, false, nil, http.StatusOK, "gym"},
+		{"edit conflict", `"3"`, `{"name":"gym"}`, false, e.ErrEditConflict, http.StatusPreconditionFailed, "gym"},
+	}
+
+	for _, tt := range tests {
+		service := &fakeGenericService[models.Tag, models.TagDTO]{err: tt.updateErr}
+		if !tt.missing {
+			service.record = &models.Tag{ID: id, Name: "work", BaseModel: models.BaseModel{Version: 3}}
+		}
+		h := NewGenericHandler(service, newTestErrorHandler())
+
+		w := httptest.NewRecorder()
+		h.Patch(w, newTestRequest(http.MethodPatch, id, tt.body, map[string]string{"If-Match": tt.ifMatch}))
+
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.status)
+		}
+
+		if tt.want == "" {
+			if service.updated != nil {
+				t.Errorf("%s: record was updated", tt.name)
+			}
+			continue
+		}
+
+		if service.updated == nil {
+			t.Errorf("%s: record was not updated", tt.name)
+			continue
+		}
+		if got := service.updated; got.Name != tt.want || got.ID != id || got.Version != 3 {
+			t.Errorf("%s: updated %s %q at version %d, want %s %q at version 3",
+				tt.name, got.ID, got.Name, got.Version, id, tt.want)
+		}
+	}
+}
+
+func TestGenericHandlerPatchLists(t *testing.T) {
+	id := uuid.New()
+
+	tests := []struct {
+		name string
+		body string
+		tags []string
+	}{
+		{"list not in the patch", `{"description":"long walk"}`, []string{"work", "gym"}},
+		{"list replaced", `{"tags":["sleep"]}`, []string{"sleep"}},
+		{"list removed", `{"tags":null}`, []string{}},
+	}
+
+	for _, tt := range tests {
+		service := &fakeGenericService[models.Daylog, models.DaylogDTO]{
+			record: &models.Daylog{
+				ID:        id,
+				Date:      time.Date(2026, 5, 4, 0, 0, 0, 0, time.UTC),
+				MoodLabel: 4,
+				Tags:      []string{"work", "gym"},
+				BaseModel: models.BaseModel{Version: 1},
+			},
+		}
+		h := NewGenericHandler(service, newTestErrorHandler())
+
+		w := httptest.NewRecorder()
+		h.Patch(w, newTestRequest(http.MethodPatch, id, tt.body, map[string]string{"If-Match": `"1"`}))
+
+		if w.Code != http.StatusOK || service.updated == nil {
+			t.Errorf("%s: status = %d, body %s", tt.name, w.Code, w.Body)
+			continue
+		}
+
+		// A nil list would tell the service to keep the current tags.
+		if got := service.updated.Tags; got == nil || !slices.Equal(got, tt.tags) {
+			t.Errorf("%s: tags = %#v, want %q", tt.name, got, tt.tags)
+		}
+	}
+}
+
+func TestGenericHandlerFindByID(t *testing.T) {
+	id := uuid.New()
+	updated := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		missing bool
+		headers map[string]string
+		status  int
+	}{
+		{"plain GET", false, nil, http.StatusOK},
+		{"same entity tag", false, map[string]string{"If-None-Match": `"3"`}, http.StatusNotModified},
+		{"older entity tag", false, map[string]string{"If-None-Match": `"2"`}, http.StatusOK},
+		{"not modified since", false, map[string]string{"If-Modified-Since": updated.Format(http.TimeFormat)}, http.StatusNotModified},
+		{"modified since", false, map[string]string{"If-Modified-Since": updated.Add(-time.Minute).Format(http.TimeFormat)}, http.StatusOK},
+		{"unknown record", true, nil, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		service := &fakeGenericService[models.Tag, models.TagDTO]{}
+		if !tt.missing {
+			service.record = &models.Tag{ID: id, Name: "work", BaseModel: models.BaseModel{Version: 3, UpdatedAt: &updated}}
+		}
+		h := NewGenericHandler(service, newTestErrorHandler())
+
+		w := httptest.NewRecorder()
+		h.FindByID(w, newTestRequest(http.MethodGet, id, "", tt.headers))
+
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.status)
+		}
+		if tt.missing {
+			continue
+		}
+		if got := w.Header().Get("ETag"); got != `"3"` {
+			t.Errorf("%s: ETag = %q", tt.name, got)
+		}
+		if got := w.Header().Get("Last-Modified"); got != updated.Format(http.TimeFormat) {
+			t.Errorf("%s: Last-Modified = %q", tt.name, got)
+		}
+	}
+}
+
+func TestGenericHandlerUpdate(t *testing.T) {
+	id := uuid.New()
+	body := `{"id":"` + id.String() + `","name":"gym","version":3}`
+
+	tests := []struct {
+		name      string
+		ifMatch   string
+		updateErr error
+		status    int
+		updated   bool
+	}{
+		{"without If-Match", "", nil, http.StatusOK, true},
+		{"conflict without If-Match", "", e.ErrEditConflict, http.StatusConflict, true},
+		{"matching If-Match", `"3"`, nil, http.StatusOK, true},
+		{"stale If-Match", `"2"`, nil, http.StatusPreconditionFailed, false},
+		{"conflict with If-Match", `"3"`, e.ErrEditConflict, http.StatusPreconditionFailed, true},
+	}
+
+	for _, tt := range tests {
+		service := &fakeGenericService[models.Tag, models.TagDTO]{err: tt.updateErr}
+		h := NewGenericHandler(service, newTestErrorHandler())
+
+		w := httptest.NewRecorder()
+		h.Update(w, newTestRequest(http.MethodPut, id, body, map[string]string{"If-Match": tt.ifMatch}))
+
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.status)
+		}
+		if updated := service.updated != nil; updated != tt.updated {
+			t.Errorf("%s: updated = %v, want %v", tt.name, updated, tt.updated)
+		}
+	}
+}
+
+func TestGenericHandlerFindByIDDaylog(t *testing.T) {
+	id := uuid.New()
+	updated := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+
+	daylog := func() *models.Daylog {
+		return &models.Daylog{
+			ID:        id,
+			Date:      time.Date(2026, 5, 4, 0, 0, 0, 0, time.UTC),
+			MoodLabel: 4,
+			Tags:      []string{"work", "gym"},
+			BaseModel: models.BaseModel{Version: 3, UpdatedAt: &updated},
+		}
+	}
+
+	service := &fakeGenericService[models.Daylog, models.DaylogDTO]{record: daylog()}
+	h := NewGenericHandler(service, newTestErrorHandler())
+
+	w := httptest.NewRecorder()
+	h.FindByID(w, newTestRequest(http.MethodGet, id, "", nil))
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" || w.Header().Get("Last-Modified") != "" {
+		t.Fatalf("status = %d, ETag = %q, Last-Modified = %q", w.Code, etag, w.Header().Get("Last-Modified"))
+	}
+
+	tests := []struct {
+		name    string
+		change  func(*models.Daylog)
+		headers map[string]string
+		status  int
+	}{
+		{"unchanged", func(d *models.Daylog) {}, map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"tag renamed", func(d *models.Daylog) { d.Tags = []string{"work", "running"} }, map[string]string{"If-None-Match": etag}, http.StatusOK},
+		{"tracker renamed", func(d *models.Daylog) {
+			d.Trackers = []*models.TrackerValue{{TrackerID: uuid.New(), Name: "Water", Unit: "ml"}}
+		}, map[string]string{"If-None-Match": etag}, http.StatusOK},
+		{"tag renamed, by date", func(d *models.Daylog) { d.Tags = []string{"work", "running"} },
+			map[string]string{"If-Modified-Since": updated.Format(http.TimeFormat)}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		service.record = daylog()
+		tt.change(service.record)
+
+		w := httptest.NewRecorder()
+		h.FindByID(w, newTestRequest(http.MethodGet, id, "", tt.headers))
+
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.status)
+		}
+	}
+
+	// The entity tag of the GET still works as If-Match.
+	service.record = daylog()
+	w = httptest.NewRecorder()
+	h.Patch(w, newTestRequest(http.MethodPatch, id, `{"description":"long walk"}`, map[string]string{"If-Match": etag}))
+	if w.Code != http.StatusOK || service.updated == nil {
+		t.Errorf("patch with the GET entity tag: status = %d, body %s", w.Code, w.Body)
+	}
+}
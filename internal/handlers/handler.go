@@ -2,23 +2,39 @@ package handlers
 
 import (
 	"database/sql"
+	"maps"
+	"moodtracker/internal/blob"
 	"moodtracker/internal/config"
 	"moodtracker/internal/jsonlog"
+	"moodtracker/internal/mailer"
+	"moodtracker/internal/notify"
+	"moodtracker/internal/passwords"
 	"moodtracker/internal/services"
+	"moodtracker/internal/signing"
 	"moodtracker/utils"
 	"moodtracker/utils/errors"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Handler struct {
-	User    UserHandlerInterface
-	Auth    AuthHandlerInterface
-	Service *services.Services
-	Daylog  DaylogHandler
-	Tag     TagHandler
-	Report  ReportHandler
+	User        UserHandlerInterface
+	Auth        AuthHandlerInterface
+	Service     *services.Services
+	Daylog      DaylogHandler
+	Tag         TagHandler
+	Report      ReportHandler
+	Admin       AdminHandler
+	MFA         MFAHandler
+	AccessToken AccessTokenHandler
+	Trash       TrashHandler
+	Tracker     TrackerHandler
+	Attachment  AttachmentHandler
+	Reminder    ReminderHandler
 }
 
 func NewHandler(
@@ -26,16 +42,29 @@ func NewHandler(
 	errRsp errors.ErrorHandlerInterface,
 	config config.Config,
 	logger jsonlog.Logger,
+	mailer mailer.Mailer,
+	store blob.Store,
+	channels notify.Channels,
+	keys *signing.KeySet,
+	policy *passwords.Policy,
+	wg *sync.WaitGroup,
 ) *Handler {
-	s := services.NewServices(logger, db, config)
+	s := services.NewServices(logger, db, config, mailer, store, channels, keys, policy, wg)
 
 	return &Handler{
-		Service: s,
-		User:    NewUserHandler(s.User, errRsp),
-		Auth:    NewAuthHandler(s.Auth, errRsp),
-		Daylog:  NewDaylogHandler(s.Daylog, errRsp),
-		Tag:     NewTagHandler(s.Tag, errRsp),
-		Report:  NewReportHandler(s.Report, errRsp),
+		Service:     s,
+		User:        NewUserHandler(s.User, errRsp),
+		Auth:        NewAuthHandler(s.Auth, errRsp),
+		Daylog:      NewDaylogHandler(s.Daylog, errRsp),
+		Tag:         NewTagHandler(s.Tag, errRsp),
+		Report:      NewReportHandler(s.Report, errRsp),
+		Admin:       NewAdminHandler(s.Admin, errRsp),
+		MFA:         NewMFAHandler(s.MFA, errRsp),
+		AccessToken: NewAccessTokenHandler(s.AccessToken, errRsp),
+		Trash:       NewTrashHandler(s.Trash, errRsp),
+		Tracker:     NewTrackerHandler(s.Tracker, errRsp),
+		Attachment:  NewAttachmentHandler(s.Attachment, errRsp),
+		Reminder:    NewReminderHandler(s.Reminder, errRsp),
 	}
 }
 
@@ -73,6 +102,9 @@ func parseUUID(
 	return uid, true
 }
 
+// respond writes data as JSON. When headers carry the ETag or Last-Modified
+// of the response and the conditional headers of a GET match them, it answers
+// 304 with no body instead.
 func respond(
 	w http.ResponseWriter,
 	r *http.Request,
@@ -81,9 +113,80 @@ func respond(
 	headers http.Header,
 	errRsp errors.ErrorHandlerInterface,
 ) {
+	if status == http.StatusOK && notModified(r, headers) {
+		maps.Copy(w.Header(), headers)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	err := utils.WriteJSON(w, status, data, headers)
 	if err != nil {
 		errRsp.ServerErrorResponse(w, r, err)
 		return
 	}
 }
+
+// validators builds the headers clients use to revalidate a cached response.
+func validators(etag string, lastModified time.Time) http.Header {
+	headers := make(http.Header)
+	headers.Set("Cache-Control", "private, no-cache")
+
+	if etag != "" {
+		headers.Set("ETag", etag)
+	}
+
+	if !lastModified.IsZero() {
+		headers.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	return headers
+}
+
+func notModified(r *http.Request, headers http.Header) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		etag := headers.Get("ETag")
+		if etag == "" {
+			return false
+		}
+
+		for candidate := range strings.SplitSeq(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || weakETag(candidate) == weakETag(etag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	lastModified, err := http.ParseTime(headers.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.After(since)
+}
+
+func weakETag(etag string) string {
+	return strings.TrimPrefix(etag, "W/")
+}
+
+// ifMatch reports whether the request sent If-Match and, if so, whether it
+// names the version with the given entity tag.
+func ifMatch(r *http.Request, etag string) (sent, ok bool) {
+	version, found, err := utils.ReadIfMatchVersion(r)
+	if !found {
+		return false, false
+	}
+
+	current, etagErr := utils.ETagVersion(etag)
+	return true, err == nil && etagErr == nil && version == current
+}
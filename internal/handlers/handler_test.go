@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"moodtracker/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestValidators(t *testing.T) {
+	modified := time.Date(2026, 5, 4, 7, 30, 15, 0, time.FixedZone("BRT", -3*3600))
+
+	tests := []struct {
+		name         string
+		etag         string
+		modified     time.Time
+		wantETag     string
+		wantModified string
+	}{
+		{"both", `"3"`, modified, `"3"`, "Mon, 04 May 2026 10:30:15 GMT"},
+		{"no last modified", `"3"`, time.Time{}, `"3"`, ""},
+		{"no entity tag", "", modified, "", "Mon, 04 May 2026 10:30:15 GMT"},
+		{"neither", "", time.Time{}, "", ""},
+	}
+
+	for _, tt := range tests {
+		headers := validators(tt.etag, tt.modified)
+
+		if got := headers.Get("ETag"); got != tt.wantETag {
+			t.Errorf("%s: ETag = %q, want %q", tt.name, got, tt.wantETag)
+		}
+		if got := headers.Get("Last-Modified"); got != tt.wantModified {
+			t.Errorf("%s: Last-Modified = %q, want %q", tt.name, got, tt.wantModified)
+		}
+		if got := headers.Get("Cache-Control"); got != "private, no-cache" {
+			t.Errorf("%s: Cache-Control = %q", tt.name, got)
+		}
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	httpDate := func(t time.Time) string { return t.Format(http.TimeFormat) }
+
+	tests := []struct {
+		name            string
+		method          string
+		etag            string
+		modified        time.Time
+		ifNoneMatch     string
+		ifModifiedSince string
+		want            bool
+	}{
+		{"no conditional headers", http.MethodGet, `"3"`, modified, "", "", false},
+		{"same entity tag", http.MethodGet, `"3"`, modified, `"3"`, "", true},
+		{"weak entity tag", http.MethodGet, `"3"`, modified, `W/"3"`, "", true},
+		{"weak response tag", http.MethodGet, `W/"ab"`, modified, `"ab"`, "", true},
+		{"other entity tag", http.MethodGet, `"3"`, modified, `"2"`, "", false},
+		{"one of several tags", http.MethodGet, `"3"`, modified, `"1", "2" ,"3"`, "", true},
+		{"any tag", http.MethodGet, `"3"`, modified, "*", "", true},
+		{"response without a tag", http.MethodGet, "", modified, `"3"`, "", false},
+		{"HEAD", http.MethodHead, `"3"`, modified, `"3"`, "", true},
+		{"PUT", http.MethodPut, `"3"`, modified, `"3"`, "", false},
+		{"entity tag wins over the date", http.MethodGet, `"3"`, modified, `"2"`, httpDate(modified), false},
+		{"not modified since", http.MethodGet, `"3"`, modified, "", httpDate(modified), true},
+		{"modified before", http.MethodGet, `"3"`, modified, "", httpDate(modified.Add(time.Hour)), true},
+		{"modified after", http.MethodGet, `"3"`, modified, "", httpDate(modified.Add(-time.Second)), false},
+		{"within the same second", http.MethodGet, `"3"`, modified.Add(500 * time.Millisecond), "", httpDate(modified), true},
+		{"invalid date", http.MethodGet, `"3"`, modified, "", "yesterday", false},
+		{"response without a date", http.MethodGet, `"3"`, time.Time{}, "", httpDate(modified), false},
+		{"POST", http.MethodPost, `"3"`, modified, "", httpDate(modified), false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, "/v1/daylogs", nil)
+		if tt.ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", tt.ifNoneMatch)
+		}
+		if tt.ifModifiedSince != "" {
+			r.Header.Set("If-Modified-Since", tt.ifModifiedSince)
+		}
+
+		if got := notModified(r, validators(tt.etag, tt.modified)); got != tt.want {
+			t.Errorf("%s: notModified = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		sent   bool
+		ok     bool
+	}{
+		{"", false, false},
+		{`"3"`, true, true},
+		{`W/"3"`, true, true},
+		{"3", true, true},
+		{`"4"`, true, false},
+		{`"abc"`, true, false},
+		{"*", true, false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPut, "/v1/tags", nil)
+		r.Header.Set("If-Match", tt.header)
+
+		sent, ok := ifMatch(r, `"3"`)
+		if sent != tt.sent || ok != tt.ok {
+			t.Errorf("ifMatch(%q) = (%v, %v), want (%v, %v)", tt.header, sent, ok, tt.sent, tt.ok)
+		}
+	}
+}
+
+func TestRespond(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		status      int
+		ifNoneMatch string
+		wantStatus  int
+		wantBody    bool
+	}{
+		{"fresh GET", http.MethodGet, http.StatusOK, "", http.StatusOK, true},
+		{"revalidated GET", http.MethodGet, http.StatusOK, `"3"`, http.StatusNotModified, false},
+		{"stale GET", http.MethodGet, http.StatusOK, `"2"`, http.StatusOK, true},
+		{"created", http.MethodPost, http.StatusCreated, `"3"`, http.StatusCreated, true},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, "/v1/tags", nil)
+		if tt.ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", tt.ifNoneMatch)
+		}
+
+		w := httptest.NewRecorder()
+		respond(w, r, tt.status, utils.Envelope{"tag": "work"}, validators(`"3"`, time.Time{}), newTestErrorHandler())
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.wantStatus)
+		}
+		if got := w.Header().Get("ETag"); got != `"3"` {
+			t.Errorf("%s: ETag = %q", tt.name, got)
+		}
+		if hasBody := w.Body.Len() > 0; hasBody != tt.wantBody {
+			t.Errorf("%s: body %q", tt.name, w.Body)
+		}
+	}
+}
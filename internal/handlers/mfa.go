@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"moodtracker/internal/contexts"
+	"moodtracker/internal/services"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"net/http"
+)
+
+type mfaHandler struct {
+	mfa    services.MFAService
+	errRsp e.ErrorHandlerInterface
+}
+
+type MFAHandler interface {
+	EnrollTOTP(w http.ResponseWriter, r *http.Request)
+	ConfirmTOTP(w http.ResponseWriter, r *http.Request)
+	DisableTOTP(w http.ResponseWriter, r *http.Request)
+	RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request)
+}
+
+func NewMFAHandler(
+	mfa services.MFAService,
+	errRsp e.ErrorHandlerInterface,
+) *mfaHandler {
+	return &mfaHandler{
+		mfa:    mfa,
+		errRsp: errRsp,
+	}
+}
+
+func (h *mfaHandler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Password string `json:"password"`
+	}
+
+	err := utils.ReadJSON(w, r, &input)
+	if err != nil {
+		h.errRsp.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	user := contexts.ContextGetUser(r)
+	enrollment, err := h.mfa.EnrollTOTP(user, input.Password, v)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	respond(w, r, http.StatusCreated, utils.Envelope{"totp": enrollment}, nil, h.errRsp)
+}
+
+func (h *mfaHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Code string `json:"code"`
+	}
+
+	err := utils.ReadJSON(w, r, &input)
+	if err != nil {
+		h.errRsp.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	user := contexts.ContextGetUser(r)
+	codes, err := h.mfa.ConfirmTOTP(user, input.Code, v)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	respond(w, r, http.StatusOK, utils.Envelope{"recovery_codes": codes}, nil, h.errRsp)
+}
+
+func (h *mfaHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+
+	err := utils.ReadJSON(w, r, &input)
+	if err != nil {
+		h.errRsp.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	user := contexts.ContextGetUser(r)
+	err = h.mfa.DisableTOTP(user, input.Password, input.Code, v)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	respond(w, r, http.StatusNoContent, nil, nil, h.errRsp)
+}
+
+func (h *mfaHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Code string `json:"code"`
+	}
+
+	err := utils.ReadJSON(w, r, &input)
+	if err != nil {
+		h.errRsp.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	user := contexts.ContextGetUser(r)
+	codes, err := h.mfa.RegenerateRecoveryCodes(user, input.Code, v)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	respond(w, r, http.StatusOK, utils.Envelope{"recovery_codes": codes}, nil, h.errRsp)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"moodtracker/internal/contexts"
+	"moodtracker/internal/models"
+	"moodtracker/internal/models/filters"
+	"moodtracker/internal/services"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"net/http"
+)
+
+type reminderHandler struct {
+	reminder services.ReminderService
+	errRsp   e.ErrorHandlerInterface
+	GenericHandlerInterface[models.Reminder, models.ReminderDTO]
+}
+
+func NewReminderHandler(
+	reminder services.ReminderService,
+	errRsp e.ErrorHandlerInterface,
+) *reminderHandler {
+	return &reminderHandler{
+		reminder:                reminder,
+		errRsp:                  errRsp,
+		GenericHandlerInterface: NewGenericHandler(reminder, errRsp),
+	}
+}
+
+type ReminderHandler interface {
+	GetAllByUserID(w http.ResponseWriter, r *http.Request)
+	GenericHandlerInterface[
+		models.Reminder,
+		models.ReminderDTO,
+	]
+}
+
+func (h *reminderHandler) GetAllByUserID(w http.ResponseWriter, r *http.Request) {
+	var f filters.Filters
+
+	v := validator.New()
+	f.Page = utils.ReadIntParam(r, "page", 1, v)
+	f.PageSize = utils.ReadIntParam(r, "page_size", 20, v)
+	f.Sort = utils.ReadStringParam(r, "sort", "time_of_day")
+	f.SortSafelist = []string{"time_of_day", "next_run_at", "created_at", "-time_of_day", "-next_run_at", "-created_at"}
+
+	user := contexts.ContextGetUser(r)
+	reminders, metadata, err := h.reminder.GetAllByUserID(user.ID, f, v)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	dtos := make([]*models.ReminderDTO, 0, len(reminders))
+	for _, reminder := range reminders {
+		dtos = append(dtos, reminder.ToDTO())
+	}
+
+	respond(w, r, http.StatusOK, utils.Envelope{"reminders": dtos, "metadata": metadata}, nil, h.errRsp)
+}
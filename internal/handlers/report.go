@@ -9,6 +9,8 @@ import (
 	"moodtracker/utils/validator"
 	"net/http"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type reportHandler struct {
@@ -20,6 +22,7 @@ type ReportHandler interface {
 	GetMonthlyReport(w http.ResponseWriter, r *http.Request)
 	GetTagReport(w http.ResponseWriter, r *http.Request)
 	GetMoodReport(w http.ResponseWriter, r *http.Request)
+	GetTrackerReport(w http.ResponseWriter, r *http.Request)
 }
 
 func NewReportHandler(
@@ -69,7 +72,8 @@ func (h *reportHandler) GetTagReport(w http.ResponseWriter, r *http.Request) {
 func (h *reportHandler) GetMoodReport(w http.ResponseWriter, r *http.Request) {
 	v := validator.New()
 
-	moodLabel := utils.ReadIntParam(r, "mood_label", 1, v)
+	moodLabel := models.ParseMoodLabel(utils.ReadStringParam(r, "mood_label", "1"))
+	models.ValidateMoodLabel(v, moodLabel)
 
 	if !v.Valid() {
 		h.errorHandler.HandlerError(w, r, e.ErrInvalidData, v)
@@ -78,7 +82,7 @@ func (h *reportHandler) GetMoodReport(w http.ResponseWriter, r *http.Request) {
 
 	user := contexts.ContextGetUser(r)
 
-	moodReport, err := h.report.GetMoodReport(models.MoodLabel(moodLabel), user.ID)
+	moodReport, err := h.report.GetMoodReport(moodLabel, user.ID)
 	if err != nil {
 		h.errorHandler.HandlerError(w, r, err, nil)
 		return
@@ -86,3 +90,41 @@ func (h *reportHandler) GetMoodReport(w http.ResponseWriter, r *http.Request) {
 
 	respond(w, r, http.StatusOK, utils.Envelope{utils.GetTypeName(moodReport): moodReport}, nil, h.errorHandler)
 }
+
+func (h *reportHandler) GetTrackerReport(w http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+	qs := r.URL.Query()
+
+	trackerID, err := uuid.Parse(qs.Get("tracker_id"))
+	v.Check(err == nil, "tracker_id", "must be a valid id")
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	endDate := utils.ReadDate(qs, "end_date", time.DateOnly)
+	v.Check(qs.Get("end_date") == "" || endDate != nil, "end_date", "must be a valid date (YYYY-MM-DD)")
+	if endDate == nil {
+		endDate = &today
+	}
+
+	startDate := utils.ReadDate(qs, "start_date", time.DateOnly)
+	v.Check(qs.Get("start_date") == "" || startDate != nil, "start_date", "must be a valid date (YYYY-MM-DD)")
+	if startDate == nil {
+		start := endDate.AddDate(0, 0, -89)
+		startDate = &start
+	}
+
+	if !v.Valid() {
+		h.errorHandler.HandlerError(w, r, e.ErrInvalidData, v)
+		return
+	}
+
+	user := contexts.ContextGetUser(r)
+
+	report, err := h.report.GetTrackerReport(trackerID, *startDate, *endDate, user.ID, v)
+	if err != nil {
+		h.errorHandler.HandlerError(w, r, err, v)
+		return
+	}
+
+	respond(w, r, http.StatusOK, utils.Envelope{"tracker_report": report}, nil, h.errorHandler)
+}
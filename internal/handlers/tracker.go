@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"moodtracker/internal/contexts"
+	"moodtracker/internal/models"
+	"moodtracker/internal/models/filters"
+	"moodtracker/internal/services"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"net/http"
+)
+
+type trackerHandler struct {
+	tracker services.TrackerService
+	errRsp  e.ErrorHandlerInterface
+	GenericHandlerInterface[models.Tracker, models.TrackerDTO]
+}
+
+func NewTrackerHandler(
+	tracker services.TrackerService,
+	errRsp e.ErrorHandlerInterface,
+) *trackerHandler {
+	return &trackerHandler{
+		tracker:                 tracker,
+		errRsp:                  errRsp,
+		GenericHandlerInterface: NewGenericHandler(tracker, errRsp),
+	}
+}
+
+type TrackerHandler interface {
+	GetAllByUserID(w http.ResponseWriter, r *http.Request)
+	GenericHandlerInterface[
+		models.Tracker,
+		models.TrackerDTO,
+	]
+}
+
+func (h *trackerHandler) GetAllByUserID(w http.ResponseWriter, r *http.Request) {
+	var f filters.Filters
+
+	v := validator.New()
+	f.Page = utils.ReadIntParam(r, "page", 1, v)
+	f.PageSize = utils.ReadIntParam(r, "page_size", 20, v)
+	f.Sort = utils.ReadStringParam(r, "sort", "name")
+	f.SortSafelist = []string{"id", "name", "kind", "-id", "-name", "-kind"}
+
+	user := contexts.ContextGetUser(r)
+	trackers, metadata, err := h.tracker.GetAllByUserID(user.ID, f, v)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	dtos := make([]*models.TrackerDTO, 0, len(trackers))
+	for _, t := range trackers {
+		dtos = append(dtos, t.ToDTO())
+	}
+
+	respond(w, r, http.StatusOK, utils.Envelope{"trackers": dtos, "metadata": metadata}, nil, h.errRsp)
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"moodtracker/internal/contexts"
+	"moodtracker/internal/models"
+	"moodtracker/internal/models/filters"
+	"moodtracker/internal/services"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"net/http"
+)
+
+type trashHandler struct {
+	trash  services.TrashService
+	errRsp e.ErrorHandlerInterface
+}
+
+func NewTrashHandler(
+	trash services.TrashService,
+	errRsp e.ErrorHandlerInterface,
+) *trashHandler {
+	return &trashHandler{
+		trash:  trash,
+		errRsp: errRsp,
+	}
+}
+
+type TrashHandler interface {
+	GetAll(w http.ResponseWriter, r *http.Request)
+	RestoreDaylog(w http.ResponseWriter, r *http.Request)
+	RestoreTag(w http.ResponseWriter, r *http.Request)
+}
+
+func (h *trashHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		itemType string
+		filters.Filters
+	}
+
+	v := validator.New()
+
+	input.itemType = utils.ReadStringParam(r, "type", "")
+	input.Filters.Page = utils.ReadIntParam(r, "page", 1, v)
+	input.Filters.PageSize = utils.ReadIntParam(r, "page_size", 20, v)
+	input.Filters.Sort = utils.ReadStringParam(r, "sort", "-deleted_at")
+	input.Filters.SortSafelist = []string{"deleted_at", "type", "-deleted_at", "-type"}
+
+	if !v.Valid() {
+		h.errRsp.HandlerError(w, r, e.ErrInvalidData, v)
+		return
+	}
+
+	user := contexts.ContextGetUser(r)
+	items, metadata, err := h.trash.GetAll(user.ID, input.itemType, input.Filters, v)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	dtos := make([]*models.TrashItemDTO, 0, len(items))
+	for _, item := range items {
+		dtos = append(dtos, item.ToDTO())
+	}
+
+	respond(w, r, http.StatusOK, utils.Envelope{"trash": dtos, "metadata": metadata}, nil, h.errRsp)
+}
+
+func (h *trashHandler) RestoreDaylog(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseUUID(w, r, h.errRsp)
+	if !ok {
+		return
+	}
+
+	user := contexts.ContextGetUser(r)
+	daylog, err := h.trash.RestoreDaylog(id, user.ID)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, nil)
+		return
+	}
+
+	respond(w, r, http.StatusOK, utils.Envelope{"daylog": daylog.ToDTO()}, nil, h.errRsp)
+}
+
+func (h *trashHandler) RestoreTag(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseUUID(w, r, h.errRsp)
+	if !ok {
+		return
+	}
+
+	user := contexts.ContextGetUser(r)
+	tag, err := h.trash.RestoreTag(id, user.ID)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, nil)
+		return
+	}
+
+	respond(w, r, http.StatusOK, utils.Envelope{"tag": tag.ToDTO()}, nil, h.errRsp)
+}
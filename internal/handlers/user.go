@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"moodtracker/internal/contexts"
 	"moodtracker/internal/models"
 	"moodtracker/internal/services"
 	"moodtracker/utils"
@@ -17,6 +18,15 @@ type UserHandler struct {
 type UserHandlerInterface interface {
 	ActivateUserHandler(w http.ResponseWriter, r *http.Request)
 	CreateUserHandler(w http.ResponseWriter, r *http.Request)
+	ResendActivationHandler(w http.ResponseWriter, r *http.Request)
+	RequestPasswordResetHandler(w http.ResponseWriter, r *http.Request)
+	ResetPasswordHandler(w http.ResponseWriter, r *http.Request)
+	GetMeHandler(w http.ResponseWriter, r *http.Request)
+	UpdateMeHandler(w http.ResponseWriter, r *http.Request)
+	ChangePasswordHandler(w http.ResponseWriter, r *http.Request)
+	DeleteMeHandler(w http.ResponseWriter, r *http.Request)
+	RequestEmailChangeHandler(w http.ResponseWriter, r *http.Request)
+	ConfirmEmailChangeHandler(w http.ResponseWriter, r *http.Request)
 }
 
 func NewUserHandler(
@@ -62,6 +72,34 @@ func (h *UserHandler) ActivateUserHandler(w http.ResponseWriter, r *http.Request
 	)
 }
 
+func (h *UserHandler) ResendActivationHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Email string `json:"email"`
+	}
+
+	err := utils.ReadJSON(w, r, &input)
+	if err != nil {
+		h.errRsp.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	err = h.user.ResendActivationCode(input.Email, v)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	respond(
+		w,
+		r,
+		http.StatusAccepted,
+		utils.Envelope{"message": "if the account exists and is not yet activated, a new activation code will be sent to its email"},
+		nil,
+		h.errRsp,
+	)
+}
+
 func (h *UserHandler) CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 	var userDTO models.UserSaveDTO
 	if err := utils.ReadJSON(w, r, &userDTO); err != nil {
@@ -91,3 +129,238 @@ func (h *UserHandler) CreateUserHandler(w http.ResponseWriter, r *http.Request)
 		h.errRsp,
 	)
 }
+
+func (h *UserHandler) RequestPasswordResetHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Email string `json:"email"`
+	}
+
+	err := utils.ReadJSON(w, r, &input)
+	if err != nil {
+		h.errRsp.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	err = h.user.RequestPasswordReset(input.Email, v)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	respond(
+		w,
+		r,
+		http.StatusAccepted,
+		utils.Envelope{"message": "if the account exists, an email will be sent to it containing password reset instructions"},
+		nil,
+		h.errRsp,
+	)
+}
+
+func (h *UserHandler) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	err := utils.ReadJSON(w, r, &input)
+	if err != nil {
+		h.errRsp.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	err = h.user.ResetPassword(input.Token, input.Password, v)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	respond(
+		w,
+		r,
+		http.StatusOK,
+		utils.Envelope{"message": "your password was successfully reset"},
+		nil,
+		h.errRsp,
+	)
+}
+
+func (h *UserHandler) GetMeHandler(w http.ResponseWriter, r *http.Request) {
+	user := contexts.ContextGetUser(r)
+
+	respond(
+		w,
+		r,
+		http.StatusOK,
+		utils.Envelope{"user": user.ToDTO()},
+		nil,
+		h.errRsp,
+	)
+}
+
+func (h *UserHandler) UpdateMeHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name     *string `json:"name"`
+		Phone    *string `json:"phone"`
+		Locale   *string `json:"locale"`
+		TimeZone *string `json:"time_zone"`
+		Version  *int    `json:"version"`
+	}
+
+	err := utils.ReadJSON(w, r, &input)
+	if err != nil {
+		h.errRsp.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user := contexts.ContextGetUser(r)
+
+	if input.Version != nil && *input.Version != user.Version {
+		h.errRsp.EditConflictResponse(w, r)
+		return
+	}
+
+	if input.Name != nil {
+		user.Name = *input.Name
+	}
+
+	if input.Phone != nil {
+		user.Phone = *input.Phone
+	}
+
+	if input.Locale != nil {
+		user.Locale = *input.Locale
+	}
+
+	if input.TimeZone != nil {
+		user.TimeZone = *input.TimeZone
+	}
+
+	v := validator.New()
+	err = h.user.UpdateProfile(user, v)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	respond(
+		w,
+		r,
+		http.StatusOK,
+		utils.Envelope{"user": user.ToDTO()},
+		nil,
+		h.errRsp,
+	)
+}
+
+func (h *UserHandler) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+
+	err := utils.ReadJSON(w, r, &input)
+	if err != nil {
+		h.errRsp.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	user := contexts.ContextGetUser(r)
+	err = h.user.ChangePassword(user, input.CurrentPassword, input.NewPassword, v)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	respond(
+		w,
+		r,
+		http.StatusOK,
+		utils.Envelope{"message": "your password was successfully changed, please log in again"},
+		nil,
+		h.errRsp,
+	)
+}
+
+func (h *UserHandler) DeleteMeHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Password string `json:"password"`
+	}
+
+	err := utils.ReadJSON(w, r, &input)
+	if err != nil {
+		h.errRsp.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	user := contexts.ContextGetUser(r)
+	err = h.user.DeleteAccount(user, input.Password, v)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	respond(w, r, http.StatusNoContent, nil, nil, h.errRsp)
+}
+
+func (h *UserHandler) RequestEmailChangeHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+
+	err := utils.ReadJSON(w, r, &input)
+	if err != nil {
+		h.errRsp.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	user := contexts.ContextGetUser(r)
+	err = h.user.RequestEmailChange(user, input.Email, input.Password, v)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	respond(
+		w,
+		r,
+		http.StatusAccepted,
+		utils.Envelope{"message": "a confirmation token was sent to the new email address"},
+		nil,
+		h.errRsp,
+	)
+}
+
+func (h *UserHandler) ConfirmEmailChangeHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Token string `json:"token"`
+	}
+
+	err := utils.ReadJSON(w, r, &input)
+	if err != nil {
+		h.errRsp.BadRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	user, err := h.user.ConfirmEmailChange(input.Token, v)
+	if err != nil {
+		h.errRsp.HandlerError(w, r, err, v)
+		return
+	}
+
+	respond(
+		w,
+		r,
+		http.StatusOK,
+		utils.Envelope{"user": user.ToDTO()},
+		nil,
+		h.errRsp,
+	)
+}
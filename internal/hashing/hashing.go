@@ -0,0 +1,214 @@
+// Package hashing stores user passwords behind an algorithm identifier so the
+// hashing scheme can change without invalidating existing credentials. New
+// hashes use the configured default; older ones keep verifying with the
+// algorithm they were created with until they are re-hashed.
+package hashing
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"moodtracker/internal/config"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+var (
+	ErrUnknownAlgorithm = errors.New("unknown password hashing algorithm")
+	ErrInvalidHash      = errors.New("invalid password hash")
+)
+
+type Hasher interface {
+	Hash(plaintext string) ([]byte, error)
+	Verify(hash []byte, plaintext string) (bool, error)
+	// NeedsRehash reports whether hash was created with parameters other
+	// than the ones currently configured.
+	NeedsRehash(hash []byte) bool
+	// MaxLength is the longest password, in bytes, the algorithm accepts.
+	MaxLength() int
+}
+
+var (
+	defaultAlgorithm = AlgorithmArgon2id
+	hashers          = map[string]Hasher{
+		AlgorithmBcrypt:   Bcrypt{Cost: 12},
+		AlgorithmArgon2id: Argon2id{Memory: 64 * 1024, Iterations: 3, Parallelism: 2},
+	}
+)
+
+// Configure sets the default algorithm and its parameters from the
+// PASSWORD_HASH_* settings. It must be called before serving requests.
+func Configure(cfg config.Config) error {
+	p := cfg.Password
+
+	if _, ok := hashers[p.Algorithm]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownAlgorithm, p.Algorithm)
+	}
+
+	if p.BcryptCost < bcrypt.MinCost || p.BcryptCost > bcrypt.MaxCost {
+		return fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	if p.Argon2Memory < 8*uint32(p.Argon2Parallelism) || p.Argon2Iterations < 1 || p.Argon2Parallelism < 1 {
+		return errors.New("invalid argon2id parameters")
+	}
+
+	defaultAlgorithm = p.Algorithm
+	hashers[AlgorithmBcrypt] = Bcrypt{Cost: p.BcryptCost}
+	hashers[AlgorithmArgon2id] = Argon2id{
+		Memory:      p.Argon2Memory,
+		Iterations:  p.Argon2Iterations,
+		Parallelism: p.Argon2Parallelism,
+	}
+	return nil
+}
+
+func Default() string {
+	return defaultAlgorithm
+}
+
+func Get(algorithm string) (Hasher, error) {
+	h, ok := hashers[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, algorithm)
+	}
+	return h, nil
+}
+
+type Bcrypt struct {
+	Cost int
+}
+
+func (b Bcrypt) Hash(plaintext string) ([]byte, error) {
+	return bcrypt.GenerateFromPassword([]byte(plaintext), b.Cost)
+}
+
+func (b Bcrypt) Verify(hash []byte, plaintext string) (bool, error) {
+	if len(plaintext) > b.MaxLength() {
+		return false, nil
+	}
+
+	err := bcrypt.CompareHashAndPassword(hash, []byte(plaintext))
+	if err != nil {
+		switch {
+		case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (b Bcrypt) NeedsRehash(hash []byte) bool {
+	cost, err := bcrypt.Cost(hash)
+	return err != nil || cost != b.Cost
+}
+
+func (b Bcrypt) MaxLength() int {
+	return 72
+}
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+var argon2Encoding = base64.RawStdEncoding
+
+// Argon2id hashes are stored in the PHC string format
+// ($argon2id$v=19$m=65536,t=3,p=2$salt$key) so each hash carries the
+// parameters it was created with.
+type Argon2id struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+}
+
+func (a Argon2id) Hash(plaintext string) ([]byte, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	key := argon2.IDKey([]byte(plaintext), salt, a.Iterations, a.Memory, a.Parallelism, argon2KeyLength)
+
+	encoded := fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		a.Memory,
+		a.Iterations,
+		a.Parallelism,
+		argon2Encoding.EncodeToString(salt),
+		argon2Encoding.EncodeToString(key),
+	)
+	return []byte(encoded), nil
+}
+
+func (a Argon2id) Verify(hash []byte, plaintext string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+
+	other := argon2.IDKey(
+		[]byte(plaintext),
+		salt,
+		params.Iterations,
+		params.Memory,
+		params.Parallelism,
+		uint32(len(key)),
+	)
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+func (a Argon2id) NeedsRehash(hash []byte) bool {
+	params, _, key, err := decodeArgon2id(hash)
+	return err != nil || params != a || len(key) != argon2KeyLength
+}
+
+// MaxLength only guards against abuse of the request body; Argon2 itself
+// has no practical limit on the password length.
+func (a Argon2id) MaxLength() int {
+	return 1024
+}
+
+func decodeArgon2id(hash []byte) (Argon2id, []byte, []byte, error) {
+	var params Argon2id
+
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := argon2Encoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	key, err := argon2Encoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	return params, salt, key, nil
+}
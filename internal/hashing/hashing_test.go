@@ -0,0 +1,211 @@
+package hashing
+
+import (
+	"errors"
+	"maps"
+	"moodtracker/internal/config"
+	"strings"
+	"testing"
+)
+
+// The tests use the cheapest parameters each algorithm accepts.
+var (
+	testBcrypt   = Bcrypt{Cost: 4}
+	testArgon2id = Argon2id{Memory: 64, Iterations: 1, Parallelism: 1}
+)
+
+func TestHashers(t *testing.T) {
+	tests := []struct {
+		name   string
+		hasher Hasher
+		other  Hasher
+	}{
+		{"bcrypt", testBcrypt, Bcrypt{Cost: 5}},
+		{"argon2id", testArgon2id, Argon2id{Memory: 128, Iterations: 1, Parallelism: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := tt.hasher.Hash("correct horse battery staple")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checks := []struct {
+				plaintext string
+				match     bool
+			}{
+				{"correct horse battery staple", true},
+				{"Correct horse battery staple", false},
+				{"correct horse battery stapl", false},
+				{"", false},
+			}
+
+			for _, c := range checks {
+				match, err := tt.hasher.Verify(hash, c.plaintext)
+				if err != nil || match != c.match {
+					t.Errorf("Verify(%q) = %v, %v, want %v", c.plaintext, match, err, c.match)
+				}
+			}
+
+			again, err := tt.hasher.Hash("correct horse battery staple")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(again) == string(hash) {
+				t.Error("hashing the same password twice gave the same hash")
+			}
+
+			if tt.hasher.NeedsRehash(hash) {
+				t.Error("a hash with the current parameters needs a rehash")
+			}
+			if !tt.other.NeedsRehash(hash) {
+				t.Error("a hash with other parameters does not need a rehash")
+			}
+
+			// Hashes keep verifying after the parameters change.
+			if match, err := tt.other.Verify(hash, "correct horse battery staple"); err != nil || !match {
+				t.Errorf("Verify with other parameters = %v, %v", match, err)
+			}
+		})
+	}
+}
+
+func TestArgon2idFormat(t *testing.T) {
+	hash, err := testArgon2id.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(string(hash), "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("hash %s is not in the PHC format", hash)
+	}
+
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params != testArgon2id || len(salt) != argon2SaltLength || len(key) != argon2KeyLength {
+		t.Errorf("decoded %+v with %d byte salt and %d byte key", params, len(salt), len(key))
+	}
+}
+
+func TestArgon2idInvalidHash(t *testing.T) {
+	bcryptHash, err := testBcrypt.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{"bcrypt hash", string(bcryptHash)},
+		{"empty", ""},
+		{"missing key", "$argon2id$v=19$m=64,t=1,p=1$c29tZXNhbHQ"},
+		{"other variant", "$argon2i$v=19$m=64,t=1,p=1$c29tZXNhbHQ$a2V5"},
+		{"other version", "$argon2id$v=16$m=64,t=1,p=1$c29tZXNhbHQ$a2V5"},
+		{"bad parameters", "$argon2id$v=19$m=big,t=1,p=1$c29tZXNhbHQ$a2V5"},
+		{"bad salt", "$argon2id$v=19$m=64,t=1,p=1$!!!$a2V5"},
+		{"bad key", "$argon2id$v=19$m=64,t=1,p=1$c29tZXNhbHQ$!!!"},
+		{"empty key", "$argon2id$v=19$m=64,t=1,p=1$c29tZXNhbHQ$"},
+	}
+
+	for _, tt := range tests {
+		if match, err := testArgon2id.Verify([]byte(tt.hash), "password"); match || !errors.Is(err, ErrInvalidHash) {
+			t.Errorf("%s: Verify = %v, %v, want ErrInvalidHash", tt.name, match, err)
+		}
+		if !testArgon2id.NeedsRehash([]byte(tt.hash)) {
+			t.Errorf("%s: NeedsRehash = false", tt.name)
+		}
+	}
+}
+
+func TestBcryptMaxLength(t *testing.T) {
+	long := strings.Repeat("a", 72)
+
+	hash, err := testBcrypt.Hash(long)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// bcrypt ignores anything after 72 bytes, so longer passwords must not
+	// match a hash of their prefix.
+	if match, err := testBcrypt.Verify(hash, long+"b"); match || err != nil {
+		t.Errorf("Verify of a 73 byte password = %v, %v, want false", match, err)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	saved := maps.Clone(hashers)
+	savedDefault := defaultAlgorithm
+	t.Cleanup(func() {
+		hashers = saved
+		defaultAlgorithm = savedDefault
+	})
+
+	password := func(algorithm string, cost int, memory, iterations uint32, parallelism uint8) config.Config {
+		var cfg config.Config
+		cfg.Password.Algorithm = algorithm
+		cfg.Password.BcryptCost = cost
+		cfg.Password.Argon2Memory = memory
+		cfg.Password.Argon2Iterations = iterations
+		cfg.Password.Argon2Parallelism = parallelism
+		return cfg
+	}
+
+	tests := []struct {
+		name   string
+		config config.Config
+		valid  bool
+	}{
+		{"argon2id", password(AlgorithmArgon2id, 12, 64*1024, 3, 2), true},
+		{"bcrypt", password(AlgorithmBcrypt, 10, 64*1024, 3, 2), true},
+		{"unknown algorithm", password("md5", 12, 64*1024, 3, 2), false},
+		{"bcrypt cost too low", password(AlgorithmBcrypt, 3, 64*1024, 3, 2), false},
+		{"bcrypt cost too high", password(AlgorithmBcrypt, 32, 64*1024, 3, 2), false},
+		{"too little memory for the lanes", password(AlgorithmArgon2id, 12, 8, 1, 2), false},
+		{"no iterations", password(AlgorithmArgon2id, 12, 64*1024, 0, 2), false},
+		{"no parallelism", password(AlgorithmArgon2id, 12, 64*1024, 3, 0), false},
+	}
+
+	for _, tt := range tests {
+		hashers = maps.Clone(saved)
+		defaultAlgorithm = savedDefault
+
+		err := Configure(tt.config)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: Configure returned %v", tt.name, err)
+			continue
+		}
+
+		if !tt.valid {
+			if Default() != savedDefault {
+				t.Errorf("%s: the default changed to %q", tt.name, Default())
+			}
+			continue
+		}
+
+		if Default() != tt.config.Password.Algorithm {
+			t.Errorf("%s: default = %q, want %q", tt.name, Default(), tt.config.Password.Algorithm)
+		}
+
+		want := map[string]Hasher{
+			AlgorithmBcrypt: Bcrypt{Cost: tt.config.Password.BcryptCost},
+			AlgorithmArgon2id: Argon2id{
+				Memory:      tt.config.Password.Argon2Memory,
+				Iterations:  tt.config.Password.Argon2Iterations,
+				Parallelism: tt.config.Password.Argon2Parallelism,
+			},
+		}
+		for algorithm, hasher := range want {
+			if got, _ := Get(algorithm); got != hasher {
+				t.Errorf("%s: %s hasher = %+v, want %+v", tt.name, algorithm, got, hasher)
+			}
+		}
+	}
+
+	if _, err := Get("md5"); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("Get(md5) returned %v, want ErrUnknownAlgorithm", err)
+	}
+}
@@ -0,0 +1,75 @@
+package mailer
+
+import (
+	"fmt"
+	"moodtracker/internal/jsonlog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type fileMailer struct {
+	dir    string
+	sender string
+}
+
+func NewFileMailer(dir, sender string) (*fileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &fileMailer{
+		dir:    dir,
+		sender: sender,
+	}, nil
+}
+
+func (m *fileMailer) Send(recipient, locale, templateFile string, data any) error {
+	msg, err := render(m.sender, recipient, locale, templateFile, data)
+	if err != nil {
+		return err
+	}
+
+	body, err := msg.bytes()
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf(
+		"%s-%s-%s.eml",
+		time.Now().UTC().Format("20060102T150405.000000000"),
+		strings.TrimSuffix(templateFile, filepath.Ext(templateFile)),
+		strings.ReplaceAll(recipient, "@", "_at_"),
+	)
+
+	return os.WriteFile(filepath.Join(m.dir, filepath.Base(name)), body, 0o644)
+}
+
+type logMailer struct {
+	logger jsonlog.Logger
+	sender string
+}
+
+func NewLogMailer(logger jsonlog.Logger, sender string) *logMailer {
+	return &logMailer{
+		logger: logger,
+		sender: sender,
+	}
+}
+
+// Send renders the message, so template errors still surface, but only logs
+// who it was for. The content carries activation codes and reset links.
+func (m *logMailer) Send(recipient, locale, templateFile string, data any) error {
+	msg, err := render(m.sender, recipient, locale, templateFile, data)
+	if err != nil {
+		return err
+	}
+
+	m.logger.PrintInfo("email sent", map[string]string{
+		"to":       msg.recipient,
+		"template": templateFile,
+		"locale":   locale,
+	})
+	return nil
+}
@@ -0,0 +1,111 @@
+package mailer
+
+import (
+	"bytes"
+	"encoding/json"
+	"moodtracker/internal/config"
+	"moodtracker/internal/jsonlog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var activationData = map[string]any{
+	"name":  "Ana",
+	"email": "ana@example.com",
+	"code":  482913,
+	"ttl":   "15 minutes",
+}
+
+func TestLogMailerOmitsContent(t *testing.T) {
+	var out bytes.Buffer
+	m := NewLogMailer(jsonlog.New(&out, jsonlog.LevelInfo), "MoodTracker <no-reply@moodtracker.local>")
+
+	if err := m.Send("ana@example.com", "en", "user_activation.tmpl", activationData); err != nil {
+		t.Fatal(err)
+	}
+
+	var entry struct {
+		Properties map[string]string `json:"properties"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("log line %q: %v", out.String(), err)
+	}
+
+	want := map[string]string{
+		"to":       "ana@example.com",
+		"template": "user_activation.tmpl",
+		"locale":   "en",
+	}
+	if len(entry.Properties) != len(want) {
+		t.Errorf("logged properties %v, want only %v", entry.Properties, want)
+	}
+	for name, value := range want {
+		if entry.Properties[name] != value {
+			t.Errorf("%s = %q, want %q", name, entry.Properties[name], value)
+		}
+	}
+
+	if strings.Contains(out.String(), "482913") {
+		t.Errorf("the activation code was logged: %s", out.String())
+	}
+}
+
+func TestLogMailerReportsTemplateErrors(t *testing.T) {
+	m := NewLogMailer(jsonlog.New(&bytes.Buffer{}, jsonlog.LevelInfo), "")
+
+	if err := m.Send("ana@example.com", "en", "missing.tmpl", nil); err == nil {
+		t.Error("Send with an unknown template returned nil")
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+
+	m, err := NewFileMailer(dir, "MoodTracker <no-reply@moodtracker.local>")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Send("ana@example.com", "en", "user_activation.tmpl", activationData); err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*-user_activation-ana_at_example.com.eml"))
+	if len(files) != 1 {
+		t.Fatalf("found %v in %s, want one .eml file", files, dir)
+	}
+
+	body, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(body, []byte("482913")) {
+		t.Errorf("message does not contain the activation code:\n%s", body)
+	}
+}
+
+func TestNewRequiresKnownDriver(t *testing.T) {
+	tests := []struct {
+		driver string
+		ok     bool
+	}{
+		{"log", true},
+		{"smtp", true},
+		{"file", true},
+		{"", false},
+		{"sendmail", false},
+	}
+
+	for _, tt := range tests {
+		var cfg config.Config
+		cfg.Mailer.Driver = tt.driver
+		cfg.Mailer.FileDir = t.TempDir()
+
+		_, err := New(cfg, jsonlog.New(&bytes.Buffer{}, jsonlog.LevelInfo))
+		if (err == nil) != tt.ok {
+			t.Errorf("New with driver %q returned %v", tt.driver, err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"mime/multipart"
+	"moodtracker/internal/config"
+	"moodtracker/internal/jsonlog"
+	"net/textproto"
+	"path"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed "templates"
+var templateFS embed.FS
+
+const defaultLocale = "pt-BR"
+
+type Mailer interface {
+	Send(recipient, locale, templateFile string, data any) error
+}
+
+type message struct {
+	sender    string
+	recipient string
+	subject   string
+	plainBody string
+	htmlBody  string
+}
+
+func New(cfg config.Config, logger jsonlog.Logger) (Mailer, error) {
+	switch cfg.Mailer.Driver {
+	case "smtp":
+		return NewSMTPMailer(
+			cfg.Mailer.Host,
+			cfg.Mailer.Port,
+			cfg.Mailer.Username,
+			cfg.Mailer.Password,
+			cfg.Mailer.Sender,
+		), nil
+	case "file":
+		return NewFileMailer(cfg.Mailer.FileDir, cfg.Mailer.Sender)
+	case "log":
+		return NewLogMailer(logger, cfg.Mailer.Sender), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer driver %q", cfg.Mailer.Driver)
+	}
+}
+
+func render(sender, recipient, locale, templateFile string, data any) (*message, error) {
+	name := templatePath(locale, templateFile)
+
+	textTmpl, err := texttemplate.New("email").ParseFS(templateFS, name)
+	if err != nil {
+		return nil, err
+	}
+
+	htmlTmpl, err := template.New("email").ParseFS(templateFS, name)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := new(bytes.Buffer)
+	if err := textTmpl.ExecuteTemplate(subject, "subject", data); err != nil {
+		return nil, err
+	}
+
+	plainBody := new(bytes.Buffer)
+	if err := textTmpl.ExecuteTemplate(plainBody, "plainBody", data); err != nil {
+		return nil, err
+	}
+
+	htmlBody := new(bytes.Buffer)
+	if err := htmlTmpl.ExecuteTemplate(htmlBody, "htmlBody", data); err != nil {
+		return nil, err
+	}
+
+	return &message{
+		sender:    sender,
+		recipient: recipient,
+		subject:   strings.TrimSpace(subject.String()),
+		plainBody: plainBody.String(),
+		htmlBody:  htmlBody.String(),
+	}, nil
+}
+
+func templatePath(locale, templateFile string) string {
+	name := path.Join("templates", locale, templateFile)
+	if _, err := fs.Stat(templateFS, name); err != nil {
+		return path.Join("templates", defaultLocale, templateFile)
+	}
+	return name
+}
+
+func (m *message) bytes() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	writer := multipart.NewWriter(buf)
+
+	fmt.Fprintf(buf, "From: %s\r\n", m.sender)
+	fmt.Fprintf(buf, "To: %s\r\n", m.recipient)
+	fmt.Fprintf(buf, "Subject: %s\r\n", m.subject)
+	fmt.Fprintf(buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+
+	bodies := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=UTF-8", m.plainBody},
+		{"text/html; charset=UTF-8", m.htmlBody},
+	}
+
+	for _, b := range bodies {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {b.contentType},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := part.Write([]byte(b.body)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
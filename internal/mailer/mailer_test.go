@@ -0,0 +1,139 @@
+package mailer
+
+import (
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTemplatePath(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"en", "templates/en/user_activation.tmpl"},
+		{"pt-BR", "templates/pt-BR/user_activation.tmpl"},
+		{"fr", "templates/pt-BR/user_activation.tmpl"},
+		{"EN", "templates/pt-BR/user_activation.tmpl"},
+		{"", "templates/pt-BR/user_activation.tmpl"},
+	}
+
+	for _, tt := range tests {
+		if got := templatePath(tt.locale, "user_activation.tmpl"); got != tt.want {
+			t.Errorf("templatePath(%q) = %q, want %q", tt.locale, got, tt.want)
+		}
+	}
+}
+
+// TestTemplatesRender checks that every locale has the same templates and
+// that each defines a subject and both bodies.
+func TestTemplatesRender(t *testing.T) {
+	files := map[string][]string{}
+	for _, locale := range []string{"en", defaultLocale} {
+		names, err := fs.Glob(templateFS, path.Join("templates", locale, "*.tmpl"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range names {
+			files[locale] = append(files[locale], path.Base(name))
+		}
+	}
+
+	if !slices.Equal(files["en"], files[defaultLocale]) {
+		t.Errorf("templates differ between locales: en %v, %s %v", files["en"], defaultLocale, files[defaultLocale])
+	}
+
+	for locale, names := range files {
+		for _, name := range names {
+			msg, err := render("sender@example.com", "ana@example.com", locale, name, activationData)
+			if err != nil {
+				t.Errorf("%s/%s: %v", locale, name, err)
+				continue
+			}
+			if msg.subject == "" || strings.TrimSpace(msg.plainBody) == "" || strings.TrimSpace(msg.htmlBody) == "" {
+				t.Errorf("%s/%s rendered an empty part: %+v", locale, name, msg)
+			}
+		}
+	}
+}
+
+func TestRenderEscapesHTMLOnly(t *testing.T) {
+	data := map[string]any{"name": "<b>Ana</b>", "code": 482913, "ttl": "15 minutes"}
+
+	msg, err := render("sender@example.com", "ana@example.com", "en", "user_activation.tmpl", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(msg.plainBody, "<b>Ana</b>") {
+		t.Errorf("plain body does not contain the name as sent:\n%s", msg.plainBody)
+	}
+	if strings.Contains(msg.htmlBody, "<b>Ana</b>") || !strings.Contains(msg.htmlBody, "&lt;b&gt;Ana&lt;/b&gt;") {
+		t.Errorf("HTML body does not escape the name:\n%s", msg.htmlBody)
+	}
+}
+
+func TestMessageBytes(t *testing.T) {
+	msg := &message{
+		sender:    "MoodTracker <no-reply@moodtracker.local>",
+		recipient: "ana@example.com",
+		subject:   "Activate your account",
+		plainBody: "code 482913",
+		htmlBody:  "<p>code 482913</p>",
+	}
+
+	raw, err := msg.bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		"From":         msg.sender,
+		"To":           msg.recipient,
+		"Subject":      msg.subject,
+		"MIME-Version": "1.0",
+	} {
+		if got := parsed.Header.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if _, err := parsed.Header.Date(); err != nil {
+		t.Errorf("Date: %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, %v", parsed.Header.Get("Content-Type"), err)
+	}
+
+	want := []struct{ contentType, body string }{
+		{"text/plain; charset=UTF-8", msg.plainBody},
+		{"text/html; charset=UTF-8", msg.htmlBody},
+	}
+
+	mr := multipart.NewReader(parsed.Body, params["boundary"])
+	for _, w := range want {
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(part)
+		if part.Header.Get("Content-Type") != w.contentType || string(body) != w.body {
+			t.Errorf("part %q = %q, want %q %q", part.Header.Get("Content-Type"), body, w.contentType, w.body)
+		}
+	}
+	if _, err := mr.NextPart(); err != io.EOF {
+		t.Errorf("more than two parts: %v", err)
+	}
+}
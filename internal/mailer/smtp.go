@@ -0,0 +1,59 @@
+package mailer
+
+import (
+	"fmt"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+type smtpMailer struct {
+	addr     string
+	host     string
+	username string
+	password string
+	sender   string
+}
+
+func NewSMTPMailer(host string, port int, username, password, sender string) *smtpMailer {
+	return &smtpMailer{
+		addr:     fmt.Sprintf("%s:%d", host, port),
+		host:     host,
+		username: username,
+		password: password,
+		sender:   sender,
+	}
+}
+
+func (m *smtpMailer) Send(recipient, locale, templateFile string, data any) error {
+	msg, err := render(m.sender, recipient, locale, templateFile, data)
+	if err != nil {
+		return err
+	}
+
+	body, err := msg.bytes()
+	if err != nil {
+		return err
+	}
+
+	from, err := mail.ParseAddress(m.sender)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	for i := 1; i <= 3; i++ {
+		err = smtp.SendMail(m.addr, auth, from.Address, []string{recipient}, body)
+		if err == nil {
+			return nil
+		}
+
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	return err
+}
@@ -1,14 +1,14 @@
 package middleware
 
 import (
+	"errors"
 	"expvar"
 	"fmt"
 	"moodtracker/internal/config"
 	"moodtracker/internal/contexts"
 	"moodtracker/internal/models"
 	"moodtracker/internal/services"
-	"moodtracker/utils/errors"
-	"moodtracker/utils/validator"
+	e "moodtracker/utils/errors"
 	"net"
 	"net/http"
 	"strconv"
@@ -27,10 +27,11 @@ var (
 )
 
 type Middleware struct {
-	errRsp      errors.ErrorHandlerInterface
-	userService services.UserService
-	authService services.AuthServiceInterface
-	config      config.Config
+	errRsp             e.ErrorHandlerInterface
+	userService        services.UserService
+	authService        services.AuthServiceInterface
+	accessTokenService services.AccessTokenService
+	config             config.Config
 }
 
 type MiddlewareInterface interface {
@@ -38,22 +39,26 @@ type MiddlewareInterface interface {
 	EnableCORS(next http.Handler) http.Handler
 	RequireAuthenticatedUser(next http.Handler) http.Handler
 	RequireActivatedUser(next http.Handler) http.Handler
+	RequirePermission(code string) func(next http.Handler) http.Handler
+	RequireScope(scope string) func(next http.Handler) http.Handler
 	Authenticate(next http.Handler) http.Handler
 	RateLimit(next http.Handler) http.Handler
 	RecoverPanic(next http.Handler) http.Handler
 }
 
 func New(
-	errRsp errors.ErrorHandlerInterface,
+	errRsp e.ErrorHandlerInterface,
 	userService services.UserService,
 	authService services.AuthServiceInterface,
+	accessTokenService services.AccessTokenService,
 	config config.Config,
 ) *Middleware {
 	return &Middleware{
-		errRsp:      errRsp,
-		userService: userService,
-		authService: authService,
-		config:      config,
+		errRsp:             errRsp,
+		userService:        userService,
+		authService:        authService,
+		accessTokenService: accessTokenService,
+		config:             config,
 	}
 }
 
@@ -116,9 +121,12 @@ func (m *Middleware) EnableCORS(next http.Handler) http.Handler {
 			for i := range m.config.CORS.TrustedOrigins {
 				if origin == m.config.CORS.TrustedOrigins[i] {
 					w.Header().Set("Access-Control-Allow-Origin", origin)
+					w.Header().Set("Access-Control-Expose-Headers", "ETag, Last-Modified")
+					// Lets trusted apps keep the cookie of an OIDC sign-in.
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
 					if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
 						w.Header().Set("Access-Control-Allow-Methods", "OPTIONS, PUT, PATCH, DELETE")
-						w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+						w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, If-Match, If-None-Match, If-Modified-Since")
 						w.WriteHeader(http.StatusOK)
 						return
 					}
@@ -130,6 +138,8 @@ func (m *Middleware) EnableCORS(next http.Handler) http.Handler {
 	})
 }
 
+// RequireAuthenticatedUser only admits sessions started with a password.
+// Personal access tokens reach a route exclusively through RequireScope.
 func (m *Middleware) RequireAuthenticatedUser(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		user := contexts.ContextGetUser(r)
@@ -137,6 +147,11 @@ func (m *Middleware) RequireAuthenticatedUser(next http.Handler) http.Handler {
 			m.errRsp.AuthenticationRequiredResponse(w, r)
 			return
 		}
+
+		if contexts.ContextGetAccessToken(r) != nil {
+			m.errRsp.NotPermittedResponse(w, r)
+			return
+		}
 		next.ServeHTTP(w, r)
 	})
 }
@@ -152,6 +167,52 @@ func (m *Middleware) RequireActivatedUser(next http.Handler) http.Handler {
 	}))
 }
 
+func (m *Middleware) RequirePermission(code string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return m.RequireActivatedUser(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := contexts.ContextGetUser(r)
+
+			permissions, err := m.userService.GetPermissions(user.ID)
+			if err != nil {
+				m.errRsp.ServerErrorResponse(w, r, err)
+				return
+			}
+
+			if !permissions.Include(code) {
+				m.errRsp.InvalidRoleResponse(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}))
+	}
+}
+
+func (m *Middleware) RequireScope(scope string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := contexts.ContextGetUser(r)
+			if user.IsAnonymous() {
+				m.errRsp.AuthenticationRequiredResponse(w, r)
+				return
+			}
+
+			if !user.Activated {
+				m.errRsp.InactiveAccountResponse(w, r)
+				return
+			}
+
+			token := contexts.ContextGetAccessToken(r)
+			if token != nil && !token.HasScope(scope) {
+				m.errRsp.NotPermittedResponse(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func (m *Middleware) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("Vary", "Authorization")
@@ -170,24 +231,88 @@ func (m *Middleware) Authenticate(next http.Handler) http.Handler {
 		}
 
 		token := headerParts[1]
-		username, err := m.authService.ExtractUsername(token)
+		if models.IsPersonalAccessToken(token) {
+			m.authenticateAccessToken(w, r, next, token)
+			return
+		}
+
+		claims, err := m.authService.ExtractClaims(token)
 		if err != nil {
 			m.errRsp.InvalidAuthenticationTokenResponse(w, r)
 			return
 		}
 
-		v := validator.New()
-		user, err := m.userService.GetUserByEmail(username, v)
+		user, err := m.userService.GetUserByID(claims.UserID)
 		if err != nil {
-			m.errRsp.HandlerError(w, r, err, v)
+			switch {
+			case errors.Is(err, e.ErrRecordNotFound):
+				m.errRsp.InvalidAuthenticationTokenResponse(w, r)
+			default:
+				m.errRsp.ServerErrorResponse(w, r, err)
+			}
 			return
 		}
 
+		if user.TokenRevoked(claims.IssuedAt) {
+			m.errRsp.InvalidAuthenticationTokenResponse(w, r)
+			return
+		}
+
+		revoked, err := m.authService.IsRevoked(claims)
+		if err != nil {
+			m.errRsp.ServerErrorResponse(w, r, err)
+			return
+		}
+
+		if revoked {
+			m.errRsp.InvalidAuthenticationTokenResponse(w, r)
+			return
+		}
+
+		r = contexts.ContextSetClaims(r, claims)
 		r = contexts.ContextSetUser(r, user)
 		next.ServeHTTP(w, r)
 	})
 }
 
+func (m *Middleware) authenticateAccessToken(
+	w http.ResponseWriter,
+	r *http.Request,
+	next http.Handler,
+	plaintext string,
+) {
+	token, err := m.accessTokenService.Authenticate(plaintext)
+	if err != nil {
+		switch {
+		case errors.Is(err, e.ErrInvalidCredentials):
+			m.errRsp.InvalidAuthenticationTokenResponse(w, r)
+		default:
+			m.errRsp.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	user, err := m.userService.GetUserByID(token.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, e.ErrRecordNotFound):
+			m.errRsp.InvalidAuthenticationTokenResponse(w, r)
+		default:
+			m.errRsp.ServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if user.AccessTokenRevoked(token.CreatedAt) {
+		m.errRsp.InvalidAuthenticationTokenResponse(w, r)
+		return
+	}
+
+	r = contexts.ContextSetAccessToken(r, token)
+	r = contexts.ContextSetUser(r, user)
+	next.ServeHTTP(w, r)
+}
+
 func (m *Middleware) RateLimit(next http.Handler) http.Handler {
 	type client struct {
 		limiter  *rate.Limiter
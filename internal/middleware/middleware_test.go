@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"moodtracker/internal/config"
+	"moodtracker/internal/contexts"
+	"moodtracker/internal/jsonlog"
+	"moodtracker/internal/models"
+	"moodtracker/internal/services"
+	e "moodtracker/utils/errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type fakeUserService struct {
+	services.UserService
+	permissions models.Permissions
+	err         error
+}
+
+func (s *fakeUserService) GetPermissions(userID uuid.UUID) (models.Permissions, error) {
+	return s.permissions, s.err
+}
+
+func newTestMiddleware(userService services.UserService) *Middleware {
+	return New(e.NewErrorHandler(jsonlog.New(io.Discard, jsonlog.LevelOff)), userService, nil, nil, config.Config{})
+}
+
+// serve runs the request through the handler as user, authenticated with
+// token when it is not nil, and returns the status code.
+func serve(h http.Handler, user *models.User, token *models.PersonalAccessToken) int {
+	r := httptest.NewRequest(http.MethodGet, "/v1/admin/users", nil)
+	r = contexts.ContextSetUser(r, user)
+	if token != nil {
+		r = contexts.ContextSetAccessToken(r, token)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Code
+}
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func TestRequirePermission(t *testing.T) {
+	active := &models.User{ID: uuid.New(), Activated: true}
+	inactive := &models.User{ID: uuid.New()}
+	admin := models.Permissions{models.PermissionUsersRead, models.PermissionUsersWrite}
+
+	tests := []struct {
+		name        string
+		user        *models.User
+		token       *models.PersonalAccessToken
+		permissions models.Permissions
+		err         error
+		status      int
+	}{
+		{"with the permission", active, nil, admin, nil, http.StatusOK},
+		{"without the permission", active, nil, models.Permissions{models.PermissionMetricsRead}, nil, http.StatusForbidden},
+		{"without any permission", active, nil, nil, nil, http.StatusForbidden},
+		{"anonymous", models.AnonymousUser, nil, admin, nil, http.StatusUnauthorized},
+		{"inactive account", inactive, nil, admin, nil, http.StatusForbidden},
+		{"personal access token", active, &models.PersonalAccessToken{Scopes: []string{"users:write"}}, admin, nil, http.StatusForbidden},
+		{"permissions can't be loaded", active, nil, nil, errors.New("connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		m := newTestMiddleware(&fakeUserService{permissions: tt.permissions, err: tt.err})
+		h := m.RequirePermission(models.PermissionUsersWrite)(okHandler)
+
+		if status := serve(h, tt.user, tt.token); status != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, status, tt.status)
+		}
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	active := &models.User{ID: uuid.New(), Activated: true}
+	inactive := &models.User{ID: uuid.New()}
+	token := func(scopes ...string) *models.PersonalAccessToken {
+		return &models.PersonalAccessToken{UserID: active.ID, Scopes: scopes}
+	}
+
+	tests := []struct {
+		name   string
+		user   *models.User
+		token  *models.PersonalAccessToken
+		status int
+	}{
+		{"session", active, nil, http.StatusOK},
+		{"token with the scope", active, token(models.ScopeDaylogsRead, models.ScopeDaylogsWrite), http.StatusOK},
+		{"token without the scope", active, token(models.ScopeDaylogsRead), http.StatusForbidden},
+		{"token without scopes", active, token(), http.StatusForbidden},
+		{"anonymous", models.AnonymousUser, nil, http.StatusUnauthorized},
+		{"token of an inactive account", inactive, token(models.ScopeDaylogsWrite), http.StatusForbidden},
+	}
+
+	m := newTestMiddleware(&fakeUserService{})
+	h := m.RequireScope(models.ScopeDaylogsWrite)(okHandler)
+
+	for _, tt := range tests {
+		if status := serve(h, tt.user, tt.token); status != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, status, tt.status)
+		}
+	}
+}
+
+func TestRequireAuthenticatedUserRefusesAccessTokens(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Activated: true}
+	token := &models.PersonalAccessToken{UserID: user.ID, Scopes: models.AccessTokenScopes}
+
+	m := newTestMiddleware(&fakeUserService{})
+	h := m.RequireAuthenticatedUser(okHandler)
+
+	if status := serve(h, user, nil); status != http.StatusOK {
+		t.Errorf("session: status = %d, want %d", status, http.StatusOK)
+	}
+	if status := serve(h, user, token); status != http.StatusForbidden {
+		t.Errorf("personal access token: status = %d, want %d", status, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"crypto/rand"
+	"moodtracker/utils/validator"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccessTokenPrefix tells personal access tokens apart from JWTs in the
+// Authorization header.
+const AccessTokenPrefix = "mtp_"
+
+const (
+	ScopeDaylogsRead  = "daylogs:read"
+	ScopeDaylogsWrite = "daylogs:write"
+	ScopeReportsRead  = "reports:read"
+)
+
+var AccessTokenScopes = []string{
+	ScopeDaylogsRead,
+	ScopeDaylogsWrite,
+	ScopeReportsRead,
+}
+
+type PersonalAccessToken struct {
+	ID         uuid.UUID  `db:"id"`
+	UserID     uuid.UUID  `db:"user_id"`
+	Name       string     `db:"name"`
+	Hash       []byte     `db:"hash"`
+	Scopes     []string   `db:"scopes"`
+	ExpiresAt  *time.Time `db:"expires_at"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+	CreatedAt  time.Time  `db:"created_at"`
+	Plaintext  string     `db:"-"`
+}
+
+type PersonalAccessTokenDTO struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Token      string     `json:"token,omitempty"`
+}
+
+func GeneratePersonalAccessToken(
+	userID uuid.UUID,
+	name string,
+	scopes []string,
+	expiresAt *time.Time,
+) *PersonalAccessToken {
+	token := &PersonalAccessToken{
+		UserID:    userID,
+		Name:      name,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		Plaintext: AccessTokenPrefix + rand.Text(),
+	}
+
+	token.Hash = HashToken(token.Plaintext)
+	return token
+}
+
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, AccessTokenPrefix)
+}
+
+func (t *PersonalAccessToken) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, scope)
+}
+
+func (t *PersonalAccessToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+func (t *PersonalAccessToken) ToDTO() *PersonalAccessTokenDTO {
+	return &PersonalAccessTokenDTO{
+		ID:         t.ID,
+		Name:       t.Name,
+		Scopes:     t.Scopes,
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+		CreatedAt:  t.CreatedAt,
+		Token:      t.Plaintext,
+	}
+}
+
+func (t *PersonalAccessToken) Validate(v *validator.Validator) {
+	v.Check(strings.TrimSpace(t.Name) != "", "name", "must be provided")
+	v.Check(len(t.Name) <= 100, "name", "must not be more than 100 bytes long")
+	v.Check(len(t.Scopes) > 0, "scopes", "must contain at least one scope")
+	v.Check(validator.Unique(t.Scopes), "scopes", "must not contain duplicate values")
+	for _, scope := range t.Scopes {
+		v.Check(validator.In(scope, AccessTokenScopes...), "scopes", "must only contain "+strings.Join(AccessTokenScopes, ", "))
+	}
+	v.Check(t.ExpiresAt == nil || t.ExpiresAt.After(time.Now()), "expires_at", "must be in the future")
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"moodtracker/utils/validator"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestGeneratePersonalAccessToken(t *testing.T) {
+	token := GeneratePersonalAccessToken(uuid.New(), "export", []string{ScopeDaylogsRead}, nil)
+
+	if !IsPersonalAccessToken(token.Plaintext) {
+		t.Errorf("plaintext %q does not have the %s prefix", token.Plaintext, AccessTokenPrefix)
+	}
+	if string(token.Hash) != string(HashToken(token.Plaintext)) {
+		t.Error("the hash is not the hash of the plaintext")
+	}
+}
+
+func TestIsPersonalAccessToken(t *testing.T) {
+	tests := []struct {
+		token string
+		want  bool
+	}{
+		{"mtp_Y3WJ6XQ2ZKRBNUX7ZM5TPHLQKA", true},
+		{"eyJhbGciOiJIUzI1NiIsImtpZCI6ImRlZmF1bHQifQ.e30.c2ln", false},
+		{"MTP_Y3WJ6XQ2ZKRBNUX7ZM5TPHLQKA", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsPersonalAccessToken(tt.token); got != tt.want {
+			t.Errorf("IsPersonalAccessToken(%q) = %v, want %v", tt.token, got, tt.want)
+		}
+	}
+}
+
+func TestPersonalAccessTokenExpired(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	tests := []struct {
+		name      string
+		expiresAt *time.Time
+		expired   bool
+	}{
+		{"no expiry", nil, false},
+		{"expires later", at(time.Second), false},
+		{"expires now", at(0), true},
+		{"expired", at(-time.Hour), true},
+	}
+
+	for _, tt := range tests {
+		token := &PersonalAccessToken{ExpiresAt: tt.expiresAt}
+		if got := token.Expired(now); got != tt.expired {
+			t.Errorf("%s: Expired = %v, want %v", tt.name, got, tt.expired)
+		}
+	}
+}
+
+func TestPersonalAccessTokenHasScope(t *testing.T) {
+	token := &PersonalAccessToken{Scopes: []string{ScopeDaylogsRead, ScopeReportsRead}}
+
+	tests := []struct {
+		scope string
+		want  bool
+	}{
+		{ScopeDaylogsRead, true},
+		{ScopeReportsRead, true},
+		{ScopeDaylogsWrite, false},
+		{"daylogs", false},
+	}
+
+	for _, tt := range tests {
+		if got := token.HasScope(tt.scope); got != tt.want {
+			t.Errorf("HasScope(%q) = %v, want %v", tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestPersonalAccessTokenValidate(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		tokenName string
+		scopes    []string
+		expiresAt *time.Time
+		field     string
+	}{
+		{"valid", "export", []string{ScopeDaylogsRead}, nil, ""},
+		{"all scopes with an expiry", "export", AccessTokenScopes, &future, ""},
+		{"missing name", "", []string{ScopeDaylogsRead}, nil, "name"},
+		{"blank name", "   ", []string{ScopeDaylogsRead}, nil, "name"},
+		{"name too long", strings.Repeat("a", 101), []string{ScopeDaylogsRead}, nil, "name"},
+		{"no scopes", "export", nil, nil, "scopes"},
+		{"unknown scope", "export", []string{"users:write"}, nil, "scopes"},
+		{"duplicate scopes", "export", []string{ScopeDaylogsRead, ScopeDaylogsRead}, nil, "scopes"},
+		{"expiry in the past", "export", []string{ScopeDaylogsRead}, &past, "expires_at"},
+	}
+
+	for _, tt := range tests {
+		token := &PersonalAccessToken{Name: tt.tokenName, Scopes: tt.scopes, ExpiresAt: tt.expiresAt}
+
+		v := validator.New()
+		token.Validate(v)
+
+		if tt.field == "" {
+			if !v.Valid() {
+				t.Errorf("%s: unexpected errors %v", tt.name, v.Errors)
+			}
+			continue
+		}
+
+		if _, ok := v.Errors[tt.field]; !ok || len(v.Errors) != 1 {
+			t.Errorf("%s: errors = %v, want one for %q", tt.name, v.Errors, tt.field)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"fmt"
+	"mime"
+	"moodtracker/utils/validator"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+type Attachment struct {
+	ID          uuid.UUID `db:"id"`
+	LogID       uuid.UUID `db:"log_id"`
+	UserID      uuid.UUID `db:"user_id"`
+	StorageKey  string    `db:"storage_key"`
+	FileName    string    `db:"file_name"`
+	ContentType string    `db:"content_type"`
+	Size        int64     `db:"size"`
+	Checksum    string    `db:"checksum"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+type AttachmentDTO struct {
+	ID           uuid.UUID `json:"id"`
+	FileName     string    `json:"file_name"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	Checksum     string    `json:"checksum"`
+	CreatedAt    time.Time `json:"created_at"`
+	URL          string    `json:"url,omitempty"`
+	URLExpiresAt time.Time `json:"url_expires_at,omitzero"`
+}
+
+func (a Attachment) ToDTO() *AttachmentDTO {
+	return &AttachmentDTO{
+		ID:          a.ID,
+		FileName:    a.FileName,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		Checksum:    a.Checksum,
+		CreatedAt:   a.CreatedAt,
+	}
+}
+
+// ambiguousTypes are what content sniffing reports for containers shared by
+// several formats (an .m4a voice note sniffs as video/mp4). For those the type
+// declared by the client is trusted, as long as it is allowed.
+var ambiguousTypes = []string{
+	"application/octet-stream",
+	"application/ogg",
+	"video/mp4",
+	"video/webm",
+}
+
+// AttachmentContentType picks the type an upload is stored and served with.
+// The sniffed type wins unless it is ambiguous, so a script can't be uploaded
+// as an image by lying about its type.
+func AttachmentContentType(sniffed, declared string, allowed []string) string {
+	sniffed = baseMediaType(sniffed)
+	declared = baseMediaType(declared)
+
+	if validator.In(sniffed, allowed...) || !validator.In(sniffed, ambiguousTypes...) {
+		return sniffed
+	}
+
+	if declared == "" {
+		return sniffed
+	}
+
+	return declared
+}
+
+var mediaTypeAliases = map[string]string{
+	"audio/wave":  "audio/wav",
+	"audio/x-wav": "audio/wav",
+	"audio/mp3":   "audio/mpeg",
+	"audio/x-m4a": "audio/mp4",
+	"image/jpg":   "image/jpeg",
+}
+
+func baseMediaType(t string) string {
+	mediaType, _, err := mime.ParseMediaType(t)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(t))
+	}
+
+	if alias, ok := mediaTypeAliases[mediaType]; ok {
+		return alias
+	}
+
+	return mediaType
+}
+
+// CleanFileName keeps only the base name of what the client sent, without
+// control characters, so it is safe to echo in Content-Disposition.
+func CleanFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+
+	return name
+}
+
+func (a *Attachment) ValidateAttachment(v *validator.Validator, maxSize int64, allowedTypes []string) {
+	v.Check(a.Size > 0, "file", "must not be empty")
+	v.Check(a.Size <= maxSize, "file", fmt.Sprintf("must not be larger than %d bytes", maxSize))
+	v.Check(len(a.FileName) <= 255, "file_name", "must not be more than 255 bytes long")
+	v.Check(validator.In(a.ContentType, allowedTypes...), "content_type",
+		"must be one of "+strings.Join(allowedTypes, ", "))
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"moodtracker/utils/validator"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAttachmentContentType(t *testing.T) {
+	allowed := []string{"image/jpeg", "image/png", "audio/mp4", "audio/mpeg", "audio/wav", "audio/ogg"}
+
+	tests := []struct {
+		name     string
+		sniffed  string
+		declared string
+		want     string
+	}{
+		{"sniffed and declared agree", "image/png", "image/png", "image/png"},
+		{"sniffed wins over a lie", "text/html; charset=utf-8", "image/png", "text/html"},
+		{"sniffed allowed type wins", "image/jpeg", "image/png", "image/jpeg"},
+		{"ambiguous container", "video/mp4", "audio/x-m4a", "audio/mp4"},
+		{"ambiguous ogg", "application/ogg", "audio/ogg", "audio/ogg"},
+		{"unknown bytes", "application/octet-stream", "audio/mpeg", "audio/mpeg"},
+		{"ambiguous without a declared type", "video/mp4", "", "video/mp4"},
+		{"declared type is not checked here", "application/octet-stream", "application/x-sh", "application/x-sh"},
+		{"aliases", "audio/wave", "", "audio/wav"},
+		{"parameters and case", "IMAGE/JPG; q=1", "", "image/jpeg"},
+	}
+
+	for _, tt := range tests {
+		if got := AttachmentContentType(tt.sniffed, tt.declared, allowed); got != tt.want {
+			t.Errorf("%s: AttachmentContentType(%q, %q) = %q, want %q", tt.name, tt.sniffed, tt.declared, got, tt.want)
+		}
+	}
+}
+
+func TestCleanFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"photo.jpg", "photo.jpg"},
+		{"  voice note.m4a ", "voice note.m4a"},
+		{"../../etc/passwd", "passwd"},
+		{`C:\Users\ana\photo.png`, "photo.png"},
+		{"/tmp/", "tmp"},
+		{"say \"hi\".txt", "say hi.txt"},
+		{"line\r\nbreak.txt", "linebreak.txt"},
+		{"férias.png", "férias.png"},
+		{"", "attachment"},
+		{".", "attachment"},
+		{"/", "attachment"},
+		{"\x00\x01", "attachment"},
+	}
+
+	for _, tt := range tests {
+		if got := CleanFileName(tt.name); got != tt.want {
+			t.Errorf("CleanFileName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateAttachment(t *testing.T) {
+	allowed := []string{"image/png", "audio/mpeg"}
+
+	tests := []struct {
+		name       string
+		attachment Attachment
+		fields     []string
+	}{
+		{"valid", Attachment{FileName: "a.png", ContentType: "image/png", Size: 10}, nil},
+		{"at the size limit", Attachment{FileName: "a.png", ContentType: "image/png", Size: 1024}, nil},
+		{"empty", Attachment{FileName: "a.png", ContentType: "image/png"}, []string{"file"}},
+		{"too large", Attachment{FileName: "a.png", ContentType: "image/png", Size: 1025}, []string{"file"}},
+		{"long name", Attachment{FileName: strings.Repeat("a", 256), ContentType: "image/png", Size: 10}, []string{"file_name"}},
+		{"type not allowed", Attachment{FileName: "a.html", ContentType: "text/html", Size: 10}, []string{"content_type"}},
+	}
+
+	for _, tt := range tests {
+		v := validator.New()
+		tt.attachment.ValidateAttachment(v, 1024, allowed)
+
+		fields := make([]string, 0, len(v.Errors))
+		for field := range v.Errors {
+			fields = append(fields, field)
+		}
+		if !slices.Equal(fields, tt.fields) && !(len(fields) == 0 && len(tt.fields) == 0) {
+			t.Errorf("%s: errors = %v, want errors for %q", tt.name, v.Errors, tt.fields)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AuthMethodPassword = "password"
+	AuthMethodMFA      = "mfa"
+	AuthMethodOIDC     = "oidc"
+)
+
+const (
+	AuthReasonInvalidCredentials = "invalid_credentials"
+	AuthReasonInactiveAccount    = "inactive_account"
+	AuthReasonLocked             = "locked"
+	AuthReasonMFARequired        = "mfa_required"
+)
+
+// ClientInfo identifies where an authentication attempt came from.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+type AuthEvent struct {
+	ID        uuid.UUID  `db:"id"`
+	UserID    *uuid.UUID `db:"user_id"`
+	Email     string     `db:"email"`
+	Method    string     `db:"method"`
+	Success   bool       `db:"success"`
+	Reason    *string    `db:"reason"`
+	IP        string     `db:"ip"`
+	UserAgent string     `db:"user_agent"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+type AuthEventDTO struct {
+	ID        uuid.UUID `json:"id"`
+	Method    string    `json:"method"`
+	Success   bool      `json:"success"`
+	Reason    *string   `json:"reason,omitempty"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LoginAttempt struct {
+	Email         string     `db:"email"`
+	Failures      int        `db:"failures"`
+	LastFailureAt time.Time  `db:"last_failure_at"`
+	LockedUntil   *time.Time `db:"locked_until"`
+}
+
+func NewAuthEvent(user *User, email, method string, success bool, reason string, client ClientInfo) *AuthEvent {
+	event := &AuthEvent{
+		Email:     email,
+		Method:    method,
+		Success:   success,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+	}
+
+	if user != nil {
+		event.UserID = &user.ID
+	}
+
+	if reason != "" {
+		event.Reason = &reason
+	}
+
+	return event
+}
+
+func (a *LoginAttempt) Locked(now time.Time) bool {
+	return a.LockedUntil != nil && now.Before(*a.LockedUntil)
+}
+
+func (a *AuthEvent) ToDTO() *AuthEventDTO {
+	return &AuthEventDTO{
+		ID:        a.ID,
+		Method:    a.Method,
+		Success:   a.Success,
+		Reason:    a.Reason,
+		IP:        a.IP,
+		UserAgent: a.UserAgent,
+		CreatedAt: a.CreatedAt,
+	}
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestLoginAttemptLocked(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	tests := []struct {
+		name        string
+		lockedUntil *time.Time
+		locked      bool
+	}{
+		{"never locked", nil, false},
+		{"locked until later", at(time.Second), true},
+		{"lock ends now", at(0), false},
+		{"lock ended", at(-time.Minute), false},
+	}
+
+	for _, tt := range tests {
+		a := &LoginAttempt{Failures: 5, LockedUntil: tt.lockedUntil}
+		if got := a.Locked(now); got != tt.locked {
+			t.Errorf("%s: Locked = %v, want %v", tt.name, got, tt.locked)
+		}
+	}
+}
+
+func TestNewAuthEvent(t *testing.T) {
+	user := &User{ID: uuid.New()}
+	client := ClientInfo{IP: "203.0.113.7", UserAgent: "curl/8.5"}
+
+	tests := []struct {
+		name   string
+		user   *User
+		reason string
+	}{
+		{"success", user, ""},
+		{"failure of an account", user, AuthReasonInvalidCredentials},
+		{"failure without an account", nil, AuthReasonInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		event := NewAuthEvent(tt.user, "ana@example.com", AuthMethodPassword, tt.reason == "", tt.reason, client)
+
+		if (event.UserID == nil) != (tt.user == nil) || (event.UserID != nil && *event.UserID != user.ID) {
+			t.Errorf("%s: user id = %v", tt.name, event.UserID)
+		}
+		if (event.Reason == nil) != (tt.reason == "") || (event.Reason != nil && *event.Reason != tt.reason) {
+			t.Errorf("%s: reason = %v, want %q", tt.name, event.Reason, tt.reason)
+		}
+		if event.IP != client.IP || event.UserAgent != client.UserAgent || event.Email != "ana@example.com" {
+			t.Errorf("%s: unexpected event %+v", tt.name, event)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,3 +15,20 @@ type BaseModel struct {
 	UpdatedAt *time.Time `db:"updated_at"`
 	UpdatedBy *uuid.UUID `db:"updated_by"`
 }
+
+// VersionETag is the entity tag of a record at version. Clients send it back
+// in If-Match to update the record.
+func VersionETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+func (b BaseModel) ETag() string {
+	return VersionETag(b.Version)
+}
+
+func (b BaseModel) LastModified() time.Time {
+	if b.UpdatedAt != nil {
+		return *b.UpdatedAt
+	}
+	return b.CreatedAt
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVersionETag(t *testing.T) {
+	tests := []struct {
+		version int
+		want    string
+	}{
+		{1, `"1"`},
+		{42, `"42"`},
+	}
+
+	for _, tt := range tests {
+		if got := VersionETag(tt.version); got != tt.want {
+			t.Errorf("VersionETag(%d) = %s, want %s", tt.version, got, tt.want)
+		}
+		if got := (BaseModel{Version: tt.version}).ETag(); got != tt.want {
+			t.Errorf("ETag at version %d = %s, want %s", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestLastModified(t *testing.T) {
+	created := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+
+	tests := []struct {
+		name  string
+		model BaseModel
+		want  time.Time
+	}{
+		{"never updated", BaseModel{CreatedAt: created}, created},
+		{"updated", BaseModel{CreatedAt: created, UpdatedAt: &updated}, updated},
+	}
+
+	for _, tt := range tests {
+		if got := tt.model.LastModified(); !got.Equal(tt.want) {
+			t.Errorf("%s: LastModified = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,30 +1,30 @@
 package models
 
 import (
+	"crypto/sha256"
+	"fmt"
 	"moodtracker/utils"
 	"moodtracker/utils/validator"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-type MoodLabel int
-
-const (
-	MOOD_RUIM MoodLabel = iota + 1
-	MOOD_MEDIO
-	MOOD_BOM
-)
-
 type Daylog struct {
 	BaseModel
 	ID          uuid.UUID `db:"id"`
 	Date        time.Time `db:"date"`
 	Description string    `db:"description"`
 	MoodLabel   MoodLabel `db:"mood_label"`
-	User        *User     `db:"-"`
-	Tags        []string  `db:"tags"`
+	// LoggedAt and TimeZone are only set on intraday check-ins. A log without
+	// them is the single daily entry for Date.
+	LoggedAt *time.Time      `db:"logged_at"`
+	TimeZone *string         `db:"time_zone"`
+	User     *User           `db:"-"`
+	Tags     []string        `db:"tags"`
+	Trackers []*TrackerValue `db:"-"`
 }
 
 type Tag struct {
@@ -34,30 +34,58 @@ type Tag struct {
 	User *User     `db:"-"`
 }
 type DaylogDTO struct {
-	ID          uuid.UUID  `json:"id"`
-	Date        *time.Time `json:"date"`
-	Description *string    `json:"description,omitempty"`
-	MoodLabel   *string    `json:"mood_label"`
-	User        *UserDTO   `json:"user,omitempty"`
-	Tags        []*string  `json:"tags"`
+	ID          uuid.UUID          `json:"id"`
+	Date        *time.Time         `json:"date"`
+	Description *string            `json:"description,omitempty"`
+	MoodLabel   *MoodInput         `json:"mood_label"`
+	Mood        *MoodLevel         `json:"mood,omitempty"`
+	LoggedAt    *time.Time         `json:"logged_at,omitempty"`
+	TimeZone    *string            `json:"time_zone,omitempty"`
+	Version     int                `json:"version,omitempty"`
+	User        *UserDTO           `json:"user,omitempty"`
+	Tags        []*string          `json:"tags"`
+	Trackers    []*TrackerValueDTO `json:"trackers,omitempty"`
+}
+
+// DailySummary aggregates every log of a day: the daily entry, if any, and
+// all check-ins.
+type DailySummary struct {
+	Date         time.Time  `db:"date" json:"date"`
+	Entries      int        `db:"entries" json:"entries"`
+	CheckIns     int        `db:"check_ins" json:"check_ins"`
+	AverageMood  float64    `db:"average_mood" json:"average_mood"`
+	MinMood      MoodLabel  `db:"min_mood" json:"min_mood"`
+	MaxMood      MoodLabel  `db:"max_mood" json:"max_mood"`
+	LastMood     MoodLabel  `db:"last_mood" json:"last_mood"`
+	FirstCheckIn *time.Time `db:"first_check_in" json:"first_check_in,omitempty"`
+	LastCheckIn  *time.Time `db:"last_check_in" json:"last_check_in,omitempty"`
 }
 
 type TagDTO struct {
-	ID   uuid.UUID `json:"id"`
-	Name *string   `json:"name"`
-	User *UserDTO  `json:"user,omitempty"`
+	ID      uuid.UUID `json:"id"`
+	Name    *string   `json:"name"`
+	Version int       `json:"version,omitempty"`
+	User    *UserDTO  `json:"user,omitempty"`
 }
 
 func (t Tag) ToDTO() *TagDTO {
-	return &TagDTO{
-		ID:   t.ID,
-		Name: &t.Name,
-		User: t.User.ToDTO(),
+	dto := TagDTO{
+		ID:      t.ID,
+		Name:    &t.Name,
+		Version: t.Version,
 	}
+
+	if t.User != nil {
+		dto.User = t.User.ToDTO()
+	}
+
+	return &dto
 }
 
 func (dto TagDTO) ToModel() *Tag {
 	var model Tag
+	model.ID = dto.ID
+	model.Version = dto.Version
 
 	if dto.Name != nil {
 		model.Name = *dto.Name
@@ -70,16 +98,51 @@ func (dto TagDTO) ToModel() *Tag {
 	return &model
 }
 
+// ETag is a weak entity tag of the version plus the tag names and trackers of
+// the log. Renaming or deleting a tag or editing a tracker changes the
+// response without bumping the version. The version stays in front, so the
+// tag can still be sent back in If-Match.
+func (d Daylog) ETag() string {
+	tags := slices.Clone(d.Tags)
+	slices.Sort(tags)
+
+	hash := sha256.New()
+	hash.Write([]byte(strings.Join(tags, ",")))
+	for _, t := range d.Trackers {
+		fmt.Fprintf(hash, ":%s=%s/%s", t.TrackerID, t.Name, t.Unit)
+	}
+
+	return fmt.Sprintf(`W/"%d-%x"`, d.Version, hash.Sum(nil)[:8])
+}
+
+// LastModified is always zero: updated_at doesn't move when a tag or tracker
+// of the log changes, so day logs are only revalidated by ETag.
+func (d Daylog) LastModified() time.Time {
+	return time.Time{}
+}
+
 func (d Daylog) ToDTO() *DaylogDTO {
 	dto := DaylogDTO{}
 
 	dto.ID = d.ID
 	dto.Date = &d.Date
 	dto.Description = &d.Description
-	label := d.MoodLabel.String()
+	label := MoodInput(d.MoodLabel.String())
 	dto.MoodLabel = &label
+
+	if level, ok := moodScale.Level(d.MoodLabel); ok {
+		dto.Mood = &level
+	}
+
+	dto.LoggedAt = d.LoggedAt
+	dto.TimeZone = d.TimeZone
+	dto.Version = d.Version
 	dto.Tags = utils.StringSliceToPtrSlice(d.Tags)
 
+	for _, value := range d.Trackers {
+		dto.Trackers = append(dto.Trackers, value.ToDTO())
+	}
+
 	if d.User != nil {
 		dto.User = d.User.ToDTO()
 	}
@@ -89,6 +152,10 @@ func (d Daylog) ToDTO() *DaylogDTO {
 
 func (dto DaylogDTO) ToModel() *Daylog {
 	model := Daylog{}
+	model.ID = dto.ID
+	model.Version = dto.Version
+	model.LoggedAt = dto.LoggedAt
+	model.TimeZone = dto.TimeZone
 
 	if dto.Date != nil {
 		model.Date = *dto.Date
@@ -99,8 +166,7 @@ func (dto DaylogDTO) ToModel() *Daylog {
 	}
 
 	if dto.MoodLabel != nil {
-		label := parseMoodLabel(*dto.MoodLabel)
-		model.MoodLabel = label
+		model.MoodLabel = ParseMoodLabel(string(*dto.MoodLabel))
 	}
 
 	if dto.User != nil {
@@ -111,45 +177,70 @@ func (dto DaylogDTO) ToModel() *Daylog {
 		model.Tags = utils.PtrStringSliceToSlice(dto.Tags)
 	}
 
+	if dto.Trackers != nil {
+		model.Trackers = make([]*TrackerValue, 0, len(dto.Trackers))
+		for _, value := range dto.Trackers {
+			model.Trackers = append(model.Trackers, value.ToModel())
+		}
+	}
+
 	return &model
 }
 
-func (m MoodLabel) String() string {
-	switch m {
-	case MOOD_RUIM:
-		return "RUIM"
-	case MOOD_MEDIO:
-		return "MEDIO"
-	case MOOD_BOM:
-		return "BOM"
-	default:
-		return "unknown"
-	}
+func (d *Daylog) IsCheckIn() bool {
+	return d.LoggedAt != nil
 }
 
-func parseMoodLabel(s string) MoodLabel {
-	s = strings.TrimSpace(strings.ToUpper(s))
-	switch s {
-	case "RUIM":
-		return MOOD_RUIM
-	case "MEDIO":
-		return MOOD_MEDIO
-	case "BOM":
-		return MOOD_BOM
-	default:
-		return 0
+// ResolveCheckIn fills the date of a check-in from its timestamp in the
+// check-in time zone. A date sent along with logged_at must match it.
+func (d *Daylog) ResolveCheckIn(v *validator.Validator) {
+	if !d.IsCheckIn() {
+		d.TimeZone = nil
+		return
+	}
+
+	if d.TimeZone == nil {
+		v.AddError("time_zone", "must be provided")
+		return
+	}
+
+	ValidateTimeZone(v, "time_zone", *d.TimeZone)
+	if !v.Valid() {
+		return
 	}
+
+	loc, _ := time.LoadLocation(*d.TimeZone)
+	local := d.LoggedAt.In(loc)
+	date := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+
+	if !d.Date.IsZero() {
+		y, m, day := d.Date.Date()
+		v.Check(y == date.Year() && m == date.Month() && day == date.Day(),
+			"date", "must match logged_at in the check-in time zone")
+	}
+
+	d.Date = date
 }
 
 func (d *Daylog) ValidateDaylog(v *validator.Validator) {
 	v.Check(d.Date.IsZero() == false, "date", "must be provided")
-	v.Check(d.MoodLabel >= MOOD_RUIM && d.MoodLabel <= MOOD_BOM,
-		"mood_label", "invalid mood label")
+	ValidateMoodLabel(v, d.MoodLabel)
+
+	if d.IsCheckIn() {
+		v.Check(d.LoggedAt.Before(time.Now().Add(time.Minute)), "logged_at", "must not be in the future")
+	}
 
 	if d.Description != "" {
 		v.Check(len(d.Description) <= 1000,
 			"description", "must not be more than 1000 bytes long")
 	}
+
+	for _, tag := range d.Tags {
+		if strings.TrimSpace(tag) == "" {
+			v.AddError("tags", "must not contain empty names")
+			break
+		}
+	}
 }
 
 func (model *Tag) ValidateTag(v *validator.Validator) {
@@ -0,0 +1,158 @@
+package models
+
+import (
+	"moodtracker/utils/validator"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestResolveCheckIn(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	instant := func(s string) *time.Time {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			panic(err)
+		}
+		return &t
+	}
+	zone := func(name string) *string { return &name }
+
+	tests := []struct {
+		name     string
+		loggedAt *time.Time
+		timeZone *string
+		date     time.Time
+		want     time.Time
+		field    string
+	}{
+		{"daily entry keeps its date", nil, nil, date(2026, 3, 10), date(2026, 3, 10), ""},
+		{"daily entry drops the time zone", nil, zone("America/Sao_Paulo"), date(2026, 3, 10), date(2026, 3, 10), ""},
+		{"same day", instant("2026-03-10T15:00:00Z"), zone("America/Sao_Paulo"), time.Time{}, date(2026, 3, 10), ""},
+		{"late evening is the previous day", instant("2026-03-11T01:30:00Z"), zone("America/Sao_Paulo"), time.Time{}, date(2026, 3, 10), ""},
+		{"early morning is the next day", instant("2026-03-10T16:00:00Z"), zone("Asia/Tokyo"), time.Time{}, date(2026, 3, 11), ""},
+		{"offset in the timestamp is ignored", instant("2026-03-10T22:30:00-03:00"), zone("UTC"), time.Time{}, date(2026, 3, 11), ""},
+		{"matching date", instant("2026-03-11T01:30:00Z"), zone("America/Sao_Paulo"), date(2026, 3, 10), date(2026, 3, 10), ""},
+		{"date of the UTC day", instant("2026-03-11T01:30:00Z"), zone("America/Sao_Paulo"), date(2026, 3, 11), date(2026, 3, 10), "date"},
+		{"missing time zone", instant("2026-03-10T15:00:00Z"), nil, time.Time{}, time.Time{}, "time_zone"},
+		{"unknown time zone", instant("2026-03-10T15:00:00Z"), zone("Brazil/Recife"), time.Time{}, time.Time{}, "time_zone"},
+	}
+
+	for _, tt := range tests {
+		d := &Daylog{Date: tt.date, LoggedAt: tt.loggedAt, TimeZone: tt.timeZone}
+
+		v := validator.New()
+		d.ResolveCheckIn(v)
+
+		if tt.field != "" {
+			if _, ok := v.Errors[tt.field]; !ok || len(v.Errors) != 1 {
+				t.Errorf("%s: errors = %v, want one for %q", tt.name, v.Errors, tt.field)
+			}
+			continue
+		}
+
+		if !v.Valid() {
+			t.Errorf("%s: unexpected errors %v", tt.name, v.Errors)
+		}
+		if !d.Date.Equal(tt.want) {
+			t.Errorf("%s: date = %v, want %v", tt.name, d.Date, tt.want)
+		}
+		if !d.IsCheckIn() && d.TimeZone != nil {
+			t.Errorf("%s: daily entry kept time zone %q", tt.name, *d.TimeZone)
+		}
+	}
+}
+
+func TestValidateDaylog(t *testing.T) {
+	now := time.Now()
+	future := now.Add(time.Hour)
+	soon := now.Add(30 * time.Second)
+
+	valid := func() Daylog {
+		return Daylog{Date: time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC), MoodLabel: 4, Tags: []string{"work"}}
+	}
+
+	tests := []struct {
+		name   string
+		change func(d *Daylog)
+		field  string
+	}{
+		{"daily entry", func(d *Daylog) {}, ""},
+		{"check-in", func(d *Daylog) { d.LoggedAt = &now }, ""},
+		{"check-in within the clock skew", func(d *Daylog) { d.LoggedAt = &soon }, ""},
+		{"check-in in the future", func(d *Daylog) { d.LoggedAt = &future }, "logged_at"},
+		{"missing date", func(d *Daylog) { d.Date = time.Time{} }, "date"},
+		{"missing mood", func(d *Daylog) { d.MoodLabel = 0 }, "mood_label"},
+		{"description at the limit", func(d *Daylog) { d.Description = strings.Repeat("a", 1000) }, ""},
+		{"description too long", func(d *Daylog) { d.Description = strings.Repeat("a", 1001) }, "description"},
+		{"blank tag", func(d *Daylog) { d.Tags = []string{"work", " "} }, "tags"},
+	}
+
+	for _, tt := range tests {
+		d := valid()
+		tt.change(&d)
+
+		v := validator.New()
+		d.ValidateDaylog(v)
+
+		if tt.field == "" {
+			if !v.Valid() {
+				t.Errorf("%s: unexpected errors %v", tt.name, v.Errors)
+			}
+			continue
+		}
+
+		if _, ok := v.Errors[tt.field]; !ok || len(v.Errors) != 1 {
+			t.Errorf("%s: errors = %v, want one for %q", tt.name, v.Errors, tt.field)
+		}
+	}
+}
+
+func TestDaylogETag(t *testing.T) {
+	tracker := uuid.MustParse("33333333-3333-3333-3333-333333333333")
+	updated := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+
+	log := func() Daylog {
+		return Daylog{
+			Tags:      []string{"work", "gym"},
+			Trackers:  []*TrackerValue{{TrackerID: tracker, Name: "Water", Unit: "ml", Value: 500}},
+			BaseModel: BaseModel{Version: 3, UpdatedAt: &updated},
+		}
+	}
+
+	base := log().ETag()
+	if !strings.HasPrefix(base, `W/"3-`) {
+		t.Errorf("ETag = %s, want a weak tag starting with the version", base)
+	}
+
+	tests := []struct {
+		name    string
+		change  func(*Daylog)
+		changed bool
+	}{
+		{"same log", func(d *Daylog) {}, false},
+		{"tags in another order", func(d *Daylog) { d.Tags = []string{"gym", "work"} }, false},
+		{"tracker value", func(d *Daylog) { d.Trackers[0].Value = 750 }, false},
+		{"tag renamed", func(d *Daylog) { d.Tags = []string{"work", "running"} }, true},
+		{"tag deleted", func(d *Daylog) { d.Tags = []string{"work"} }, true},
+		{"tracker renamed", func(d *Daylog) { d.Trackers[0].Name = "Coffee" }, true},
+		{"tracker unit", func(d *Daylog) { d.Trackers[0].Unit = "l" }, true},
+		{"new version", func(d *Daylog) { d.Version++ }, true},
+	}
+
+	for _, tt := range tests {
+		d := log()
+		tt.change(&d)
+		if changed := d.ETag() != base; changed != tt.changed {
+			t.Errorf("%s: ETag changed = %v, want %v", tt.name, changed, tt.changed)
+		}
+	}
+
+	if got := log().LastModified(); !got.IsZero() {
+		t.Errorf("LastModified = %v, want zero", got)
+	}
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Identity struct {
+	ID          uuid.UUID  `db:"id"`
+	UserID      uuid.UUID  `db:"user_id"`
+	Issuer      string     `db:"issuer"`
+	Subject     string     `db:"subject"`
+	Email       *string    `db:"email"`
+	CreatedAt   time.Time  `db:"created_at"`
+	LastLoginAt *time.Time `db:"last_login_at"`
+}
+
+// OIDCState keeps the PKCE verifier and nonce of a pending authorization
+// request, looked up by the hash of the state sent to the provider.
+type OIDCState struct {
+	Plaintext    string    `db:"-"`
+	Hash         []byte    `db:"hash"`
+	CodeVerifier string    `db:"code_verifier"`
+	Nonce        string    `db:"nonce"`
+	Expiry       time.Time `db:"expiry"`
+}
+
+func GenerateOIDCState(verifier string, ttl time.Duration) *OIDCState {
+	state := &OIDCState{
+		Plaintext:    rand.Text(),
+		CodeVerifier: verifier,
+		Nonce:        rand.Text(),
+		Expiry:       time.Now().Add(ttl),
+	}
+
+	state.Hash = HashToken(state.Plaintext)
+	return state
+}
+
+// OIDCStateBinding is the value kept in a cookie of the browser that started
+// the sign-in. The callback only accepts a state that matches it, so a state
+// issued to someone else can't be completed in this browser.
+func OIDCStateBinding(state string) string {
+	return hex.EncodeToString(HashToken(state))
+}
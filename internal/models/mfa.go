@@ -0,0 +1,34 @@
+package models
+
+import (
+	"crypto/rand"
+	"moodtracker/internal/totp"
+	"moodtracker/utils/validator"
+	"strings"
+)
+
+const RecoveryCodeCount = 10
+
+type TOTPEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+func GenerateRecoveryCodes() []string {
+	codes := make([]string, 0, RecoveryCodeCount)
+	for range RecoveryCodeCount {
+		code := rand.Text()[:10]
+		codes = append(codes, code[:5]+"-"+code[5:])
+	}
+	return codes
+}
+
+func HashRecoveryCode(code string) []byte {
+	code = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	return HashToken(code)
+}
+
+func ValidateTOTPCode(v *validator.Validator, code string) {
+	v.Check(code != "", "code", "must be provided")
+	v.Check(len(code) == totp.Digits, "code", "must be 6 digits long")
+}
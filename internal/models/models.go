@@ -1,7 +1,11 @@
 package models
 
+import "time"
+
 type ModelInterface[D any] interface {
 	ToDTO() *D
+	ETag() string
+	LastModified() time.Time
 }
 
 type DTOInterface[T any] interface {
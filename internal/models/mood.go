@@ -0,0 +1,211 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"moodtracker/utils/validator"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type MoodLabel int
+
+// MoodUnknown is assigned to input that doesn't match any level of the scale,
+// so validation can tell it apart from a missing value.
+const MoodUnknown MoodLabel = -1
+
+const (
+	minMoodLevels = 2
+	maxMoodLevels = 10
+)
+
+var (
+	ErrInvalidMoodScale = errors.New("invalid mood scale")
+
+	colorRX = regexp.MustCompile("^#[0-9a-fA-F]{6}$")
+)
+
+type MoodLevel struct {
+	Value MoodLabel `json:"value"`
+	Label string    `json:"label"`
+	Emoji string    `json:"emoji,omitempty"`
+	Color string    `json:"color,omitempty"`
+}
+
+// MoodScale lists the levels a day log can be rated with, from the worst (1)
+// to the best (N).
+type MoodScale []MoodLevel
+
+var DefaultMoodScale = MoodScale{
+	{Value: 1, Label: "PESSIMO", Emoji: "😞", Color: "#D32F2F"},
+	{Value: 2, Label: "RUIM", Emoji: "🙁", Color: "#F57C00"},
+	{Value: 3, Label: "MEDIO", Emoji: "😐", Color: "#FBC02D"},
+	{Value: 4, Label: "BOM", Emoji: "🙂", Color: "#7CB342"},
+	{Value: 5, Label: "OTIMO", Emoji: "😄", Color: "#388E3C"},
+}
+
+var moodScale = DefaultMoodScale
+
+// SetMoodScale replaces the scale used to parse, validate and render moods.
+// It is meant to be called once at startup.
+func SetMoodScale(scale MoodScale) {
+	moodScale = scale
+}
+
+func CurrentMoodScale() MoodScale {
+	return moodScale
+}
+
+// ParseMoodScale reads MOOD_SCALE: a comma separated list of
+// "LABEL:emoji:#RRGGBB" levels, from the worst to the best. Emoji and color
+// are optional. An empty spec returns the default 5-point scale.
+func ParseMoodScale(spec string) (MoodScale, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return DefaultMoodScale, nil
+	}
+
+	var scale MoodScale
+	seen := make(map[string]bool)
+
+	for i, item := range strings.Split(spec, ",") {
+		parts := strings.Split(strings.TrimSpace(item), ":")
+		if len(parts) > 3 {
+			return nil, fmt.Errorf("%w: level %d has too many fields", ErrInvalidMoodScale, i+1)
+		}
+
+		level := MoodLevel{
+			Value: MoodLabel(i + 1),
+			Label: strings.ToUpper(strings.TrimSpace(parts[0])),
+		}
+
+		if len(parts) > 1 {
+			level.Emoji = strings.TrimSpace(parts[1])
+		}
+
+		if len(parts) > 2 {
+			level.Color = strings.ToUpper(strings.TrimSpace(parts[2]))
+		}
+
+		if level.Label == "" {
+			return nil, fmt.Errorf("%w: level %d has no label", ErrInvalidMoodScale, i+1)
+		}
+
+		if _, err := strconv.Atoi(level.Label); err == nil {
+			return nil, fmt.Errorf("%w: label %q must not be a number", ErrInvalidMoodScale, level.Label)
+		}
+
+		if seen[level.Label] {
+			return nil, fmt.Errorf("%w: duplicated label %q", ErrInvalidMoodScale, level.Label)
+		}
+
+		if level.Color != "" && !colorRX.MatchString(level.Color) {
+			return nil, fmt.Errorf("%w: color %q must be in the #RRGGBB format", ErrInvalidMoodScale, level.Color)
+		}
+
+		seen[level.Label] = true
+		scale = append(scale, level)
+	}
+
+	if len(scale) < minMoodLevels || len(scale) > maxMoodLevels {
+		return nil, fmt.Errorf("%w: must have between %d and %d levels", ErrInvalidMoodScale, minMoodLevels, maxMoodLevels)
+	}
+
+	return scale, nil
+}
+
+func (s MoodScale) Level(m MoodLabel) (MoodLevel, bool) {
+	if m < 1 || int(m) > len(s) {
+		return MoodLevel{}, false
+	}
+	return s[m-1], true
+}
+
+// CheckStored fails when logs are rated above the top level of the scale, as
+// happens when MOOD_SCALE is configured with fewer levels than the data uses.
+func (s MoodScale) CheckStored(highest MoodLabel) error {
+	if int(highest) > len(s) {
+		return fmt.Errorf("%w: day logs are rated up to %d but the scale has %d levels",
+			ErrInvalidMoodScale, highest, len(s))
+	}
+	return nil
+}
+
+func (s MoodScale) Labels() []string {
+	labels := make([]string, 0, len(s))
+	for _, level := range s {
+		labels = append(labels, level.Label)
+	}
+	return labels
+}
+
+func (m MoodLabel) String() string {
+	level, ok := moodScale.Level(m)
+	if !ok {
+		return "unknown"
+	}
+	return level.Label
+}
+
+// ParseMoodLabel accepts either the numeric value or the label of a level,
+// returning MoodUnknown when s matches neither.
+func ParseMoodLabel(s string) MoodLabel {
+	s = strings.TrimSpace(s)
+
+	if n, err := strconv.Atoi(s); err == nil {
+		if _, ok := moodScale.Level(MoodLabel(n)); ok {
+			return MoodLabel(n)
+		}
+		return MoodUnknown
+	}
+
+	s = strings.ToUpper(s)
+	for _, level := range moodScale {
+		if level.Label == s {
+			return level.Value
+		}
+	}
+
+	return MoodUnknown
+}
+
+func ValidateMoodLabel(v *validator.Validator, m MoodLabel) {
+	if m == 0 {
+		v.AddError("mood_label", "must be provided")
+		return
+	}
+
+	_, ok := moodScale.Level(m)
+	v.Check(ok, "mood_label", fmt.Sprintf(
+		"must be a value from 1 to %d or one of %s",
+		len(moodScale),
+		strings.Join(moodScale.Labels(), ", "),
+	))
+}
+
+// MoodInput holds a mood as sent by clients, either as a JSON number or as a
+// string with the value or the label.
+type MoodInput string
+
+func (m *MoodInput) UnmarshalJSON(data []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err == nil {
+		*m = MoodInput(n.String())
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return &json.UnmarshalTypeError{
+			Value: string(data),
+			Type:  reflect.TypeFor[MoodInput](),
+			Field: "mood_label",
+		}
+	}
+
+	*m = MoodInput(s)
+	return nil
+}
@@ -0,0 +1,248 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"moodtracker/utils/validator"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// useMoodScale replaces the package scale for the duration of the test.
+func useMoodScale(t *testing.T, scale MoodScale) {
+	t.Helper()
+
+	previous := moodScale
+	SetMoodScale(scale)
+	t.Cleanup(func() { SetMoodScale(previous) })
+}
+
+func TestParseMoodScale(t *testing.T) {
+	tests := []struct {
+		spec  string
+		scale MoodScale
+	}{
+		{"", DefaultMoodScale},
+		{"   ", DefaultMoodScale},
+		{"bad,good", MoodScale{{Value: 1, Label: "BAD"}, {Value: 2, Label: "GOOD"}}},
+		{
+			" Awful : 😫 : #b71c1c , Meh:😐, Great::#1b5e20",
+			MoodScale{
+				{Value: 1, Label: "AWFUL", Emoji: "😫", Color: "#B71C1C"},
+				{Value: 2, Label: "MEH", Emoji: "😐"},
+				{Value: 3, Label: "GREAT", Color: "#1B5E20"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		scale, err := ParseMoodScale(tt.spec)
+		if err != nil {
+			t.Errorf("ParseMoodScale(%q) returned %v", tt.spec, err)
+			continue
+		}
+		if !reflect.DeepEqual(scale, tt.scale) {
+			t.Errorf("ParseMoodScale(%q) = %+v, want %+v", tt.spec, scale, tt.scale)
+		}
+	}
+
+	invalid := []struct {
+		name string
+		spec string
+	}{
+		{"a single level", "OK"},
+		{"more than ten levels", strings.Repeat("L,", 10) + "LAST"},
+		{"too many fields", "BAD:😞:#D32F2F:extra,GOOD"},
+		{"empty label", "BAD,,GOOD"},
+		{"label with only an emoji", "BAD,:🙂,GOOD"},
+		{"numeric label", "1,2"},
+		{"duplicated label", "ok,OK"},
+		{"color without a hash", "BAD::D32F2F,GOOD"},
+		{"short color", "BAD::#FFF,GOOD"},
+		{"color name", "BAD::red,GOOD"},
+	}
+
+	for _, tt := range invalid {
+		if _, err := ParseMoodScale(tt.spec); !errors.Is(err, ErrInvalidMoodScale) {
+			t.Errorf("%s: ParseMoodScale(%q) returned %v, want ErrInvalidMoodScale", tt.name, tt.spec, err)
+		}
+	}
+}
+
+func TestParseMoodLabel(t *testing.T) {
+	tests := []struct {
+		s    string
+		want MoodLabel
+	}{
+		{"1", 1},
+		{"5", 5},
+		{" 3 ", 3},
+		{"BOM", 4},
+		{"otimo", 5},
+		{" Pessimo ", 1},
+		{"0", MoodUnknown},
+		{"6", MoodUnknown},
+		{"-1", MoodUnknown},
+		{"3.5", MoodUnknown},
+		{"GREAT", MoodUnknown},
+		{"", MoodUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := ParseMoodLabel(tt.s); got != tt.want {
+			t.Errorf("ParseMoodLabel(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+
+	// The parser follows the configured scale.
+	scale, err := ParseMoodScale("BAD,OK,GOOD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useMoodScale(t, scale)
+
+	custom := []struct {
+		s    string
+		want MoodLabel
+	}{
+		{"good", 3},
+		{"3", 3},
+		{"4", MoodUnknown},
+		{"BOM", MoodUnknown},
+	}
+
+	for _, tt := range custom {
+		if got := ParseMoodLabel(tt.s); got != tt.want {
+			t.Errorf("with a custom scale, ParseMoodLabel(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestMoodLabelString(t *testing.T) {
+	tests := []struct {
+		mood MoodLabel
+		want string
+	}{
+		{1, "PESSIMO"},
+		{5, "OTIMO"},
+		{0, "unknown"},
+		{6, "unknown"},
+		{MoodUnknown, "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.mood.String(); got != tt.want {
+			t.Errorf("MoodLabel(%d).String() = %q, want %q", tt.mood, got, tt.want)
+		}
+	}
+}
+
+func TestMoodScaleCheckStored(t *testing.T) {
+	threeLevels := MoodScale{{Value: 1, Label: "RUIM"}, {Value: 2, Label: "MEDIO"}, {Value: 3, Label: "BOM"}}
+
+	tests := []struct {
+		name    string
+		scale   MoodScale
+		highest MoodLabel
+		valid   bool
+	}{
+		{"no logs", DefaultMoodScale, 0, true},
+		{"top level", DefaultMoodScale, 5, true},
+		{"below the top", threeLevels, 2, true},
+		{"migrated BOM on a shorter scale", threeLevels, 4, false},
+		{"above every scale", DefaultMoodScale, 11, false},
+	}
+
+	for _, tt := range tests {
+		err := tt.scale.CheckStored(tt.highest)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: CheckStored(%d) = %v, want valid %v", tt.name, tt.highest, err, tt.valid)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidMoodScale) {
+			t.Errorf("%s: error %v is not ErrInvalidMoodScale", tt.name, err)
+		}
+	}
+}
+
+func TestValidateMoodLabel(t *testing.T) {
+	tests := []struct {
+		mood    MoodLabel
+		message string
+	}{
+		{1, ""},
+		{5, ""},
+		{0, "must be provided"},
+		{MoodUnknown, "must be a value from 1 to 5 or one of PESSIMO, RUIM, MEDIO, BOM, OTIMO"},
+		{6, "must be a value from 1 to 5 or one of PESSIMO, RUIM, MEDIO, BOM, OTIMO"},
+	}
+
+	for _, tt := range tests {
+		v := validator.New()
+		ValidateMoodLabel(v, tt.mood)
+
+		if got := v.Errors["mood_label"]; got != tt.message {
+			t.Errorf("ValidateMoodLabel(%d) error = %q, want %q", tt.mood, got, tt.message)
+		}
+	}
+}
+
+func TestMoodInputUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		json string
+		want MoodInput
+	}{
+		{`4`, "4"},
+		{`"4"`, "4"},
+		{`"BOM"`, "BOM"},
+		{`"bom"`, "bom"},
+		{`2.5`, "2.5"},
+		{`""`, ""},
+	}
+
+	for _, tt := range tests {
+		var m MoodInput
+		if err := json.Unmarshal([]byte(tt.json), &m); err != nil || m != tt.want {
+			t.Errorf("Unmarshal(%s) = %q, %v, want %q", tt.json, m, err, tt.want)
+		}
+	}
+
+	for _, input := range []string{`true`, `{"value":4}`, `[4]`} {
+		var m MoodInput
+		var typeErr *json.UnmarshalTypeError
+		if err := json.Unmarshal([]byte(input), &m); !errors.As(err, &typeErr) || typeErr.Field != "mood_label" {
+			t.Errorf("Unmarshal(%s) returned %v, want a type error for mood_label", input, err)
+		}
+	}
+}
+
+func TestAverageMood(t *testing.T) {
+	tests := []struct {
+		name          string
+		distribuition []MoodDistribuition
+		want          float64
+	}{
+		{"no logs", nil, 0},
+		{"levels without logs", []MoodDistribuition{{MoodLabel: 3}}, 0},
+		{"a single level", []MoodDistribuition{{MoodLabel: 4, Count: 7}}, 4},
+		{"weighted by count", []MoodDistribuition{{MoodLabel: 1, Count: 1}, {MoodLabel: 5, Count: 3}}, 4},
+		{"rounded to two places", []MoodDistribuition{{MoodLabel: 1, Count: 1}, {MoodLabel: 2, Count: 2}}, 1.67},
+	}
+
+	for _, tt := range tests {
+		if got := AverageMood(tt.distribuition); got != tt.want {
+			t.Errorf("%s: AverageMood = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewMoodDistribuition(t *testing.T) {
+	d := NewMoodDistribuition(4, 2, 50)
+	if d.Mood == nil || d.Mood.Label != "BOM" || d.Count != 2 || d.Percentage != 50 {
+		t.Errorf("NewMoodDistribuition(4) = %+v", d)
+	}
+
+	if d := NewMoodDistribuition(9, 1, 100); d.Mood != nil {
+		t.Errorf("a level outside the scale got %+v", d.Mood)
+	}
+}
@@ -0,0 +1,23 @@
+package models
+
+import "testing"
+
+func TestPermissionsInclude(t *testing.T) {
+	tests := []struct {
+		permissions Permissions
+		code        string
+		want        bool
+	}{
+		{Permissions{PermissionUsersRead, PermissionUsersWrite}, PermissionUsersWrite, true},
+		{Permissions{PermissionUsersRead}, PermissionUsersWrite, false},
+		{Permissions{"users:*"}, PermissionUsersWrite, false},
+		{Permissions{}, PermissionMetricsRead, false},
+		{nil, PermissionMetricsRead, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.permissions.Include(tt.code); got != tt.want {
+			t.Errorf("%v.Include(%q) = %v, want %v", tt.permissions, tt.code, got, tt.want)
+		}
+	}
+}
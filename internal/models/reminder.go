@@ -0,0 +1,229 @@
+package models
+
+import (
+	"fmt"
+	"moodtracker/utils/validator"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ReminderChannelEmail   = "email"
+	ReminderChannelWebhook = "webhook"
+)
+
+// DailyReminder is the kind of notification sent for a reminder. It also
+// names the email template.
+const DailyReminder = "daily_reminder"
+
+// Reminder is a rule such as "every day at 21:00 in America/Sao_Paulo, unless
+// I have already logged". NextRunAt is the next time it is due.
+type Reminder struct {
+	BaseModel
+	ID           uuid.UUID  `db:"id"`
+	TimeOfDay    string     `db:"time_of_day"`
+	TimeZone     string     `db:"time_zone"`
+	Weekdays     []int64    `db:"weekdays"`
+	SkipIfLogged bool       `db:"skip_if_logged"`
+	Channel      string     `db:"channel"`
+	WebhookURL   string     `db:"webhook_url"`
+	Enabled      bool       `db:"enabled"`
+	NextRunAt    time.Time  `db:"next_run_at"`
+	LastSentAt   *time.Time `db:"last_sent_at"`
+}
+
+type ReminderDTO struct {
+	ID           uuid.UUID  `json:"id"`
+	Time         *string    `json:"time"`
+	TimeZone     *string    `json:"time_zone"`
+	Weekdays     []int64    `json:"weekdays"`
+	SkipIfLogged *bool      `json:"skip_if_logged"`
+	Channel      *string    `json:"channel"`
+	WebhookURL   *string    `json:"webhook_url,omitempty"`
+	Enabled      *bool      `json:"enabled"`
+	NextRunAt    *time.Time `json:"next_run_at,omitempty"`
+	LastSentAt   *time.Time `json:"last_sent_at,omitempty"`
+	Version      int        `json:"version,omitempty"`
+}
+
+// DueReminder is a reminder claimed by the dispatcher, with what is needed to
+// deliver it.
+type DueReminder struct {
+	Reminder
+	UserID uuid.UUID `db:"user_id"`
+	Name   string    `db:"name"`
+	Email  string    `db:"email"`
+	Locale string    `db:"locale"`
+	Logged bool      `db:"logged"`
+}
+
+func (r Reminder) ToDTO() *ReminderDTO {
+	dto := ReminderDTO{
+		ID:           r.ID,
+		Time:         &r.TimeOfDay,
+		TimeZone:     &r.TimeZone,
+		Weekdays:     r.Weekdays,
+		SkipIfLogged: &r.SkipIfLogged,
+		Channel:      &r.Channel,
+		Enabled:      &r.Enabled,
+		LastSentAt:   r.LastSentAt,
+		Version:      r.Version,
+	}
+
+	if dto.Weekdays == nil {
+		dto.Weekdays = []int64{}
+	}
+
+	if r.WebhookURL != "" {
+		dto.WebhookURL = &r.WebhookURL
+	}
+
+	if r.Enabled && !r.NextRunAt.IsZero() {
+		dto.NextRunAt = &r.NextRunAt
+	}
+
+	return &dto
+}
+
+func (dto ReminderDTO) ToModel() *Reminder {
+	model := Reminder{
+		ID:           dto.ID,
+		Weekdays:     dto.Weekdays,
+		SkipIfLogged: true,
+		Channel:      ReminderChannelEmail,
+		Enabled:      true,
+	}
+	model.Version = dto.Version
+
+	if dto.Time != nil {
+		model.TimeOfDay = strings.TrimSpace(*dto.Time)
+	}
+
+	if dto.TimeZone != nil {
+		model.TimeZone = strings.TrimSpace(*dto.TimeZone)
+	}
+
+	if dto.SkipIfLogged != nil {
+		model.SkipIfLogged = *dto.SkipIfLogged
+	}
+
+	if dto.Channel != nil {
+		model.Channel = strings.ToLower(strings.TrimSpace(*dto.Channel))
+	}
+
+	if dto.WebhookURL != nil {
+		model.WebhookURL = strings.TrimSpace(*dto.WebhookURL)
+	}
+
+	if dto.Enabled != nil {
+		model.Enabled = *dto.Enabled
+	}
+
+	if model.Weekdays != nil {
+		model.Weekdays = slices.Clone(model.Weekdays)
+		slices.Sort(model.Weekdays)
+	}
+
+	return &model
+}
+
+func (r *Reminder) ValidateReminder(v *validator.Validator, channels []string) {
+	hour, minute, ok := r.clock()
+	v.Check(r.TimeOfDay != "", "time", "must be provided")
+	v.Check(r.TimeOfDay == "" || ok, "time", "must be a time of day in the HH:MM format, such as 21:00")
+	if ok {
+		r.TimeOfDay = fmt.Sprintf("%02d:%02d", hour, minute)
+	}
+
+	ValidateTimeZone(v, "time_zone", r.TimeZone)
+
+	unique := slices.Compact(slices.Sorted(slices.Values(r.Weekdays)))
+	v.Check(len(unique) == len(r.Weekdays), "weekdays", "must not contain duplicate values")
+	for _, day := range r.Weekdays {
+		v.Check(day >= 0 && day <= 6, "weekdays", "must only contain days from 0 (Sunday) to 6 (Saturday)")
+	}
+
+	v.Check(validator.In(r.Channel, channels...), "channel", "must be one of "+strings.Join(channels, ", "))
+
+	if r.Channel == ReminderChannelWebhook {
+		u, err := url.Parse(r.WebhookURL)
+		v.Check(err == nil && u.Scheme == "https" && u.Host != "", "webhook_url", "must be an https URL")
+		v.Check(err != nil || publicHostname(u.Hostname()), "webhook_url", "must use a public host name, not an IP address")
+		v.Check(len(r.WebhookURL) <= 2048, "webhook_url", "must not be more than 2048 bytes long")
+	} else {
+		v.Check(r.WebhookURL == "", "webhook_url", "must only be set for the webhook channel")
+	}
+}
+
+// publicHostname rejects IP literals and names that can only point inside the
+// network. It is a first filter: the webhook channel checks the address again
+// after DNS resolution, which is what actually stops internal targets.
+func publicHostname(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "" || net.ParseIP(host) != nil {
+		return false
+	}
+
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+
+	// Hosts such as 2130706433 or 127.1 are read as IPv4 addresses by some
+	// resolvers. Public names end in a top-level domain, never in a number.
+	labels := strings.Split(host, ".")
+	tld := labels[len(labels)-1]
+	if len(labels) < 2 || strings.Trim(tld, "0123456789") == "" || strings.HasPrefix(tld, "0x") {
+		return false
+	}
+
+	return true
+}
+
+func (r *Reminder) clock() (hour, minute int, ok bool) {
+	t, err := time.Parse("15:04", r.TimeOfDay)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return t.Hour(), t.Minute(), true
+}
+
+// NextRun returns the first time the reminder is due after the given time,
+// reading the time of day and weekdays in its own time zone.
+func (r *Reminder) NextRun(after time.Time) time.Time {
+	loc, err := time.LoadLocation(r.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	hour, minute, _ := r.clock()
+	local := after.In(loc)
+
+	for i := 0; i <= 7; i++ {
+		next := time.Date(local.Year(), local.Month(), local.Day()+i, hour, minute, 0, 0, loc)
+		if !next.After(after) {
+			continue
+		}
+
+		if len(r.Weekdays) == 0 || slices.Contains(r.Weekdays, int64(next.Weekday())) {
+			return next
+		}
+	}
+
+	return after.AddDate(0, 0, 7)
+}
+
+// LocalDate is the day, in the reminder time zone, that a run at t is for.
+func (r *Reminder) LocalDate(t time.Time) string {
+	loc, err := time.LoadLocation(r.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	return t.In(loc).Format(time.DateOnly)
+}
@@ -0,0 +1,194 @@
+package models
+
+import (
+	"moodtracker/utils/validator"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateReminder(t *testing.T) {
+	channels := []string{ReminderChannelEmail, ReminderChannelWebhook}
+
+	webhook := func(url string) Reminder {
+		return Reminder{TimeOfDay: "21:00", TimeZone: "UTC", Channel: ReminderChannelWebhook, WebhookURL: url}
+	}
+
+	tests := []struct {
+		name     string
+		reminder Reminder
+		field    string
+	}{
+		{"valid email reminder", Reminder{TimeOfDay: "21:00", TimeZone: "America/Sao_Paulo", Channel: "email"}, ""},
+		{"single digit hour", Reminder{TimeOfDay: "9:05", TimeZone: "UTC", Channel: "email"}, ""},
+		{"weekdays", Reminder{TimeOfDay: "21:00", TimeZone: "UTC", Channel: "email", Weekdays: []int64{0, 6}}, ""},
+		{"missing time", Reminder{TimeZone: "UTC", Channel: "email"}, "time"},
+		{"invalid time", Reminder{TimeOfDay: "25:00", TimeZone: "UTC", Channel: "email"}, "time"},
+		{"time with seconds", Reminder{TimeOfDay: "21:00:00", TimeZone: "UTC", Channel: "email"}, "time"},
+		{"missing time zone", Reminder{TimeOfDay: "21:00", Channel: "email"}, "time_zone"},
+		{"invalid time zone", Reminder{TimeOfDay: "21:00", TimeZone: "Mars/Olympus", Channel: "email"}, "time_zone"},
+		{"local time zone", Reminder{TimeOfDay: "21:00", TimeZone: "Local", Channel: "email"}, "time_zone"},
+		{"duplicate weekdays", Reminder{TimeOfDay: "21:00", TimeZone: "UTC", Channel: "email", Weekdays: []int64{1, 1}}, "weekdays"},
+		{"duplicate weekdays out of order", Reminder{TimeOfDay: "21:00", TimeZone: "UTC", Channel: "email", Weekdays: []int64{1, 2, 1}}, "weekdays"},
+		{"weekday out of range", Reminder{TimeOfDay: "21:00", TimeZone: "UTC", Channel: "email", Weekdays: []int64{7}}, "weekdays"},
+		{"negative weekday", Reminder{TimeOfDay: "21:00", TimeZone: "UTC", Channel: "email", Weekdays: []int64{-1}}, "weekdays"},
+		{"unknown channel", Reminder{TimeOfDay: "21:00", TimeZone: "UTC", Channel: "sms"}, "channel"},
+		{"webhook URL on the email channel", Reminder{TimeOfDay: "21:00", TimeZone: "UTC", Channel: "email", WebhookURL: "https://hooks.example.com"}, "webhook_url"},
+		{"valid webhook", webhook("https://hooks.example.com/moodtracker?token=abc"), ""},
+		{"webhook with a port", webhook("https://hooks.example.com:8443/moodtracker"), ""},
+		{"missing webhook URL", webhook(""), "webhook_url"},
+		{"plain http webhook", webhook("http://hooks.example.com"), "webhook_url"},
+		{"relative webhook URL", webhook("/hooks"), "webhook_url"},
+		{"webhook URL too long", webhook("https://hooks.example.com/" + strings.Repeat("a", 2048)), "webhook_url"},
+		{"loopback IPv4 literal", webhook("https://127.0.0.1/hook"), "webhook_url"},
+		{"private IPv4 literal", webhook("https://10.0.0.5/hook"), "webhook_url"},
+		{"metadata endpoint", webhook("https://169.254.169.254/latest/meta-data"), "webhook_url"},
+		{"public IPv4 literal", webhook("https://93.184.216.34/hook"), "webhook_url"},
+		{"loopback IPv6 literal", webhook("https://[::1]/hook"), "webhook_url"},
+		{"IPv4 mapped IPv6 literal", webhook("https://[::ffff:127.0.0.1]/hook"), "webhook_url"},
+		{"localhost", webhook("https://localhost/hook"), "webhook_url"},
+		{"localhost with a trailing dot", webhook("https://LOCALHOST./hook"), "webhook_url"},
+		{"localhost subdomain", webhook("https://app.localhost/hook"), "webhook_url"},
+		{"decimal IPv4", webhook("https://2130706433/hook"), "webhook_url"},
+		{"short IPv4", webhook("https://127.1/hook"), "webhook_url"},
+		{"hexadecimal IPv4", webhook("https://0x7f000001/hook"), "webhook_url"},
+		{"single label host", webhook("https://intranet/hook"), "webhook_url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			tt.reminder.ValidateReminder(v, channels)
+
+			if tt.field == "" && !v.Valid() {
+				t.Errorf("unexpected errors %v", v.Errors)
+			}
+
+			if _, ok := v.Errors[tt.field]; tt.field != "" && (!ok || len(v.Errors) != 1) {
+				t.Errorf("errors = %v, want one for %q", v.Errors, tt.field)
+			}
+		})
+	}
+}
+
+func TestValidateReminderNormalizesTime(t *testing.T) {
+	r := Reminder{TimeOfDay: "9:05", TimeZone: "UTC", Channel: "email"}
+	r.ValidateReminder(validator.New(), []string{"email"})
+
+	if r.TimeOfDay != "09:05" {
+		t.Errorf("TimeOfDay = %q, want 09:05", r.TimeOfDay)
+	}
+}
+
+func TestReminderNextRun(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		reminder Reminder
+		after    time.Time
+		want     time.Time
+	}{
+		{
+			name:     "later the same day",
+			reminder: Reminder{TimeOfDay: "21:00", TimeZone: "America/Sao_Paulo"},
+			after:    time.Date(2026, 2, 10, 12, 0, 0, 0, saoPaulo),
+			want:     time.Date(2026, 2, 10, 21, 0, 0, 0, saoPaulo),
+		},
+		{
+			name:     "exactly at the time moves to the next day",
+			reminder: Reminder{TimeOfDay: "21:00", TimeZone: "America/Sao_Paulo"},
+			after:    time.Date(2026, 2, 10, 21, 0, 0, 0, saoPaulo),
+			want:     time.Date(2026, 2, 11, 21, 0, 0, 0, saoPaulo),
+		},
+		{
+			name:     "reads the day in the reminder time zone",
+			reminder: Reminder{TimeOfDay: "21:00", TimeZone: "America/Sao_Paulo"},
+			after:    time.Date(2026, 2, 10, 23, 30, 0, 0, time.UTC),
+			want:     time.Date(2026, 2, 10, 21, 0, 0, 0, saoPaulo),
+		},
+		{
+			name:     "next allowed weekday",
+			reminder: Reminder{TimeOfDay: "08:00", TimeZone: "UTC", Weekdays: []int64{1}},
+			after:    time.Date(2026, 2, 10, 9, 0, 0, 0, time.UTC),
+			want:     time.Date(2026, 2, 16, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "only weekday is today but the time has passed",
+			reminder: Reminder{TimeOfDay: "08:00", TimeZone: "UTC", Weekdays: []int64{2}},
+			after:    time.Date(2026, 2, 10, 9, 0, 0, 0, time.UTC),
+			want:     time.Date(2026, 2, 17, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "unknown time zone falls back to UTC",
+			reminder: Reminder{TimeOfDay: "21:00", TimeZone: "Mars/Olympus"},
+			after:    time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC),
+			want:     time.Date(2026, 2, 10, 21, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "keeps the wall clock when DST starts",
+			reminder: Reminder{TimeOfDay: "21:00", TimeZone: "America/New_York"},
+			after:    time.Date(2026, 3, 7, 22, 0, 0, 0, newYork),
+			want:     time.Date(2026, 3, 9, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "keeps the wall clock when DST ends",
+			reminder: Reminder{TimeOfDay: "21:00", TimeZone: "America/New_York"},
+			after:    time.Date(2026, 10, 31, 22, 0, 0, 0, newYork),
+			want:     time.Date(2026, 11, 2, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "morning reminder the day DST starts",
+			reminder: Reminder{TimeOfDay: "08:00", TimeZone: "America/New_York"},
+			after:    time.Date(2026, 3, 8, 0, 0, 0, 0, newYork),
+			want:     time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "morning reminder the day DST ends",
+			reminder: Reminder{TimeOfDay: "08:00", TimeZone: "America/New_York"},
+			after:    time.Date(2026, 11, 1, 0, 0, 0, 0, newYork),
+			want:     time.Date(2026, 11, 1, 13, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.reminder.NextRun(tt.after); !got.Equal(tt.want) {
+				t.Errorf("NextRun = %v, want %v", got.UTC(), tt.want.UTC())
+			}
+		})
+	}
+}
+
+func TestReminderNextRunInSkippedHour(t *testing.T) {
+	// 02:30 does not exist in New York on 2026-03-08. The run still happens
+	// that day, once, and after the given time.
+	r := Reminder{TimeOfDay: "02:30", TimeZone: "America/New_York"}
+	after := time.Date(2026, 3, 8, 5, 0, 0, 0, time.UTC)
+
+	next := r.NextRun(after)
+	if !next.After(after) || r.LocalDate(next) != "2026-03-08" {
+		t.Errorf("NextRun = %v, want a run on 2026-03-08 after %v", next, after)
+	}
+
+	following := r.NextRun(next)
+	if r.LocalDate(following) != "2026-03-09" {
+		t.Errorf("NextRun after the skipped hour = %v, want a run on 2026-03-09", following)
+	}
+}
+
+func TestReminderLocalDate(t *testing.T) {
+	r := Reminder{TimeZone: "America/Sao_Paulo"}
+
+	if got := r.LocalDate(time.Date(2026, 2, 11, 1, 0, 0, 0, time.UTC)); got != "2026-02-10" {
+		t.Errorf("LocalDate = %s, want 2026-02-10", got)
+	}
+}
@@ -1,26 +1,35 @@
 package models
 
+import (
+	"math"
+	"time"
+)
+
 type MonthlyReport struct {
 	Year          int                 `db:"year"`
 	Month         int                 `db:"month"`
+	AverageMood   float64             `db:"-"`
 	Distribuition []MoodDistribuition `db:"-"`
 	Tags          []CountTags         `db:"-"`
 }
 
 type TagReport struct {
 	Tag           string              `db:"tag"`
+	AverageMood   float64             `db:"-"`
 	Distribuition []MoodDistribuition `db:"-"`
 }
 
 type MoodReport struct {
 	MoodLabel     `db:"mood_label"`
+	Mood          *MoodLevel         `db:"-"`
 	Distribuition []TagDistribuition `db:"-"`
 }
 
 type MoodDistribuition struct {
 	MoodLabel  `db:"mood_label"`
-	Count      int     `db:"count"`
-	Percentage float64 `db:"percentage"`
+	Mood       *MoodLevel `db:"-"`
+	Count      int        `db:"count"`
+	Percentage float64    `db:"percentage"`
 }
 
 type TagDistribuition struct {
@@ -30,6 +39,141 @@ type TagDistribuition struct {
 }
 
 type CountTags struct {
-	Tag   string `db:"tag"`
-	Count int    `db:"count"`
+	Tag         string  `db:"tag"`
+	Count       int     `db:"count"`
+	AverageMood float64 `db:"average_mood"`
+}
+
+func NewMoodDistribuition(mood MoodLabel, count int, percentage float64) MoodDistribuition {
+	d := MoodDistribuition{
+		MoodLabel:  mood,
+		Count:      count,
+		Percentage: percentage,
+	}
+
+	if level, ok := moodScale.Level(mood); ok {
+		d.Mood = &level
+	}
+
+	return d
+}
+
+// AverageMood is the mean mood value weighted by the number of logs on each
+// level, rounded to two decimal places.
+func AverageMood(distribuition []MoodDistribuition) float64 {
+	var sum, count int
+	for _, d := range distribuition {
+		sum += int(d.MoodLabel) * d.Count
+		count += d.Count
+	}
+
+	if count == 0 {
+		return 0
+	}
+
+	return math.Round(float64(sum)/float64(count)*100) / 100
+}
+
+// TrackerReport lines a tracker up against the mood of the logs it was
+// recorded on. Correlation is Pearson's r between value and mood level and is
+// left empty when there are too few points or either side never varies.
+type TrackerReport struct {
+	Tracker     *TrackerDTO        `json:"tracker"`
+	StartDate   time.Time          `json:"start_date"`
+	EndDate     time.Time          `json:"end_date"`
+	Samples     int                `json:"samples"`
+	Correlation *float64           `json:"correlation"`
+	ByMood      []TrackerMoodStats `json:"by_mood"`
+	Points      []*TrackerPoint    `json:"points"`
+}
+
+type TrackerPoint struct {
+	Date      time.Time  `db:"date" json:"date"`
+	LoggedAt  *time.Time `db:"logged_at" json:"logged_at,omitempty"`
+	MoodLabel MoodLabel  `db:"mood_label" json:"mood_label"`
+	Raw       float64    `db:"value" json:"-"`
+	Value     any        `db:"-" json:"value"`
+}
+
+type TrackerMoodStats struct {
+	MoodLabel    MoodLabel  `json:"mood_label"`
+	Mood         *MoodLevel `json:"mood,omitempty"`
+	Count        int        `json:"count"`
+	AverageValue float64    `json:"average_value"`
+}
+
+func NewTrackerReport(tracker *Tracker, startDate, endDate time.Time, points []*TrackerPoint) *TrackerReport {
+	report := TrackerReport{
+		Tracker:   tracker.ToDTO(),
+		StartDate: startDate,
+		EndDate:   endDate,
+		Samples:   len(points),
+		ByMood:    []TrackerMoodStats{},
+		Points:    points,
+	}
+
+	sums := map[MoodLabel]float64{}
+	counts := map[MoodLabel]int{}
+
+	for _, p := range points {
+		p.Value = tracker.Kind.Render(p.Raw)
+		sums[p.MoodLabel] += p.Raw
+		counts[p.MoodLabel]++
+	}
+
+	for mood := MoodLabel(1); int(mood) <= len(moodScale); mood++ {
+		if counts[mood] == 0 {
+			continue
+		}
+
+		stats := TrackerMoodStats{
+			MoodLabel:    mood,
+			Count:        counts[mood],
+			AverageValue: round(sums[mood]/float64(counts[mood]), 2),
+		}
+
+		if level, ok := moodScale.Level(mood); ok {
+			stats.Mood = &level
+		}
+
+		report.ByMood = append(report.ByMood, stats)
+	}
+
+	report.Correlation = correlation(points)
+
+	return &report
+}
+
+func correlation(points []*TrackerPoint) *float64 {
+	n := float64(len(points))
+	if n < 2 {
+		return nil
+	}
+
+	var sumX, sumY float64
+	for _, p := range points {
+		sumX += p.Raw
+		sumY += float64(p.MoodLabel)
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var cov, varX, varY float64
+	for _, p := range points {
+		dx, dy := p.Raw-meanX, float64(p.MoodLabel)-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+
+	if varX == 0 || varY == 0 {
+		return nil
+	}
+
+	r := round(cov/math.Sqrt(varX*varY), 3)
+	return &r
+}
+
+func round(f float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(f*p) / p
 }
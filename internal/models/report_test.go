@@ -0,0 +1,88 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewTrackerReport(t *testing.T) {
+	start := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 30)
+
+	points := func(values []float64, moods []MoodLabel) []*TrackerPoint {
+		list := make([]*TrackerPoint, len(values))
+		for i := range values {
+			list[i] = &TrackerPoint{Date: start.AddDate(0, 0, i), Raw: values[i], MoodLabel: moods[i]}
+		}
+		return list
+	}
+	correlation := func(r float64) *float64 { return &r }
+
+	tests := []struct {
+		name        string
+		values      []float64
+		moods       []MoodLabel
+		correlation *float64
+		byMood      map[MoodLabel][2]float64
+	}{
+		{"no points", nil, nil, nil, map[MoodLabel][2]float64{}},
+		{"one point", []float64{7}, []MoodLabel{4}, nil, map[MoodLabel][2]float64{4: {1, 7}}},
+		{"value never varies", []float64{7, 7, 7}, []MoodLabel{2, 3, 4}, nil,
+			map[MoodLabel][2]float64{2: {1, 7}, 3: {1, 7}, 4: {1, 7}}},
+		{"mood never varies", []float64{5, 6, 7}, []MoodLabel{3, 3, 3}, nil, map[MoodLabel][2]float64{3: {3, 6}}},
+		{"positive", []float64{5, 6, 7}, []MoodLabel{2, 3, 4}, correlation(1),
+			map[MoodLabel][2]float64{2: {1, 5}, 3: {1, 6}, 4: {1, 7}}},
+		{"negative", []float64{1, 2, 3}, []MoodLabel{5, 3, 1}, correlation(-1),
+			map[MoodLabel][2]float64{1: {1, 3}, 3: {1, 2}, 5: {1, 1}}},
+		{"partial", []float64{1, 2, 3, 4}, []MoodLabel{2, 1, 4, 3}, correlation(0.6),
+			map[MoodLabel][2]float64{1: {1, 2}, 2: {1, 1}, 3: {1, 4}, 4: {1, 3}}},
+		{"averages rounded", []float64{1, 1, 2}, []MoodLabel{5, 5, 5}, nil, map[MoodLabel][2]float64{5: {3, 1.33}}},
+	}
+
+	for _, tt := range tests {
+		tracker := &Tracker{Name: "sleep", Kind: TrackerDecimal, Unit: "h"}
+		report := NewTrackerReport(tracker, start, end, points(tt.values, tt.moods))
+
+		if report.Samples != len(tt.values) || *report.Tracker.Name != "sleep" || !report.StartDate.Equal(start) || !report.EndDate.Equal(end) {
+			t.Errorf("%s: report = %+v", tt.name, report)
+		}
+
+		switch {
+		case tt.correlation == nil && report.Correlation != nil:
+			t.Errorf("%s: correlation = %v, want none", tt.name, *report.Correlation)
+		case tt.correlation != nil && (report.Correlation == nil || *report.Correlation != *tt.correlation):
+			t.Errorf("%s: correlation = %v, want %v", tt.name, report.Correlation, *tt.correlation)
+		}
+
+		if report.ByMood == nil || len(report.ByMood) != len(tt.byMood) {
+			t.Errorf("%s: by mood = %+v, want %v", tt.name, report.ByMood, tt.byMood)
+			continue
+		}
+
+		// Moods are listed from the worst to the best.
+		for i, stats := range report.ByMood {
+			want := tt.byMood[stats.MoodLabel]
+			if stats.Count != int(want[0]) || stats.AverageValue != want[1] || stats.Mood == nil {
+				t.Errorf("%s: stats for %d = %+v, want %v", tt.name, stats.MoodLabel, stats, want)
+			}
+			if i > 0 && stats.MoodLabel <= report.ByMood[i-1].MoodLabel {
+				t.Errorf("%s: by mood out of order: %+v", tt.name, report.ByMood)
+			}
+		}
+	}
+}
+
+func TestTrackerReportRendersValues(t *testing.T) {
+	tracker := &Tracker{Name: "meditated", Kind: TrackerBoolean}
+	report := NewTrackerReport(tracker, time.Time{}, time.Time{}, []*TrackerPoint{
+		{Raw: 1, MoodLabel: 4},
+		{Raw: 0, MoodLabel: 2},
+	})
+
+	if report.Points[0].Value != true || report.Points[1].Value != false {
+		t.Errorf("points = %v, %v, want true, false", report.Points[0].Value, report.Points[1].Value)
+	}
+	if report.Correlation == nil || *report.Correlation != 1 {
+		t.Errorf("correlation = %v, want 1", report.Correlation)
+	}
+}
@@ -0,0 +1,363 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// DaylogRevision is a day log as it was at Version. EditedBy and EditedAt
+// tell who wrote that version and when.
+type DaylogRevision struct {
+	LogID       uuid.UUID  `db:"log_id"`
+	Version     int        `db:"version"`
+	Date        time.Time  `db:"date"`
+	Description string     `db:"description"`
+	MoodLabel   MoodLabel  `db:"mood_label"`
+	LoggedAt    *time.Time `db:"logged_at"`
+	TimeZone    *string    `db:"time_zone"`
+	Tags        []string   `db:"tags"`
+	EditedBy    *uuid.UUID `db:"edited_by"`
+	EditedAt    time.Time  `db:"edited_at"`
+	// Trackers is nil for revisions saved before tracker values were kept.
+	Trackers RevisionTrackers `db:"trackers"`
+}
+
+// RevisionTrackers are the tracker values of a revision, saved as JSON along
+// with the tracker name and unit of that time.
+type RevisionTrackers []*TrackerValue
+
+func (t *RevisionTrackers) Scan(src any) error {
+	switch src := src.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(src, t)
+	default:
+		return fmt.Errorf("cannot scan %T into revision trackers", src)
+	}
+}
+
+type DaylogRevisionDTO struct {
+	Version     int        `json:"version"`
+	Date        time.Time  `json:"date"`
+	Description string     `json:"description"`
+	MoodLabel   string     `json:"mood_label"`
+	Mood        *MoodLevel `json:"mood,omitempty"`
+	LoggedAt    *time.Time `json:"logged_at,omitempty"`
+	TimeZone    *string    `json:"time_zone,omitempty"`
+	Tags        []string   `json:"tags"`
+	EditedBy    *uuid.UUID `json:"edited_by,omitempty"`
+	EditedAt    time.Time  `json:"edited_at"`
+	// Trackers is omitted for revisions saved before tracker values were kept.
+	Trackers []*TrackerValueDTO `json:"trackers,omitempty"`
+}
+
+// RevisionOf describes the current state of d as a revision, so it can be
+// compared with the stored ones.
+func RevisionOf(d *Daylog) *DaylogRevision {
+	rev := DaylogRevision{
+		LogID:       d.ID,
+		Version:     d.Version,
+		Date:        d.Date,
+		Description: d.Description,
+		MoodLabel:   d.MoodLabel,
+		LoggedAt:    d.LoggedAt,
+		TimeZone:    d.TimeZone,
+		Tags:        d.Tags,
+		EditedBy:    d.CreatedBy,
+		EditedAt:    d.BaseModel.LastModified(),
+		Trackers:    RevisionTrackers(d.Trackers),
+	}
+
+	if rev.Trackers == nil {
+		rev.Trackers = RevisionTrackers{}
+	}
+
+	if d.UpdatedBy != nil {
+		rev.EditedBy = d.UpdatedBy
+	}
+
+	return &rev
+}
+
+func (r DaylogRevision) ToDTO() *DaylogRevisionDTO {
+	dto := DaylogRevisionDTO{
+		Version:     r.Version,
+		Date:        r.Date,
+		Description: r.Description,
+		MoodLabel:   r.MoodLabel.String(),
+		LoggedAt:    r.LoggedAt,
+		TimeZone:    r.TimeZone,
+		Tags:        r.Tags,
+		EditedBy:    r.EditedBy,
+		EditedAt:    r.EditedAt,
+	}
+
+	if dto.Tags == nil {
+		dto.Tags = []string{}
+	}
+
+	for _, value := range r.Trackers {
+		dto.Trackers = append(dto.Trackers, value.ToDTO())
+	}
+
+	if level, ok := moodScale.Level(r.MoodLabel); ok {
+		dto.Mood = &level
+	}
+
+	return &dto
+}
+
+// ToDaylog returns the content of the revision as an update to log id. The
+// tracker values are sent as input again, so they are checked against the
+// trackers as they are now. A revision without tracker values leaves the
+// current ones untouched.
+func (r DaylogRevision) ToDaylog() *Daylog {
+	tags := r.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	var trackers []*TrackerValue
+	if r.Trackers != nil {
+		trackers = make([]*TrackerValue, 0, len(r.Trackers))
+		for _, value := range r.Trackers {
+			input, _ := json.Marshal(value.Kind.Render(value.Value))
+			trackers = append(trackers, &TrackerValue{TrackerID: value.TrackerID, Input: input})
+		}
+	}
+
+	return &Daylog{
+		ID:          r.LogID,
+		Date:        r.Date,
+		Description: r.Description,
+		MoodLabel:   r.MoodLabel,
+		LoggedAt:    r.LoggedAt,
+		TimeZone:    r.TimeZone,
+		Tags:        tags,
+		Trackers:    trackers,
+	}
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+type TextChange struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+const (
+	TextEqual  = "equal"
+	TextInsert = "insert"
+	TextDelete = "delete"
+)
+
+// RevisionDiff lists the fields that changed between two versions. The
+// description is also compared word by word.
+type RevisionDiff struct {
+	From        int           `json:"from"`
+	To          int           `json:"to"`
+	Changes     []FieldChange `json:"changes"`
+	Description []TextChange  `json:"description,omitempty"`
+}
+
+func DiffRevisions(from, to *DaylogRevision) *RevisionDiff {
+	diff := RevisionDiff{
+		From:    from.Version,
+		To:      to.Version,
+		Changes: []FieldChange{},
+	}
+
+	add := func(field string, a, b any) {
+		diff.Changes = append(diff.Changes, FieldChange{Field: field, From: a, To: b})
+	}
+
+	if !from.Date.Equal(to.Date) {
+		add("date", from.Date.Format(time.DateOnly), to.Date.Format(time.DateOnly))
+	}
+
+	if from.MoodLabel != to.MoodLabel {
+		add("mood_label", from.MoodLabel.String(), to.MoodLabel.String())
+	}
+
+	if !equalTime(from.LoggedAt, to.LoggedAt) {
+		add("logged_at", from.LoggedAt, to.LoggedAt)
+	}
+
+	if !equalString(from.TimeZone, to.TimeZone) {
+		add("time_zone", from.TimeZone, to.TimeZone)
+	}
+
+	fromTags, toTags := sortedTags(from.Tags), sortedTags(to.Tags)
+	if !slices.Equal(fromTags, toTags) {
+		add("tags", fromTags, toTags)
+	}
+
+	if from.Description != to.Description {
+		add("description", from.Description, to.Description)
+		diff.Description = diffWords(from.Description, to.Description)
+	}
+
+	// Revisions saved before tracker values were kept can't be compared.
+	if from.Trackers != nil && to.Trackers != nil {
+		for _, change := range diffTrackers(from.Trackers, to.Trackers) {
+			add(change.Field, change.From, change.To)
+		}
+	}
+
+	return &diff
+}
+
+// diffTrackers lists the tracker values that were set, changed or removed,
+// as "trackers.<name>" fields ordered by name. A missing value is nil.
+func diffTrackers(from, to RevisionTrackers) []FieldChange {
+	type pair struct {
+		name     string
+		from, to any
+	}
+
+	byID := make(map[uuid.UUID]*pair)
+	var pairs []*pair
+
+	get := func(value *TrackerValue) *pair {
+		p, ok := byID[value.TrackerID]
+		if !ok {
+			p = &pair{}
+			byID[value.TrackerID] = p
+			pairs = append(pairs, p)
+		}
+		// The name at the later version wins.
+		p.name = value.Name
+		return p
+	}
+
+	for _, value := range from {
+		get(value).from = value.Kind.Render(value.Value)
+	}
+	for _, value := range to {
+		get(value).to = value.Kind.Render(value.Value)
+	}
+
+	slices.SortFunc(pairs, func(a, b *pair) int { return strings.Compare(a.name, b.name) })
+
+	var changes []FieldChange
+	for _, p := range pairs {
+		if p.from != p.to {
+			changes = append(changes, FieldChange{Field: "trackers." + p.name, From: p.from, To: p.to})
+		}
+	}
+
+	return changes
+}
+
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func equalString(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sortedTags(tags []string) []string {
+	sorted := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		sorted = append(sorted, strings.ToLower(tag))
+	}
+	slices.Sort(sorted)
+	return sorted
+}
+
+// diffWords compares two texts word by word. Whitespace runs are tokens of
+// their own, so joining the Text of the equal and insert changes rebuilds b.
+func diffWords(a, b string) []TextChange {
+	x, y := splitWords(a), splitWords(b)
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:] and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var changes []TextChange
+	emit := func(op, text string) {
+		if n := len(changes); n > 0 && changes[n-1].Op == op {
+			changes[n-1].Text += text
+			return
+		}
+		changes = append(changes, TextChange{Op: op, Text: text})
+	}
+
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			emit(TextEqual, x[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			emit(TextDelete, x[i])
+			i++
+		default:
+			emit(TextInsert, y[j])
+			j++
+		}
+	}
+
+	for ; i < len(x); i++ {
+		emit(TextDelete, x[i])
+	}
+
+	for ; j < len(y); j++ {
+		emit(TextInsert, y[j])
+	}
+
+	return changes
+}
+
+func splitWords(s string) []string {
+	var words []string
+	start := 0
+	inSpace := false
+
+	for i, r := range s {
+		space := unicode.IsSpace(r)
+		if i > start && space != inSpace {
+			words = append(words, s[start:i])
+			start = i
+		}
+		inSpace = space
+	}
+
+	if start < len(s) {
+		words = append(words, s[start:])
+	}
+
+	return words
+}
@@ -0,0 +1,288 @@
+package models
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"run", []string{"run"}},
+		{"went for  a run", []string{"went", " ", "for", "  ", "a", " ", "run"}},
+		{" padded\n", []string{" ", "padded", "\n"}},
+		{"café\tà noite", []string{"café", "\t", "à", " ", "noite"}},
+	}
+
+	for _, tt := range tests {
+		if got := splitWords(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("splitWords(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestDiffWords(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want []TextChange
+	}{
+		{"same text", "same text", []TextChange{{TextEqual, "same text"}}},
+		{"", "hello", []TextChange{{TextInsert, "hello"}}},
+		{"hello", "", []TextChange{{TextDelete, "hello"}}},
+		{"", "", nil},
+		{"went for a run", "went for a long run", []TextChange{
+			{TextEqual, "went for a "}, {TextInsert, "long "}, {TextEqual, "run"},
+		}},
+		{"went for a long run", "went for a run", []TextChange{
+			{TextEqual, "went for a "}, {TextDelete, "long "}, {TextEqual, "run"},
+		}},
+		{"tired day", "good day", []TextChange{
+			{TextDelete, "tired"}, {TextInsert, "good"}, {TextEqual, " day"},
+		}},
+		{"slept well", "slept well, worked late", []TextChange{
+			{TextEqual, "slept "}, {TextDelete, "well"}, {TextInsert, "well, worked late"},
+		}},
+	}
+
+	for _, tt := range tests {
+		got := diffWords(tt.a, tt.b)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("diffWords(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+
+		// Dropping the inserts rebuilds a and dropping the deletes rebuilds b.
+		var a, b strings.Builder
+		for _, c := range got {
+			if c.Op != TextInsert {
+				a.WriteString(c.Text)
+			}
+			if c.Op != TextDelete {
+				b.WriteString(c.Text)
+			}
+		}
+		if a.String() != tt.a || b.String() != tt.b {
+			t.Errorf("diffWords(%q, %q) rebuilds %q and %q", tt.a, tt.b, a.String(), b.String())
+		}
+	}
+}
+
+func TestDiffRevisions(t *testing.T) {
+	loggedAt := time.Date(2026, 5, 1, 21, 15, 0, 0, time.UTC)
+	zone := "America/Sao_Paulo"
+
+	base := func() *DaylogRevision {
+		return &DaylogRevision{
+			Version:     2,
+			Date:        time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC),
+			Description: "went for a run",
+			MoodLabel:   4,
+			Tags:        []string{"Work", "gym"},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change func(r *DaylogRevision)
+		want   []FieldChange
+		words  []TextChange
+	}{
+		{"nothing changed", func(r *DaylogRevision) {}, []FieldChange{}, nil},
+		{"tags in another order and case", func(r *DaylogRevision) {
+			r.Tags = []string{"GYM", "work"}
+		}, []FieldChange{}, nil},
+		{"same date in another zone", func(r *DaylogRevision) {
+			r.Date = r.Date.In(time.FixedZone("BRT", -3*3600))
+		}, []FieldChange{}, nil},
+		{"date", func(r *DaylogRevision) {
+			r.Date = r.Date.AddDate(0, 0, 1)
+		}, []FieldChange{{"date", "2026-05-01", "2026-05-02"}}, nil},
+		{"mood", func(r *DaylogRevision) {
+			r.MoodLabel = 5
+		}, []FieldChange{{"mood_label", "BOM", "OTIMO"}}, nil},
+		{"became a check-in", func(r *DaylogRevision) {
+			r.LoggedAt, r.TimeZone = &loggedAt, &zone
+		}, []FieldChange{
+			{"logged_at", (*time.Time)(nil), &loggedAt},
+			{"time_zone", (*string)(nil), &zone},
+		}, nil},
+		{"tag added", func(r *DaylogRevision) {
+			r.Tags = append(r.Tags, "Sleep")
+		}, []FieldChange{{"tags", []string{"gym", "work"}, []string{"gym", "sleep", "work"}}}, nil},
+		{"tags removed", func(r *DaylogRevision) {
+			r.Tags = nil
+		}, []FieldChange{{"tags", []string{"gym", "work"}, []string{}}}, nil},
+		{"description", func(r *DaylogRevision) {
+			r.Description = "went for a long run"
+		}, []FieldChange{{"description", "went for a run", "went for a long run"}}, []TextChange{
+			{TextEqual, "went for a "}, {TextInsert, "long "}, {TextEqual, "run"},
+		}},
+	}
+
+	for _, tt := range tests {
+		to := base()
+		to.Version = 5
+		tt.change(to)
+
+		diff := DiffRevisions(base(), to)
+		if diff.From != 2 || diff.To != 5 {
+			t.Errorf("%s: diff from %d to %d, want 2 to 5", tt.name, diff.From, diff.To)
+		}
+		if !reflect.DeepEqual(diff.Changes, tt.want) {
+			t.Errorf("%s: changes = %v, want %v", tt.name, diff.Changes, tt.want)
+		}
+		if !reflect.DeepEqual(diff.Description, tt.words) {
+			t.Errorf("%s: description = %v, want %v", tt.name, diff.Description, tt.words)
+		}
+	}
+}
+
+func TestRevisionOf(t *testing.T) {
+	created := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+	author, editor := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name     string
+		base     BaseModel
+		editedBy uuid.UUID
+		editedAt time.Time
+	}{
+		{"created", BaseModel{Version: 1, CreatedAt: created, CreatedBy: &author}, author, created},
+		{"updated", BaseModel{Version: 2, CreatedAt: created, CreatedBy: &author, UpdatedAt: &updated, UpdatedBy: &editor}, editor, updated},
+	}
+
+	for _, tt := range tests {
+		d := &Daylog{BaseModel: tt.base, ID: uuid.New(), Description: "run", Tags: []string{"gym"}}
+
+		rev := RevisionOf(d)
+		if rev.LogID != d.ID || rev.Version != tt.base.Version || rev.Description != "run" || !slices.Equal(rev.Tags, d.Tags) {
+			t.Errorf("%s: revision = %+v", tt.name, rev)
+		}
+		if *rev.EditedBy != tt.editedBy || !rev.EditedAt.Equal(tt.editedAt) {
+			t.Errorf("%s: edited by %v at %v, want %v at %v", tt.name, *rev.EditedBy, rev.EditedAt, tt.editedBy, tt.editedAt)
+		}
+	}
+}
+
+func TestRevisionWithoutTags(t *testing.T) {
+	rev := DaylogRevision{LogID: uuid.New(), Version: 3, MoodLabel: 4}
+
+	// A revision with no tags restores and lists an empty list, not a missing
+	// one that would keep the current tags.
+	if d := rev.ToDaylog(); d.Tags == nil || len(d.Tags) != 0 || d.ID != rev.LogID || d.Version != 0 {
+		t.Errorf("ToDaylog = %+v", d)
+	}
+	if dto := rev.ToDTO(); dto.Tags == nil || dto.MoodLabel != "BOM" || dto.Mood == nil || dto.Mood.Value != 4 {
+		t.Errorf("ToDTO = %+v", dto)
+	}
+}
+
+func TestDiffRevisionTrackers(t *testing.T) {
+	sleep, ran, steps := uuid.New(), uuid.New(), uuid.New()
+
+	value := func(id uuid.UUID, name string, kind TrackerKind, v float64) *TrackerValue {
+		return &TrackerValue{TrackerID: id, Name: name, Kind: kind, Value: v}
+	}
+	base := RevisionTrackers{
+		value(sleep, "sleep", TrackerDecimal, 7.5),
+		value(ran, "ran", TrackerBoolean, 1),
+	}
+
+	tests := []struct {
+		name string
+		from RevisionTrackers
+		to   RevisionTrackers
+		want []FieldChange
+	}{
+		{"same values", base, base, []FieldChange{}},
+		{"not versioned before", nil, base, []FieldChange{}},
+		{"not versioned after", base, nil, []FieldChange{}},
+		{"value changed", base, RevisionTrackers{
+			value(sleep, "sleep", TrackerDecimal, 6),
+			value(ran, "ran", TrackerBoolean, 1),
+		}, []FieldChange{{"trackers.sleep", 7.5, 6.0}}},
+		{"value set and removed", base, RevisionTrackers{
+			value(sleep, "sleep", TrackerDecimal, 7.5),
+			value(steps, "steps", TrackerInteger, 9000),
+		}, []FieldChange{{"trackers.ran", true, nil}, {"trackers.steps", nil, int64(9000)}}},
+		{"all values removed", base, RevisionTrackers{}, []FieldChange{
+			{"trackers.ran", true, nil}, {"trackers.sleep", 7.5, nil},
+		}},
+		{"tracker renamed", base, RevisionTrackers{
+			value(sleep, "sleep time", TrackerDecimal, 8),
+			value(ran, "ran", TrackerBoolean, 1),
+		}, []FieldChange{{"trackers.sleep time", 7.5, 8.0}}},
+	}
+
+	for _, tt := range tests {
+		from := &DaylogRevision{Version: 1, Trackers: tt.from}
+		to := &DaylogRevision{Version: 2, Trackers: tt.to}
+
+		if got := DiffRevisions(from, to).Changes; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: changes = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRevisionTrackers(t *testing.T) {
+	sleep := uuid.New()
+	d := &Daylog{Trackers: []*TrackerValue{
+		{TrackerID: sleep, Name: "sleep", Kind: TrackerDuration, Unit: "min", Value: 90},
+	}}
+
+	// Revisions of logs without values record an empty list, so they can be
+	// told apart from revisions saved before values were kept.
+	if rev := RevisionOf(&Daylog{}); rev.Trackers == nil {
+		t.Error("RevisionOf a log without values has nil trackers")
+	}
+
+	rev := RevisionOf(d)
+	restored := rev.ToDaylog().Trackers
+	if len(restored) != 1 || restored[0].TrackerID != sleep || string(restored[0].Input) != "90" {
+		t.Errorf("ToDaylog trackers = %+v", restored)
+	}
+	if dto := rev.ToDTO(); len(dto.Trackers) != 1 || dto.Trackers[0].Name != "sleep" {
+		t.Errorf("ToDTO trackers = %+v", dto.Trackers)
+	}
+
+	if got := (DaylogRevision{}).ToDaylog().Trackers; got != nil {
+		t.Errorf("revision without tracker values restores %v, want nil to keep the current ones", got)
+	}
+}
+
+func TestRevisionTrackersScan(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   any
+		want  RevisionTrackers
+		valid bool
+	}{
+		{"not versioned", nil, nil, true},
+		{"no values", []byte(`[]`), RevisionTrackers{}, true},
+		{"values", []byte(`[{"tracker_id":"11111111-1111-1111-1111-111111111111","name":"sleep","kind":"decimal","unit":"h","value":7.5}]`),
+			RevisionTrackers{{TrackerID: uuid.MustParse("11111111-1111-1111-1111-111111111111"),
+				Name: "sleep", Kind: TrackerDecimal, Unit: "h", Value: 7.5}}, true},
+		{"not JSON", []byte(`{`), nil, false},
+		{"wrong type", 42, nil, false},
+	}
+
+	for _, tt := range tests {
+		var got RevisionTrackers
+		err := got.Scan(tt.src)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: err = %v, want valid %v", tt.name, err, tt.valid)
+			continue
+		}
+		if tt.valid && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: scanned %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
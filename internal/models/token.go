@@ -0,0 +1,66 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"moodtracker/utils/validator"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ScopePasswordReset = "password-reset"
+	ScopeRefresh       = "refresh"
+	ScopeEmailChange   = "email-change"
+	ScopeMFAPending    = "mfa-pending"
+)
+
+type Token struct {
+	Plaintext string    `db:"-"`
+	Hash      []byte    `db:"hash"`
+	UserID    uuid.UUID `db:"user_id"`
+	Expiry    time.Time `db:"expiry"`
+	Scope     string    `db:"scope"`
+}
+
+func GenerateToken(userID uuid.UUID, ttl time.Duration, scope string) *Token {
+	token := &Token{
+		Plaintext: rand.Text(),
+		UserID:    userID,
+		Expiry:    time.Now().Add(ttl),
+		Scope:     scope,
+	}
+
+	token.Hash = HashToken(token.Plaintext)
+	return token
+}
+
+func HashToken(plaintext string) []byte {
+	hash := sha256.Sum256([]byte(plaintext))
+	return hash[:]
+}
+
+func ValidateTokenPlaintext(v *validator.Validator, tokenPlaintext string) {
+	v.Check(tokenPlaintext != "", "token", "must be provided")
+	v.Check(len(tokenPlaintext) == 26, "token", "must be 26 bytes long")
+}
+
+type AccessClaims struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+type LoginResult struct {
+	Tokens   *AuthTokens
+	MFAToken *Token
+}
+
+type AuthTokens struct {
+	AccessToken        string
+	AccessTokenExpiry  time.Time
+	RefreshToken       string
+	RefreshTokenExpiry time.Time
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"bytes"
+	"moodtracker/utils/validator"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestGenerateToken(t *testing.T) {
+	userID := uuid.New()
+	before := time.Now()
+
+	token := GenerateToken(userID, time.Hour, ScopePasswordReset)
+
+	if len(token.Plaintext) != 26 {
+		t.Errorf("plaintext %q has %d characters, want 26", token.Plaintext, len(token.Plaintext))
+	}
+	if !bytes.Equal(token.Hash, HashToken(token.Plaintext)) {
+		t.Error("the hash is not the hash of the plaintext")
+	}
+	if token.UserID != userID || token.Scope != ScopePasswordReset {
+		t.Errorf("unexpected token %+v", token)
+	}
+	if token.Expiry.Before(before.Add(time.Hour)) || token.Expiry.After(time.Now().Add(time.Hour)) {
+		t.Errorf("expiry %v is not an hour from now", token.Expiry)
+	}
+
+	if GenerateToken(userID, time.Hour, ScopePasswordReset).Plaintext == token.Plaintext {
+		t.Error("GenerateToken returned the same plaintext twice")
+	}
+
+	v := validator.New()
+	if ValidateTokenPlaintext(v, token.Plaintext); !v.Valid() {
+		t.Errorf("generated token is not valid: %v", v.Errors)
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	hash := HashToken("Y3WJ6XQ2ZKRBNUX7ZM5TPHLQKA")
+
+	if len(hash) != 32 {
+		t.Errorf("hash has %d bytes, want 32", len(hash))
+	}
+	if !bytes.Equal(hash, HashToken("Y3WJ6XQ2ZKRBNUX7ZM5TPHLQKA")) {
+		t.Error("the same plaintext hashed differently")
+	}
+	if bytes.Equal(hash, HashToken("Y3WJ6XQ2ZKRBNUX7ZM5TPHLQKB")) {
+		t.Error("different plaintexts hashed the same")
+	}
+}
+
+func TestValidateTokenPlaintext(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"26 characters", strings.Repeat("A", 26), true},
+		{"empty", "", false},
+		{"too short", strings.Repeat("A", 25), false},
+		{"too long", strings.Repeat("A", 27), false},
+	}
+
+	for _, tt := range tests {
+		v := validator.New()
+		if ValidateTokenPlaintext(v, tt.token); v.Valid() != tt.valid {
+			t.Errorf("%s: valid = %v, want %v (%v)", tt.name, v.Valid(), tt.valid, v.Errors)
+		}
+	}
+}
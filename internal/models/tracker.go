@@ -0,0 +1,264 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"moodtracker/utils/validator"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TrackerKind string
+
+const (
+	TrackerInteger  TrackerKind = "integer"
+	TrackerDecimal  TrackerKind = "decimal"
+	TrackerBoolean  TrackerKind = "boolean"
+	TrackerDuration TrackerKind = "duration"
+)
+
+var TrackerKinds = []string{
+	string(TrackerInteger),
+	string(TrackerDecimal),
+	string(TrackerBoolean),
+	string(TrackerDuration),
+}
+
+// DurationUnit is the unit durations are stored and returned in.
+const DurationUnit = "min"
+
+// Tracker is a user defined measure recorded alongside the mood, such as hours
+// of sleep or minutes of exercise.
+type Tracker struct {
+	BaseModel
+	ID       uuid.UUID   `db:"id"`
+	Name     string      `db:"name"`
+	Kind     TrackerKind `db:"kind"`
+	Unit     string      `db:"unit"`
+	MinValue *float64    `db:"min_value"`
+	MaxValue *float64    `db:"max_value"`
+}
+
+type TrackerDTO struct {
+	ID       uuid.UUID `json:"id"`
+	Name     *string   `json:"name"`
+	Kind     *string   `json:"kind"`
+	Unit     *string   `json:"unit,omitempty"`
+	MinValue *float64  `json:"min_value,omitempty"`
+	MaxValue *float64  `json:"max_value,omitempty"`
+	Version  int       `json:"version,omitempty"`
+}
+
+func (t Tracker) ToDTO() *TrackerDTO {
+	kind := string(t.Kind)
+
+	dto := TrackerDTO{
+		ID:       t.ID,
+		Name:     &t.Name,
+		Kind:     &kind,
+		MinValue: t.MinValue,
+		MaxValue: t.MaxValue,
+		Version:  t.Version,
+	}
+
+	if t.Unit != "" {
+		dto.Unit = &t.Unit
+	}
+
+	return &dto
+}
+
+func (dto TrackerDTO) ToModel() *Tracker {
+	var model Tracker
+	model.ID = dto.ID
+	model.Version = dto.Version
+	model.MinValue = dto.MinValue
+	model.MaxValue = dto.MaxValue
+
+	if dto.Name != nil {
+		model.Name = strings.TrimSpace(*dto.Name)
+	}
+
+	if dto.Kind != nil {
+		model.Kind = TrackerKind(strings.ToLower(*dto.Kind))
+	}
+
+	if dto.Unit != nil {
+		model.Unit = strings.TrimSpace(*dto.Unit)
+	}
+
+	return &model
+}
+
+func (t *Tracker) ValidateTracker(v *validator.Validator) {
+	v.Check(t.Name != "", "name", "must be provided")
+	v.Check(len(t.Name) <= 50, "name", "must not be more than 50 bytes long")
+	v.Check(len(t.Unit) <= 20, "unit", "must not be more than 20 bytes long")
+	v.Check(validator.In(string(t.Kind), TrackerKinds...), "kind",
+		"must be one of "+strings.Join(TrackerKinds, ", "))
+
+	switch t.Kind {
+	case TrackerBoolean:
+		v.Check(t.Unit == "", "unit", "must be empty for boolean trackers")
+		v.Check(t.MinValue == nil && t.MaxValue == nil, "min_value", "must be empty for boolean trackers")
+	case TrackerInteger:
+		v.Check(t.MinValue == nil || isWhole(*t.MinValue), "min_value", "must be an integer")
+		v.Check(t.MaxValue == nil || isWhole(*t.MaxValue), "max_value", "must be an integer")
+	case TrackerDuration:
+		v.Check(t.Unit == "" || t.Unit == DurationUnit, "unit", "must be "+DurationUnit+" for duration trackers")
+		t.Unit = DurationUnit
+	}
+
+	if t.MinValue != nil && t.MaxValue != nil {
+		v.Check(*t.MinValue <= *t.MaxValue, "max_value", "must not be less than min_value")
+	}
+}
+
+func isWhole(f float64) bool {
+	return f == math.Trunc(f) && !math.IsInf(f, 0)
+}
+
+// TrackerValue is the value recorded for a tracker on a day log. Input keeps
+// the JSON sent by the client until the tracker kind is known and it can be
+// parsed into Value. The JSON form is the one kept in day log revisions.
+type TrackerValue struct {
+	LogID     uuid.UUID       `db:"log_id" json:"-"`
+	TrackerID uuid.UUID       `db:"tracker_id" json:"tracker_id"`
+	Name      string          `db:"name" json:"name"`
+	Kind      TrackerKind     `db:"kind" json:"kind"`
+	Unit      string          `db:"unit" json:"unit"`
+	Value     float64         `db:"value" json:"value"`
+	Input     json.RawMessage `db:"-" json:"-"`
+}
+
+type TrackerValueDTO struct {
+	TrackerID uuid.UUID       `json:"tracker_id"`
+	Name      string          `json:"name,omitempty"`
+	Kind      string          `json:"kind,omitempty"`
+	Unit      string          `json:"unit,omitempty"`
+	Value     json.RawMessage `json:"value"`
+}
+
+func (t TrackerValue) ToDTO() *TrackerValueDTO {
+	value, _ := json.Marshal(t.Kind.Render(t.Value))
+
+	return &TrackerValueDTO{
+		TrackerID: t.TrackerID,
+		Name:      t.Name,
+		Kind:      string(t.Kind),
+		Unit:      t.Unit,
+		Value:     value,
+	}
+}
+
+func (dto TrackerValueDTO) ToModel() *TrackerValue {
+	return &TrackerValue{
+		TrackerID: dto.TrackerID,
+		Input:     dto.Value,
+	}
+}
+
+// Render converts a stored value back to its JSON type.
+func (k TrackerKind) Render(value float64) any {
+	switch k {
+	case TrackerInteger:
+		return int64(value)
+	case TrackerBoolean:
+		return value != 0
+	default:
+		return value
+	}
+}
+
+// Parse reads a value sent by a client. Durations may be a number of minutes
+// or a string such as "1h30m".
+func (k TrackerKind) Parse(raw json.RawMessage) (float64, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return 0, fmt.Errorf("must be provided")
+	}
+
+	switch k {
+	case TrackerBoolean:
+		var b bool
+		if err := json.Unmarshal(raw, &b); err != nil {
+			return 0, fmt.Errorf("must be true or false")
+		}
+		if b {
+			return 1, nil
+		}
+		return 0, nil
+
+	case TrackerDuration:
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			d, err := time.ParseDuration(s)
+			if err != nil || d < 0 {
+				return 0, fmt.Errorf("must be a number of minutes or a duration such as 1h30m")
+			}
+			return d.Minutes(), nil
+		}
+	}
+
+	var f float64
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return 0, fmt.Errorf("must be a number")
+	}
+
+	if k == TrackerInteger && !isWhole(f) {
+		return 0, fmt.Errorf("must be an integer")
+	}
+
+	return f, nil
+}
+
+// ResolveTrackerValues parses values against the trackers they refer to,
+// filling the tracker details. Errors are keyed by tracker name, or by id for
+// unknown trackers.
+func ResolveTrackerValues(v *validator.Validator, values []*TrackerValue, trackers []*Tracker) {
+	byID := make(map[uuid.UUID]*Tracker, len(trackers))
+	for _, t := range trackers {
+		byID[t.ID] = t
+	}
+
+	seen := make(map[uuid.UUID]bool, len(values))
+
+	for _, value := range values {
+		tracker, ok := byID[value.TrackerID]
+		if !ok {
+			v.AddError("trackers."+value.TrackerID.String(), "tracker not found")
+			continue
+		}
+
+		key := "trackers." + tracker.Name
+
+		if seen[value.TrackerID] {
+			v.AddError(key, "must not be repeated")
+			continue
+		}
+		seen[value.TrackerID] = true
+
+		value.Name = tracker.Name
+		value.Kind = tracker.Kind
+		value.Unit = tracker.Unit
+
+		parsed, err := tracker.Kind.Parse(value.Input)
+		if err != nil {
+			v.AddError(key, err.Error())
+			continue
+		}
+		value.Value = parsed
+
+		if tracker.MinValue != nil {
+			v.Check(parsed >= *tracker.MinValue, key, fmt.Sprintf("must be at least %g", *tracker.MinValue))
+		}
+
+		if tracker.MaxValue != nil {
+			v.Check(parsed <= *tracker.MaxValue, key, fmt.Sprintf("must be at most %g", *tracker.MaxValue))
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package models
+
+import (
+	"encoding/json"
+	"math"
+	"moodtracker/utils/validator"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestValidateTracker(t *testing.T) {
+	value := func(f float64) *float64 { return &f }
+
+	tests := []struct {
+		name    string
+		tracker Tracker
+		fields  []string
+		unit    string
+	}{
+		{"integer", Tracker{Name: "steps", Kind: TrackerInteger, MinValue: value(0), MaxValue: value(100000)}, nil, ""},
+		{"decimal with unit", Tracker{Name: "sleep", Kind: TrackerDecimal, Unit: "h", MinValue: value(0.5)}, nil, "h"},
+		{"boolean", Tracker{Name: "meditated", Kind: TrackerBoolean}, nil, ""},
+		{"duration gets its unit", Tracker{Name: "exercise", Kind: TrackerDuration}, nil, DurationUnit},
+		{"duration in minutes", Tracker{Name: "exercise", Kind: TrackerDuration, Unit: DurationUnit}, nil, DurationUnit},
+		{"same min and max", Tracker{Name: "coffee", Kind: TrackerInteger, MinValue: value(2), MaxValue: value(2)}, nil, ""},
+		{"no name", Tracker{Kind: TrackerInteger}, []string{"name"}, ""},
+		{"long name", Tracker{Name: strings.Repeat("a", 51), Kind: TrackerInteger}, []string{"name"}, ""},
+		{"long unit", Tracker{Name: "sleep", Kind: TrackerDecimal, Unit: strings.Repeat("h", 21)}, []string{"unit"}, strings.Repeat("h", 21)},
+		{"unknown kind", Tracker{Name: "sleep", Kind: "text"}, []string{"kind"}, ""},
+		{"no kind", Tracker{Name: "sleep"}, []string{"kind"}, ""},
+		{"boolean with unit", Tracker{Name: "meditated", Kind: TrackerBoolean, Unit: "x"}, []string{"unit"}, "x"},
+		{"boolean with range", Tracker{Name: "meditated", Kind: TrackerBoolean, MaxValue: value(1)}, []string{"min_value"}, ""},
+		{"fractional integer bounds", Tracker{Name: "steps", Kind: TrackerInteger, MinValue: value(0.5), MaxValue: value(9.5)}, []string{"min_value", "max_value"}, ""},
+		{"duration in hours", Tracker{Name: "exercise", Kind: TrackerDuration, Unit: "h"}, []string{"unit"}, DurationUnit},
+		{"max below min", Tracker{Name: "sleep", Kind: TrackerDecimal, MinValue: value(8), MaxValue: value(4)}, []string{"max_value"}, ""},
+	}
+
+	for _, tt := range tests {
+		v := validator.New()
+		tracker := tt.tracker
+
+		tracker.ValidateTracker(v)
+
+		fields := make([]string, 0, len(v.Errors))
+		for field := range v.Errors {
+			fields = append(fields, field)
+		}
+		slices.Sort(fields)
+		want := slices.Sorted(slices.Values(tt.fields))
+
+		if !slices.Equal(fields, want) {
+			t.Errorf("%s: errors = %v, want errors for %q", tt.name, v.Errors, want)
+		}
+		if tracker.Unit != tt.unit {
+			t.Errorf("%s: unit = %q, want %q", tt.name, tracker.Unit, tt.unit)
+		}
+	}
+}
+
+func TestTrackerDTOToModel(t *testing.T) {
+	name, kind, unit := "  Sleep ", "DECIMAL", " h "
+
+	model := TrackerDTO{Name: &name, Kind: &kind, Unit: &unit, Version: 2}.ToModel()
+	if model.Name != "Sleep" || model.Kind != TrackerDecimal || model.Unit != "h" || model.Version != 2 {
+		t.Errorf("ToModel = %+v", model)
+	}
+
+	if dto := model.ToDTO(); *dto.Name != "Sleep" || *dto.Kind != "decimal" || *dto.Unit != "h" {
+		t.Errorf("ToDTO = %+v", dto)
+	}
+	if dto := (Tracker{Name: "meditated", Kind: TrackerBoolean}).ToDTO(); dto.Unit != nil {
+		t.Errorf("ToDTO without a unit = %+v", dto)
+	}
+}
+
+func TestTrackerKindParse(t *testing.T) {
+	tests := []struct {
+		kind  TrackerKind
+		raw   string
+		want  float64
+		valid bool
+	}{
+		{TrackerInteger, "12", 12, true},
+		{TrackerInteger, "12.0", 12, true},
+		{TrackerInteger, "-3", -3, true},
+		{TrackerInteger, "12.5", 0, false},
+		{TrackerInteger, `"12"`, 0, false},
+		{TrackerDecimal, "7.25", 7.25, true},
+		{TrackerDecimal, " 7.25 ", 7.25, true},
+		{TrackerDecimal, "true", 0, false},
+		{TrackerBoolean, "true", 1, true},
+		{TrackerBoolean, "false", 0, true},
+		{TrackerBoolean, "1", 0, false},
+		{TrackerBoolean, `"yes"`, 0, false},
+		{TrackerDuration, "45", 45, true},
+		{TrackerDuration, "12.5", 12.5, true},
+		{TrackerDuration, `"1h30m"`, 90, true},
+		{TrackerDuration, `"90s"`, 1.5, true},
+		{TrackerDuration, `"-10m"`, 0, false},
+		{TrackerDuration, `"an hour"`, 0, false},
+		{TrackerDecimal, "", 0, false},
+		{TrackerDecimal, "null", 0, false},
+		{TrackerBoolean, "null", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.kind.Parse(json.RawMessage(tt.raw))
+		if got != tt.want || (err == nil) != tt.valid {
+			t.Errorf("%s.Parse(%s) = (%v, %v), want (%v, valid %v)", tt.kind, tt.raw, got, err, tt.want, tt.valid)
+		}
+	}
+}
+
+func TestTrackerKindRender(t *testing.T) {
+	tests := []struct {
+		kind  TrackerKind
+		value float64
+		want  any
+		json  string
+	}{
+		{TrackerInteger, 12, int64(12), "12"},
+		{TrackerDecimal, 7.25, 7.25, "7.25"},
+		{TrackerDecimal, 8, 8.0, "8"},
+		{TrackerBoolean, 1, true, "true"},
+		{TrackerBoolean, 0, false, "false"},
+		{TrackerDuration, 90, 90.0, "90"},
+	}
+
+	for _, tt := range tests {
+		got := tt.kind.Render(tt.value)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s.Render(%v) = %#v, want %#v", tt.kind, tt.value, got, tt.want)
+		}
+
+		dto := TrackerValue{Kind: tt.kind, Value: tt.value}.ToDTO()
+		if string(dto.Value) != tt.json {
+			t.Errorf("%s value %v is sent as %s, want %s", tt.kind, tt.value, dto.Value, tt.json)
+		}
+	}
+}
+
+func TestResolveTrackerValues(t *testing.T) {
+	zero, eight := 0.0, 8.0
+	sleep := &Tracker{ID: uuid.New(), Name: "sleep", Kind: TrackerDecimal, Unit: "h", MinValue: &zero, MaxValue: &eight}
+	exercise := &Tracker{ID: uuid.New(), Name: "exercise", Kind: TrackerDuration, Unit: DurationUnit}
+	unknown := uuid.New()
+
+	value := func(id uuid.UUID, raw string) *TrackerValue {
+		return &TrackerValue{TrackerID: id, Input: json.RawMessage(raw)}
+	}
+
+	tests := []struct {
+		name   string
+		values []*TrackerValue
+		want   []float64
+		errors map[string]string
+	}{
+		{"valid values", []*TrackerValue{value(sleep.ID, "7.5"), value(exercise.ID, `"1h"`)}, []float64{7.5, 60}, nil},
+		{"at the bounds", []*TrackerValue{value(sleep.ID, "0")}, []float64{0}, nil},
+		{"below min", []*TrackerValue{value(sleep.ID, "-1")}, nil, map[string]string{"trackers.sleep": "must be at least 0"}},
+		{"above max", []*TrackerValue{value(sleep.ID, "8.5")}, nil, map[string]string{"trackers.sleep": "must be at most 8"}},
+		{"wrong type", []*TrackerValue{value(sleep.ID, `"long"`)}, nil, map[string]string{"trackers.sleep": "must be a number"}},
+		{"unknown tracker", []*TrackerValue{value(unknown, "1")}, nil, map[string]string{"trackers." + unknown.String(): "tracker not found"}},
+		{"repeated tracker", []*TrackerValue{value(sleep.ID, "7"), value(sleep.ID, "6")}, nil, map[string]string{"trackers.sleep": "must not be repeated"}},
+	}
+
+	for _, tt := range tests {
+		v := validator.New()
+
+		ResolveTrackerValues(v, tt.values, []*Tracker{sleep, exercise})
+
+		if len(tt.errors) > 0 || len(v.Errors) > 0 {
+			if !reflect.DeepEqual(v.Errors, tt.errors) {
+				t.Errorf("%s: errors = %v, want %v", tt.name, v.Errors, tt.errors)
+			}
+			continue
+		}
+
+		for i, value := range tt.values {
+			tracker := sleep
+			if value.TrackerID == exercise.ID {
+				tracker = exercise
+			}
+			if value.Value != tt.want[i] || value.Name != tracker.Name || value.Kind != tracker.Kind || value.Unit != tracker.Unit {
+				t.Errorf("%s: value %d = %+v, want %v of %s", tt.name, i, value, tt.want[i], tracker.Name)
+			}
+		}
+	}
+}
+
+func TestIsWhole(t *testing.T) {
+	tests := []struct {
+		f    float64
+		want bool
+	}{
+		{0, true},
+		{-4, true},
+		{1e15, true},
+		{0.5, false},
+		{math.Inf(1), false},
+		{math.NaN(), false},
+	}
+
+	for _, tt := range tests {
+		if got := isWhole(tt.f); got != tt.want {
+			t.Errorf("isWhole(%v) = %v, want %v", tt.f, got, tt.want)
+		}
+	}
+}
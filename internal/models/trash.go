@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TrashTypeDaylog = "day_log"
+	TrashTypeTag    = "tag"
+)
+
+// TrashItem is a soft-deleted day log or tag. Only the fields of its Type are
+// set.
+type TrashItem struct {
+	Type        string     `db:"type"`
+	ID          uuid.UUID  `db:"id"`
+	DeletedAt   time.Time  `db:"deleted_at"`
+	PurgeAt     *time.Time `db:"purge_at"`
+	Name        *string    `db:"name"`
+	Date        *time.Time `db:"date"`
+	Description *string    `db:"description"`
+	MoodLabel   *MoodLabel `db:"mood_label"`
+	LoggedAt    *time.Time `db:"logged_at"`
+	Tags        []string   `db:"tags"`
+}
+
+type TrashItemDTO struct {
+	Type      string     `json:"type"`
+	ID        uuid.UUID  `json:"id"`
+	DeletedAt time.Time  `json:"deleted_at"`
+	PurgeAt   *time.Time `json:"purge_at,omitempty"`
+	DayLog    *DaylogDTO `json:"day_log,omitempty"`
+	Tag       *TagDTO    `json:"tag,omitempty"`
+}
+
+func (t TrashItem) ToDTO() *TrashItemDTO {
+	dto := TrashItemDTO{
+		Type:      t.Type,
+		ID:        t.ID,
+		DeletedAt: t.DeletedAt,
+		PurgeAt:   t.PurgeAt,
+	}
+
+	switch t.Type {
+	case TrashTypeDaylog:
+		daylog := Daylog{ID: t.ID, LoggedAt: t.LoggedAt, Tags: t.Tags}
+		if t.Date != nil {
+			daylog.Date = *t.Date
+		}
+		if t.Description != nil {
+			daylog.Description = *t.Description
+		}
+		if t.MoodLabel != nil {
+			daylog.MoodLabel = *t.MoodLabel
+		}
+		dto.DayLog = daylog.ToDTO()
+
+	case TrashTypeTag:
+		tag := Tag{ID: t.ID}
+		if t.Name != nil {
+			tag.Name = *t.Name
+		}
+		dto.Tag = tag.ToDTO()
+	}
+
+	return &dto
+}
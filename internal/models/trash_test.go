@@ -0,0 +1,44 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestTrashItemToDTO(t *testing.T) {
+	id := uuid.New()
+	deleted := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	purge := deleted.AddDate(0, 0, 30)
+	date := time.Date(2026, 4, 30, 0, 0, 0, 0, time.UTC)
+	name, description := "work", "long day"
+	mood := MoodLabel(4)
+
+	daylog := TrashItem{
+		Type: TrashTypeDaylog, ID: id, DeletedAt: deleted, PurgeAt: &purge,
+		Date: &date, Description: &description, MoodLabel: &mood, Tags: []string{"work"},
+	}
+
+	dto := daylog.ToDTO()
+	if dto.Type != TrashTypeDaylog || dto.ID != id || !dto.DeletedAt.Equal(deleted) || dto.PurgeAt != &purge {
+		t.Errorf("day log item = %+v", dto)
+	}
+	if dto.Tag != nil || dto.DayLog == nil {
+		t.Fatalf("day log item has tag %v and day log %v", dto.Tag, dto.DayLog)
+	}
+	if got := dto.DayLog; got.ID != id || !got.Date.Equal(date) || *got.Description != description ||
+		string(*got.MoodLabel) != "BOM" || len(got.Tags) != 1 || *got.Tags[0] != "work" {
+		t.Errorf("day log = %+v", got)
+	}
+
+	tag := TrashItem{Type: TrashTypeTag, ID: id, DeletedAt: deleted, Name: &name}
+
+	dto = tag.ToDTO()
+	if dto.PurgeAt != nil || dto.DayLog != nil || dto.Tag == nil {
+		t.Fatalf("tag item = %+v", dto)
+	}
+	if dto.Tag.ID != id || *dto.Tag.Name != name || dto.Tag.User != nil {
+		t.Errorf("tag = %+v", dto.Tag)
+	}
+}
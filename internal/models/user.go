@@ -1,31 +1,70 @@
 package models
 
 import (
-	"errors"
+	"fmt"
+	"moodtracker/internal/hashing"
 	"moodtracker/utils/validator"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 var AnonymousUser = &User{}
 
+const DefaultLocale = "pt-BR"
+
+const DefaultTimeZone = "UTC"
+
+var SupportedLocales = []string{"pt-BR", "en"}
+
 type User struct {
-	ID        uuid.UUID `db:"id" dto:"ID"`
-	Name      string    `db:"name" dto:"Name"`
-	Email     string    `db:"email" dto:"Email"`
-	Phone     string    `db:"phone" dto:"Phone"`
-	Cod       int       `db:"cod"`
-	Password  password  `db:"-"`
-	Activated bool      `db:"activated"`
+	ID          uuid.UUID  `db:"id" dto:"ID"`
+	Name        string     `db:"name" dto:"Name"`
+	Email       string     `db:"email" dto:"Email"`
+	Phone       string     `db:"phone" dto:"Phone"`
+	Cod         int        `db:"cod"`
+	Password    password   `db:"-"`
+	Activated   bool       `db:"activated"`
+	Locale      string     `db:"locale"`
+	TimeZone    string     `db:"time_zone"`
+	CodIssuedAt *time.Time `db:"cod_issued_at"`
+	CodAttempts int        `db:"cod_attempts"`
+
+	PasswordChangedAt *time.Time `db:"password_changed_at"`
+	TokensRevokedAt   *time.Time `db:"tokens_revoked_at"`
+	PendingEmail      *string    `db:"pending_email"`
+
+	TOTPSecret      *string `db:"totp_secret"`
+	TOTPEnabled     bool    `db:"totp_enabled"`
+	TOTPLastCounter int64   `db:"totp_last_counter"`
 	BaseModel
 }
 
 type UserDTO struct {
-	ID    uuid.UUID `json:"user_id" dto:"ID"`
-	Name  string    `json:"name" dto:"Name"`
-	Email string    `json:"email" dto:"Email"`
-	Phone string    `json:"phone" dto:"Phone"`
+	ID       uuid.UUID `json:"user_id" dto:"ID"`
+	Name     string    `json:"name" dto:"Name"`
+	Email    string    `json:"email" dto:"Email"`
+	Phone    string    `json:"phone" dto:"Phone"`
+	Locale   string    `json:"locale,omitempty" dto:"Locale"`
+	TimeZone string    `json:"time_zone,omitempty" dto:"TimeZone"`
+	Version  int       `json:"version,omitempty" dto:"Version"`
+
+	PendingEmail *string `json:"pending_email,omitempty" dto:"PendingEmail"`
+	MFAEnabled   bool    `json:"mfa_enabled" dto:"TOTPEnabled"`
+}
+
+type UserAdminDTO struct {
+	ID        uuid.UUID  `json:"user_id"`
+	Name      string     `json:"name"`
+	Email     string     `json:"email"`
+	Phone     string     `json:"phone"`
+	Locale    string     `json:"locale"`
+	Activated bool       `json:"activated"`
+	Deleted   bool       `json:"deleted"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	Version   int        `json:"version"`
 }
 
 type UserSaveDTO struct {
@@ -33,40 +72,100 @@ type UserSaveDTO struct {
 	Email    string `json:"email"`
 	Phone    string `json:"phone"`
 	Password string `json:"password"`
+	Locale   string `json:"locale,omitempty"`
+	TimeZone string `json:"time_zone,omitempty"`
 }
 
 type password struct {
 	Plaintext *string
 	Hash      []byte `db:"password_hash"`
+	Algorithm string `db:"password_algorithm"`
 }
 
 func (u *User) IsAnonymous() bool {
 	return u == AnonymousUser
 }
 
+// TokenRevoked reports whether an access JWT issued at issuedAt was revoked.
+// It compares whole seconds, the precision of the iat claim. A token issued in
+// the same second as the revocation can't be told apart from one issued just
+// before it, so it is treated as revoked.
+func (u *User) TokenRevoked(issuedAt time.Time) bool {
+	if u.TokensRevokedAt == nil {
+		return false
+	}
+
+	return !issuedAt.Truncate(time.Second).After(u.TokensRevokedAt.Truncate(time.Second))
+}
+
+// AccessTokenRevoked reports whether a personal access token created at
+// createdAt was revoked. Its creation time is stored at full precision, so a
+// token created right after the revocation, even in the same second, stays
+// valid.
+func (u *User) AccessTokenRevoked(createdAt time.Time) bool {
+	if u.TokensRevokedAt == nil {
+		return false
+	}
+
+	return !createdAt.After(*u.TokensRevokedAt)
+}
+
 func (u *User) ToDTO() *UserDTO {
 	return &UserDTO{
-		ID:    u.ID,
-		Name:  u.Name,
-		Email: u.Email,
-		Phone: u.Phone,
+		ID:       u.ID,
+		Name:     u.Name,
+		Email:    u.Email,
+		Phone:    u.Phone,
+		Locale:   u.Locale,
+		TimeZone: u.TimeZone,
+		Version:  u.Version,
+
+		PendingEmail: u.PendingEmail,
+		MFAEnabled:   u.TOTPEnabled,
+	}
+}
+
+func (u *User) ToAdminDTO() *UserAdminDTO {
+	return &UserAdminDTO{
+		ID:        u.ID,
+		Name:      u.Name,
+		Email:     u.Email,
+		Phone:     u.Phone,
+		Locale:    u.Locale,
+		Activated: u.Activated,
+		Deleted:   u.Deleted,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+		Version:   u.Version,
 	}
 }
 
 func (u *UserDTO) ToModel() *User {
 	return &User{
-		ID:    u.ID,
-		Name:  u.Name,
-		Email: u.Email,
-		Phone: u.Phone,
+		ID:       u.ID,
+		Name:     u.Name,
+		Email:    u.Email,
+		Phone:    u.Phone,
+		Locale:   u.Locale,
+		TimeZone: u.TimeZone,
 	}
 }
 
 func (u *UserSaveDTO) ToModel() (*User, error) {
 	user := &User{
-		Name:  u.Name,
-		Email: u.Email,
-		Phone: u.Phone,
+		Name:     u.Name,
+		Email:    u.Email,
+		Phone:    u.Phone,
+		Locale:   u.Locale,
+		TimeZone: u.TimeZone,
+	}
+
+	if user.Locale == "" {
+		user.Locale = DefaultLocale
+	}
+
+	if user.TimeZone == "" {
+		user.TimeZone = DefaultTimeZone
 	}
 
 	err := user.Password.Set(u.Password)
@@ -78,27 +177,65 @@ func (u *UserSaveDTO) ToModel() (*User, error) {
 }
 
 func (p *password) Set(plaintextPassword string) error {
-	hash, err := bcrypt.GenerateFromPassword([]byte(plaintextPassword), 12)
+	algorithm := hashing.Default()
+
+	hasher, err := hashing.Get(algorithm)
+	if err != nil {
+		return err
+	}
+
+	hash, err := hasher.Hash(plaintextPassword)
 	if err != nil {
 		return err
 	}
 
 	p.Plaintext = &plaintextPassword
 	p.Hash = hash
+	p.Algorithm = algorithm
 	return nil
 }
 
 func (p *password) Matches(plaintextPassword string) (bool, error) {
-	err := bcrypt.CompareHashAndPassword(p.Hash, []byte(plaintextPassword))
+	hasher, err := hashing.Get(p.Algorithm)
+	if err != nil {
+		return false, err
+	}
+
+	return hasher.Verify(p.Hash, plaintextPassword)
+}
+
+// dummyPassword is hashed on first use, after hashing.Configure, so it costs
+// the same to check as the passwords being stored.
+var dummyPassword = sync.OnceValue(func() *password {
+	p := &password{}
+	if err := p.Set("moodtracker-dummy-password"); err != nil {
+		return nil
+	}
+	return p
+})
+
+// MatchDummyPassword checks plaintext against a fixed hash and discards the
+// result. A login for an email with no account calls it so that it takes as
+// long as a wrong password and does not reveal which emails are registered.
+func MatchDummyPassword(plaintext string) {
+	if p := dummyPassword(); p != nil {
+		p.Matches(plaintext)
+	}
+}
+
+// NeedsRehash reports whether the stored hash should be replaced by one using
+// the current default algorithm and parameters.
+func (p *password) NeedsRehash() bool {
+	if p.Algorithm != hashing.Default() {
+		return true
+	}
+
+	hasher, err := hashing.Get(p.Algorithm)
 	if err != nil {
-		switch {
-		case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
-			return false, nil
-		default:
-			return false, err
-		}
+		return true
 	}
-	return true, nil
+
+	return hasher.NeedsRehash(p.Hash)
 }
 
 func ValidateEmail(v *validator.Validator, email string) {
@@ -106,10 +243,24 @@ func ValidateEmail(v *validator.Validator, email string) {
 	v.Check(validator.Matches(email, validator.EmailRX), "email", "must be a valid email address")
 }
 
-func ValidatePasswordPlaintext(v *validator.Validator, password string) {
-	v.Check(password != "", "password", "must be provided")
-	v.Check(len(password) >= 8, "password", "must be at least 8 bytes long")
-	v.Check(len(password) <= 72, "password", "must not be more than 72 bytes long")
+func ValidateTimeZone(v *validator.Validator, key, timeZone string) {
+	if timeZone == "" {
+		v.AddError(key, "must be provided")
+		return
+	}
+
+	_, err := time.LoadLocation(timeZone)
+	v.Check(err == nil && timeZone != "Local", key, "must be a valid IANA time zone, such as America/Sao_Paulo")
+}
+
+func ValidatePasswordPlaintext(v *validator.Validator, key, password string) {
+	v.Check(password != "", key, "must be provided")
+	v.Check(len(password) >= 8, key, "must be at least 8 bytes long")
+
+	if hasher, err := hashing.Get(hashing.Default()); err == nil {
+		maxLength := hasher.MaxLength()
+		v.Check(len(password) <= maxLength, key, fmt.Sprintf("must not be more than %d bytes long", maxLength))
+	}
 }
 
 func (m *User) ValidateUser(v *validator.Validator) {
@@ -117,10 +268,13 @@ func (m *User) ValidateUser(v *validator.Validator) {
 	v.Check(len(m.Name) <= 500, "name", "must not be more than 500 bytes long")
 	v.Check(m.Phone != "", "phone", "must be provided")
 
+	v.Check(validator.In(m.Locale, SupportedLocales...), "locale", "must be one of pt-BR or en")
+	ValidateTimeZone(v, "time_zone", m.TimeZone)
+
 	ValidateEmail(v, m.Email)
 
 	if m.Password.Plaintext != nil {
-		ValidatePasswordPlaintext(v, *m.Password.Plaintext)
+		ValidatePasswordPlaintext(v, "password", *m.Password.Plaintext)
 	}
 
 	if m.Password.Hash == nil {
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"moodtracker/internal/hashing"
+	"moodtracker/utils/validator"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenRevoked(t *testing.T) {
+	revokedAt := time.Date(2026, 2, 10, 12, 0, 0, 700_000_000, time.UTC)
+
+	tests := []struct {
+		name      string
+		revokedAt *time.Time
+		issuedAt  time.Time
+		revoked   bool
+	}{
+		{"never revoked", nil, revokedAt.Add(-time.Hour), false},
+		{"issued a second before", &revokedAt, revokedAt.Add(-time.Second), true},
+		{"issued the same second, as a JWT iat", &revokedAt, revokedAt.Truncate(time.Second), true},
+		{"issued earlier the same second", &revokedAt, revokedAt.Add(-500 * time.Millisecond), true},
+		{"issued later the same second", &revokedAt, revokedAt.Add(200 * time.Millisecond), true},
+		{"issued the next second", &revokedAt, revokedAt.Truncate(time.Second).Add(time.Second), false},
+		{"issued an hour later", &revokedAt, revokedAt.Add(time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		u := &User{TokensRevokedAt: tt.revokedAt}
+		if got := u.TokenRevoked(tt.issuedAt); got != tt.revoked {
+			t.Errorf("%s: TokenRevoked(%v) = %v, want %v", tt.name, tt.issuedAt, got, tt.revoked)
+		}
+	}
+}
+
+func TestAccessTokenRevoked(t *testing.T) {
+	revokedAt := time.Date(2026, 2, 10, 12, 0, 0, 700_000_000, time.UTC)
+
+	tests := []struct {
+		name      string
+		revokedAt *time.Time
+		createdAt time.Time
+		revoked   bool
+	}{
+		{"never revoked", nil, revokedAt.Add(-time.Hour), false},
+		{"created a second before", &revokedAt, revokedAt.Add(-time.Second), true},
+		{"created earlier the same second", &revokedAt, revokedAt.Add(-500 * time.Millisecond), true},
+		{"created at the revocation", &revokedAt, revokedAt, true},
+		{"created later the same second", &revokedAt, revokedAt.Add(time.Millisecond), false},
+		{"created an hour later", &revokedAt, revokedAt.Add(time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		u := &User{TokensRevokedAt: tt.revokedAt}
+		if got := u.AccessTokenRevoked(tt.createdAt); got != tt.revoked {
+			t.Errorf("%s: AccessTokenRevoked(%v) = %v, want %v", tt.name, tt.createdAt, got, tt.revoked)
+		}
+	}
+}
+
+func TestMatchDummyPassword(t *testing.T) {
+	p := dummyPassword()
+	if p == nil {
+		t.Fatal("the dummy password could not be hashed")
+	}
+
+	if p.Algorithm != hashing.Default() || p.NeedsRehash() {
+		t.Errorf("dummy hash uses %q, want the current default %q", p.Algorithm, hashing.Default())
+	}
+
+	if dummyPassword() != p {
+		t.Error("the dummy password is hashed again on every call")
+	}
+}
+
+func TestValidateUser(t *testing.T) {
+	valid := func() User {
+		return User{
+			Name:     "Ana Souza",
+			Email:    "ana@example.com",
+			Phone:    "+55 11 91234-5678",
+			Locale:   "pt-BR",
+			TimeZone: "America/Sao_Paulo",
+			Password: password{Hash: []byte("hash")},
+		}
+	}
+
+	short := "short"
+
+	tests := []struct {
+		name   string
+		change func(u *User)
+		field  string
+	}{
+		{"valid", func(u *User) {}, ""},
+		{"english locale", func(u *User) { u.Locale = "en" }, ""},
+		{"UTC", func(u *User) { u.TimeZone = "UTC" }, ""},
+		{"missing name", func(u *User) { u.Name = "" }, "name"},
+		{"name too long", func(u *User) { u.Name = strings.Repeat("a", 501) }, "name"},
+		{"missing phone", func(u *User) { u.Phone = "" }, "phone"},
+		{"unsupported locale", func(u *User) { u.Locale = "fr" }, "locale"},
+		{"missing locale", func(u *User) { u.Locale = "" }, "locale"},
+		{"unknown time zone", func(u *User) { u.TimeZone = "Mars/Olympus_Mons" }, "time_zone"},
+		{"server local time zone", func(u *User) { u.TimeZone = "Local" }, "time_zone"},
+		{"missing time zone", func(u *User) { u.TimeZone = "" }, "time_zone"},
+		{"invalid email", func(u *User) { u.Email = "ana.example.com" }, "email"},
+		{"short new password", func(u *User) { u.Password.Plaintext = &short }, "password"},
+	}
+
+	for _, tt := range tests {
+		u := valid()
+		tt.change(&u)
+
+		v := validator.New()
+		u.ValidateUser(v)
+
+		if tt.field == "" {
+			if !v.Valid() {
+				t.Errorf("%s: unexpected errors %v", tt.name, v.Errors)
+			}
+			continue
+		}
+
+		if _, ok := v.Errors[tt.field]; !ok || len(v.Errors) != 1 {
+			t.Errorf("%s: errors = %v, want one for %q", tt.name, v.Errors, tt.field)
+		}
+	}
+}
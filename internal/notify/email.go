@@ -0,0 +1,22 @@
+package notify
+
+import (
+	"context"
+	"maps"
+	"moodtracker/internal/mailer"
+)
+
+type emailChannel struct {
+	mailer mailer.Mailer
+}
+
+func NewEmailChannel(m mailer.Mailer) *emailChannel {
+	return &emailChannel{mailer: m}
+}
+
+func (c *emailChannel) Send(ctx context.Context, msg Message) error {
+	data := map[string]any{"name": msg.Name}
+	maps.Copy(data, msg.Data)
+
+	return c.mailer.Send(msg.Email, msg.Locale, msg.Kind+".tmpl", data)
+}
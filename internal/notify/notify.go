@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"moodtracker/internal/config"
+	"moodtracker/internal/mailer"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Message is a notification for a single user. Kind names what is being sent
+// (and the email template); Target is the address for channels that need one,
+// such as the URL of a webhook.
+type Message struct {
+	Kind   string
+	UserID uuid.UUID
+	Name   string
+	Email  string
+	Locale string
+	Target string
+	Data   map[string]any
+}
+
+// Channel delivers messages to users. New channels only need to be added to
+// New to be offered to reminders.
+type Channel interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+type Channels map[string]Channel
+
+// New builds the channels enabled in the config.
+func New(cfg config.Config, m mailer.Mailer) (Channels, error) {
+	channels := make(Channels)
+
+	for _, name := range cfg.Reminders.Channels {
+		switch name {
+		case "email":
+			channels[name] = NewEmailChannel(m)
+		case "webhook":
+			channels[name] = NewWebhookChannel(10 * time.Second)
+		default:
+			return nil, fmt.Errorf("notify: unknown channel %q", name)
+		}
+	}
+
+	return channels, nil
+}
+
+func (c Channels) Names() []string {
+	return slices.Sorted(maps.Keys(c))
+}
+
+func (c Channels) Send(ctx context.Context, channel string, msg Message) error {
+	ch, ok := c[channel]
+	if !ok {
+		return fmt.Errorf("notify: channel %q is not enabled", channel)
+	}
+
+	return ch.Send(ctx, msg)
+}
@@ -0,0 +1,117 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"8.8.8.8", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"127.8.8.8", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"255.255.255.255", false},
+		{"224.0.0.1", false},
+		{"198.18.0.1", false},
+		{"::", false},
+		{"::1", false},
+		{"fe80::1", false},
+		{"fc00::1", false},
+		{"fd12:3456::1", false},
+		{"ff02::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+		{"2002:a00:1::", false},
+	}
+
+	for _, tt := range tests {
+		if got := isPublic(netip.MustParseAddr(tt.addr)); got != tt.public {
+			t.Errorf("isPublic(%s) = %v, want %v", tt.addr, got, tt.public)
+		}
+	}
+}
+
+func TestWebhookSend(t *testing.T) {
+	userID := uuid.New()
+
+	var payload map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected %s request with content type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	// The test server listens on loopback, which NewWebhookChannel refuses.
+	c := &webhookChannel{client: srv.Client()}
+
+	msg := Message{
+		Kind:   "daily_reminder",
+		UserID: userID,
+		Target: srv.URL + "/hook",
+		Data:   map[string]any{"date": "2026-02-10"},
+	}
+
+	if err := c.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if payload["type"] != "daily_reminder" || payload["user_id"] != userID.String() {
+		t.Errorf("unexpected payload %v", payload)
+	}
+	if data, _ := payload["data"].(map[string]any); data["date"] != "2026-02-10" {
+		t.Errorf("unexpected data %v", payload["data"])
+	}
+
+	msg.Target = srv.URL + "/fail"
+	if err := c.Send(context.Background(), msg); err == nil {
+		t.Error("Send returned nil for a 500 answer")
+	}
+}
+
+func TestWebhookRefusesInternalAddresses(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	c := NewWebhookChannel(time.Second)
+
+	for _, target := range []string{srv.URL, "http://localhost:1/hook", "http://[::1]:1/hook"} {
+		err := c.Send(context.Background(), Message{Kind: "daily_reminder", Target: target})
+		if !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("Send to %s returned %v, want ErrForbiddenAddress", target, err)
+		}
+	}
+
+	if called {
+		t.Error("the internal server was called")
+	}
+}
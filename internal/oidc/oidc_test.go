@@ -0,0 +1,461 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID    = "moodtracker"
+	testRedirectURL = "https://app.example.com/callback"
+)
+
+// fakeIdP is a minimal OpenID provider: it serves discovery and the key set,
+// remembers the PKCE challenge of each code it hands out and only redeems a
+// code for the matching verifier.
+type fakeIdP struct {
+	srv    *httptest.Server
+	issuer string
+
+	mu         sync.Mutex
+	keys       []jwk
+	challenges map[string]string
+	idToken    string
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	idp := &fakeIdP{challenges: map[string]string{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(discovery{
+			Issuer:                idp.issuer,
+			AuthorizationEndpoint: idp.srv.URL + "/authorize",
+			TokenEndpoint:         idp.srv.URL + "/token",
+			JWKSURI:               idp.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]any{"keys": idp.keys})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+
+		clientID, _, _ := r.BasicAuth()
+		challenge, ok := idp.challenges[r.PostFormValue("code")]
+		if !ok || clientID != testClientID || r.PostFormValue("grant_type") != "authorization_code" ||
+			CodeChallenge(r.PostFormValue("code_verifier")) != challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		delete(idp.challenges, r.PostFormValue("code"))
+
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idp.idToken})
+	})
+
+	idp.srv = httptest.NewServer(mux)
+	idp.issuer = idp.srv.URL
+	t.Cleanup(idp.srv.Close)
+
+	return idp
+}
+
+// authorize plays the user approving the request at authURL and returns the
+// code the provider redirects back with.
+func (idp *fakeIdP) authorize(t *testing.T, authURL string) string {
+	t.Helper()
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Fatalf("authorization URL has no S256 code challenge: %s", authURL)
+	}
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+
+	code := rand.Text()
+	idp.challenges[code] = q.Get("code_challenge")
+	return code
+}
+
+func (idp *fakeIdP) addRSAKey(t *testing.T, kid string) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.keys = append(idp.keys, jwk{
+		KeyType: "RSA",
+		KeyID:   kid,
+		N:       base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	})
+
+	return key
+}
+
+func (idp *fakeIdP) addECKey(t *testing.T, kid string) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x, y := make([]byte, 32), make([]byte, 32)
+	key.X.FillBytes(x)
+	key.Y.FillBytes(y)
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.keys = append(idp.keys, jwk{
+		KeyType: "EC",
+		KeyID:   kid,
+		Curve:   "P-256",
+		X:       base64.RawURLEncoding.EncodeToString(x),
+		Y:       base64.RawURLEncoding.EncodeToString(y),
+	})
+
+	return key
+}
+
+func (idp *fakeIdP) claims(nonce string) *Claims {
+	now := time.Now()
+
+	return &Claims{
+		Subject:       "248289761001",
+		Email:         "ana@example.com",
+		EmailVerified: true,
+		Nonce:         nonce,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    idp.issuer,
+			Audience:  jwt.ClaimStrings{testClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(5 * time.Minute)),
+		},
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, claims *Claims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed
+}
+
+func newTestProvider(issuer string) *Provider {
+	return New(Config{
+		Issuer:       issuer,
+		ClientID:     testClientID,
+		ClientSecret: "secret",
+		RedirectURL:  testRedirectURL,
+	}, nil)
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	idp := newFakeIdP(t)
+	p := newTestProvider(idp.issuer)
+
+	authURL, err := p.AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := u.Scheme + "://" + u.Host + u.Path; got != idp.srv.URL+"/authorize" {
+		t.Errorf("authorization endpoint = %s, want %s/authorize", got, idp.srv.URL)
+	}
+
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          testRedirectURL,
+		"scope":                 "openid email profile",
+		"state":                 "state",
+		"nonce":                 "nonce",
+		"code_challenge":        CodeChallenge("verifier"),
+		"code_challenge_method": "S256",
+	}
+	for name, value := range want {
+		if got := u.Query().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// Example from RFC 7636, appendix B.
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	want := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	if got := CodeChallenge(verifier); got != want {
+		t.Errorf("CodeChallenge = %s, want %s", got, want)
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	idp := newFakeIdP(t)
+	idp.issuer = "https://accounts.example.com"
+	p := newTestProvider(idp.srv.URL)
+
+	_, err := p.AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+	if !errors.Is(err, ErrDiscovery) {
+		t.Errorf("AuthCodeURL returned %v, want ErrDiscovery", err)
+	}
+}
+
+func TestExchange(t *testing.T) {
+	idp := newFakeIdP(t)
+	rsaKey := idp.addRSAKey(t, "rsa-1")
+	ecKey := idp.addECKey(t, "ec-1")
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		idToken func(claims *Claims) string
+		err     error
+	}{
+		{
+			name: "valid RS256 token",
+			idToken: func(c *Claims) string {
+				return sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, c)
+			},
+		},
+		{
+			name: "valid ES256 token",
+			idToken: func(c *Claims) string {
+				return sign(t, jwt.SigningMethodES256, "ec-1", ecKey, c)
+			},
+		},
+		{
+			name: "signed with another key",
+			idToken: func(c *Claims) string {
+				return sign(t, jwt.SigningMethodRS256, "rsa-1", otherKey, c)
+			},
+			err: ErrInvalidIDToken,
+		},
+		{
+			name: "unknown key id",
+			idToken: func(c *Claims) string {
+				return sign(t, jwt.SigningMethodRS256, "rsa-2", rsaKey, c)
+			},
+			err: ErrInvalidIDToken,
+		},
+		{
+			name: "symmetric algorithm",
+			idToken: func(c *Claims) string {
+				return sign(t, jwt.SigningMethodHS256, "rsa-1", []byte("secret"), c)
+			},
+			err: ErrInvalidIDToken,
+		},
+		{
+			name: "unsigned token",
+			idToken: func(c *Claims) string {
+				return sign(t, jwt.SigningMethodNone, "", jwt.UnsafeAllowNoneSignatureType, c)
+			},
+			err: ErrInvalidIDToken,
+		},
+		{
+			name: "nonce mismatch",
+			idToken: func(c *Claims) string {
+				c.Nonce = "replayed"
+				return sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, c)
+			},
+			err: ErrInvalidIDToken,
+		},
+		{
+			name: "other audience",
+			idToken: func(c *Claims) string {
+				c.Audience = jwt.ClaimStrings{"another-client"}
+				return sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, c)
+			},
+			err: ErrInvalidIDToken,
+		},
+		{
+			name: "other issuer",
+			idToken: func(c *Claims) string {
+				c.Issuer = "https://evil.example.com"
+				return sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, c)
+			},
+			err: ErrInvalidIDToken,
+		},
+		{
+			name: "expired",
+			idToken: func(c *Claims) string {
+				c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-2 * time.Minute))
+				return sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, c)
+			},
+			err: ErrInvalidIDToken,
+		},
+		{
+			name: "expired within the leeway",
+			idToken: func(c *Claims) string {
+				c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-30 * time.Second))
+				return sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, c)
+			},
+		},
+		{
+			name: "without expiry",
+			idToken: func(c *Claims) string {
+				c.ExpiresAt = nil
+				return sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, c)
+			},
+			err: ErrInvalidIDToken,
+		},
+		{
+			name: "without subject",
+			idToken: func(c *Claims) string {
+				c.Subject = ""
+				return sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, c)
+			},
+			err: ErrInvalidIDToken,
+		},
+	}
+
+	p := newTestProvider(idp.issuer)
+	ctx := context.Background()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := GenerateVerifier()
+			authURL, err := p.AuthCodeURL(ctx, "state", "nonce", verifier)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			code := idp.authorize(t, authURL)
+			idp.idToken = tt.idToken(idp.claims("nonce"))
+
+			claims, err := p.Exchange(ctx, code, verifier, "nonce")
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Exchange returned %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+
+			if claims.Subject != "248289761001" || claims.Email != "ana@example.com" || !claims.EmailVerified {
+				t.Errorf("unexpected claims %+v", claims)
+			}
+		})
+	}
+}
+
+func TestExchangePKCE(t *testing.T) {
+	idp := newFakeIdP(t)
+	key := idp.addRSAKey(t, "rsa-1")
+	p := newTestProvider(idp.issuer)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		code     func(code string) string
+		verifier func(verifier string) string
+		err      error
+	}{
+		{"matching verifier", nil, nil, nil},
+		{"other verifier", nil, func(string) string { return GenerateVerifier() }, ErrTokenExchange},
+		{"challenge sent as verifier", nil, CodeChallenge, ErrTokenExchange},
+		{"unknown code", func(string) string { return "forged" }, nil, ErrTokenExchange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := GenerateVerifier()
+			authURL, err := p.AuthCodeURL(ctx, "state", "nonce", verifier)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			code := idp.authorize(t, authURL)
+			idp.idToken = sign(t, jwt.SigningMethodRS256, "rsa-1", key, idp.claims("nonce"))
+
+			if tt.code != nil {
+				code = tt.code(code)
+			}
+			if tt.verifier != nil {
+				verifier = tt.verifier(verifier)
+			}
+
+			if _, err := p.Exchange(ctx, code, verifier, "nonce"); !errors.Is(err, tt.err) {
+				t.Errorf("Exchange returned %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestExchangeRefreshesRotatedKeys(t *testing.T) {
+	idp := newFakeIdP(t)
+	oldKey := idp.addRSAKey(t, "old")
+	p := newTestProvider(idp.issuer)
+	ctx := context.Background()
+
+	exchange := func(kid string, key *rsa.PrivateKey) error {
+		verifier := GenerateVerifier()
+		authURL, err := p.AuthCodeURL(ctx, "state", "nonce", verifier)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		code := idp.authorize(t, authURL)
+		idp.idToken = sign(t, jwt.SigningMethodRS256, kid, key, idp.claims("nonce"))
+
+		_, err = p.Exchange(ctx, code, verifier, "nonce")
+		return err
+	}
+
+	if err := exchange("old", oldKey); err != nil {
+		t.Fatalf("Exchange with the original key: %v", err)
+	}
+
+	newKey := idp.addRSAKey(t, "new")
+
+	if err := exchange("new", newKey); err != nil {
+		t.Fatalf("Exchange with a rotated key: %v", err)
+	}
+}
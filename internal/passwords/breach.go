@@ -0,0 +1,135 @@
+package passwords
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const prefixLength = 5
+
+var ErrInvalidCorpus = errors.New("invalid breach corpus")
+
+// Corpus answers k-anonymity range queries: given the first five hex digits
+// of a SHA-1 password hash it returns the suffixes of every breached hash
+// sharing that prefix, the same contract as the Pwned Passwords range API.
+type Corpus interface {
+	Range(prefix string) ([]string, error)
+}
+
+// OpenCorpus loads the breach corpus at path. A directory is expected to hold
+// one "<PREFIX>.txt" file per prefix with "SUFFIX:COUNT" lines, as written by
+// the Pwned Passwords downloader, and is read lazily. A regular file holds one
+// full SHA-1 hash per line (optionally followed by ":COUNT") and is loaded into
+// memory. An empty path disables the check.
+func OpenCorpus(path string) (Corpus, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return rangeDir(path), nil
+	}
+
+	return loadHashFile(path)
+}
+
+// Breached reports whether password appears in the corpus. Only the hash
+// prefix is used to select candidates, so the plaintext and its full hash never
+// leave this function.
+func Breached(c Corpus, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := c.Range(hash[:prefixLength])
+	if err != nil {
+		return false, err
+	}
+
+	_, found := slices.BinarySearch(suffixes, hash[prefixLength:])
+	return found, nil
+}
+
+type rangeDir string
+
+func (d rangeDir) Range(prefix string) ([]string, error) {
+	f, err := os.Open(filepath.Join(string(d), prefix+".txt"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var suffixes []string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		suffix, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if suffix != "" {
+			suffixes = append(suffixes, strings.ToUpper(suffix))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.Sort(suffixes)
+	return suffixes, nil
+}
+
+type hashIndex map[string][]string
+
+func (i hashIndex) Range(prefix string) ([]string, error) {
+	return i[prefix], nil
+}
+
+func loadHashFile(path string) (hashIndex, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	index := make(hashIndex)
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		hash, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if hash == "" {
+			continue
+		}
+
+		if len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("%w: line %d is not a SHA-1 hash", ErrInvalidCorpus, line)
+		}
+
+		hash = strings.ToUpper(hash)
+		prefix := hash[:prefixLength]
+		index[prefix] = append(index[prefix], hash[prefixLength:])
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, suffixes := range index {
+		slices.Sort(suffixes)
+	}
+
+	return index, nil
+}
@@ -0,0 +1,75 @@
+package passwords
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8.
+const passwordSuffix = "1E4C9B93F3F0682250B6CF8331B7EE68FD8"
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenCorpus(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "5BAA6.txt"),
+		"003D68EB55068C33ACE09247EE4C639306B:3\r\n"+passwordSuffix+":10434004\r\n")
+
+	lowercase := t.TempDir()
+	writeFile(t, filepath.Join(lowercase, "5BAA6.txt"), "1e4c9b93f3f0682250b6cf8331b7ee68fd8:1\n")
+
+	file := filepath.Join(t.TempDir(), "hashes.txt")
+	writeFile(t, file, "\n7C4A8D09CA3762AF61E59520943DC26494F8941B:24230577\n5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8\n")
+
+	tests := []struct {
+		name     string
+		path     string
+		password string
+		breached bool
+	}{
+		{"range directory", dir, "password", true},
+		{"range directory, other password", dir, "password1", false},
+		{"range directory, missing prefix file", dir, "123456", false},
+		{"range directory in lowercase", lowercase, "password", true},
+		{"hash file", file, "password", true},
+		{"hash file, hash with a count", file, "123456", true},
+		{"hash file, other password", file, "Password", false},
+	}
+
+	for _, tt := range tests {
+		corpus, err := OpenCorpus(tt.path)
+		if err != nil {
+			t.Fatalf("%s: OpenCorpus returned %v", tt.name, err)
+		}
+
+		breached, err := Breached(corpus, tt.password)
+		if err != nil || breached != tt.breached {
+			t.Errorf("%s: Breached(%q) = %v, %v, want %v", tt.name, tt.password, breached, err, tt.breached)
+		}
+	}
+}
+
+func TestOpenCorpusErrors(t *testing.T) {
+	if corpus, err := OpenCorpus(""); corpus != nil || err != nil {
+		t.Errorf("OpenCorpus(\"\") = %v, %v, want the check disabled", corpus, err)
+	}
+
+	if _, err := OpenCorpus(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("OpenCorpus of a missing path returned no error")
+	}
+
+	invalid := filepath.Join(t.TempDir(), "hashes.txt")
+	writeFile(t, invalid, "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8\nnot a hash\n")
+
+	if _, err := OpenCorpus(invalid); !errors.Is(err, ErrInvalidCorpus) {
+		t.Errorf("OpenCorpus of an invalid file returned %v, want ErrInvalidCorpus", err)
+	}
+}
@@ -0,0 +1,150 @@
+// Package passwords screens new passwords before they are hashed: it rejects
+// guessable passwords by estimated entropy, passwords built from the user's
+// own name or email, and passwords present in a local breach corpus.
+package passwords
+
+import (
+	"math"
+	"moodtracker/internal/config"
+	"moodtracker/utils/validator"
+	"strings"
+	"unicode"
+)
+
+const minPersonalTokenLength = 3
+
+type Policy struct {
+	MinEntropy float64
+	Breaches   Corpus
+}
+
+func NewPolicy(cfg config.Config) (*Policy, error) {
+	breaches, err := OpenCorpus(cfg.Password.BreachCorpus)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Policy{
+		MinEntropy: cfg.Password.MinEntropy,
+		Breaches:   breaches,
+	}, nil
+}
+
+// Validate adds a message under field when password is too weak. personal
+// holds values the password must not contain, such as the user's name and
+// email. The returned error is only set when the breach corpus can't be read.
+func (p *Policy) Validate(v *validator.Validator, field, password string, personal ...string) error {
+	lower := strings.ToLower(password)
+
+	for _, token := range personalTokens(personal) {
+		if strings.Contains(lower, token) {
+			v.AddError(field, "must not contain your name or email")
+			return nil
+		}
+	}
+
+	if Entropy(password) < p.MinEntropy {
+		v.AddError(field, "is too easy to guess, use a longer password mixing letters, numbers and symbols")
+		return nil
+	}
+
+	if p.Breaches == nil {
+		return nil
+	}
+
+	breached, err := Breached(p.Breaches, password)
+	if err != nil {
+		return err
+	}
+
+	v.Check(!breached, field, "has appeared in a data breach, please choose a different password")
+	return nil
+}
+
+// Entropy estimates the strength of password in bits from the character
+// classes it uses. Repeated characters and runs such as "aaa" or "1234" count
+// for much less than characters that can't be predicted from the previous one.
+func Entropy(password string) float64 {
+	var lower, upper, digit, symbol, other bool
+
+	for _, r := range password {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII && unicode.IsPrint(r):
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	pool := 0
+	for _, class := range []struct {
+		used bool
+		size int
+	}{
+		{lower, 26},
+		{upper, 26},
+		{digit, 10},
+		{symbol, 33},
+		{other, 100},
+	} {
+		if class.used {
+			pool += class.size
+		}
+	}
+
+	if pool == 0 {
+		return 0
+	}
+
+	perChar := math.Log2(float64(pool))
+	seen := make(map[rune]bool)
+
+	var bits float64
+	var prev rune
+
+	for i, r := range []rune(password) {
+		switch {
+		case i > 0 && (r == prev || r == prev+1 || r == prev-1):
+			bits++
+		case seen[r]:
+			bits += perChar / 2
+		default:
+			bits += perChar
+		}
+
+		seen[r] = true
+		prev = r
+	}
+
+	return bits
+}
+
+func personalTokens(values []string) []string {
+	var tokens []string
+
+	for _, value := range values {
+		value = strings.ToLower(value)
+
+		if local, _, ok := strings.Cut(value, "@"); ok {
+			value = local
+		}
+
+		parts := strings.FieldsFunc(value, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+
+		for _, part := range parts {
+			if len([]rune(part)) >= minPersonalTokenLength {
+				tokens = append(tokens, part)
+			}
+		}
+	}
+
+	return tokens
+}
@@ -0,0 +1,108 @@
+package passwords
+
+import (
+	"errors"
+	"math"
+	"moodtracker/utils/validator"
+	"slices"
+	"testing"
+)
+
+func TestEntropy(t *testing.T) {
+	lower := math.Log2(26)
+
+	tests := []struct {
+		password string
+		bits     float64
+	}{
+		{"", 0},
+		{"a", lower},
+		{"aaaaaaaa", lower + 7},
+		{"abcdefgh", lower + 7},
+		{"hgfedcba", lower + 7},
+		{"password", 7*lower + 1},
+		{"abab", lower + 3},
+		{"axax", 3 * lower},
+		{"1234", math.Log2(10) + 3},
+		{"Tr0ub4dor&3", 10.5 * math.Log2(95)},
+		{"héllo", 4*math.Log2(126) + 1},
+	}
+
+	for _, tt := range tests {
+		if got := Entropy(tt.password); math.Abs(got-tt.bits) > 1e-9 {
+			t.Errorf("Entropy(%q) = %.2f, want %.2f", tt.password, got, tt.bits)
+		}
+	}
+}
+
+func TestPersonalTokens(t *testing.T) {
+	tests := []struct {
+		values []string
+		want   []string
+	}{
+		{[]string{"Ana Souza", "ana.souza@example.com"}, []string{"ana", "souza", "ana", "souza"}},
+		{[]string{"José da Silva"}, []string{"josé", "silva"}},
+		{[]string{"Li", "li@example.com"}, nil},
+		{[]string{"mary-jane_01@example.com"}, []string{"mary", "jane"}},
+		{[]string{"user2024@example.com"}, []string{"user2024"}},
+		{nil, nil},
+	}
+
+	for _, tt := range tests {
+		if got := personalTokens(tt.values); !slices.Equal(got, tt.want) {
+			t.Errorf("personalTokens(%q) = %q, want %q", tt.values, got, tt.want)
+		}
+	}
+}
+
+type failingCorpus struct{}
+
+func (failingCorpus) Range(prefix string) ([]string, error) {
+	return nil, errors.New("corpus unavailable")
+}
+
+func TestPolicyValidate(t *testing.T) {
+	// The SHA-1 of "V3ry-Str0ng&Unusual!" is listed as breached.
+	breaches := hashIndex{"7FE87": {"0A489A0DB23A07CD4073BA9A54E0653B6D7"}}
+	if breached, _ := Breached(breaches, "V3ry-Str0ng&Unusual!"); !breached {
+		t.Fatal("the test corpus does not list the breached password")
+	}
+
+	personal := []string{"Ana Souza", "ana.souza@example.com"}
+
+	tests := []struct {
+		name     string
+		policy   *Policy
+		password string
+		valid    bool
+	}{
+		{"strong password", &Policy{MinEntropy: 50}, "correct-Horse-battery-9", true},
+		{"guessable password", &Policy{MinEntropy: 50}, "aaaaaaaaaaaa", false},
+		{"common password", &Policy{MinEntropy: 50}, "password1", false},
+		{"first name", &Policy{MinEntropy: 50}, "Ana-correct-Horse-9", false},
+		{"email local part", &Policy{MinEntropy: 50}, "correct-Horse-SOUZA-9", false},
+		{"entropy check disabled", &Policy{}, "aaaaaaaa", true},
+		{"breached password", &Policy{MinEntropy: 50, Breaches: breaches}, "V3ry-Str0ng&Unusual!", false},
+		{"password not in the breaches", &Policy{MinEntropy: 50, Breaches: breaches}, "correct-Horse-battery-9", true},
+	}
+
+	for _, tt := range tests {
+		v := validator.New()
+
+		if err := tt.policy.Validate(v, "password", tt.password, personal...); err != nil {
+			t.Fatalf("%s: Validate returned %v", tt.name, err)
+		}
+
+		if v.Valid() != tt.valid {
+			t.Errorf("%s: valid = %v, want %v (%v)", tt.name, v.Valid(), tt.valid, v.Errors)
+		}
+		if !tt.valid && v.Errors["password"] == "" {
+			t.Errorf("%s: errors = %v, want one for password", tt.name, v.Errors)
+		}
+	}
+
+	policy := &Policy{MinEntropy: 50, Breaches: failingCorpus{}}
+	if err := policy.Validate(validator.New(), "password", "correct-Horse-battery-9"); err == nil {
+		t.Error("Validate hid the corpus error")
+	}
+}
@@ -0,0 +1,191 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"moodtracker/internal/jsonlog"
+	"moodtracker/internal/models"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type accessTokenRepository struct {
+	db     *sql.DB
+	logger jsonlog.Logger
+}
+
+type AccessTokenRepository interface {
+	GetAllByUserID(userID uuid.UUID) ([]*models.PersonalAccessToken, error)
+	GetByHash(tokenHash []byte) (*models.PersonalAccessToken, error)
+	Insert(tx *sql.Tx, token *models.PersonalAccessToken) error
+	TouchLastUsed(tx *sql.Tx, id uuid.UUID) error
+	Delete(tx *sql.Tx, id, userID uuid.UUID) error
+}
+
+func NewAccessTokenRepository(
+	db *sql.DB,
+	logger jsonlog.Logger,
+) *accessTokenRepository {
+	return &accessTokenRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *accessTokenRepository) GetAllByUserID(userID uuid.UUID) ([]*models.PersonalAccessToken, error) {
+	cols := selectColumns(models.PersonalAccessToken{}, "pat")
+
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM personal_access_tokens pat
+	WHERE
+		pat.user_id = :userID
+	ORDER BY
+		pat.created_at DESC
+	`, cols)
+
+	params := map[string]any{
+		"userID": userID,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	return listQuery(
+		r.db,
+		query,
+		args,
+		func() *models.PersonalAccessToken {
+			return &models.PersonalAccessToken{}
+		},
+	)
+}
+
+func (r *accessTokenRepository) GetByHash(tokenHash []byte) (*models.PersonalAccessToken, error) {
+	cols := selectColumns(models.PersonalAccessToken{}, "pat")
+
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM personal_access_tokens pat
+	WHERE
+		pat.hash = :hash
+	`, cols)
+
+	params := map[string]any{
+		"hash": tokenHash,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	return getByQuery[models.PersonalAccessToken](r.db, query, args)
+}
+
+func (r *accessTokenRepository) Insert(tx *sql.Tx, token *models.PersonalAccessToken) error {
+	query := `
+	INSERT INTO personal_access_tokens (
+		user_id,
+		name,
+		hash,
+		scopes,
+		expires_at
+	)
+	VALUES (
+		:userID,
+		:name,
+		:hash,
+		:scopes,
+		:expiresAt
+	)
+	RETURNING
+		id,
+		created_at
+	`
+
+	params := map[string]any{
+		"userID":    token.UserID,
+		"name":      token.Name,
+		"hash":      token.Hash,
+		"scopes":    pq.Array(token.Scopes),
+		"expiresAt": token.ExpiresAt,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return tx.QueryRowContext(ctx, query, args...).Scan(
+		&token.ID,
+		&token.CreatedAt,
+	)
+}
+
+// TouchLastUsed records the token usage at most once per minute so that
+// busy scripts do not turn every request into a write.
+func (r *accessTokenRepository) TouchLastUsed(tx *sql.Tx, id uuid.UUID) error {
+	query := `
+	UPDATE personal_access_tokens SET
+		last_used_at = NOW()
+	WHERE
+		id = :id
+		AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+	`
+
+	params := map[string]any{
+		"id": id,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *accessTokenRepository) Delete(tx *sql.Tx, id, userID uuid.UUID) error {
+	query := `
+	DELETE FROM personal_access_tokens
+	WHERE
+		id = :id
+		AND user_id = :userID
+	`
+
+	params := map[string]any{
+		"id":     id,
+		"userID": userID,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return e.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,257 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"moodtracker/internal/jsonlog"
+	"moodtracker/internal/models"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type attachmentRepository struct {
+	db     *sql.DB
+	logger jsonlog.Logger
+}
+
+type AttachmentRepository interface {
+	GetAllByLogID(logID, userID uuid.UUID) ([]*models.Attachment, error)
+	FindByID(id, logID, userID uuid.UUID) (*models.Attachment, error)
+	FindForDownload(id uuid.UUID) (*models.Attachment, error)
+	Insert(tx *sql.Tx, model *models.Attachment) error
+	Delete(tx *sql.Tx, id uuid.UUID) error
+	GetPurgeable(before time.Time, limit int) ([]*models.Attachment, error)
+	DeleteByIDs(ids []uuid.UUID) (int64, error)
+}
+
+func NewAttachmentRepository(
+	db *sql.DB,
+	logger jsonlog.Logger,
+) *attachmentRepository {
+	return &attachmentRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *attachmentRepository) GetAllByLogID(logID, userID uuid.UUID) ([]*models.Attachment, error) {
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM attachments a
+	JOIN day_logs dl ON dl.id = a.log_id
+	WHERE
+		a.log_id = :logID
+		AND dl.user_id = :userID
+		AND dl.deleted = false
+	ORDER BY
+		a.created_at,
+		a.id
+	`, selectColumns(models.Attachment{}, "a"))
+
+	params := map[string]any{
+		"logID":  logID,
+		"userID": userID,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	return listQuery(
+		r.db,
+		query,
+		args,
+		func() *models.Attachment {
+			return &models.Attachment{}
+		},
+	)
+}
+
+func (r *attachmentRepository) FindByID(id, logID, userID uuid.UUID) (*models.Attachment, error) {
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM attachments a
+	JOIN day_logs dl ON dl.id = a.log_id
+	WHERE
+		a.id = :id
+		AND a.log_id = :logID
+		AND dl.user_id = :userID
+		AND dl.deleted = false
+	`, selectColumns(models.Attachment{}, "a"))
+
+	params := map[string]any{
+		"id":     id,
+		"logID":  logID,
+		"userID": userID,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	return getByQuery[models.Attachment](r.db, query, args)
+}
+
+// FindForDownload looks an attachment up by id alone; the caller has already
+// checked the signed URL. Files of logs in the trash are not served.
+func (r *attachmentRepository) FindForDownload(id uuid.UUID) (*models.Attachment, error) {
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM attachments a
+	JOIN day_logs dl ON dl.id = a.log_id
+	WHERE
+		a.id = :id
+		AND dl.deleted = false
+	`, selectColumns(models.Attachment{}, "a"))
+
+	params := map[string]any{
+		"id": id,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	return getByQuery[models.Attachment](r.db, query, args)
+}
+
+func (r *attachmentRepository) Insert(tx *sql.Tx, model *models.Attachment) error {
+	query := `
+	INSERT INTO attachments (
+		id,
+		log_id,
+		user_id,
+		storage_key,
+		file_name,
+		content_type,
+		size,
+		checksum
+	)
+	VALUES (
+		:id,
+		:logID,
+		:userID,
+		:storageKey,
+		:fileName,
+		:contentType,
+		:size,
+		:checksum
+	)
+	RETURNING created_at
+	`
+
+	params := map[string]any{
+		"id":          model.ID,
+		"logID":       model.LogID,
+		"userID":      model.UserID,
+		"storageKey":  model.StorageKey,
+		"fileName":    model.FileName,
+		"contentType": model.ContentType,
+		"size":        model.Size,
+		"checksum":    model.Checksum,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return tx.QueryRowContext(ctx, query, args...).Scan(&model.CreatedAt)
+}
+
+func (r *attachmentRepository) Delete(tx *sql.Tx, id uuid.UUID) error {
+	query := `
+	DELETE FROM attachments
+	WHERE id = :id
+	`
+
+	params := map[string]any{
+		"id": id,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return e.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetPurgeable returns up to limit attachments of logs that were moved to the
+// trash before the given time, so their blobs can be removed ahead of the
+// logs themselves.
+func (r *attachmentRepository) GetPurgeable(before time.Time, limit int) ([]*models.Attachment, error) {
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM attachments a
+	JOIN day_logs dl ON dl.id = a.log_id
+	WHERE
+		dl.deleted = true
+		AND dl.deleted_at < :before
+	ORDER BY a.id
+	LIMIT :limit
+	`, selectColumns(models.Attachment{}, "a"))
+
+	params := map[string]any{
+		"before": before,
+		"limit":  limit,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	return listQuery(
+		r.db,
+		query,
+		args,
+		func() *models.Attachment {
+			return &models.Attachment{}
+		},
+	)
+}
+
+func (r *attachmentRepository) DeleteByIDs(ids []uuid.UUID) (int64, error) {
+	query := `
+	DELETE FROM attachments
+	WHERE id = ANY(:ids::uuid[])
+	`
+
+	params := map[string]any{
+		"ids": pq.Array(uuidStrings(ids)),
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,232 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"moodtracker/internal/jsonlog"
+	"moodtracker/internal/models"
+	"moodtracker/internal/models/filters"
+	"moodtracker/utils"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type authEventRepository struct {
+	db     *sql.DB
+	logger jsonlog.Logger
+}
+
+type AuthEventRepository interface {
+	GetAllByUserID(userID uuid.UUID, f filters.Filters) ([]*models.AuthEvent, filters.Metadata, error)
+	Insert(tx *sql.Tx, event *models.AuthEvent) error
+	GetLoginAttempt(email string) (*models.LoginAttempt, error)
+	RecordLoginFailure(tx *sql.Tx, email string, now time.Time, window time.Duration) (int, error)
+	LockLogin(tx *sql.Tx, email string, until time.Time) error
+	ClearLoginFailures(tx *sql.Tx, email string) error
+}
+
+func NewAuthEventRepository(
+	db *sql.DB,
+	logger jsonlog.Logger,
+) *authEventRepository {
+	return &authEventRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *authEventRepository) GetAllByUserID(
+	userID uuid.UUID,
+	f filters.Filters,
+) ([]*models.AuthEvent, filters.Metadata, error) {
+	cols := selectColumns(models.AuthEvent{}, "ae")
+
+	query := fmt.Sprintf(`
+	SELECT
+		count(*) OVER(),
+		%s
+	FROM auth_events ae
+	WHERE
+		ae.user_id = :userID
+	ORDER BY
+		ae.created_at DESC
+	LIMIT :limit
+	OFFSET :offset
+	`, cols)
+
+	params := map[string]any{
+		"userID": userID,
+		"limit":  f.Limit(),
+		"offset": f.Offset(),
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	return paginatedQuery(
+		r.db,
+		query,
+		args,
+		f,
+		func() *models.AuthEvent {
+			return &models.AuthEvent{}
+		},
+	)
+}
+
+func (r *authEventRepository) Insert(tx *sql.Tx, event *models.AuthEvent) error {
+	query := `
+	INSERT INTO auth_events (
+		user_id,
+		email,
+		method,
+		success,
+		reason,
+		ip,
+		user_agent
+	)
+	VALUES (
+		:userID,
+		:email,
+		:method,
+		:success,
+		:reason,
+		:ip,
+		:userAgent
+	)
+	RETURNING
+		id,
+		created_at
+	`
+
+	params := map[string]any{
+		"userID":    event.UserID,
+		"email":     event.Email,
+		"method":    event.Method,
+		"success":   event.Success,
+		"reason":    event.Reason,
+		"ip":        event.IP,
+		"userAgent": event.UserAgent,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return tx.QueryRowContext(ctx, query, args...).Scan(
+		&event.ID,
+		&event.CreatedAt,
+	)
+}
+
+func (r *authEventRepository) GetLoginAttempt(email string) (*models.LoginAttempt, error) {
+	cols := selectColumns(models.LoginAttempt{}, "la")
+
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM login_attempts la
+	WHERE
+		la.email = :email
+	`, cols)
+
+	params := map[string]any{
+		"email": email,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	return getByQuery[models.LoginAttempt](r.db, query, args)
+}
+
+// RecordLoginFailure increments the failure counter for the email and returns
+// the new value. Failures older than window no longer count.
+func (r *authEventRepository) RecordLoginFailure(
+	tx *sql.Tx,
+	email string,
+	now time.Time,
+	window time.Duration,
+) (int, error) {
+	query := `
+	INSERT INTO login_attempts (
+		email,
+		failures,
+		last_failure_at
+	)
+	VALUES (
+		:email,
+		1,
+		:now
+	)
+	ON CONFLICT (email) DO UPDATE SET
+		failures = CASE
+			WHEN login_attempts.last_failure_at < :windowStart THEN 1
+			ELSE login_attempts.failures + 1
+		END,
+		last_failure_at = excluded.last_failure_at
+	RETURNING failures
+	`
+
+	params := map[string]any{
+		"email":       email,
+		"now":         now,
+		"windowStart": now.Add(-window),
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var failures int
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&failures)
+	return failures, err
+}
+
+func (r *authEventRepository) LockLogin(tx *sql.Tx, email string, until time.Time) error {
+	query := `
+	UPDATE login_attempts SET
+		locked_until = :until
+	WHERE email = :email
+	`
+
+	params := map[string]any{
+		"email": email,
+		"until": until,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *authEventRepository) ClearLoginFailures(tx *sql.Tx, email string) error {
+	query := `
+	DELETE FROM login_attempts
+	WHERE email = :email
+	`
+
+	params := map[string]any{
+		"email": email,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type daylogRepository struct {
@@ -23,9 +24,10 @@ type daylogRepository struct {
 
 type DaylogRepository interface {
 	GetAll(
-		description, tag string,
-		modelLabel *models.MoodLabel,
-		date *time.Time,
+		description string,
+		tags []string,
+		moodLabel *models.MoodLabel,
+		startDate, endDate *time.Time,
 		userID uuid.UUID,
 		f filters.Filters,
 	) ([]*models.Daylog, filters.Metadata, error)
@@ -34,11 +36,18 @@ type DaylogRepository interface {
 		userID uuid.UUID,
 	) ([]*models.Daylog, error)
 	GetByID(id, userID uuid.UUID) (*models.Daylog, error)
-	InsertLogsTags(tx *sql.Tx, daylogID, tagID uuid.UUID) error
+	GetDailySummaries(startDate, endDate time.Time, userID uuid.UUID) ([]*models.DailySummary, error)
+	SyncLogTags(tx *sql.Tx, daylogID uuid.UUID, tagIDs []uuid.UUID) error
+	InsertCheckIn(tx *sql.Tx, model *models.Daylog, userID uuid.UUID) error
 	InsertOrUpdate(tx *sql.Tx, model *models.Daylog, userID uuid.UUID) error
 	Update(tx *sql.Tx, model *models.Daylog, userID uuid.UUID) error
 	Delete(tx *sql.Tx, id uuid.UUID, userID uuid.UUID) error
-	DeleteLogTagByDaylogID(tx *sql.Tx, daylogID uuid.UUID) error
+	Restore(tx *sql.Tx, id uuid.UUID, userID uuid.UUID) error
+	InsertRevision(tx *sql.Tx, id uuid.UUID, version int, userID uuid.UUID) error
+	InsertDailyRevision(tx *sql.Tx, date time.Time, userID uuid.UUID) error
+	GetRevisions(id, userID uuid.UUID, f filters.Filters) ([]*models.DaylogRevision, filters.Metadata, error)
+	GetRevision(id uuid.UUID, version int, userID uuid.UUID) (*models.DaylogRevision, error)
+	RestoreLogTags(tx *sql.Tx, daylogID uuid.UUID, userID uuid.UUID) error
 }
 
 func NewDaylogRepository(
@@ -54,7 +63,7 @@ func NewDaylogRepository(
 /*
 create unique index uniq_day_logs_user_date
 on day_logs (user_id, date)
-where deleted = false;
+where deleted = false and logged_at is null;
 
 create unique index uniq_tags_name_user_not_deleted
 on tags (lower(name), user_id)
@@ -62,6 +71,15 @@ where deleted = false;
 
 */
 
+func parseDaylogConstraintError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Constraint == "uniq_day_logs_user_date" {
+		return fmt.Errorf("date -> a day log for this date already exists")
+	}
+
+	return err
+}
+
 func (r *daylogRepository) GetAllByYear(
 	year int,
 	userID uuid.UUID,
@@ -76,12 +94,12 @@ func (r *daylogRepository) GetAllByYear(
 	query := fmt.Sprintf(`
         SELECT
            	%s,
-			ARRAY_AGG(t.name) as tags
+			ARRAY_REMOVE(ARRAY_AGG(t.name), NULL) as tags
         FROM day_logs dl
 			LEFT JOIN users u 
 		on dl.user_id = u.id
 		LEFT JOIN log_tags lt ON dl.id = lt.log_id
-		LEFT JOIN tags t ON lt.tag_id = t.id
+		LEFT JOIN tags t ON lt.tag_id = t.id AND t.deleted = false
         WHERE
     		dl.date >= make_date(:yearStart, 1, 1)
     		AND dl.date < make_date(:yearEnd, 1, 1)
@@ -113,9 +131,10 @@ func (r *daylogRepository) GetAllByYear(
 }
 
 func (r *daylogRepository) GetAll(
-	description, tag string,
-	modelLabel *models.MoodLabel,
-	date *time.Time,
+	description string,
+	tags []string,
+	moodLabel *models.MoodLabel,
+	startDate, endDate *time.Time,
 	userID uuid.UUID,
 	f filters.Filters,
 ) ([]*models.Daylog, filters.Metadata, error) {
@@ -130,41 +149,55 @@ func (r *daylogRepository) GetAll(
         SELECT
             count(*) OVER(),
            	%s,
-			ARRAY_AGG(t.name) as tags
+			ARRAY_REMOVE(ARRAY_AGG(t.name), NULL) as tags
         FROM day_logs dl
 		LEFT JOIN users u 
 		on dl.user_id = u.id
 		LEFT JOIN log_tags lt ON dl.id = lt.log_id
-		LEFT JOIN tags t ON lt.tag_id = t.id
+		LEFT JOIN tags t ON lt.tag_id = t.id AND t.deleted = false
         WHERE
             (to_tsvector('simple', dl.description) @@ plainto_tsquery('simple', :description) OR :description = '')
-			and (:moodLabel is NULL or dl.mood_label = :modelLabel)
-			AND (:date::timestamptz IS NULL OR dl.date >= :date::timestamptz)
+			AND (:moodLabel::smallint IS NULL OR dl.mood_label = :moodLabel::smallint)
+			AND (:startDate::date IS NULL OR dl.date >= :startDate::date)
+			AND (:endDate::date IS NULL OR dl.date <= :endDate::date)
+			AND (
+				cardinality(:tags::text[]) = 0
+				OR EXISTS (
+					SELECT 1
+					FROM log_tags flt
+					JOIN tags ft ON ft.id = flt.tag_id
+					WHERE
+						flt.log_id = dl.id
+						AND ft.deleted = false
+						AND lower(ft.name) = ANY(:tags::text[])
+				)
+			)
             AND dl.deleted = false
-			and dl.user_id = :userID
+			AND dl.user_id = :userID
+		GROUP BY 
+			%s
         ORDER BY
             dl.%s %s,
             dl.id ASC
         LIMIT :limit
         OFFSET :offset
-		GROUP BY 
-			%s
     `, cols,
+		cols,
 		f.SortColumn(),
 		f.SortDirection(),
-		cols,
 	)
 
-	start := sql.NullTime{}
-	if date != nil {
-		start.Valid = true
-		start.Time = *date
+	lowerTags := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		lowerTags = append(lowerTags, strings.ToLower(tag))
 	}
 
 	params := map[string]any{
 		"description": description,
-		"modelLabel":  modelLabel,
-		"date":        start,
+		"moodLabel":   moodLabel,
+		"startDate":   nullDate(startDate),
+		"endDate":     nullDate(endDate),
+		"tags":        pq.Array(lowerTags),
 		"userID":      userID,
 		"limit":       f.Limit(),
 		"offset":      f.Offset(),
@@ -186,6 +219,17 @@ func (r *daylogRepository) GetAll(
 	)
 }
 
+func nullDate(date *time.Time) sql.NullTime {
+	if date == nil {
+		return sql.NullTime{}
+	}
+
+	return sql.NullTime{
+		Time:  *date,
+		Valid: true,
+	}
+}
+
 func (r *daylogRepository) GetByID(id, userID uuid.UUID) (*models.Daylog, error) {
 	cols := strings.Join([]string{
 		selectColumns(models.Daylog{}, "dl"),
@@ -197,12 +241,12 @@ func (r *daylogRepository) GetByID(id, userID uuid.UUID) (*models.Daylog, error)
 	query := fmt.Sprintf(`
 	select 
 	%s,
-	ARRAY_AGG(t.name) as tags
+	ARRAY_REMOVE(ARRAY_AGG(t.name), NULL) as tags
 	FROM day_logs dl
 	LEFT JOIN users u 
 		on dl.user_id = u.id
 	LEFT JOIN log_tags lt ON dl.id = lt.log_id
-	LEFT JOIN tags t ON lt.tag_id = t.id
+	LEFT JOIN tags t ON lt.tag_id = t.id AND t.deleted = false
 	WHERE
 		dl.id = :id
 		and dl.user_id = :userID
@@ -222,41 +266,94 @@ func (r *daylogRepository) GetByID(id, userID uuid.UUID) (*models.Daylog, error)
 	return getByQuery[models.Daylog](r.db, query, args)
 }
 
-func (r *daylogRepository) InsertLogsTags(tx *sql.Tx, daylogID, tagID uuid.UUID) error {
+func (r *daylogRepository) GetDailySummaries(
+	startDate, endDate time.Time,
+	userID uuid.UUID,
+) ([]*models.DailySummary, error) {
 	query := `
-	insert into log_tags (
-		log_id,
-		tag_id
-	)
-	values (
-		:logID,
-		:tagID
-	)
+	select
+		dl.date,
+		count(*) as entries,
+		count(dl.logged_at) as check_ins,
+		round(avg(dl.mood_label), 2)::float8 as average_mood,
+		min(dl.mood_label) as min_mood,
+		max(dl.mood_label) as max_mood,
+		(array_agg(
+			dl.mood_label
+			order by dl.logged_at desc nulls last, dl.created_at desc
+		))[1] as last_mood,
+		min(dl.logged_at) as first_check_in,
+		max(dl.logged_at) as last_check_in
+	from day_logs dl
+	where
+		dl.user_id = :userID
+		and dl.deleted = false
+		and dl.date >= :startDate::date
+		and dl.date <= :endDate::date
+	group by dl.date
+	order by dl.date
 	`
 
 	params := map[string]any{
-		"logID": daylogID,
-		"tagID": tagID,
+		"userID":    userID,
+		"startDate": startDate,
+		"endDate":   endDate,
 	}
 
 	query, args := namedQuery(query, params)
 	r.logger.PrintInfo(utils.MinifySQL(query), nil)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	return listQuery(
+		r.db,
+		query,
+		args,
+		func() *models.DailySummary {
+			return &models.DailySummary{}
+		},
+	)
+}
 
-	result, err := tx.ExecContext(ctx, query, args...)
-	if err != nil {
-		return err
+// SyncLogTags replaces the tag links of a log with tagIDs, keeping the links
+// that already exist so their created_at is preserved. Links to tags in the
+// trash are left alone so restoring the tag brings them back.
+func (r *daylogRepository) SyncLogTags(tx *sql.Tx, daylogID uuid.UUID, tagIDs []uuid.UUID) error {
+	ids := make([]string, 0, len(tagIDs))
+	for _, id := range tagIDs {
+		ids = append(ids, id.String())
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
+	params := map[string]any{
+		"logID":  daylogID,
+		"tagIDs": pq.Array(ids),
 	}
 
-	if rowsAffected == 0 {
-		return e.ErrRecordNotFound
+	deleteQuery := `
+	delete from log_tags
+	where
+		log_id = :logID
+		and tag_id <> ALL(:tagIDs::uuid[])
+		and tag_id in (select id from tags where deleted = false)
+	`
+
+	insertQuery := `
+	insert into log_tags (
+		log_id,
+		tag_id
+	)
+	select :logID, unnest(:tagIDs::uuid[])
+	on conflict (log_id, tag_id) do nothing
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	for _, query := range []string{deleteQuery, insertQuery} {
+		query, args := namedQuery(query, params)
+		r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -283,7 +380,7 @@ func (r *daylogRepository) InsertOrUpdate(
 		:userID,
 		:userID
 	)
-	on conflict (user_id, date) WHERE deleted = false
+	on conflict (user_id, date) WHERE deleted = false AND logged_at IS NULL
 	do update set
 		description = excluded.description,
 		mood_label = excluded.mood_label,
@@ -320,17 +417,73 @@ func (r *daylogRepository) InsertOrUpdate(
 	)
 }
 
+func (r *daylogRepository) InsertCheckIn(
+	tx *sql.Tx,
+	model *models.Daylog,
+	userID uuid.UUID,
+) error {
+	query := `
+	insert into day_logs (
+		date,
+		description,
+		mood_label,
+		logged_at,
+		time_zone,
+		user_id,
+		created_by
+	)
+	values (
+		:date,
+		:description,
+		:moodLabel,
+		:loggedAt,
+		:timeZone,
+		:userID,
+		:userID
+	)
+	returning
+		id,
+		created_at,
+		version
+	`
+
+	params := map[string]any{
+		"date":        model.Date,
+		"description": model.Description,
+		"moodLabel":   model.MoodLabel,
+		"loggedAt":    model.LoggedAt,
+		"timeZone":    model.TimeZone,
+		"userID":      userID,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return tx.QueryRowContext(ctx, query, args...).Scan(
+		&model.ID,
+		&model.CreatedAt,
+		&model.Version,
+	)
+}
+
 func (r *daylogRepository) Update(tx *sql.Tx, model *models.Daylog, userID uuid.UUID) error {
 	query := `
 	UPDATE day_logs SET
 		date = :date,
 		description = :description,
-		mood_label = :mood_label,
+		mood_label = :moodLabel,
+		logged_at = :loggedAt,
+		time_zone = :timeZone,
 		updated_at = NOW(),
 		updated_by = :userID,
 		version = version + 1
 	WHERE
 		id = :id
+		AND user_id = :userID
+		AND deleted = false
 		AND version = :version
 	RETURNING version`
 
@@ -339,9 +492,12 @@ func (r *daylogRepository) Update(tx *sql.Tx, model *models.Daylog, userID uuid.
 	start.Time = model.Date
 
 	params := map[string]any{
+		"id":          model.ID,
 		"date":        start,
 		"description": model.Description,
 		"moodLabel":   model.MoodLabel,
+		"loggedAt":    model.LoggedAt,
+		"timeZone":    model.TimeZone,
 		"userID":      userID,
 		"version":     model.BaseModel.Version,
 	}
@@ -360,7 +516,7 @@ func (r *daylogRepository) Update(tx *sql.Tx, model *models.Daylog, userID uuid.
 		if errors.Is(err, sql.ErrNoRows) {
 			return e.ErrEditConflict
 		}
-		return err
+		return parseDaylogConstraintError(err)
 	}
 	return nil
 }
@@ -368,10 +524,15 @@ func (r *daylogRepository) Update(tx *sql.Tx, model *models.Daylog, userID uuid.
 func (r *daylogRepository) Delete(tx *sql.Tx, id uuid.UUID, userID uuid.UUID) error {
 	query := `
 	UPDATE day_logs set
-		deleted = true
+		deleted = true,
+		deleted_at = NOW(),
+		updated_at = NOW(),
+		updated_by = :userID,
+		version = version + 1
 	where 
 		id = :id
 		and user_id = :userID
+		and deleted = false
 	`
 
 	params := map[string]any{
@@ -401,13 +562,23 @@ func (r *daylogRepository) Delete(tx *sql.Tx, id uuid.UUID, userID uuid.UUID) er
 	return nil
 }
 
-func (r *daylogRepository) DeleteLogTagByDaylogID(tx *sql.Tx, daylogID uuid.UUID) error {
+func (r *daylogRepository) Restore(tx *sql.Tx, id uuid.UUID, userID uuid.UUID) error {
 	query := `
-	delete from log_tags
-	where log_id = :id
+	UPDATE day_logs set
+		deleted = false,
+		deleted_at = NULL,
+		updated_at = NOW(),
+		updated_by = :userID,
+		version = version + 1
+	where 
+		id = :id
+		and user_id = :userID
+		and deleted = true
 	`
+
 	params := map[string]any{
-		"id": daylogID,
+		"id":     id,
+		"userID": userID,
 	}
 
 	query, args := namedQuery(query, params)
@@ -417,7 +588,7 @@ func (r *daylogRepository) DeleteLogTagByDaylogID(tx *sql.Tx, daylogID uuid.UUID
 
 	result, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
-		return err
+		return parseDaylogConstraintError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -431,3 +602,231 @@ func (r *daylogRepository) DeleteLogTagByDaylogID(tx *sql.Tx, daylogID uuid.UUID
 
 	return nil
 }
+
+// RestoreLogTags brings back the tags of a restored log that are in the trash.
+// When a live tag with the same name was created meanwhile, the log is linked
+// to it instead.
+func (r *daylogRepository) RestoreLogTags(tx *sql.Tx, daylogID uuid.UUID, userID uuid.UUID) error {
+	restoreQuery := `
+	UPDATE tags t set
+		deleted = false,
+		deleted_at = NULL,
+		updated_at = NOW(),
+		updated_by = :userID,
+		version = t.version + 1
+	from log_tags lt
+	where
+		lt.log_id = :logID
+		and lt.tag_id = t.id
+		and t.user_id = :userID
+		and t.deleted = true
+		and not exists (
+			select 1
+			from tags live
+			where
+				live.user_id = t.user_id
+				and lower(live.name) = lower(t.name)
+				and live.deleted = false
+		)
+	`
+
+	relinkQuery := `
+	insert into log_tags (
+		log_id,
+		tag_id
+	)
+	select lt.log_id, live.id
+	from log_tags lt
+	join tags dead on dead.id = lt.tag_id and dead.deleted = true
+	join tags live on
+		live.user_id = dead.user_id
+		and lower(live.name) = lower(dead.name)
+		and live.deleted = false
+	where lt.log_id = :logID
+	on conflict (log_id, tag_id) do nothing
+	`
+
+	unlinkQuery := `
+	delete from log_tags lt
+	using tags dead
+	where
+		lt.log_id = :logID
+		and dead.id = lt.tag_id
+		and dead.deleted = true
+	`
+
+	params := map[string]any{
+		"logID":  daylogID,
+		"userID": userID,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	for _, query := range []string{restoreQuery, relinkQuery, unlinkQuery} {
+		query, args := namedQuery(query, params)
+		r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// InsertRevision saves the log as it is at version before it is updated. It
+// saves nothing when version is stale, leaving the conflict to Update.
+func (r *daylogRepository) InsertRevision(tx *sql.Tx, id uuid.UUID, version int, userID uuid.UUID) error {
+	where := `
+		dl.id = :id
+		AND dl.version = :version
+	`
+
+	return r.insertRevision(tx, where, map[string]any{
+		"id":      id,
+		"version": version,
+		"userID":  userID,
+	})
+}
+
+// InsertDailyRevision saves the daily entry of date, if there is one, before
+// InsertOrUpdate overwrites it.
+func (r *daylogRepository) InsertDailyRevision(tx *sql.Tx, date time.Time, userID uuid.UUID) error {
+	where := `
+		dl.date = :date
+		AND dl.logged_at IS NULL
+	`
+
+	return r.insertRevision(tx, where, map[string]any{
+		"date":   sql.NullTime{Time: date, Valid: true},
+		"userID": userID,
+	})
+}
+
+func (r *daylogRepository) insertRevision(tx *sql.Tx, where string, params map[string]any) error {
+	query := fmt.Sprintf(`
+	insert into day_log_revisions (
+		log_id,
+		version,
+		date,
+		description,
+		mood_label,
+		logged_at,
+		time_zone,
+		tags,
+		trackers,
+		edited_by,
+		edited_at
+	)
+	select
+		dl.id,
+		dl.version,
+		dl.date,
+		COALESCE(dl.description, ''),
+		dl.mood_label,
+		dl.logged_at,
+		dl.time_zone,
+		(
+			SELECT COALESCE(ARRAY_AGG(t.name ORDER BY t.name), '{}')
+			FROM log_tags lt
+			JOIN tags t ON t.id = lt.tag_id AND t.deleted = false
+			WHERE lt.log_id = dl.id
+		),
+		(
+			SELECT COALESCE(jsonb_agg(jsonb_build_object(
+				'tracker_id', tr.id,
+				'name', tr.name,
+				'kind', tr.kind,
+				'unit', tr.unit,
+				'value', v.value
+			) ORDER BY tr.name), '[]')
+			FROM log_tracker_values v
+			JOIN trackers tr ON tr.id = v.tracker_id AND tr.deleted = false
+			WHERE v.log_id = dl.id
+		),
+		COALESCE(dl.updated_by, dl.created_by),
+		COALESCE(dl.updated_at, dl.created_at)
+	from day_logs dl
+	where
+		%s
+		AND dl.user_id = :userID
+		AND dl.deleted = false
+	on conflict (log_id, version) do nothing
+	`, where)
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *daylogRepository) GetRevisions(
+	id, userID uuid.UUID,
+	f filters.Filters,
+) ([]*models.DaylogRevision, filters.Metadata, error) {
+	query := fmt.Sprintf(`
+	SELECT
+		count(*) OVER(),
+		%s
+	FROM day_log_revisions r
+	JOIN day_logs dl ON dl.id = r.log_id
+	WHERE
+		r.log_id = :id
+		AND dl.user_id = :userID
+		AND dl.deleted = false
+	ORDER BY
+		r.%s %s
+	LIMIT :limit
+	OFFSET :offset
+	`, selectColumns(models.DaylogRevision{}, "r"), f.SortColumn(), f.SortDirection())
+
+	params := map[string]any{
+		"id":     id,
+		"userID": userID,
+		"limit":  f.Limit(),
+		"offset": f.Offset(),
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	return paginatedQuery(
+		r.db,
+		query,
+		args,
+		f,
+		func() *models.DaylogRevision {
+			return &models.DaylogRevision{}
+		},
+	)
+}
+
+func (r *daylogRepository) GetRevision(id uuid.UUID, version int, userID uuid.UUID) (*models.DaylogRevision, error) {
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM day_log_revisions r
+	JOIN day_logs dl ON dl.id = r.log_id
+	WHERE
+		r.log_id = :id
+		AND r.version = :version
+		AND dl.user_id = :userID
+		AND dl.deleted = false
+	`, selectColumns(models.DaylogRevision{}, "r"))
+
+	params := map[string]any{
+		"id":      id,
+		"version": version,
+		"userID":  userID,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	return getByQuery[models.DaylogRevision](r.db, query, args)
+}
@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"database/sql/driver"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSyncLogTags(t *testing.T) {
+	logID := uuid.New()
+	work, gym := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name  string
+		tags  []uuid.UUID
+		array string
+	}{
+		{"no tags", []uuid.UUID{}, "{}"},
+		{"tags", []uuid.UUID{work, gym}, `{"` + work.String() + `","` + gym.String() + `"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, d := newTestTx(t, 0)
+			r := NewDaylogRepository(nil, newTestLogger())
+
+			if err := r.SyncLogTags(tx, logID, tt.tags); err != nil {
+				t.Fatalf("SyncLogTags returned %v", err)
+			}
+
+			if len(d.queries) != 2 {
+				t.Fatalf("ran %d statements, want a delete and an insert", len(d.queries))
+			}
+
+			// Links missing from the list are removed, the others are kept so
+			// their created_at does not change.
+			if !strings.Contains(d.queries[0], "delete from log_tags") || !strings.Contains(d.queries[0], "<> ALL(") {
+				t.Errorf("first statement does not delete the other links: %s", d.queries[0])
+			}
+			if !strings.Contains(d.queries[1], "on conflict (log_id, tag_id) do nothing") {
+				t.Errorf("second statement does not keep existing links: %s", d.queries[1])
+			}
+
+			for i, args := range d.args {
+				if !slices.Contains(args, driver.Value(logID.String())) || !slices.Contains(args, driver.Value(tt.array)) {
+					t.Errorf("statement %d args = %v, want the log id and %s", i, args, tt.array)
+				}
+			}
+		})
+	}
+}
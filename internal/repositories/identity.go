@@ -0,0 +1,211 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"moodtracker/internal/jsonlog"
+	"moodtracker/internal/models"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type identityRepository struct {
+	db     *sql.DB
+	logger jsonlog.Logger
+}
+
+type IdentityRepository interface {
+	GetByIssuerAndSubject(issuer, subject string) (*models.Identity, error)
+	Insert(tx *sql.Tx, identity *models.Identity) error
+	TouchLastLogin(tx *sql.Tx, id uuid.UUID) error
+	InsertState(tx *sql.Tx, state *models.OIDCState) error
+	ConsumeState(tx *sql.Tx, stateHash []byte) (*models.OIDCState, error)
+}
+
+func NewIdentityRepository(
+	db *sql.DB,
+	logger jsonlog.Logger,
+) *identityRepository {
+	return &identityRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *identityRepository) GetByIssuerAndSubject(issuer, subject string) (*models.Identity, error) {
+	cols := selectColumns(models.Identity{}, "i")
+
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM identities i
+	WHERE
+		i.issuer = :issuer
+		AND i.subject = :subject
+	`, cols)
+
+	params := map[string]any{
+		"issuer":  issuer,
+		"subject": subject,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	return getByQuery[models.Identity](r.db, query, args)
+}
+
+func (r *identityRepository) Insert(tx *sql.Tx, identity *models.Identity) error {
+	query := `
+	INSERT INTO identities (
+		user_id,
+		issuer,
+		subject,
+		email,
+		last_login_at
+	)
+	VALUES (
+		:userID,
+		:issuer,
+		:subject,
+		:email,
+		NOW()
+	)
+	RETURNING
+		id,
+		created_at,
+		last_login_at
+	`
+
+	params := map[string]any{
+		"userID":  identity.UserID,
+		"issuer":  identity.Issuer,
+		"subject": identity.Subject,
+		"email":   identity.Email,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return tx.QueryRowContext(ctx, query, args...).Scan(
+		&identity.ID,
+		&identity.CreatedAt,
+		&identity.LastLoginAt,
+	)
+}
+
+func (r *identityRepository) TouchLastLogin(tx *sql.Tx, id uuid.UUID) error {
+	query := `
+	UPDATE identities SET
+		last_login_at = NOW()
+	WHERE id = :id
+	`
+
+	params := map[string]any{
+		"id": id,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *identityRepository) InsertState(tx *sql.Tx, state *models.OIDCState) error {
+	query := `
+	INSERT INTO oidc_states (
+		hash,
+		code_verifier,
+		nonce,
+		expiry
+	)
+	VALUES (
+		:hash,
+		:codeVerifier,
+		:nonce,
+		:expiry
+	)
+	`
+
+	params := map[string]any{
+		"hash":         state.Hash,
+		"codeVerifier": state.CodeVerifier,
+		"nonce":        state.Nonce,
+		"expiry":       state.Expiry,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+// ConsumeState deletes the pending authorization request so each state can
+// be redeemed only once, and also drops any expired leftovers.
+func (r *identityRepository) ConsumeState(tx *sql.Tx, stateHash []byte) (*models.OIDCState, error) {
+	query := `
+	DELETE FROM oidc_states
+	WHERE
+		hash = :hash
+		OR expiry <= NOW()
+	RETURNING
+		hash,
+		code_verifier,
+		nonce,
+		expiry
+	`
+
+	params := map[string]any{
+		"hash": stateHash,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var found *models.OIDCState
+	for rows.Next() {
+		var state models.OIDCState
+		err := rows.Scan(&state.Hash, &state.CodeVerifier, &state.Nonce, &state.Expiry)
+		if err != nil {
+			return nil, err
+		}
+
+		if string(state.Hash) == string(stateHash) && state.Expiry.After(time.Now()) {
+			found = &state
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if found == nil {
+		return nil, e.ErrRecordNotFound
+	}
+
+	return found, nil
+}
@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"moodtracker/internal/jsonlog"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type mfaRepository struct {
+	db     *sql.DB
+	logger jsonlog.Logger
+}
+
+type MFARepository interface {
+	InsertRecoveryCodes(tx *sql.Tx, userID uuid.UUID, hashes [][]byte) error
+	UseRecoveryCode(tx *sql.Tx, userID uuid.UUID, hash []byte) error
+	DeleteRecoveryCodes(tx *sql.Tx, userID uuid.UUID) error
+}
+
+func NewMFARepository(
+	db *sql.DB,
+	logger jsonlog.Logger,
+) *mfaRepository {
+	return &mfaRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *mfaRepository) InsertRecoveryCodes(tx *sql.Tx, userID uuid.UUID, hashes [][]byte) error {
+	query := `
+	INSERT INTO mfa_recovery_codes (hash, user_id)
+	SELECT
+		unnest(:hashes::bytea[]),
+		:userID
+	`
+
+	params := map[string]any{
+		"hashes": pq.ByteaArray(hashes),
+		"userID": userID,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *mfaRepository) UseRecoveryCode(tx *sql.Tx, userID uuid.UUID, hash []byte) error {
+	query := `
+	DELETE FROM mfa_recovery_codes
+	WHERE
+		hash = :hash
+		AND user_id = :userID
+	`
+
+	params := map[string]any{
+		"hash":   hash,
+		"userID": userID,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return e.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *mfaRepository) DeleteRecoveryCodes(tx *sql.Tx, userID uuid.UUID) error {
+	query := `
+	DELETE FROM mfa_recovery_codes
+	WHERE user_id = :userID
+	`
+
+	params := map[string]any{
+		"userID": userID,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"moodtracker/internal/jsonlog"
+	"moodtracker/internal/models"
+	"moodtracker/utils"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type permissionRepository struct {
+	db     *sql.DB
+	logger jsonlog.Logger
+}
+
+type PermissionRepository interface {
+	GetAllForUser(userID uuid.UUID) (models.Permissions, error)
+}
+
+func NewPermissionRepository(
+	db *sql.DB,
+	logger jsonlog.Logger,
+) *permissionRepository {
+	return &permissionRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *permissionRepository) GetAllForUser(userID uuid.UUID) (models.Permissions, error) {
+	query := `
+	SELECT DISTINCT
+		p.code
+	FROM permissions p
+	INNER JOIN roles_permissions rp ON rp.permission_id = p.id
+	INNER JOIN users_roles ur ON ur.role_id = rp.role_id
+	WHERE
+		ur.user_id = :userID
+	`
+
+	params := map[string]any{
+		"userID": userID,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var permissions models.Permissions
+
+	for rows.Next() {
+		var permission string
+
+		if err := rows.Scan(&permission); err != nil {
+			return nil, err
+		}
+
+		permissions = append(permissions, permission)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
@@ -0,0 +1,380 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"moodtracker/internal/jsonlog"
+	"moodtracker/internal/models"
+	"moodtracker/internal/models/filters"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type reminderRepository struct {
+	db     *sql.DB
+	logger jsonlog.Logger
+}
+
+type ReminderRepository interface {
+	GetAllByUserID(
+		userID uuid.UUID,
+		f filters.Filters,
+	) ([]*models.Reminder, filters.Metadata, error)
+	FindByID(id, userID uuid.UUID) (*models.Reminder, error)
+	Insert(tx *sql.Tx, model *models.Reminder, userID uuid.UUID) error
+	Update(tx *sql.Tx, model *models.Reminder, userID uuid.UUID) error
+	Delete(tx *sql.Tx, id, userID uuid.UUID) error
+	ClaimDue(tx *sql.Tx, now time.Time, limit int) ([]*models.DueReminder, error)
+	Reschedule(tx *sql.Tx, id uuid.UUID, nextRunAt time.Time) error
+	MarkSent(id uuid.UUID, sentAt time.Time) error
+}
+
+func NewReminderRepository(
+	db *sql.DB,
+	logger jsonlog.Logger,
+) *reminderRepository {
+	return &reminderRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *reminderRepository) GetAllByUserID(
+	userID uuid.UUID,
+	f filters.Filters,
+) ([]*models.Reminder, filters.Metadata, error) {
+	query := fmt.Sprintf(`
+	SELECT
+		count(*) OVER(),
+		%s
+	FROM reminders r
+	WHERE
+		r.user_id = :userID
+		AND r.deleted = false
+	ORDER BY
+		r.%s %s,
+		r.id ASC
+	LIMIT :limit
+	OFFSET :offset
+	`, selectColumns(models.Reminder{}, "r"), f.SortColumn(), f.SortDirection())
+
+	params := map[string]any{
+		"userID": userID,
+		"limit":  f.Limit(),
+		"offset": f.Offset(),
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	return paginatedQuery(
+		r.db,
+		query,
+		args,
+		f,
+		func() *models.Reminder {
+			return &models.Reminder{}
+		},
+	)
+}
+
+func (r *reminderRepository) FindByID(id, userID uuid.UUID) (*models.Reminder, error) {
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM reminders r
+	WHERE
+		r.id = :id
+		AND r.user_id = :userID
+		AND r.deleted = false
+	`, selectColumns(models.Reminder{}, "r"))
+
+	params := map[string]any{
+		"id":     id,
+		"userID": userID,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	return getByQuery[models.Reminder](r.db, query, args)
+}
+
+func (r *reminderRepository) Insert(tx *sql.Tx, model *models.Reminder, userID uuid.UUID) error {
+	query := `
+	INSERT INTO reminders (
+		time_of_day,
+		time_zone,
+		weekdays,
+		skip_if_logged,
+		channel,
+		webhook_url,
+		enabled,
+		next_run_at,
+		user_id,
+		created_by
+	)
+	VALUES (
+		:timeOfDay,
+		:timeZone,
+		:weekdays,
+		:skipIfLogged,
+		:channel,
+		:webhookURL,
+		:enabled,
+		:nextRunAt,
+		:userID,
+		:userID
+	)
+	RETURNING id, created_at, version
+	`
+
+	params := map[string]any{
+		"timeOfDay":    model.TimeOfDay,
+		"timeZone":     model.TimeZone,
+		"weekdays":     pq.Array(weekdays(model.Weekdays)),
+		"skipIfLogged": model.SkipIfLogged,
+		"channel":      model.Channel,
+		"webhookURL":   model.WebhookURL,
+		"enabled":      model.Enabled,
+		"nextRunAt":    model.NextRunAt,
+		"userID":       userID,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return tx.QueryRowContext(ctx, query, args...).Scan(
+		&model.ID,
+		&model.CreatedAt,
+		&model.Version,
+	)
+}
+
+func (r *reminderRepository) Update(tx *sql.Tx, model *models.Reminder, userID uuid.UUID) error {
+	query := `
+	UPDATE reminders
+	SET
+		time_of_day = :timeOfDay,
+		time_zone = :timeZone,
+		weekdays = :weekdays,
+		skip_if_logged = :skipIfLogged,
+		channel = :channel,
+		webhook_url = :webhookURL,
+		enabled = :enabled,
+		next_run_at = :nextRunAt,
+		updated_at = NOW(),
+		updated_by = :userID,
+		version = version + 1
+	WHERE
+		user_id = :userID
+		AND id = :id
+		AND version = :version
+		AND deleted = false
+	RETURNING version, last_sent_at
+	`
+
+	params := map[string]any{
+		"id":           model.ID,
+		"timeOfDay":    model.TimeOfDay,
+		"timeZone":     model.TimeZone,
+		"weekdays":     pq.Array(weekdays(model.Weekdays)),
+		"skipIfLogged": model.SkipIfLogged,
+		"channel":      model.Channel,
+		"webhookURL":   model.WebhookURL,
+		"enabled":      model.Enabled,
+		"nextRunAt":    model.NextRunAt,
+		"userID":       userID,
+		"version":      model.Version,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&model.Version, &model.LastSentAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return e.ErrEditConflict
+	}
+
+	return err
+}
+
+func (r *reminderRepository) Delete(tx *sql.Tx, id, userID uuid.UUID) error {
+	query := `
+	UPDATE reminders
+	SET
+		deleted = true,
+		updated_at = NOW(),
+		updated_by = :userID,
+		version = version + 1
+	WHERE
+		user_id = :userID
+		AND id = :id
+		AND deleted = false
+	`
+
+	params := map[string]any{
+		"id":     id,
+		"userID": userID,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return e.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// ClaimDue locks up to limit reminders that are due. Rows already locked by
+// another replica are skipped, so each run is handled by a single instance.
+// logged tells whether the user has a day log for the local date of the run.
+func (r *reminderRepository) ClaimDue(tx *sql.Tx, now time.Time, limit int) ([]*models.DueReminder, error) {
+	query := fmt.Sprintf(`
+	SELECT
+		%s,
+		r.user_id,
+		u.name,
+		u.email,
+		u.locale,
+		EXISTS (
+			SELECT 1
+			FROM day_logs dl
+			WHERE
+				dl.user_id = r.user_id
+				AND dl.deleted = false
+				AND dl.date = (r.next_run_at AT TIME ZONE r.time_zone)::date
+		) AS logged
+	FROM reminders r
+	JOIN users u ON u.id = r.user_id
+	WHERE
+		r.next_run_at <= :now
+		AND r.enabled = true
+		AND r.deleted = false
+		AND u.activated = true
+		AND u.deleted = false
+	ORDER BY r.next_run_at
+	LIMIT :limit
+	FOR UPDATE OF r SKIP LOCKED
+	`, selectColumns(models.Reminder{}, "r"))
+
+	params := map[string]any{
+		"now":   now,
+		"limit": limit,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	due := []*models.DueReminder{}
+	for rows.Next() {
+		var reminder models.DueReminder
+
+		fields, err := collectFields(&reminder)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := rows.Scan(fields...); err != nil {
+			return nil, err
+		}
+
+		due = append(due, &reminder)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return due, nil
+}
+
+// Reschedule moves a claimed reminder to its next run. It is not a user edit,
+// so the version is left alone.
+func (r *reminderRepository) Reschedule(tx *sql.Tx, id uuid.UUID, nextRunAt time.Time) error {
+	query := `
+	UPDATE reminders
+	SET next_run_at = :nextRunAt
+	WHERE id = :id
+	`
+
+	params := map[string]any{
+		"id":        id,
+		"nextRunAt": nextRunAt,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *reminderRepository) MarkSent(id uuid.UUID, sentAt time.Time) error {
+	query := `
+	UPDATE reminders
+	SET last_sent_at = :sentAt
+	WHERE id = :id
+	`
+
+	params := map[string]any{
+		"id":     id,
+		"sentAt": sentAt,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// weekdays makes sure "every day" is stored as an empty array, not NULL.
+func weekdays(days []int64) []int64 {
+	if days == nil {
+		return []int64{}
+	}
+	return days
+}
@@ -31,6 +31,12 @@ type ReportRepository interface {
 		moodLabel models.MoodLabel,
 		userID uuid.UUID,
 	) (*models.MoodReport, error)
+
+	GetTrackerPoints(
+		trackerID uuid.UUID,
+		startDate, endDate time.Time,
+		userID uuid.UUID,
+	) ([]*models.TrackerPoint, error)
 }
 
 func NewReportRepository(
@@ -66,8 +72,9 @@ type moodTagRow struct {
 }
 
 type monthlyTagRow struct {
-	Tag   string `db:"tag"`
-	Count int    `db:"count"`
+	Tag         string  `db:"tag"`
+	Count       int     `db:"count"`
+	AverageMood float64 `db:"average_mood"`
 }
 
 func (r *reportRepository) GetMonthlyReport(
@@ -118,7 +125,8 @@ func (r *reportRepository) GetMonthlyReport(
 	tagQuery := `
 	select
 		t.name as tag,
-		count(*) as count
+		count(*) as count,
+		round(avg(dl.mood_label), 2)::float8 as average_mood
 	from day_logs dl
 	join log_tags lt on lt.log_id = dl.id
 	join tags t on t.id = lt.tag_id
@@ -153,23 +161,22 @@ func (r *reportRepository) GetMonthlyReport(
 
 	for _, row := range moodList {
 		report.Distribuition = append(report.Distribuition,
-			models.MoodDistribuition{
-				MoodLabel:  row.MoodLabel,
-				Count:      row.Count,
-				Percentage: row.Percentage,
-			},
+			models.NewMoodDistribuition(row.MoodLabel, row.Count, row.Percentage),
 		)
 	}
 
 	for _, row := range tagList {
 		report.Tags = append(report.Tags,
 			models.CountTags{
-				Tag:   row.Tag,
-				Count: row.Count,
+				Tag:         row.Tag,
+				Count:       row.Count,
+				AverageMood: row.AverageMood,
 			},
 		)
 	}
 
+	report.AverageMood = models.AverageMood(report.Distribuition)
+
 	return report, nil
 }
 
@@ -223,14 +230,12 @@ func (r *reportRepository) GetTagReport(
 
 	for _, row := range tagList {
 		tagReport.Distribuition = append(tagReport.Distribuition,
-			models.MoodDistribuition{
-				MoodLabel:  row.MoodLabel,
-				Count:      row.Count,
-				Percentage: row.Percentage,
-			},
+			models.NewMoodDistribuition(row.MoodLabel, row.Count, row.Percentage),
 		)
 	}
 
+	tagReport.AverageMood = models.AverageMood(tagReport.Distribuition)
+
 	return tagReport, nil
 }
 
@@ -249,7 +254,7 @@ func (r *reportRepository) GetMoodReport(
 		)as percentage
 	from day_logs dl
 	join log_tags lt on lt.log_id = dl.id
-	join tags on t.id = lt.tag_id
+	join tags t on t.id = lt.tag_id
 	where
 		dl.user_id = :userID
 		and dl.deleted = false
@@ -281,6 +286,10 @@ func (r *reportRepository) GetMoodReport(
 		Distribuition: []models.TagDistribuition{},
 	}
 
+	if level, ok := models.CurrentMoodScale().Level(moodLabel); ok {
+		moodReport.Mood = &level
+	}
+
 	for _, row := range list {
 		moodReport.Distribuition = append(moodReport.Distribuition,
 			models.TagDistribuition{
@@ -293,3 +302,45 @@ func (r *reportRepository) GetMoodReport(
 
 	return moodReport, nil
 }
+
+func (r *reportRepository) GetTrackerPoints(
+	trackerID uuid.UUID,
+	startDate, endDate time.Time,
+	userID uuid.UUID,
+) ([]*models.TrackerPoint, error) {
+	query := `
+	select
+		dl.date,
+		dl.logged_at,
+		dl.mood_label,
+		v.value
+	from log_tracker_values v
+	join day_logs dl on dl.id = v.log_id
+	where
+		v.tracker_id = :trackerID
+		and dl.user_id = :userID
+		and dl.deleted = false
+		and dl.date >= :startDate::date
+		and dl.date <= :endDate::date
+	order by dl.date, dl.logged_at nulls first
+	`
+
+	params := map[string]any{
+		"trackerID": trackerID,
+		"userID":    userID,
+		"startDate": startDate,
+		"endDate":   endDate,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	return listQuery(
+		r.db,
+		query,
+		args,
+		func() *models.TrackerPoint {
+			return &models.TrackerPoint{}
+		},
+	)
+}
@@ -1,14 +1,17 @@
 package repositories
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"maps"
 	"moodtracker/internal/jsonlog"
 	"moodtracker/internal/models/filters"
 	e "moodtracker/utils/errors"
 	"reflect"
+	"slices"
 	"strings"
 	"time"
 
@@ -16,10 +19,20 @@ import (
 )
 
 type Repository struct {
-	User   UserRepositoryInterface
-	DayLog DaylogRepository
-	Tag    TagRepository
-	Report ReportRepository
+	User        UserRepositoryInterface
+	DayLog      DaylogRepository
+	Tag         TagRepository
+	Report      ReportRepository
+	Token       TokenRepository
+	Permission  PermissionRepository
+	MFA         MFARepository
+	AccessToken AccessTokenRepository
+	Identity    IdentityRepository
+	AuthEvent   AuthEventRepository
+	Trash       TrashRepository
+	Tracker     TrackerRepository
+	Attachment  AttachmentRepository
+	Reminder    ReminderRepository
 }
 
 func NewRepository(
@@ -27,10 +40,20 @@ func NewRepository(
 	db *sql.DB,
 ) *Repository {
 	return &Repository{
-		User:   NewUserRepository(db, logger),
-		DayLog: NewDaylogRepository(db, logger),
-		Tag:    NewTagRepository(db, logger),
-		Report: NewReportRepository(db, logger),
+		User:        NewUserRepository(db, logger),
+		DayLog:      NewDaylogRepository(db, logger),
+		Tag:         NewTagRepository(db, logger),
+		Report:      NewReportRepository(db, logger),
+		Token:       NewTokenRepository(db, logger),
+		Permission:  NewPermissionRepository(db, logger),
+		MFA:         NewMFARepository(db, logger),
+		AccessToken: NewAccessTokenRepository(db, logger),
+		Identity:    NewIdentityRepository(db, logger),
+		AuthEvent:   NewAuthEventRepository(db, logger),
+		Trash:       NewTrashRepository(db, logger),
+		Tracker:     NewTrackerRepository(db, logger),
+		Attachment:  NewAttachmentRepository(db, logger),
+		Reminder:    NewReminderRepository(db, logger),
 	}
 }
 
@@ -72,6 +95,11 @@ func collectFields(dest any) ([]any, error) {
 
 		if tag != "" && tag != "-" {
 
+			if scanner, ok := fieldVal.Addr().Interface().(sql.Scanner); ok {
+				fields = append(fields, scanner)
+				continue
+			}
+
 			if fieldVal.Kind() == reflect.Slice {
 				elemKind := fieldVal.Type().Elem().Kind()
 
@@ -126,16 +154,21 @@ func collectFields(dest any) ([]any, error) {
 	return fields, nil
 }
 
+// namedQuery replaces each :key in query with a positional placeholder.
+// Longer keys are replaced first so that :id never rewrites the start of :ids,
+// and keys are numbered in a fixed order so the same call builds the same query.
 func namedQuery(query string, params map[string]any) (string, []any) {
-	args := []any{}
-	i := 1
-
-	for key, value := range params {
-		placeholder := fmt.Sprintf("$%d", i)
-		paramName := ":" + key
-		query = strings.ReplaceAll(query, paramName, placeholder)
-		args = append(args, value)
-		i++
+	keys := slices.Collect(maps.Keys(params))
+	slices.SortFunc(keys, func(a, b string) int {
+		return cmp.Or(cmp.Compare(len(b), len(a)), strings.Compare(a, b))
+	})
+
+	args := make([]any, 0, len(keys))
+
+	for i, key := range keys {
+		placeholder := fmt.Sprintf("$%d", i+1)
+		query = strings.ReplaceAll(query, ":"+key, placeholder)
+		args = append(args, params[key])
 	}
 
 	return query, args
@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"moodtracker/internal/jsonlog"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// execDriver is a database/sql driver that records the statements it is
+// given and answers every Exec with a fixed number of affected rows. It lets
+// tests check how a repository reads the outcome of an UPDATE or DELETE.
+type execDriver struct {
+	mu           sync.Mutex
+	rowsAffected int64
+	queries      []string
+	args         [][]driver.Value
+}
+
+type execConn struct{ d *execDriver }
+
+type execStmt struct {
+	d     *execDriver
+	query string
+}
+
+type execTx struct{}
+
+var testDriver = &execDriver{}
+
+func init() {
+	sql.Register("repositories_test", testDriver)
+}
+
+func (d *execDriver) Open(string) (driver.Conn, error) { return execConn{d}, nil }
+
+func (c execConn) Prepare(query string) (driver.Stmt, error) { return execStmt{c.d, query}, nil }
+
+func (execConn) Close() error { return nil }
+
+func (execConn) Begin() (driver.Tx, error) { return execTx{}, nil }
+
+func (execTx) Commit() error { return nil }
+
+func (execTx) Rollback() error { return nil }
+
+func (execStmt) Close() error { return nil }
+
+func (execStmt) NumInput() int { return -1 }
+
+func (s execStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	s.d.queries = append(s.d.queries, s.query)
+	s.d.args = append(s.d.args, args)
+	return driver.RowsAffected(s.d.rowsAffected), nil
+}
+
+func (execStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("execDriver: queries are not supported")
+}
+
+// newTestTx opens a transaction on the recording driver. Every Exec reports
+// rowsAffected rows.
+func newTestTx(t *testing.T, rowsAffected int64) (*sql.Tx, *execDriver) {
+	t.Helper()
+
+	testDriver.mu.Lock()
+	testDriver.rowsAffected = rowsAffected
+	testDriver.queries = nil
+	testDriver.args = nil
+	testDriver.mu.Unlock()
+
+	db, err := sql.Open("repositories_test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+
+	return tx, testDriver
+}
+
+func newTestLogger() jsonlog.Logger {
+	return jsonlog.New(io.Discard, jsonlog.LevelOff)
+}
+
+func TestNamedQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		params map[string]any
+		want   string
+		args   []any
+	}{
+		{
+			name:   "single key used twice",
+			query:  "values (:name, :userId, :userId)",
+			params: map[string]any{"name": "work", "userId": "u1"},
+			want:   "values ($2, $1, $1)",
+			args:   []any{"u1", "work"},
+		},
+		{
+			name:   "key that is the prefix of another",
+			query:  "where id = :id and log_id = ANY(:ids)",
+			params: map[string]any{"id": 1, "ids": []int{2, 3}},
+			want:   "where id = $2 and log_id = ANY($1)",
+			args:   []any{[]int{2, 3}, 1},
+		},
+		{
+			name:   "keys sharing a prefix",
+			query:  "select :tagIDs, :tag, :tags, :tagID",
+			params: map[string]any{"tag": "a", "tags": "b", "tagID": "c", "tagIDs": "d"},
+			want:   "select $1, $4, $3, $2",
+			args:   []any{"d", "c", "b", "a"},
+		},
+		{
+			name:   "type cast after a key",
+			query:  "unnest(:tagIDs::uuid[])",
+			params: map[string]any{"tagIDs": "{}"},
+			want:   "unnest($1::uuid[])",
+			args:   []any{"{}"},
+		},
+		{
+			name:   "ten keys",
+			query:  ":a1 :a2 :a3 :a4 :a5 :a6 :a7 :a8 :a9 :a10",
+			params: map[string]any{"a1": 1, "a2": 2, "a3": 3, "a4": 4, "a5": 5, "a6": 6, "a7": 7, "a8": 8, "a9": 9, "a10": 10},
+			want:   "$2 $3 $4 $5 $6 $7 $8 $9 $10 $1",
+			args:   []any{10, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		},
+		{
+			name:   "no keys",
+			query:  "select 1",
+			params: map[string]any{},
+			want:   "select 1",
+			args:   []any{},
+		},
+	}
+
+	for _, tt := range tests {
+		// Map order changes between runs, so every case is built a few times.
+		for range 20 {
+			query, args := namedQuery(tt.query, tt.params)
+			if query != tt.want || !reflect.DeepEqual(args, tt.args) {
+				t.Fatalf("%s: namedQuery = %q %v, want %q %v", tt.name, query, args, tt.want, tt.args)
+			}
+		}
+	}
+}
@@ -43,7 +43,7 @@ type TagRepository interface {
 		id,
 		userID uuid.UUID,
 	) error
-	DeleteLogTagByTagID(tx *sql.Tx, tagID uuid.UUID) error
+	Restore(tx *sql.Tx, id, userID uuid.UUID) error
 	GetIDByNameOrCreate(
 		tx *sql.Tx,
 		name string,
@@ -64,13 +64,13 @@ func NewTagRepository(
 func parseTagConstraintError(err error) error {
 	if pqErr, ok := err.(*pq.Error); ok {
 		switch pqErr.Constraint {
-		case "uniq_tags_name_user":
+		case "uniq_tags_name_user_not_deleted":
 			return e.ValidationAlreadyExists("tag")
 		}
 		return err
 	}
 
-	return nil
+	return err
 }
 
 func (r *tagRepository) GetAllByUserID(
@@ -129,7 +129,7 @@ func (r *tagRepository) FindByID(tagID, userID uuid.UUID) (*models.Tag, error) {
         SELECT
            	%s
         FROM tags t
-        LEFT JOIN users u ON u.id = t.use_id
+        LEFT JOIN users u ON u.id = t.user_id
         WHERE
 			t.user_id = :userID
 			and t.id = :tagID
@@ -288,8 +288,10 @@ func (r *tagRepository) Delete(
 	UPDATE tags 
 	SET
 		deleted = true,
+		deleted_at = NOW(),
 		updated_at = NOW(),
-		updated_by = :userID
+		updated_by = :userID,
+		version = version + 1
 	WHERE
 		user_id = :userID
 		AND id = :id
@@ -324,23 +326,39 @@ func (r *tagRepository) Delete(
 	return nil
 }
 
-func (r *tagRepository) DeleteLogTagByTagID(tx *sql.Tx, tagID uuid.UUID) error {
+func (r *tagRepository) Restore(
+	tx *sql.Tx,
+	id,
+	userID uuid.UUID,
+) error {
 	query := `
-	delete from log_tags
-	where tag_id = :id
+	UPDATE tags 
+	SET
+		deleted = false,
+		deleted_at = NULL,
+		updated_at = NOW(),
+		updated_by = :userID,
+		version = version + 1
+	WHERE
+		user_id = :userID
+		AND id = :id
+		AND deleted = true
 	`
+
 	params := map[string]any{
-		"id": tagID,
+		"id":     id,
+		"userID": userID,
 	}
 
 	query, args := namedQuery(query, params)
 	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
 	result, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
-		return err
+		return parseTagConstraintError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -0,0 +1,232 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"moodtracker/internal/jsonlog"
+	"moodtracker/internal/models"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type tokenRepository struct {
+	db     *sql.DB
+	logger jsonlog.Logger
+}
+
+type TokenRepository interface {
+	GetByHash(scope string, tokenHash []byte) (*models.Token, error)
+	Insert(tx *sql.Tx, token *models.Token) error
+	Delete(tx *sql.Tx, scope string, tokenHash []byte, userID uuid.UUID) error
+	DeleteAllForUser(tx *sql.Tx, scope string, userID uuid.UUID) error
+	IsRevoked(jti uuid.UUID) (bool, error)
+	Revoke(tx *sql.Tx, jti, userID uuid.UUID, expiry time.Time) error
+	DeleteExpiredRevoked(tx *sql.Tx) error
+}
+
+func NewTokenRepository(
+	db *sql.DB,
+	logger jsonlog.Logger,
+) *tokenRepository {
+	return &tokenRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *tokenRepository) GetByHash(scope string, tokenHash []byte) (*models.Token, error) {
+	cols := selectColumns(models.Token{}, "t")
+
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM tokens t
+	WHERE
+		t.hash = :hash
+		AND t.scope = :scope
+		AND t.expiry > NOW()
+	`, cols)
+
+	params := map[string]any{
+		"hash":  tokenHash,
+		"scope": scope,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	return getByQuery[models.Token](r.db, query, args)
+}
+
+func (r *tokenRepository) Insert(tx *sql.Tx, token *models.Token) error {
+	query := `
+	INSERT INTO tokens (
+		hash,
+		user_id,
+		expiry,
+		scope
+	)
+	VALUES (
+		:hash,
+		:userID,
+		:expiry,
+		:scope
+	)
+	`
+
+	params := map[string]any{
+		"hash":   token.Hash,
+		"userID": token.UserID,
+		"expiry": token.Expiry,
+		"scope":  token.Scope,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *tokenRepository) Delete(
+	tx *sql.Tx,
+	scope string,
+	tokenHash []byte,
+	userID uuid.UUID,
+) error {
+	query := `
+	DELETE FROM tokens
+	WHERE
+		hash = :hash
+		AND scope = :scope
+		AND user_id = :userID
+	`
+
+	params := map[string]any{
+		"hash":   tokenHash,
+		"scope":  scope,
+		"userID": userID,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return e.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *tokenRepository) DeleteAllForUser(tx *sql.Tx, scope string, userID uuid.UUID) error {
+	query := `
+	DELETE FROM tokens
+	WHERE
+		scope = :scope
+		AND user_id = :userID
+	`
+
+	params := map[string]any{
+		"scope":  scope,
+		"userID": userID,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *tokenRepository) IsRevoked(jti uuid.UUID) (bool, error) {
+	query := `
+	SELECT EXISTS (
+		SELECT 1
+		FROM revoked_tokens
+		WHERE jti = :jti
+	)
+	`
+
+	params := map[string]any{
+		"jti": jti,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var revoked bool
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&revoked)
+	return revoked, err
+}
+
+func (r *tokenRepository) Revoke(tx *sql.Tx, jti, userID uuid.UUID, expiry time.Time) error {
+	query := `
+	INSERT INTO revoked_tokens (
+		jti,
+		user_id,
+		expiry
+	)
+	VALUES (
+		:jti,
+		:userID,
+		:expiry
+	)
+	ON CONFLICT (jti) DO NOTHING
+	`
+
+	params := map[string]any{
+		"jti":    jti,
+		"userID": userID,
+		"expiry": expiry,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *tokenRepository) DeleteExpiredRevoked(tx *sql.Tx) error {
+	query := `
+	DELETE FROM revoked_tokens
+	WHERE expiry < NOW()
+	`
+
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query)
+	return err
+}
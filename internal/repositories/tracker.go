@@ -0,0 +1,381 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"moodtracker/internal/jsonlog"
+	"moodtracker/internal/models"
+	"moodtracker/internal/models/filters"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type trackerRepository struct {
+	db     *sql.DB
+	logger jsonlog.Logger
+}
+
+type TrackerRepository interface {
+	GetAllByUserID(
+		userID uuid.UUID,
+		f filters.Filters,
+	) ([]*models.Tracker, filters.Metadata, error)
+	FindByID(id, userID uuid.UUID) (*models.Tracker, error)
+	FindByIDs(ids []uuid.UUID, userID uuid.UUID) ([]*models.Tracker, error)
+	Insert(tx *sql.Tx, model *models.Tracker, userID uuid.UUID) error
+	Update(tx *sql.Tx, model *models.Tracker, userID uuid.UUID) error
+	Delete(tx *sql.Tx, id, userID uuid.UUID) error
+	GetLogValues(logIDs []uuid.UUID) ([]*models.TrackerValue, error)
+	SyncLogValues(tx *sql.Tx, daylogID uuid.UUID, values []*models.TrackerValue) error
+}
+
+func NewTrackerRepository(
+	db *sql.DB,
+	logger jsonlog.Logger,
+) *trackerRepository {
+	return &trackerRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func parseTrackerConstraintError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Constraint == "uniq_trackers_name_user" {
+		return e.ValidationAlreadyExists("tracker")
+	}
+
+	return err
+}
+
+func (r *trackerRepository) GetAllByUserID(
+	userID uuid.UUID,
+	f filters.Filters,
+) ([]*models.Tracker, filters.Metadata, error) {
+	query := fmt.Sprintf(`
+	SELECT
+		count(*) OVER(),
+		%s
+	FROM trackers t
+	WHERE
+		t.user_id = :userID
+		AND t.deleted = false
+	ORDER BY
+		t.%s %s,
+		t.id ASC
+	LIMIT :limit
+	OFFSET :offset
+	`, selectColumns(models.Tracker{}, "t"), f.SortColumn(), f.SortDirection())
+
+	params := map[string]any{
+		"userID": userID,
+		"limit":  f.Limit(),
+		"offset": f.Offset(),
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	return paginatedQuery(
+		r.db,
+		query,
+		args,
+		f,
+		func() *models.Tracker {
+			return &models.Tracker{}
+		},
+	)
+}
+
+func (r *trackerRepository) FindByID(id, userID uuid.UUID) (*models.Tracker, error) {
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM trackers t
+	WHERE
+		t.id = :id
+		AND t.user_id = :userID
+		AND t.deleted = false
+	`, selectColumns(models.Tracker{}, "t"))
+
+	params := map[string]any{
+		"id":     id,
+		"userID": userID,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	return getByQuery[models.Tracker](r.db, query, args)
+}
+
+func (r *trackerRepository) FindByIDs(ids []uuid.UUID, userID uuid.UUID) ([]*models.Tracker, error) {
+	query := fmt.Sprintf(`
+	SELECT
+		%s
+	FROM trackers t
+	WHERE
+		t.id = ANY(:trackerIDs::uuid[])
+		AND t.user_id = :userID
+		AND t.deleted = false
+	`, selectColumns(models.Tracker{}, "t"))
+
+	params := map[string]any{
+		"trackerIDs": pq.Array(uuidStrings(ids)),
+		"userID":     userID,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	return listQuery(
+		r.db,
+		query,
+		args,
+		func() *models.Tracker {
+			return &models.Tracker{}
+		},
+	)
+}
+
+func (r *trackerRepository) Insert(tx *sql.Tx, model *models.Tracker, userID uuid.UUID) error {
+	query := `
+	INSERT INTO trackers (
+		name,
+		kind,
+		unit,
+		min_value,
+		max_value,
+		user_id,
+		created_by
+	)
+	VALUES (
+		:name,
+		:kind,
+		:unit,
+		:minValue,
+		:maxValue,
+		:userID,
+		:userID
+	)
+	RETURNING id, created_at, version
+	`
+
+	params := map[string]any{
+		"name":     model.Name,
+		"kind":     model.Kind,
+		"unit":     model.Unit,
+		"minValue": model.MinValue,
+		"maxValue": model.MaxValue,
+		"userID":   userID,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := tx.QueryRowContext(ctx, query, args...).Scan(
+		&model.ID,
+		&model.CreatedAt,
+		&model.Version,
+	)
+	if err != nil {
+		return parseTrackerConstraintError(err)
+	}
+
+	return nil
+}
+
+// Update changes everything but the kind, which is fixed once values were
+// recorded with it.
+func (r *trackerRepository) Update(tx *sql.Tx, model *models.Tracker, userID uuid.UUID) error {
+	query := `
+	UPDATE trackers
+	SET
+		name = :name,
+		unit = :unit,
+		min_value = :minValue,
+		max_value = :maxValue,
+		updated_at = NOW(),
+		updated_by = :userID,
+		version = version + 1
+	WHERE
+		user_id = :userID
+		AND id = :id
+		AND version = :version
+		AND deleted = false
+	RETURNING version
+	`
+
+	params := map[string]any{
+		"id":       model.ID,
+		"name":     model.Name,
+		"unit":     model.Unit,
+		"minValue": model.MinValue,
+		"maxValue": model.MaxValue,
+		"userID":   userID,
+		"version":  model.Version,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&model.Version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return e.ErrEditConflict
+		}
+		return parseTrackerConstraintError(err)
+	}
+
+	return nil
+}
+
+func (r *trackerRepository) Delete(tx *sql.Tx, id, userID uuid.UUID) error {
+	query := `
+	UPDATE trackers
+	SET
+		deleted = true,
+		updated_at = NOW(),
+		updated_by = :userID,
+		version = version + 1
+	WHERE
+		user_id = :userID
+		AND id = :id
+		AND deleted = false
+	`
+
+	params := map[string]any{
+		"id":     id,
+		"userID": userID,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return e.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetLogValues returns the values recorded on the given logs for trackers
+// that were not deleted, ordered by tracker name.
+func (r *trackerRepository) GetLogValues(logIDs []uuid.UUID) ([]*models.TrackerValue, error) {
+	query := `
+	SELECT
+		v.log_id,
+		v.tracker_id,
+		t.name,
+		t.kind,
+		t.unit,
+		v.value
+	FROM log_tracker_values v
+	JOIN trackers t ON t.id = v.tracker_id AND t.deleted = false
+	WHERE
+		v.log_id = ANY(:logIDs::uuid[])
+	ORDER BY
+		v.log_id,
+		lower(t.name)
+	`
+
+	params := map[string]any{
+		"logIDs": pq.Array(uuidStrings(logIDs)),
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	return listQuery(
+		r.db,
+		query,
+		args,
+		func() *models.TrackerValue {
+			return &models.TrackerValue{}
+		},
+	)
+}
+
+// SyncLogValues replaces the tracker values of a log with values. As with
+// tags, values of deleted trackers are left alone.
+func (r *trackerRepository) SyncLogValues(tx *sql.Tx, daylogID uuid.UUID, values []*models.TrackerValue) error {
+	ids := make([]string, 0, len(values))
+	nums := make([]float64, 0, len(values))
+	for _, value := range values {
+		ids = append(ids, value.TrackerID.String())
+		nums = append(nums, value.Value)
+	}
+
+	params := map[string]any{
+		"logID":      daylogID,
+		"trackerIDs": pq.Array(ids),
+		"values":     pq.Array(nums),
+	}
+
+	deleteQuery := `
+	delete from log_tracker_values
+	where
+		log_id = :logID
+		and tracker_id <> ALL(:trackerIDs::uuid[])
+		and tracker_id in (select id from trackers where deleted = false)
+	`
+
+	upsertQuery := `
+	insert into log_tracker_values (
+		log_id,
+		tracker_id,
+		value
+	)
+	select :logID, v.tracker_id, v.value
+	from unnest(:trackerIDs::uuid[], :values::float8[]) as v(tracker_id, value)
+	on conflict (log_id, tracker_id) do update set
+		value = excluded.value
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	for _, query := range []string{deleteQuery, upsertQuery} {
+		query, args := namedQuery(query, params)
+		r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func uuidStrings(ids []uuid.UUID) []string {
+	strs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		strs = append(strs, id.String())
+	}
+	return strs
+}
@@ -0,0 +1,189 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"moodtracker/internal/jsonlog"
+	"moodtracker/internal/models"
+	"moodtracker/internal/models/filters"
+	"moodtracker/utils"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type trashRepository struct {
+	db     *sql.DB
+	logger jsonlog.Logger
+}
+
+type TrashRepository interface {
+	GetAll(
+		userID uuid.UUID,
+		itemType string,
+		retentionDays int,
+		f filters.Filters,
+	) ([]*models.TrashItem, filters.Metadata, error)
+	PurgeDaylogs(before time.Time, limit int) (int64, error)
+	PurgeTags(before time.Time, limit int) (int64, error)
+}
+
+func NewTrashRepository(
+	db *sql.DB,
+	logger jsonlog.Logger,
+) *trashRepository {
+	return &trashRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *trashRepository) GetAll(
+	userID uuid.UUID,
+	itemType string,
+	retentionDays int,
+	f filters.Filters,
+) ([]*models.TrashItem, filters.Metadata, error) {
+	query := fmt.Sprintf(`
+	SELECT
+		count(*) OVER(),
+		trash.type,
+		trash.id,
+		trash.deleted_at,
+		CASE
+			WHEN :retentionDays::int > 0
+			THEN trash.deleted_at + make_interval(days => :retentionDays::int)
+		END AS purge_at,
+		trash.name,
+		trash.date,
+		trash.description,
+		trash.mood_label,
+		trash.logged_at,
+		trash.tags
+	FROM (
+		SELECT
+			'day_log'::text AS type,
+			dl.id,
+			dl.deleted_at,
+			NULL AS name,
+			dl.date,
+			dl.description,
+			dl.mood_label,
+			dl.logged_at,
+			(
+				SELECT COALESCE(ARRAY_AGG(t.name ORDER BY t.name), '{}')
+				FROM log_tags lt
+				JOIN tags t ON t.id = lt.tag_id
+				WHERE lt.log_id = dl.id
+			) AS tags
+		FROM day_logs dl
+		WHERE
+			dl.user_id = :userID
+			AND dl.deleted = true
+
+		UNION ALL
+
+		SELECT
+			'tag',
+			t.id,
+			t.deleted_at,
+			t.name,
+			NULL,
+			NULL,
+			NULL,
+			NULL,
+			NULL
+		FROM tags t
+		WHERE
+			t.user_id = :userID
+			AND t.deleted = true
+	) trash
+	WHERE
+		(:itemType::text = '' OR trash.type = :itemType::text)
+	ORDER BY
+		trash.%s %s,
+		trash.id ASC
+	LIMIT :limit
+	OFFSET :offset
+	`, f.SortColumn(), f.SortDirection())
+
+	params := map[string]any{
+		"userID":        userID,
+		"itemType":      itemType,
+		"retentionDays": retentionDays,
+		"limit":         f.Limit(),
+		"offset":        f.Offset(),
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	return paginatedQuery(
+		r.db,
+		query,
+		args,
+		f,
+		func() *models.TrashItem {
+			return &models.TrashItem{}
+		},
+	)
+}
+
+// PurgeDaylogs permanently deletes up to limit day logs that were moved to
+// the trash before the given time. Their tag links go with them. Logs that
+// still have attachments wait until the files are removed.
+func (r *trashRepository) PurgeDaylogs(before time.Time, limit int) (int64, error) {
+	query := `
+	DELETE FROM day_logs
+	WHERE id IN (
+		SELECT id
+		FROM day_logs
+		WHERE
+			deleted = true
+			AND deleted_at < :before
+			AND NOT EXISTS (SELECT 1 FROM attachments a WHERE a.log_id = day_logs.id)
+		LIMIT :limit
+	)
+	`
+
+	return r.purge(query, before, limit)
+}
+
+// PurgeTags permanently deletes up to limit tags that were moved to the trash
+// before the given time.
+func (r *trashRepository) PurgeTags(before time.Time, limit int) (int64, error) {
+	query := `
+	DELETE FROM tags
+	WHERE id IN (
+		SELECT id
+		FROM tags
+		WHERE
+			deleted = true
+			AND deleted_at < :before
+		LIMIT :limit
+	)
+	`
+
+	return r.purge(query, before, limit)
+}
+
+func (r *trashRepository) purge(query string, before time.Time, limit int) (int64, error) {
+	params := map[string]any{
+		"before": before,
+		"limit":  limit,
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"moodtracker/internal/jsonlog"
 	"moodtracker/internal/models"
+	"moodtracker/internal/models/filters"
 	"moodtracker/utils"
 	e "moodtracker/utils/errors"
 	"strings"
@@ -22,12 +23,24 @@ type UserRepository struct {
 }
 
 type UserRepositoryInterface interface {
+	GetAll(
+		search string,
+		activated, deleted *bool,
+		f filters.Filters,
+	) ([]*models.User, filters.Metadata, error)
 	GetByCodAndEmail(cod int, email string) (*models.User, error)
 	GetByID(id uuid.UUID) (*models.User, error)
 	GetByEmail(email string) (*models.User, error)
+	GetForToken(scope string, tokenHash []byte) (*models.User, error)
 	Insert(tx *sql.Tx, user *models.User) error
 	UpdateCodByEmail(tx *sql.Tx, user *models.User) error
+	IncrementCodAttempts(tx *sql.Tx, user *models.User, maxAttempts int) error
+	UseTOTPCounter(tx *sql.Tx, userID uuid.UUID, counter int64) error
+	UpgradePasswordHash(tx *sql.Tx, user *models.User, previousHash []byte) error
+	RevokeTokens(tx *sql.Tx, userID uuid.UUID, revokedAt time.Time) error
 	Update(tx *sql.Tx, user *models.User) error
+	SetActivated(tx *sql.Tx, id uuid.UUID, activated bool) error
+	Restore(tx *sql.Tx, id uuid.UUID) error
 	Delete(tx *sql.Tx, idUser uuid.UUID) error
 }
 
@@ -53,15 +66,84 @@ func parseUserConstraintError(err error) error {
 	return err
 }
 
+func (r *UserRepository) GetAll(
+	search string,
+	activated, deleted *bool,
+	f filters.Filters,
+) ([]*models.User, filters.Metadata, error) {
+	cols := selectColumns(models.User{}, "u")
+
+	query := fmt.Sprintf(`
+	SELECT
+		count(*) OVER(),
+		%s
+	FROM users u
+	WHERE
+		(
+			:search = ''
+			OR u.name ILIKE '%%' || :search || '%%'
+			OR u.email ILIKE '%%' || :search || '%%'
+			OR u.phone ILIKE '%%' || :search || '%%'
+		)
+		AND (:activated::boolean IS NULL OR u.activated = :activated::boolean)
+		AND (:deleted::boolean IS NULL OR u.deleted = :deleted::boolean)
+	ORDER BY
+		u.%s %s,
+		u.id ASC
+	LIMIT :limit
+	OFFSET :offset
+	`, cols,
+		f.SortColumn(),
+		f.SortDirection(),
+	)
+
+	params := map[string]any{
+		"search":    search,
+		"activated": nullBool(activated),
+		"deleted":   nullBool(deleted),
+		"limit":     f.Limit(),
+		"offset":    f.Offset(),
+	}
+
+	query, args := namedQuery(query, params)
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	return paginatedQuery(
+		r.db,
+		query,
+		args,
+		f,
+		func() *models.User {
+			return &models.User{}
+		},
+	)
+}
+
+func nullBool(b *bool) sql.NullBool {
+	if b == nil {
+		return sql.NullBool{}
+	}
+
+	return sql.NullBool{
+		Bool:  *b,
+		Valid: true,
+	}
+}
+
 func (r *UserRepository) GetByCodAndEmail(cod int, email string) (*models.User, error) {
-	query := `
-	select u.* 
+	cols := strings.Join([]string{
+		selectColumns(models.User{}, "u"),
+	}, ", ")
+
+	query := fmt.Sprintf(`
+	select 
+		%s
 	from users u
 	WHERE
 		email = :email
 		AND deleted = false
 		AND cod = :cod
-	`
+	`, cols)
 	params := map[string]any{
 		"email": email,
 		"cod":   cod,
@@ -118,10 +200,38 @@ func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 	return getByQuery[models.User](r.db, query, args)
 }
 
+func (r *UserRepository) GetForToken(scope string, tokenHash []byte) (*models.User, error) {
+	cols := strings.Join([]string{
+		selectColumns(models.User{}, "u"),
+	}, ", ")
+
+	query := fmt.Sprintf(`
+	select 
+		%s
+	from users u
+	INNER JOIN tokens t ON t.user_id = u.id
+	WHERE
+		t.hash = $1
+		AND t.scope = $2
+		AND t.expiry > $3
+		AND u.deleted = false
+	`, cols)
+
+	args := []any{
+		tokenHash,
+		scope,
+		time.Now(),
+	}
+
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	return getByQuery[models.User](r.db, query, args)
+}
+
 func (r *UserRepository) Insert(tx *sql.Tx, user *models.User) error {
 	query := `
-	INSERT INTO users (name, email, phone,cod, password_hash, activated,deleted)
-	VALUES ($1, $2, $3, $4, $5, $6,false)
+	INSERT INTO users (name, email, phone,cod, password_hash, password_algorithm, activated, locale, time_zone, cod_issued_at, deleted)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, false)
 	RETURNING id, created_at, version
 	`
 	args := []any{
@@ -130,7 +240,11 @@ func (r *UserRepository) Insert(tx *sql.Tx, user *models.User) error {
 		user.Phone,
 		user.Cod,
 		user.Password.Hash,
+		user.Password.Algorithm,
 		user.Activated,
+		user.Locale,
+		user.TimeZone,
+		user.CodIssuedAt,
 	}
 
 	r.logger.PrintInfo(utils.MinifySQL(query), nil)
@@ -158,23 +272,27 @@ func (r *UserRepository) Insert(tx *sql.Tx, user *models.User) error {
 func (r *UserRepository) UpdateCodByEmail(tx *sql.Tx, user *models.User) error {
 	query := `
 	UPDATE users SET
-	cod = $1
-	WHERE id = $2 AND version = $3
-	RETURNING version`
+		cod = $1,
+		cod_issued_at = $2,
+		cod_attempts = 0,
+		version = version + 1
+	WHERE id = $3 AND version = $4
+	RETURNING cod_attempts, version`
 
 	r.logger.PrintInfo(utils.MinifySQL(query), nil)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	err := tx.QueryRowContext(ctx, query, user.Cod, user.ID, user.Version).Scan(
+	err := tx.QueryRowContext(ctx, query, user.Cod, user.CodIssuedAt, user.ID, user.Version).Scan(
+		&user.CodAttempts,
 		&user.Version,
 	)
 
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
-			return e.ErrRecordNotFound
+			return e.ErrEditConflict
 		default:
 			return err
 		}
@@ -183,6 +301,128 @@ func (r *UserRepository) UpdateCodByEmail(tx *sql.Tx, user *models.User) error {
 
 }
 
+func (r *UserRepository) IncrementCodAttempts(tx *sql.Tx, user *models.User, maxAttempts int) error {
+	query := `
+	UPDATE users SET
+		cod_attempts = cod_attempts + 1,
+		version = version + 1
+	WHERE
+		id = $1
+		AND deleted = false
+		AND cod_attempts < $2
+	RETURNING cod_attempts, version`
+
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := tx.QueryRowContext(ctx, query, user.ID, maxAttempts).Scan(
+		&user.CodAttempts,
+		&user.Version,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return e.ErrTooManyAttempts
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// UseTOTPCounter records the step of an accepted TOTP code. It fails when the
+// same or a later step was already used, so a code can never be replayed.
+func (r *UserRepository) UseTOTPCounter(tx *sql.Tx, userID uuid.UUID, counter int64) error {
+	query := `
+	UPDATE users SET
+		totp_last_counter = $1
+	WHERE
+		id = $2
+		AND deleted = false
+		AND totp_last_counter < $1`
+
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := tx.ExecContext(ctx, query, counter, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return e.ErrInvalidCredentials
+	}
+
+	return nil
+}
+
+// UpgradePasswordHash replaces the stored hash without bumping the version, so
+// a transparent re-hash never conflicts with a client editing the profile. It
+// does nothing if the password changed since previousHash was read.
+func (r *UserRepository) UpgradePasswordHash(tx *sql.Tx, user *models.User, previousHash []byte) error {
+	query := `
+	UPDATE users SET
+		password_hash = $1,
+		password_algorithm = $2
+	WHERE
+		id = $3
+		AND password_hash = $4`
+
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		user.Password.Hash,
+		user.Password.Algorithm,
+		user.ID,
+		previousHash,
+	)
+	return err
+}
+
+func (r *UserRepository) RevokeTokens(tx *sql.Tx, userID uuid.UUID, revokedAt time.Time) error {
+	query := `
+	UPDATE users SET
+		tokens_revoked_at = $1,
+		version = version + 1
+	WHERE id = $2 AND deleted = false`
+
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := tx.ExecContext(ctx, query, revokedAt, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return e.ErrRecordNotFound
+	}
+
+	return nil
+}
+
 func (r *UserRepository) Update(tx *sql.Tx, user *models.User) error {
 	query := `
 	UPDATE users SET
@@ -192,10 +432,20 @@ func (r *UserRepository) Update(tx *sql.Tx, user *models.User) error {
 		phone = $4,
 		password_hash = $5,
 		activated = $6,
+		locale = $7,
+		cod_issued_at = $8,
+		cod_attempts = $9,
+		password_changed_at = $10,
+		tokens_revoked_at = $11,
+		pending_email = $12,
+		totp_secret = $13,
+		totp_enabled = $14,
+		password_algorithm = $15,
+		time_zone = $16,
 		version = version + 1
 	WHERE
-		id = $7
-		AND version = $8
+		id = $17
+		AND version = $18
 	RETURNING version`
 
 	args := []any{
@@ -205,6 +455,16 @@ func (r *UserRepository) Update(tx *sql.Tx, user *models.User) error {
 		user.Phone,
 		user.Password.Hash,
 		user.Activated,
+		user.Locale,
+		user.CodIssuedAt,
+		user.CodAttempts,
+		user.PasswordChangedAt,
+		user.TokensRevokedAt,
+		user.PendingEmail,
+		user.TOTPSecret,
+		user.TOTPEnabled,
+		user.Password.Algorithm,
+		user.TimeZone,
 		user.ID,
 		user.Version,
 	}
@@ -228,6 +488,65 @@ func (r *UserRepository) Update(tx *sql.Tx, user *models.User) error {
 	return nil
 }
 
+func (r *UserRepository) SetActivated(tx *sql.Tx, id uuid.UUID, activated bool) error {
+	query := `
+	UPDATE users SET
+		activated = $1,
+		tokens_revoked_at = CASE WHEN $1 THEN tokens_revoked_at ELSE NOW() END,
+		version = version + 1
+	WHERE id = $2 AND deleted = false`
+
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := tx.ExecContext(ctx, query, activated, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return e.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepository) Restore(tx *sql.Tx, id uuid.UUID) error {
+	query := `
+	UPDATE users SET
+		deleted = false,
+		version = version + 1
+	WHERE id = $1 AND deleted = true`
+
+	r.logger.PrintInfo(utils.MinifySQL(query), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return e.ErrRecordNotFound
+	}
+
+	return nil
+}
+
 func (r *UserRepository) Delete(tx *sql.Tx, idUser uuid.UUID) error {
 	query := `
 	UPDATE users 
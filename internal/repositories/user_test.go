@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"errors"
+	e "moodtracker/utils/errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestUseTOTPCounter(t *testing.T) {
+	tests := []struct {
+		name         string
+		rowsAffected int64
+		err          error
+	}{
+		{"later step", 1, nil},
+		{"same or older step", 0, e.ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, d := newTestTx(t, tt.rowsAffected)
+			r := NewUserRepository(nil, newTestLogger())
+			userID := uuid.New()
+
+			if err := r.UseTOTPCounter(tx, userID, 42); !errors.Is(err, tt.err) {
+				t.Fatalf("UseTOTPCounter returned %v, want %v", err, tt.err)
+			}
+
+			if len(d.queries) != 1 || !strings.Contains(d.queries[0], "totp_last_counter < $1") {
+				t.Fatalf("the update is not guarded by the last counter: %q", d.queries)
+			}
+
+			if d.args[0][0] != int64(42) {
+				t.Errorf("counter argument = %v, want 42", d.args[0][0])
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package routers
+
+import (
+	"moodtracker/internal/handlers"
+	"moodtracker/internal/middleware"
+
+	"github.com/go-chi/chi"
+)
+
+type accessTokenRouter struct {
+	accessToken handlers.AccessTokenHandler
+	m           middleware.MiddlewareInterface
+}
+
+type AccessTokenRouter interface {
+	AccessTokenRoutes(r chi.Router)
+}
+
+func NewAccessTokenRouter(
+	accessToken handlers.AccessTokenHandler,
+	m middleware.MiddlewareInterface,
+) *accessTokenRouter {
+	return &accessTokenRouter{
+		accessToken: accessToken,
+		m:           m,
+	}
+}
+
+func (a *accessTokenRouter) AccessTokenRoutes(router chi.Router) {
+	router.Route("/access_tokens", func(router chi.Router) {
+		router.Use(a.m.RequireActivatedUser)
+
+		router.Get("/", a.accessToken.GetAll)
+		router.Post("/", a.accessToken.Create)
+		router.Delete("/{id}", a.accessToken.Revoke)
+	})
+}
@@ -0,0 +1,43 @@
+package routers
+
+import (
+	"moodtracker/internal/handlers"
+	"moodtracker/internal/middleware"
+	"moodtracker/internal/models"
+
+	"github.com/go-chi/chi"
+)
+
+type adminRouter struct {
+	admin handlers.AdminHandler
+	m     middleware.MiddlewareInterface
+}
+
+type AdminRouter interface {
+	AdminRoutes(r chi.Router)
+}
+
+func NewAdminRouter(
+	admin handlers.AdminHandler,
+	m middleware.MiddlewareInterface,
+) *adminRouter {
+	return &adminRouter{
+		admin: admin,
+		m:     m,
+	}
+}
+
+func (a *adminRouter) AdminRoutes(router chi.Router) {
+	router.Route("/admin/users", func(router chi.Router) {
+		router.With(a.m.RequirePermission(models.PermissionUsersRead)).
+			Get("/", a.admin.ListUsers)
+
+		router.Group(func(router chi.Router) {
+			router.Use(a.m.RequirePermission(models.PermissionUsersWrite))
+
+			router.Put("/{id}/activate", a.admin.ActivateUser)
+			router.Put("/{id}/deactivate", a.admin.DeactivateUser)
+			router.Put("/{id}/restore", a.admin.RestoreUser)
+		})
+	})
+}
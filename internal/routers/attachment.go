@@ -0,0 +1,29 @@
+package routers
+
+import (
+	"moodtracker/internal/handlers"
+
+	"github.com/go-chi/chi"
+)
+
+type attachmentRouter struct {
+	attachment handlers.AttachmentHandler
+}
+
+type AttachmentRouter interface {
+	AttachmentRoutes(r chi.Router)
+}
+
+func NewAttachmentRouter(
+	attachment handlers.AttachmentHandler,
+) *attachmentRouter {
+	return &attachmentRouter{
+		attachment: attachment,
+	}
+}
+
+// AttachmentRoutes only serves signed download URLs; managing attachments
+// happens under /day_logs/{id}/attachments.
+func (r *attachmentRouter) AttachmentRoutes(router chi.Router) {
+	router.Get("/attachments/{id}/content", r.attachment.Content)
+}
@@ -2,26 +2,58 @@ package routers
 
 import (
 	"moodtracker/internal/handlers"
+	"moodtracker/internal/middleware"
 
 	"github.com/go-chi/chi"
 )
 
 type AuthRouter struct {
 	Auth handlers.AuthHandlerInterface
+	MFA  handlers.MFAHandler
+	m    middleware.MiddlewareInterface
 }
 
 type AuthRoutesInterface interface {
 	AuthRoutes(r chi.Router)
 }
 
-func NewAuthRouter(authHandler handlers.AuthHandlerInterface) *AuthRouter {
+func NewAuthRouter(
+	authHandler handlers.AuthHandlerInterface,
+	mfaHandler handlers.MFAHandler,
+	m middleware.MiddlewareInterface,
+) *AuthRouter {
 	return &AuthRouter{
 		Auth: authHandler,
+		MFA:  mfaHandler,
+		m:    m,
 	}
 }
 
 func (a *AuthRouter) AuthRoutes(r chi.Router) {
+	r.Get("/.well-known/jwks.json", a.Auth.JWKSHandler)
+
 	r.Route("/auth", func(r chi.Router) {
 		r.Post("/login", a.Auth.LoginHandler)
+		r.Post("/refresh", a.Auth.RefreshHandler)
+		r.Post("/mfa", a.Auth.VerifyMFAHandler)
+		r.Get("/oidc/login", a.Auth.OIDCLoginHandler)
+		r.Get("/oidc/callback", a.Auth.OIDCCallbackHandler)
+
+		r.Group(func(r chi.Router) {
+			r.Use(a.m.RequireAuthenticatedUser)
+
+			r.Post("/logout", a.Auth.LogoutHandler)
+			r.Post("/logout-all", a.Auth.LogoutAllHandler)
+			r.Get("/sign_ins", a.Auth.SignInsHandler)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(a.m.RequireActivatedUser)
+
+			r.Post("/mfa/totp", a.MFA.EnrollTOTP)
+			r.Post("/mfa/totp/confirm", a.MFA.ConfirmTOTP)
+			r.Post("/mfa/totp/disable", a.MFA.DisableTOTP)
+			r.Post("/mfa/recovery-codes", a.MFA.RegenerateRecoveryCodes)
+		})
 	})
 }
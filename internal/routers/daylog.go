@@ -3,13 +3,15 @@ package routers
 import (
 	"moodtracker/internal/handlers"
 	"moodtracker/internal/middleware"
+	"moodtracker/internal/models"
 
 	"github.com/go-chi/chi"
 )
 
 type daylogRouter struct {
-	daylog handlers.DaylogHandler
-	m      middleware.MiddlewareInterface
+	daylog     handlers.DaylogHandler
+	attachment handlers.AttachmentHandler
+	m          middleware.MiddlewareInterface
 }
 
 type DaylogRouter interface {
@@ -18,23 +20,43 @@ type DaylogRouter interface {
 
 func NewDaylogRouter(
 	daylog handlers.DaylogHandler,
+	attachment handlers.AttachmentHandler,
 	m middleware.MiddlewareInterface,
 
 ) *daylogRouter {
 	return &daylogRouter{
-		daylog: daylog,
-		m:      m,
+		daylog:     daylog,
+		attachment: attachment,
+		m:          m,
 	}
 }
 
 func (r *daylogRouter) DaylogRoutes(router chi.Router) {
 	router.Route("/day_logs", func(router chi.Router) {
-		router.Use(r.m.RequireActivatedUser)
-
-		router.Get("/{id}", r.daylog.FindByID)
-		router.Get("/year", r.daylog.GetAllByYear)
-		router.Post("/", r.daylog.Save)
-		router.Put("/", r.daylog.Update)
-		router.Delete("/{id}", r.daylog.Delete)
+		router.Group(func(router chi.Router) {
+			router.Use(r.m.RequireScope(models.ScopeDaylogsRead))
+
+			router.Get("/", r.daylog.GetAll)
+			router.Get("/{id}", r.daylog.FindByID)
+			router.Get("/year", r.daylog.GetAllByYear)
+			router.Get("/moods", r.daylog.GetMoodScale)
+			router.Get("/daily", r.daylog.GetDailySummaries)
+			router.Get("/{id}/revisions", r.daylog.GetRevisions)
+			router.Get("/{id}/revisions/diff", r.daylog.DiffRevisions)
+			router.Get("/{id}/attachments", r.attachment.GetAll)
+			router.Get("/{id}/attachments/{attachmentID}", r.attachment.FindByID)
+		})
+
+		router.Group(func(router chi.Router) {
+			router.Use(r.m.RequireScope(models.ScopeDaylogsWrite))
+
+			router.Post("/", r.daylog.Save)
+			router.Put("/", r.daylog.Update)
+			router.Patch("/{id}", r.daylog.Patch)
+			router.Post("/{id}/revisions/{version}/revert", r.daylog.RevertToRevision)
+			router.Delete("/{id}", r.daylog.Delete)
+			router.Post("/{id}/attachments", r.attachment.Upload)
+			router.Delete("/{id}/attachments/{attachmentID}", r.attachment.Delete)
+		})
 	})
 }
@@ -0,0 +1,40 @@
+package routers
+
+import (
+	"moodtracker/internal/handlers"
+	"moodtracker/internal/middleware"
+
+	"github.com/go-chi/chi"
+)
+
+type reminderRouter struct {
+	reminder handlers.ReminderHandler
+	m        middleware.MiddlewareInterface
+}
+
+type ReminderRouter interface {
+	ReminderRoutes(r chi.Router)
+}
+
+func NewReminderRouter(
+	reminder handlers.ReminderHandler,
+	m middleware.MiddlewareInterface,
+) *reminderRouter {
+	return &reminderRouter{
+		reminder: reminder,
+		m:        m,
+	}
+}
+
+func (r *reminderRouter) ReminderRoutes(router chi.Router) {
+	router.Route("/reminders", func(router chi.Router) {
+		router.Use(r.m.RequireActivatedUser)
+
+		router.Get("/", r.reminder.GetAllByUserID)
+		router.Get("/{id}", r.reminder.FindByID)
+		router.Post("/", r.reminder.Save)
+		router.Put("/", r.reminder.Update)
+		router.Patch("/{id}", r.reminder.Patch)
+		router.Delete("/{id}", r.reminder.Delete)
+	})
+}
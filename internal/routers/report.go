@@ -3,6 +3,7 @@ package routers
 import (
 	"moodtracker/internal/handlers"
 	"moodtracker/internal/middleware"
+	"moodtracker/internal/models"
 
 	"github.com/go-chi/chi"
 )
@@ -29,11 +30,12 @@ func NewReportRouter(
 
 func (r *reportRouter) ReportRoutes(router chi.Router) {
 	router.Route("/reports", func(router chi.Router) {
-		router.Use(r.m.RequireActivatedUser)
+		router.Use(r.m.RequireScope(models.ScopeReportsRead))
 
 		router.Get("/monthly", r.report.GetMonthlyReport)
 		router.Get("/tag", r.report.GetTagReport)
 		router.Get("/mood", r.report.GetMoodReport)
+		router.Get("/tracker", r.report.GetTrackerReport)
 
 	})
 }
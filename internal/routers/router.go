@@ -3,47 +3,73 @@ package routers
 import (
 	"database/sql"
 	"expvar"
+	"moodtracker/internal/blob"
 	"moodtracker/internal/config"
 	"moodtracker/internal/handlers"
 	"moodtracker/internal/jsonlog"
+	"moodtracker/internal/mailer"
 	"moodtracker/internal/middleware"
+	"moodtracker/internal/models"
+	"moodtracker/internal/notify"
+	"moodtracker/internal/passwords"
+	"moodtracker/internal/signing"
 	"moodtracker/utils/errors"
 	"net/http"
+	"sync"
 
 	"github.com/go-chi/chi"
 )
 
 type Router struct {
-	errResp errors.ErrorHandlerInterface
-	m       middleware.MiddlewareInterface
-	user    UserRoutesInterface
-	auth    AuthRoutesInterface
-	tag     TagRouter
-	daylog  DaylogRouter
-	report  ReportRouter
+	errResp     errors.ErrorHandlerInterface
+	m           middleware.MiddlewareInterface
+	user        UserRoutesInterface
+	auth        AuthRoutesInterface
+	tag         TagRouter
+	daylog      DaylogRouter
+	report      ReportRouter
+	admin       AdminRouter
+	accessToken AccessTokenRouter
+	trash       TrashRouter
+	tracker     TrackerRouter
+	attachment  AttachmentRouter
+	reminder    ReminderRouter
 }
 
 func NewRouter(
 	db *sql.DB,
 	logger jsonlog.Logger,
 	config config.Config,
+	mailer mailer.Mailer,
+	store blob.Store,
+	channels notify.Channels,
+	keys *signing.KeySet,
+	policy *passwords.Policy,
+	wg *sync.WaitGroup,
 ) *Router {
 	e := errors.NewErrorHandler(logger)
-	h := handlers.NewHandler(db, e, config, logger)
+	h := handlers.NewHandler(db, e, config, logger, mailer, store, channels, keys, policy, wg)
 	m := middleware.New(
 		e,
 		h.Service.User,
 		h.Service.Auth,
+		h.Service.AccessToken,
 		config,
 	)
 	return &Router{
-		errResp: e,
-		m:       m,
-		user:    NewUserRouter(h.User),
-		auth:    NewAuthRouter(h.Auth),
-		tag:     NewTagRouter(h.Tag, m),
-		daylog:  NewDaylogRouter(h.Daylog, m),
-		report:  NewReportRouter(h.Report, m),
+		errResp:     e,
+		m:           m,
+		user:        NewUserRouter(h.User, m),
+		auth:        NewAuthRouter(h.Auth, h.MFA, m),
+		tag:         NewTagRouter(h.Tag, m),
+		daylog:      NewDaylogRouter(h.Daylog, h.Attachment, m),
+		report:      NewReportRouter(h.Report, m),
+		admin:       NewAdminRouter(h.Admin, m),
+		accessToken: NewAccessTokenRouter(h.AccessToken, m),
+		trash:       NewTrashRouter(h.Trash, m),
+		tracker:     NewTrackerRouter(h.Tracker, m),
+		attachment:  NewAttachmentRouter(h.Attachment),
+		reminder:    NewReminderRouter(h.Reminder, m),
 	}
 }
 
@@ -64,12 +90,19 @@ func (router *Router) RegisterRoutes() *chi.Mux {
 	})
 
 	r.Route("/v1", func(r chi.Router) {
-		r.Mount("/debug/vars", expvar.Handler())
+		r.With(router.m.RequirePermission(models.PermissionMetricsRead)).
+			Mount("/debug/vars", expvar.Handler())
 		router.user.UserRoutes(r)
 		router.auth.AuthRoutes(r)
 		router.daylog.DaylogRoutes(r)
 		router.tag.TagRoutes(r)
 		router.report.ReportRoutes(r)
+		router.admin.AdminRoutes(r)
+		router.accessToken.AccessTokenRoutes(r)
+		router.trash.TrashRoutes(r)
+		router.tracker.TrackerRoutes(r)
+		router.attachment.AttachmentRoutes(r)
+		router.reminder.ReminderRoutes(r)
 	})
 
 	return r
@@ -35,6 +35,7 @@ func (r *tagRouter) TagRoutes(router chi.Router) {
 		router.Get("/user/{id}", r.tag.GetAllByUserID)
 		router.Post("/", r.tag.Save)
 		router.Put("/", r.tag.Update)
+		router.Patch("/{id}", r.tag.Patch)
 		router.Delete("/{id}", r.tag.Delete)
 	})
 }
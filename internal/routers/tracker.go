@@ -0,0 +1,48 @@
+package routers
+
+import (
+	"moodtracker/internal/handlers"
+	"moodtracker/internal/middleware"
+	"moodtracker/internal/models"
+
+	"github.com/go-chi/chi"
+)
+
+type trackerRouter struct {
+	tracker handlers.TrackerHandler
+	m       middleware.MiddlewareInterface
+}
+
+type TrackerRouter interface {
+	TrackerRoutes(r chi.Router)
+}
+
+func NewTrackerRouter(
+	tracker handlers.TrackerHandler,
+	m middleware.MiddlewareInterface,
+) *trackerRouter {
+	return &trackerRouter{
+		tracker: tracker,
+		m:       m,
+	}
+}
+
+func (r *trackerRouter) TrackerRoutes(router chi.Router) {
+	router.Route("/trackers", func(router chi.Router) {
+		router.Group(func(router chi.Router) {
+			router.Use(r.m.RequireScope(models.ScopeDaylogsRead))
+
+			router.Get("/", r.tracker.GetAllByUserID)
+			router.Get("/{id}", r.tracker.FindByID)
+		})
+
+		router.Group(func(router chi.Router) {
+			router.Use(r.m.RequireScope(models.ScopeDaylogsWrite))
+
+			router.Post("/", r.tracker.Save)
+			router.Put("/", r.tracker.Update)
+			router.Patch("/{id}", r.tracker.Patch)
+			router.Delete("/{id}", r.tracker.Delete)
+		})
+	})
+}
@@ -0,0 +1,36 @@
+package routers
+
+import (
+	"moodtracker/internal/handlers"
+	"moodtracker/internal/middleware"
+	"moodtracker/internal/models"
+
+	"github.com/go-chi/chi"
+)
+
+type trashRouter struct {
+	trash handlers.TrashHandler
+	m     middleware.MiddlewareInterface
+}
+
+type TrashRouter interface {
+	TrashRoutes(r chi.Router)
+}
+
+func NewTrashRouter(
+	trash handlers.TrashHandler,
+	m middleware.MiddlewareInterface,
+) *trashRouter {
+	return &trashRouter{
+		trash: trash,
+		m:     m,
+	}
+}
+
+func (r *trashRouter) TrashRoutes(router chi.Router) {
+	router.Route("/trash", func(router chi.Router) {
+		router.With(r.m.RequireScope(models.ScopeDaylogsRead)).Get("/", r.trash.GetAll)
+		router.With(r.m.RequireScope(models.ScopeDaylogsWrite)).Put("/day_logs/{id}/restore", r.trash.RestoreDaylog)
+		router.With(r.m.RequireActivatedUser).Put("/tags/{id}/restore", r.trash.RestoreTag)
+	})
+}
@@ -2,17 +2,23 @@ package routers
 
 import (
 	"moodtracker/internal/handlers"
+	"moodtracker/internal/middleware"
 
 	"github.com/go-chi/chi"
 )
 
 type UserRouter struct {
 	User handlers.UserHandlerInterface
+	m    middleware.MiddlewareInterface
 }
 
-func NewUserRouter(userHandler handlers.UserHandlerInterface) *UserRouter {
+func NewUserRouter(
+	userHandler handlers.UserHandlerInterface,
+	m middleware.MiddlewareInterface,
+) *UserRouter {
 	return &UserRouter{
 		User: userHandler,
+		m:    m,
 	}
 }
 
@@ -23,6 +29,20 @@ type UserRoutesInterface interface {
 func (u *UserRouter) UserRoutes(r chi.Router) {
 	r.Route("/users", func(r chi.Router) {
 		r.Post("/activate", u.User.ActivateUserHandler)
+		r.Post("/activate/resend", u.User.ResendActivationHandler)
 		r.Post("/", u.User.CreateUserHandler)
+		r.Post("/password-reset", u.User.RequestPasswordResetHandler)
+		r.Put("/password", u.User.ResetPasswordHandler)
+		r.Post("/email/confirm", u.User.ConfirmEmailChangeHandler)
+
+		r.Route("/me", func(r chi.Router) {
+			r.Use(u.m.RequireActivatedUser)
+
+			r.Get("/", u.User.GetMeHandler)
+			r.Patch("/", u.User.UpdateMeHandler)
+			r.Delete("/", u.User.DeleteMeHandler)
+			r.Put("/password", u.User.ChangePasswordHandler)
+			r.Post("/email", u.User.RequestEmailChangeHandler)
+		})
 	})
 }
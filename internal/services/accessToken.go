@@ -0,0 +1,81 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"moodtracker/internal/models"
+	"moodtracker/internal/repositories"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type accessTokenService struct {
+	accessToken repositories.AccessTokenRepository
+	db          *sql.DB
+}
+
+type AccessTokenService interface {
+	GetAllByUserID(userID uuid.UUID) ([]*models.PersonalAccessToken, error)
+	Create(token *models.PersonalAccessToken, v *validator.Validator) error
+	Revoke(id, userID uuid.UUID) error
+	Authenticate(plaintext string) (*models.PersonalAccessToken, error)
+}
+
+func NewAccessTokenService(
+	accessTokenRepository repositories.AccessTokenRepository,
+	db *sql.DB,
+) *accessTokenService {
+	return &accessTokenService{
+		accessToken: accessTokenRepository,
+		db:          db,
+	}
+}
+
+func (s *accessTokenService) GetAllByUserID(userID uuid.UUID) ([]*models.PersonalAccessToken, error) {
+	return s.accessToken.GetAllByUserID(userID)
+}
+
+func (s *accessTokenService) Create(token *models.PersonalAccessToken, v *validator.Validator) error {
+	if token.Validate(v); !v.Valid() {
+		return e.ErrInvalidData
+	}
+
+	return utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		return s.accessToken.Insert(tx, token)
+	})
+}
+
+func (s *accessTokenService) Revoke(id, userID uuid.UUID) error {
+	return utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		return s.accessToken.Delete(tx, id, userID)
+	})
+}
+
+func (s *accessTokenService) Authenticate(plaintext string) (*models.PersonalAccessToken, error) {
+	token, err := s.accessToken.GetByHash(models.HashToken(plaintext))
+	if err != nil {
+		switch {
+		case errors.Is(err, e.ErrRecordNotFound):
+			return nil, e.ErrInvalidCredentials
+		default:
+			return nil, err
+		}
+	}
+
+	if token.Expired(time.Now()) {
+		return nil, e.ErrInvalidCredentials
+	}
+
+	err = utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		return s.accessToken.TouchLastUsed(tx, token.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"moodtracker/internal/models"
+	"moodtracker/internal/repositories"
+	e "moodtracker/utils/errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type fakeAccessTokenRepository struct {
+	repositories.AccessTokenRepository
+	tokens  []*models.PersonalAccessToken
+	touched []uuid.UUID
+}
+
+func (r *fakeAccessTokenRepository) GetByHash(tokenHash []byte) (*models.PersonalAccessToken, error) {
+	for _, token := range r.tokens {
+		if string(token.Hash) == string(tokenHash) {
+			return token, nil
+		}
+	}
+	return nil, e.ErrRecordNotFound
+}
+
+func (r *fakeAccessTokenRepository) TouchLastUsed(tx *sql.Tx, id uuid.UUID) error {
+	r.touched = append(r.touched, id)
+	return nil
+}
+
+func TestAccessTokenAuthenticate(t *testing.T) {
+	userID := uuid.New()
+	later := time.Now().Add(time.Hour)
+	earlier := time.Now().Add(-time.Second)
+
+	valid := models.GeneratePersonalAccessToken(userID, "export", []string{models.ScopeDaylogsRead}, nil)
+	unexpired := models.GeneratePersonalAccessToken(userID, "sync", []string{models.ScopeDaylogsRead}, &later)
+	expired := models.GeneratePersonalAccessToken(userID, "old", []string{models.ScopeDaylogsRead}, &earlier)
+	unknown := models.GeneratePersonalAccessToken(userID, "revoked", []string{models.ScopeDaylogsRead}, nil)
+
+	tests := []struct {
+		name      string
+		plaintext string
+		token     *models.PersonalAccessToken
+		err       error
+	}{
+		{"valid token", valid.Plaintext, valid, nil},
+		{"token that expires later", unexpired.Plaintext, unexpired, nil},
+		{"expired token", expired.Plaintext, nil, e.ErrInvalidCredentials},
+		{"unknown token", unknown.Plaintext, nil, e.ErrInvalidCredentials},
+		{"hash instead of the token", string(valid.Hash), nil, e.ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := &fakeAccessTokenRepository{tokens: []*models.PersonalAccessToken{valid, unexpired, expired}}
+			s := &accessTokenService{accessToken: tokens, db: newTestDB(t)}
+
+			token, err := s.Authenticate(tt.plaintext)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Authenticate returned %v, want %v", err, tt.err)
+			}
+			if token != tt.token {
+				t.Errorf("Authenticate returned token %v, want %v", token, tt.token)
+			}
+
+			if touched := len(tokens.touched) == 1; touched != (tt.err == nil) {
+				t.Errorf("last use recorded = %v, want %v", touched, tt.err == nil)
+			}
+		})
+	}
+}
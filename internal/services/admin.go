@@ -0,0 +1,94 @@
+package services
+
+import (
+	"database/sql"
+	"moodtracker/internal/models"
+	"moodtracker/internal/models/filters"
+	"moodtracker/internal/repositories"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+
+	"github.com/google/uuid"
+)
+
+type adminService struct {
+	user  repositories.UserRepositoryInterface
+	token repositories.TokenRepository
+	db    *sql.DB
+}
+
+type AdminService interface {
+	ListUsers(
+		search string,
+		activated, deleted *bool,
+		f filters.Filters,
+		v *validator.Validator,
+	) ([]*models.User, filters.Metadata, error)
+	ActivateUser(id uuid.UUID) (*models.User, error)
+	DeactivateUser(id uuid.UUID) (*models.User, error)
+	RestoreUser(id uuid.UUID) (*models.User, error)
+}
+
+func NewAdminService(
+	userRepository repositories.UserRepositoryInterface,
+	tokenRepository repositories.TokenRepository,
+	db *sql.DB,
+) *adminService {
+	return &adminService{
+		user:  userRepository,
+		token: tokenRepository,
+		db:    db,
+	}
+}
+
+func (s *adminService) ListUsers(
+	search string,
+	activated, deleted *bool,
+	f filters.Filters,
+	v *validator.Validator,
+) ([]*models.User, filters.Metadata, error) {
+	if filters.ValidateFilters(v, f); !v.Valid() {
+		return nil, filters.Metadata{}, e.ErrInvalidData
+	}
+
+	return s.user.GetAll(search, activated, deleted, f)
+}
+
+func (s *adminService) ActivateUser(id uuid.UUID) (*models.User, error) {
+	err := utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		return s.user.SetActivated(tx, id, true)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.user.GetByID(id)
+}
+
+func (s *adminService) DeactivateUser(id uuid.UUID) (*models.User, error) {
+	err := utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		err := s.user.SetActivated(tx, id, false)
+		if err != nil {
+			return err
+		}
+
+		return s.token.DeleteAllForUser(tx, models.ScopeRefresh, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.user.GetByID(id)
+}
+
+func (s *adminService) RestoreUser(id uuid.UUID) (*models.User, error) {
+	err := utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		return s.user.Restore(tx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.user.GetByID(id)
+}
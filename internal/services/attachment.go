@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"moodtracker/internal/blob"
+	"moodtracker/internal/config"
+	"moodtracker/internal/jsonlog"
+	"moodtracker/internal/models"
+	"moodtracker/internal/repositories"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// blobTimeout bounds a single transfer to or from the blob store.
+const blobTimeout = 2 * time.Minute
+
+type attachmentService struct {
+	attachment   repositories.AttachmentRepository
+	daylog       repositories.DaylogRepository
+	store        blob.Store
+	db           *sql.DB
+	logger       jsonlog.Logger
+	maxSize      int64
+	allowedTypes []string
+	urlTTL       time.Duration
+	secret       []byte
+	now          func() time.Time
+}
+
+type AttachmentService interface {
+	MaxSize() int64
+	CheckLog(logID, userID uuid.UUID) error
+	GetAll(logID, userID uuid.UUID) ([]*models.Attachment, error)
+	FindByID(id, logID, userID uuid.UUID) (*models.Attachment, error)
+	Save(logID, userID uuid.UUID, upload *utils.Upload, v *validator.Validator) (*models.Attachment, error)
+	Delete(id, logID, userID uuid.UUID) error
+	SignedURL(a *models.Attachment) (string, time.Time)
+	Open(id uuid.UUID, expires, signature string) (*models.Attachment, io.ReadCloser, error)
+}
+
+func NewAttachmentService(
+	attachment repositories.AttachmentRepository,
+	daylog repositories.DaylogRepository,
+	store blob.Store,
+	db *sql.DB,
+	config config.Config,
+	logger jsonlog.Logger,
+) *attachmentService {
+	return &attachmentService{
+		attachment:   attachment,
+		daylog:       daylog,
+		store:        store,
+		db:           db,
+		logger:       logger,
+		maxSize:      config.Attachments.MaxSize,
+		allowedTypes: config.Attachments.AllowedTypes,
+		urlTTL:       config.Attachments.URLTTL,
+		secret:       []byte(config.Security.SecretKey),
+		now:          time.Now,
+	}
+}
+
+func (s *attachmentService) MaxSize() int64 {
+	return s.maxSize
+}
+
+// CheckLog fails with ErrRecordNotFound unless the log exists and belongs to
+// the user. Uploads call it before reading the file.
+func (s *attachmentService) CheckLog(logID, userID uuid.UUID) error {
+	_, err := s.daylog.GetByID(logID, userID)
+	return err
+}
+
+func (s *attachmentService) GetAll(logID, userID uuid.UUID) ([]*models.Attachment, error) {
+	if err := s.CheckLog(logID, userID); err != nil {
+		return nil, err
+	}
+
+	return s.attachment.GetAllByLogID(logID, userID)
+}
+
+func (s *attachmentService) FindByID(id, logID, userID uuid.UUID) (*models.Attachment, error) {
+	return s.attachment.FindByID(id, logID, userID)
+}
+
+// Save stores the upload and records it on the log. The blob is written
+// first and removed again if the row can't be inserted.
+func (s *attachmentService) Save(
+	logID, userID uuid.UUID,
+	upload *utils.Upload,
+	v *validator.Validator,
+) (*models.Attachment, error) {
+	if _, err := s.daylog.GetByID(logID, userID); err != nil {
+		return nil, err
+	}
+
+	model := &models.Attachment{
+		ID:          uuid.New(),
+		LogID:       logID,
+		UserID:      userID,
+		FileName:    models.CleanFileName(upload.FileName),
+		ContentType: models.AttachmentContentType(upload.SniffedType, upload.DeclaredType, s.allowedTypes),
+		Size:        upload.Size,
+		Checksum:    upload.Checksum,
+	}
+
+	if model.ValidateAttachment(v, s.maxSize, s.allowedTypes); !v.Valid() {
+		return nil, e.ErrInvalidData
+	}
+
+	model.StorageKey = fmt.Sprintf("%s/%s/%s", userID, logID, model.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), blobTimeout)
+	defer cancel()
+
+	if err := s.store.Put(ctx, model.StorageKey, upload.File, model.Size, model.ContentType); err != nil {
+		return nil, err
+	}
+
+	err := utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		return s.attachment.Insert(tx, model)
+	})
+	if err != nil {
+		if deleteErr := s.store.Delete(ctx, model.StorageKey); deleteErr != nil {
+			s.logger.PrintError(deleteErr, map[string]string{
+				"storage_key": model.StorageKey,
+				"message":     "orphaned attachment blob after a failed insert",
+			})
+		}
+		return nil, err
+	}
+
+	return model, nil
+}
+
+// Delete removes the blob before the row. If the row delete fails the file is
+// already gone, and deleting again finishes the job.
+func (s *attachmentService) Delete(id, logID, userID uuid.UUID) error {
+	model, err := s.attachment.FindByID(id, logID, userID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), blobTimeout)
+	defer cancel()
+
+	if err := s.store.Delete(ctx, model.StorageKey); err != nil {
+		return err
+	}
+
+	return utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		return s.attachment.Delete(tx, model.ID)
+	})
+}
+
+// SignedURL returns a link to the content of a that anyone holding it can use
+// until it expires, so it works in <img> and <audio> tags.
+func (s *attachmentService) SignedURL(a *models.Attachment) (string, time.Time) {
+	expiresAt := s.now().Add(s.urlTTL).Truncate(time.Second)
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+
+	url := fmt.Sprintf(
+		"/v1/attachments/%s/content?expires=%s&signature=%s",
+		a.ID, expires, s.sign(a.ID, expires),
+	)
+
+	return url, expiresAt
+}
+
+func (s *attachmentService) sign(id uuid.UUID, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("attachment:" + id.String() + ":" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Open checks a signed URL and opens the content it points to. The caller
+// must close the reader.
+func (s *attachmentService) Open(id uuid.UUID, expires, signature string) (*models.Attachment, io.ReadCloser, error) {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || s.now().Unix() > unix {
+		return nil, nil, e.ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(s.sign(id, expires))) {
+		return nil, nil, e.ErrInvalidSignature
+	}
+
+	model, err := s.attachment.FindForDownload(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), blobTimeout)
+
+	content, err := s.store.Get(ctx, model.StorageKey)
+	if err != nil {
+		cancel()
+		if errors.Is(err, blob.ErrNotFound) {
+			return nil, nil, e.ErrRecordNotFound
+		}
+		return nil, nil, err
+	}
+
+	return model, &cancelReadCloser{ReadCloser: content, cancel: cancel}, nil
+}
+
+// cancelReadCloser releases the context of a blob download once the caller
+// is done reading it.
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelReadCloser) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"moodtracker/internal/blob"
+	"moodtracker/internal/jsonlog"
+	"moodtracker/internal/models"
+	"moodtracker/internal/repositories"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type fakeAttachmentRepository struct {
+	repositories.AttachmentRepository
+	attachments map[uuid.UUID]*models.Attachment
+	purgeable   []*models.Attachment
+	insertErr   error
+}
+
+func (r *fakeAttachmentRepository) FindForDownload(id uuid.UUID) (*models.Attachment, error) {
+	a, ok := r.attachments[id]
+	if !ok {
+		return nil, e.ErrRecordNotFound
+	}
+	return a, nil
+}
+
+type fakeBlobStore struct {
+	blob.Store
+	objects map[string]string
+	failing map[string]bool
+}
+
+func (s *fakeBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	content, ok := s.objects[key]
+	if !ok {
+		return nil, blob.ErrNotFound
+	}
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+
+func TestAttachmentSignedURL(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 500, time.UTC)
+	stored := &models.Attachment{ID: uuid.New(), StorageKey: "user/log/stored"}
+	missing := &models.Attachment{ID: uuid.New(), StorageKey: "user/log/missing"}
+
+	s := &attachmentService{
+		attachment: &fakeAttachmentRepository{attachments: map[uuid.UUID]*models.Attachment{
+			stored.ID:  stored,
+			missing.ID: missing,
+		}},
+		store:  &fakeBlobStore{objects: map[string]string{"user/log/stored": "content"}},
+		urlTTL: 15 * time.Minute,
+		secret: []byte("secret"),
+		now:    func() time.Time { return now },
+	}
+
+	signed := func(a *models.Attachment) (string, string) {
+		raw, expiresAt := s.SignedURL(a)
+		if want := now.Add(15 * time.Minute).Truncate(time.Second); !expiresAt.Equal(want) {
+			t.Errorf("expiresAt = %v, want %v", expiresAt, want)
+		}
+
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "/v1/attachments/" + a.ID.String() + "/content"; u.Path != want {
+			t.Errorf("path = %q, want %q", u.Path, want)
+		}
+		return u.Query().Get("expires"), u.Query().Get("signature")
+	}
+
+	expires, signature := signed(stored)
+	missingExpires, missingSignature := signed(missing)
+
+	tests := []struct {
+		name      string
+		id        uuid.UUID
+		expires   string
+		signature string
+		now       time.Time
+		err       error
+	}{
+		{"valid", stored.ID, expires, signature, now, nil},
+		{"valid until the last second", stored.ID, expires, signature, now.Add(15 * time.Minute), nil},
+		{"expired", stored.ID, expires, signature, now.Add(16 * time.Minute), e.ErrInvalidSignature},
+		{"tampered signature", stored.ID, expires, signature[1:] + "A", now, e.ErrInvalidSignature},
+		{"extended expiry", stored.ID, expires + "0", signature, now, e.ErrInvalidSignature},
+		{"malformed expiry", stored.ID, "soon", signature, now, e.ErrInvalidSignature},
+		{"other attachment", missing.ID, expires, signature, now, e.ErrInvalidSignature},
+		{"missing content", missing.ID, missingExpires, missingSignature, now, e.ErrRecordNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.now = func() time.Time { return tt.now }
+
+			model, content, err := s.Open(tt.id, tt.expires, tt.signature)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Open returned %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			defer content.Close()
+
+			if model != stored {
+				t.Errorf("Open returned attachment %v, want %v", model.ID, stored.ID)
+			}
+			body, _ := io.ReadAll(content)
+			if string(body) != "content" {
+				t.Errorf("content = %q, want %q", body, "content")
+			}
+		})
+	}
+}
+
+func (r *fakeAttachmentRepository) FindByID(id, logID, userID uuid.UUID) (*models.Attachment, error) {
+	a, ok := r.attachments[id]
+	if !ok || a.LogID != logID {
+		return nil, e.ErrRecordNotFound
+	}
+	return a, nil
+}
+
+func (r *fakeAttachmentRepository) Insert(tx *sql.Tx, model *models.Attachment) error {
+	if r.insertErr != nil {
+		return r.insertErr
+	}
+	r.attachments[model.ID] = model
+	return nil
+}
+
+func (r *fakeAttachmentRepository) Delete(tx *sql.Tx, id uuid.UUID) error {
+	delete(r.attachments, id)
+	return nil
+}
+
+func (s *fakeBlobStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	s.objects[key] = contentType
+	return nil
+}
+
+func newTestAttachmentService(t *testing.T, logID uuid.UUID) (*attachmentService, *fakeAttachmentRepository, *fakeBlobStore) {
+	repo := &fakeAttachmentRepository{attachments: map[uuid.UUID]*models.Attachment{}}
+	store := &fakeBlobStore{objects: map[string]string{}, failing: map[string]bool{}}
+
+	s := &attachmentService{
+		attachment:   repo,
+		daylog:       &fakeDaylogRepository{current: &models.Daylog{ID: logID}},
+		store:        store,
+		db:           newTestDB(t),
+		logger:       jsonlog.New(io.Discard, jsonlog.LevelOff),
+		maxSize:      1024,
+		allowedTypes: []string{"image/png", "audio/mp4"},
+	}
+
+	return s, repo, store
+}
+
+func TestAttachmentSave(t *testing.T) {
+	logID, userID := uuid.New(), uuid.New()
+	insertErr := errors.New("connection reset")
+
+	upload := func(change func(u *utils.Upload)) *utils.Upload {
+		u := &utils.Upload{FileName: "../photo.png", DeclaredType: "image/png", SniffedType: "image/png", Size: 10, Checksum: "abc"}
+		change(u)
+		return u
+	}
+
+	tests := []struct {
+		name        string
+		logID       uuid.UUID
+		upload      *utils.Upload
+		insertErr   error
+		failing     bool
+		err         error
+		contentType string
+	}{
+		{"image", logID, upload(func(u *utils.Upload) {}), nil, false, nil, "image/png"},
+		{"voice note", logID, upload(func(u *utils.Upload) {
+			u.SniffedType, u.DeclaredType = "video/mp4", "audio/x-m4a"
+		}), nil, false, nil, "audio/mp4"},
+		{"script sent as an image", logID, upload(func(u *utils.Upload) { u.SniffedType = "text/html; charset=utf-8" }), nil, false, e.ErrInvalidData, ""},
+		{"too large", logID, upload(func(u *utils.Upload) { u.Size = 1025 }), nil, false, e.ErrInvalidData, ""},
+		{"unknown log", uuid.New(), upload(func(u *utils.Upload) {}), nil, false, e.ErrRecordNotFound, ""},
+		{"insert fails", logID, upload(func(u *utils.Upload) {}), insertErr, false, insertErr, ""},
+		{"insert and cleanup fail", logID, upload(func(u *utils.Upload) {}), insertErr, true, insertErr, ""},
+	}
+
+	for _, tt := range tests {
+		s, repo, store := newTestAttachmentService(t, logID)
+		repo.insertErr = tt.insertErr
+		if tt.failing {
+			s.store = &failingDeleteStore{store}
+		}
+
+		a, err := s.Save(tt.logID, userID, tt.upload, validator.New())
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+
+		if err != nil {
+			// Only a blob whose cleanup failed is left behind.
+			if left := len(store.objects) > 0; left != tt.failing {
+				t.Errorf("%s: blobs left = %v, want %v", tt.name, store.objects, tt.failing)
+			}
+			continue
+		}
+
+		if a.FileName != "photo.png" || a.ContentType != tt.contentType || a.LogID != logID || a.UserID != userID {
+			t.Errorf("%s: attachment = %+v", tt.name, a)
+		}
+		if want := userID.String() + "/" + logID.String() + "/" + a.ID.String(); a.StorageKey != want {
+			t.Errorf("%s: storage key = %q, want %q", tt.name, a.StorageKey, want)
+		}
+		if store.objects[a.StorageKey] != tt.contentType || repo.attachments[a.ID] != a {
+			t.Errorf("%s: blob %v and row %v", tt.name, store.objects, repo.attachments)
+		}
+	}
+}
+
+// failingDeleteStore stores blobs but can't delete them.
+type failingDeleteStore struct {
+	*fakeBlobStore
+}
+
+func (s *failingDeleteStore) Delete(ctx context.Context, key string) error {
+	return errors.New("store unavailable")
+}
+
+func TestAttachmentDelete(t *testing.T) {
+	logID := uuid.New()
+
+	tests := []struct {
+		name    string
+		logID   uuid.UUID
+		failing bool
+		err     bool
+		deleted bool
+	}{
+		{"attachment", logID, false, false, true},
+		{"other log", uuid.New(), false, true, false},
+		{"store fails", logID, true, true, false},
+	}
+
+	for _, tt := range tests {
+		s, repo, store := newTestAttachmentService(t, logID)
+		a := &models.Attachment{ID: uuid.New(), LogID: logID, StorageKey: "user/log/a"}
+		repo.attachments[a.ID] = a
+		store.objects[a.StorageKey] = "image/png"
+		store.failing[a.StorageKey] = tt.failing
+
+		err := s.Delete(a.ID, tt.logID, uuid.New())
+		if (err != nil) != tt.err {
+			t.Errorf("%s: err = %v", tt.name, err)
+		}
+
+		_, rowLeft := repo.attachments[a.ID]
+		_, blobLeft := store.objects[a.StorageKey]
+		if rowLeft == tt.deleted || blobLeft == tt.deleted {
+			t.Errorf("%s: row left %v, blob left %v, want deleted %v", tt.name, rowLeft, blobLeft, tt.deleted)
+		}
+	}
+}
@@ -1,30 +1,94 @@
 package services
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"moodtracker/internal/config"
 	"moodtracker/internal/models"
+	"moodtracker/internal/models/filters"
+	"moodtracker/internal/oidc"
+	"moodtracker/internal/repositories"
+	"moodtracker/internal/signing"
+	"moodtracker/utils"
 	e "moodtracker/utils/errors"
 	"moodtracker/utils/validator"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type AuthService struct {
-	user   UserService
-	config config.Config
+	user      UserService
+	mfa       MFAService
+	token     repositories.TokenRepository
+	users     repositories.UserRepositoryInterface
+	identity  repositories.IdentityRepository
+	authEvent repositories.AuthEventRepository
+	oidc      OIDCProvider
+	db        *sql.DB
+	keys      *signing.KeySet
+	config    config.Config
+}
+
+// OIDCProvider is the external identity provider used by the single sign-on
+// flow. It is nil when OIDC_ISSUER is not configured.
+type OIDCProvider interface {
+	Issuer() string
+	AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error)
+	Exchange(ctx context.Context, code, verifier, nonce string) (*oidc.Claims, error)
 }
 
 type AuthServiceInterface interface {
-	Login(v *validator.Validator, email, password string) (string, error)
-	ExtractUsername(tokenString string) (string, error)
+	Login(v *validator.Validator, email, password string, client models.ClientInfo) (*models.LoginResult, error)
+	VerifyMFA(
+		v *validator.Validator,
+		mfaToken,
+		code,
+		recoveryCode string,
+		client models.ClientInfo,
+	) (*models.AuthTokens, error)
+	StartOIDC() (string, *models.OIDCState, error)
+	CompleteOIDC(v *validator.Validator, code, state string, client models.ClientInfo) (*models.LoginResult, error)
+	GetAuthEvents(userID uuid.UUID, f filters.Filters, v *validator.Validator) ([]*models.AuthEvent, filters.Metadata, error)
+	Refresh(v *validator.Validator, refreshToken string) (*models.AuthTokens, error)
+	Logout(
+		v *validator.Validator,
+		user *models.User,
+		claims *models.AccessClaims,
+		refreshToken string,
+	) error
+	LogoutAll(user *models.User) error
+	ExtractClaims(tokenString string) (*models.AccessClaims, error)
+	IsRevoked(claims *models.AccessClaims) (bool, error)
+	JWKS() []signing.JWK
 }
 
-func NewAuthService(userService UserService, config config.Config) *AuthService {
+func NewAuthService(
+	userService UserService,
+	mfaService MFAService,
+	tokenRepository repositories.TokenRepository,
+	userRepository repositories.UserRepositoryInterface,
+	identityRepository repositories.IdentityRepository,
+	authEventRepository repositories.AuthEventRepository,
+	oidcProvider OIDCProvider,
+	db *sql.DB,
+	keys *signing.KeySet,
+	config config.Config,
+) *AuthService {
 	return &AuthService{
-		user:   userService,
-		config: config,
+		user:      userService,
+		mfa:       mfaService,
+		token:     tokenRepository,
+		users:     userRepository,
+		identity:  identityRepository,
+		authEvent: authEventRepository,
+		oidc:      oidcProvider,
+		db:        db,
+		keys:      keys,
+		config:    config,
 	}
 }
 
@@ -32,82 +96,604 @@ func (s *AuthService) Login(
 	v *validator.Validator,
 	email,
 	password string,
-) (string, error) {
+	client models.ClientInfo,
+) (*models.LoginResult, error) {
 	models.ValidateEmail(v, email)
-	models.ValidatePasswordPlaintext(v, password)
+	models.ValidatePasswordPlaintext(v, "password", password)
 
 	if !v.Valid() {
-		return "", e.ErrInvalidData
+		return nil, e.ErrInvalidData
+	}
+
+	attempt, err := s.authEvent.GetLoginAttempt(email)
+	if err != nil && !errors.Is(err, e.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if attempt != nil && attempt.Locked(time.Now()) {
+		event := models.NewAuthEvent(nil, email, models.AuthMethodPassword, false, models.AuthReasonLocked, client)
+		if err := s.recordEvent(event); err != nil {
+			return nil, err
+		}
+		return nil, e.ErrAccountLocked
 	}
 
 	user, err := s.user.GetUserByEmail(email, v)
 	if err != nil {
 		switch {
 		case errors.Is(err, e.ErrRecordNotFound):
-			return "", e.ErrInvalidCredentials
+			models.MatchDummyPassword(password)
+			return nil, s.loginFailed(nil, email, client)
 		default:
-			return "", err
+			return nil, err
 		}
 	}
 
-	if !user.Activated {
-		return "", e.ErrInactiveAccount
-	}
-
 	match, err := user.Password.Matches(password)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if !match {
-		return "", e.ErrInvalidCredentials
+		return nil, s.loginFailed(user, email, client)
+	}
+
+	if !user.Activated {
+		event := models.NewAuthEvent(user, email, models.AuthMethodPassword, false, models.AuthReasonInactiveAccount, client)
+		if err := s.recordEvent(event); err != nil {
+			return nil, err
+		}
+		return nil, e.ErrInactiveAccount
 	}
 
-	token, err := s.createToken(user.Email)
+	reason := ""
+	if user.TOTPEnabled {
+		reason = models.AuthReasonMFARequired
+	}
+
+	err = utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		if err := s.authEvent.ClearLoginFailures(tx, email); err != nil {
+			return err
+		}
+
+		if user.Password.NeedsRehash() {
+			previousHash := user.Password.Hash
+			if err := user.Password.Set(password); err != nil {
+				return err
+			}
+
+			if err := s.users.UpgradePasswordHash(tx, user, previousHash); err != nil {
+				return err
+			}
+		}
+
+		event := models.NewAuthEvent(user, email, models.AuthMethodPassword, true, reason, client)
+		return s.authEvent.Insert(tx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.startSession(user)
+}
+
+// loginFailed counts the failure against the email, whether or not it belongs
+// to an account, and locks further attempts once the backoff kicks in.
+func (s *AuthService) loginFailed(user *models.User, email string, client models.ClientInfo) error {
+	now := time.Now()
+
+	err := utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		failures, err := s.authEvent.RecordLoginFailure(tx, email, now, s.config.Login.LockoutDuration)
+		if err != nil {
+			return err
+		}
+
+		if delay := s.loginDelay(failures); delay > 0 {
+			if err := s.authEvent.LockLogin(tx, email, now.Add(delay)); err != nil {
+				return err
+			}
+		}
+
+		event := models.NewAuthEvent(user, email, models.AuthMethodPassword, false, models.AuthReasonInvalidCredentials, client)
+		return s.authEvent.Insert(tx, event)
+	})
 	if err != nil {
-		return "", err
+		return err
+	}
+
+	return e.ErrInvalidCredentials
+}
+
+// loginDelay doubles the wait for each failure past LOGIN_BACKOFF_AFTER and
+// locks the email for LOGIN_LOCKOUT_DURATION after LOGIN_LOCKOUT_AFTER.
+func (s *AuthService) loginDelay(failures int) time.Duration {
+	cfg := s.config.Login
+
+	if failures >= cfg.LockoutAfter {
+		return cfg.LockoutDuration
+	}
+
+	if failures < cfg.BackoffAfter {
+		return 0
+	}
+
+	delay := cfg.BackoffBase << (failures - cfg.BackoffAfter)
+	return min(delay, cfg.LockoutDuration)
+}
+
+func (s *AuthService) recordEvent(event *models.AuthEvent) error {
+	return utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		return s.authEvent.Insert(tx, event)
+	})
+}
+
+func (s *AuthService) GetAuthEvents(
+	userID uuid.UUID,
+	f filters.Filters,
+	v *validator.Validator,
+) ([]*models.AuthEvent, filters.Metadata, error) {
+	if filters.ValidateFilters(v, f); !v.Valid() {
+		return nil, filters.Metadata{}, e.ErrInvalidData
 	}
 
-	return token, nil
+	return s.authEvent.GetAllByUserID(userID, f)
 }
 
-func (s *AuthService) createToken(username string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
-		jwt.MapClaims{
-			"username": username,
-			"exp":      time.Now().Add(time.Hour * 24).Unix(),
+// startSession issues the token pair, or a pending MFA token when the user
+// must still present a second factor.
+func (s *AuthService) startSession(user *models.User) (*models.LoginResult, error) {
+	if user.TOTPEnabled {
+		token := models.GenerateToken(user.ID, s.config.MFA.TokenTTL, models.ScopeMFAPending)
+
+		err := utils.RunInTx(s.db, func(tx *sql.Tx) error {
+			return s.token.Insert(tx, token)
 		})
-	tokenStr, err := token.SignedString([]byte(s.config.Security.SecretKey))
+		if err != nil {
+			return nil, err
+		}
+
+		return &models.LoginResult{MFAToken: token}, nil
+	}
+
+	var tokens *models.AuthTokens
+	err := utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		var err error
+		tokens, err = s.issueTokens(tx, user)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.LoginResult{Tokens: tokens}, nil
+}
+
+// StartOIDC returns the provider URL for a new authorization request and its
+// state, which the caller binds to the client before redirecting.
+func (s *AuthService) StartOIDC() (string, *models.OIDCState, error) {
+	if s.oidc == nil {
+		return "", nil, e.ErrRecordNotFound
+	}
+
+	state := models.GenerateOIDCState(oidc.GenerateVerifier(), s.config.OIDC.StateTTL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	authURL, err := s.oidc.AuthCodeURL(ctx, state.Plaintext, state.Nonce, state.CodeVerifier)
+	if err != nil {
+		return "", nil, err
+	}
+
+	err = utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		return s.identity.InsertState(tx, state)
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return authURL, state, nil
+}
+
+func (s *AuthService) CompleteOIDC(
+	v *validator.Validator,
+	code,
+	state string,
+	client models.ClientInfo,
+) (*models.LoginResult, error) {
+	if s.oidc == nil {
+		return nil, e.ErrRecordNotFound
+	}
+
+	v.Check(code != "", "code", "must be provided")
+	v.Check(state != "", "state", "must be provided")
+
+	if !v.Valid() {
+		return nil, e.ErrInvalidData
+	}
+
+	var pending *models.OIDCState
+	err := utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		var err error
+		pending, err = s.identity.ConsumeState(tx, models.HashToken(state))
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, e.ErrRecordNotFound):
+			v.AddError("state", "invalid or expired authorization request")
+			return nil, e.ErrInvalidData
+		default:
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	claims, err := s.oidc.Exchange(ctx, code, pending.CodeVerifier, pending.Nonce)
+	if err != nil {
+		switch {
+		case errors.Is(err, oidc.ErrTokenExchange), errors.Is(err, oidc.ErrInvalidIDToken):
+			return nil, e.ErrInvalidCredentials
+		default:
+			return nil, err
+		}
+	}
+
+	user, err := s.linkIdentity(claims, v)
+	if err != nil {
+		event := models.NewAuthEvent(user, claims.Email, models.AuthMethodOIDC, false, oidcFailureReason(err), client)
+		if err := s.recordEvent(event); err != nil {
+			return nil, err
+		}
+		return nil, err
+	}
+
+	event := models.NewAuthEvent(user, claims.Email, models.AuthMethodOIDC, true, "", client)
+	if err := s.recordEvent(event); err != nil {
+		return nil, err
+	}
+
+	return s.startSession(user)
+}
+
+func oidcFailureReason(err error) string {
+	if errors.Is(err, e.ErrInactiveAccount) {
+		return models.AuthReasonInactiveAccount
+	}
+	return models.AuthReasonInvalidCredentials
+}
+
+// linkIdentity resolves the local user for the provider claims. A new
+// identity is only linked to an existing account when the provider vouches
+// for the email address, and the same proof activates pending accounts.
+func (s *AuthService) linkIdentity(claims *oidc.Claims, v *validator.Validator) (*models.User, error) {
+	issuer := s.oidc.Issuer()
+
+	identity, err := s.identity.GetByIssuerAndSubject(issuer, claims.Subject)
+	if err != nil && !errors.Is(err, e.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var user *models.User
+	if identity != nil {
+		user, err = s.users.GetByID(identity.UserID)
+		if err != nil {
+			switch {
+			case errors.Is(err, e.ErrRecordNotFound):
+				return nil, e.ErrInvalidCredentials
+			default:
+				return nil, err
+			}
+		}
+	} else {
+		if !claims.EmailVerified || claims.Email == "" {
+			v.AddError("email", "must be verified by the identity provider")
+			return nil, e.ErrInvalidData
+		}
+
+		user, err = s.users.GetByEmail(claims.Email)
+		if err != nil {
+			switch {
+			case errors.Is(err, e.ErrRecordNotFound):
+				v.AddError("email", "no account is registered with this email address")
+				return nil, e.ErrInvalidData
+			default:
+				return nil, err
+			}
+		}
+	}
+
+	activate := !user.Activated && claims.EmailVerified && strings.EqualFold(claims.Email, user.Email)
+	if !user.Activated && !activate {
+		return nil, e.ErrInactiveAccount
+	}
+
+	err = utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		if identity == nil {
+			identity = &models.Identity{
+				UserID:  user.ID,
+				Issuer:  issuer,
+				Subject: claims.Subject,
+				Email:   &claims.Email,
+			}
+
+			if err := s.identity.Insert(tx, identity); err != nil {
+				return err
+			}
+		} else {
+			if err := s.identity.TouchLastLogin(tx, identity.ID); err != nil {
+				return err
+			}
+		}
 
+		if activate {
+			user.Activated = true
+			user.Cod = 0
+			user.CodIssuedAt = nil
+			user.CodAttempts = 0
+
+			return s.users.Update(tx, user)
+		}
+
+		return nil
+	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return tokenStr, nil
+	return user, nil
 }
 
-func (s *AuthService) ExtractUsername(tokenString string) (string, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-		return []byte(s.config.Security.SecretKey), nil
+func (s *AuthService) VerifyMFA(
+	v *validator.Validator,
+	mfaToken,
+	code,
+	recoveryCode string,
+	client models.ClientInfo,
+) (*models.AuthTokens, error) {
+	models.ValidateTokenPlaintext(v, mfaToken)
+	v.Check(code != "" || recoveryCode != "", "code", "must be provided")
+	if code != "" {
+		models.ValidateTOTPCode(v, code)
+	}
+
+	if !v.Valid() {
+		return nil, e.ErrInvalidData
+	}
+
+	hash := models.HashToken(mfaToken)
+	user, err := s.users.GetForToken(models.ScopeMFAPending, hash)
+	if err != nil {
+		switch {
+		case errors.Is(err, e.ErrRecordNotFound):
+			return nil, e.ErrInvalidCredentials
+		default:
+			return nil, err
+		}
+	}
+
+	// the pending token is consumed on its own so a wrong code still burns
+	// it and guesses require signing in with the password again
+	err = utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		return s.token.Delete(tx, models.ScopeMFAPending, hash, user.ID)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, e.ErrRecordNotFound):
+			return nil, e.ErrInvalidCredentials
+		default:
+			return nil, err
+		}
+	}
+
+	var tokens *models.AuthTokens
+	err = utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		if err := s.mfa.Verify(tx, user, code, recoveryCode, v); err != nil {
+			return err
+		}
+
+		event := models.NewAuthEvent(user, user.Email, models.AuthMethodMFA, true, "", client)
+		if err := s.authEvent.Insert(tx, event); err != nil {
+			return err
+		}
+
+		tokens, err = s.issueTokens(tx, user)
+		return err
 	})
+	if err != nil {
+		if errors.Is(err, e.ErrInvalidCredentials) {
+			event := models.NewAuthEvent(user, user.Email, models.AuthMethodMFA, false, models.AuthReasonInvalidCredentials, client)
+			if err := s.recordEvent(event); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (s *AuthService) Refresh(v *validator.Validator, refreshToken string) (*models.AuthTokens, error) {
+	if models.ValidateTokenPlaintext(v, refreshToken); !v.Valid() {
+		return nil, e.ErrInvalidData
+	}
 
+	hash := models.HashToken(refreshToken)
+	token, err := s.token.GetByHash(models.ScopeRefresh, hash)
 	if err != nil {
-		return "", err
+		switch {
+		case errors.Is(err, e.ErrRecordNotFound):
+			return nil, e.ErrInvalidCredentials
+		default:
+			return nil, err
+		}
 	}
 
-	if !token.Valid {
-		return "", nil
+	user, err := s.user.GetUserByID(token.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, e.ErrRecordNotFound):
+			return nil, e.ErrInvalidCredentials
+		default:
+			return nil, err
+		}
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return "", nil
+	if !user.Activated {
+		return nil, e.ErrInactiveAccount
 	}
 
-	username, ok := claims["username"].(string)
-	if !ok {
-		return "", nil
+	var tokens *models.AuthTokens
+	err = utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		err := s.token.Delete(tx, models.ScopeRefresh, hash, user.ID)
+		if err != nil {
+			if errors.Is(err, e.ErrRecordNotFound) {
+				return e.ErrInvalidCredentials
+			}
+			return err
+		}
+
+		tokens, err = s.issueTokens(tx, user)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (s *AuthService) Logout(
+	v *validator.Validator,
+	user *models.User,
+	claims *models.AccessClaims,
+	refreshToken string,
+) error {
+	if refreshToken != "" {
+		if models.ValidateTokenPlaintext(v, refreshToken); !v.Valid() {
+			return e.ErrInvalidData
+		}
+	}
+
+	return utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		if err := s.token.DeleteExpiredRevoked(tx); err != nil {
+			return err
+		}
+
+		if err := s.token.Revoke(tx, claims.ID, user.ID, claims.ExpiresAt); err != nil {
+			return err
+		}
+
+		if refreshToken == "" {
+			return nil
+		}
+
+		err := s.token.Delete(tx, models.ScopeRefresh, models.HashToken(refreshToken), user.ID)
+		if err != nil && !errors.Is(err, e.ErrRecordNotFound) {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (s *AuthService) LogoutAll(user *models.User) error {
+	return utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		if err := s.token.DeleteAllForUser(tx, models.ScopeRefresh, user.ID); err != nil {
+			return err
+		}
+
+		return s.users.RevokeTokens(tx, user.ID, time.Now())
+	})
+}
+
+func (s *AuthService) IsRevoked(claims *models.AccessClaims) (bool, error) {
+	return s.token.IsRevoked(claims.ID)
+}
+
+func (s *AuthService) issueTokens(tx *sql.Tx, user *models.User) (*models.AuthTokens, error) {
+	accessToken, expiry, err := s.createToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh := models.GenerateToken(user.ID, s.config.Security.RefreshTokenTTL, models.ScopeRefresh)
+	if err := s.token.Insert(tx, refresh); err != nil {
+		return nil, err
+	}
+
+	return &models.AuthTokens{
+		AccessToken:        accessToken,
+		AccessTokenExpiry:  expiry,
+		RefreshToken:       refresh.Plaintext,
+		RefreshTokenExpiry: refresh.Expiry,
+	}, nil
+}
+
+func (s *AuthService) JWKS() []signing.JWK {
+	return s.keys.JWKS()
+}
+
+func (s *AuthService) createToken(user *models.User) (string, time.Time, error) {
+	now := time.Now()
+	expiry := now.Add(s.config.Security.AccessTokenTTL)
+
+	claims := jwt.RegisteredClaims{
+		ID:        uuid.NewString(),
+		Subject:   user.ID.String(),
+		Issuer:    s.config.Security.JWTIssuer,
+		Audience:  jwt.ClaimStrings{s.config.Security.JWTAudience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expiry),
+	}
+
+	tokenStr, err := s.keys.Sign(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return tokenStr, expiry, nil
+}
+
+func (s *AuthService) ExtractClaims(tokenString string) (*models.AccessClaims, error) {
+	var claims jwt.RegisteredClaims
+
+	token, err := jwt.ParseWithClaims(
+		tokenString,
+		&claims,
+		s.keys.Keyfunc,
+		jwt.WithValidMethods([]string{s.keys.Algorithm()}),
+		jwt.WithIssuer(s.config.Security.JWTIssuer),
+		jwt.WithAudience(s.config.Security.JWTAudience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid || claims.IssuedAt == nil {
+		return nil, e.ErrInvalidCredentials
+	}
+
+	id, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, e.ErrInvalidCredentials
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, e.ErrInvalidCredentials
 	}
 
-	return username, nil
+	return &models.AccessClaims{
+		ID:        id,
+		UserID:    userID,
+		IssuedAt:  claims.IssuedAt.Time,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
 }
@@ -0,0 +1,667 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"moodtracker/internal/config"
+	"moodtracker/internal/hashing"
+	"moodtracker/internal/models"
+	"moodtracker/internal/oidc"
+	"moodtracker/internal/repositories"
+	"moodtracker/internal/signing"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const testIssuer = "https://accounts.example.com"
+
+type fakeOIDCProvider struct {
+	claims   *oidc.Claims
+	err      error
+	verifier string
+	nonce    string
+	calls    int
+}
+
+func (p *fakeOIDCProvider) Issuer() string { return testIssuer }
+
+func (p *fakeOIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	return testIssuer + "/authorize?state=" + state, nil
+}
+
+func (p *fakeOIDCProvider) Exchange(ctx context.Context, code, verifier, nonce string) (*oidc.Claims, error) {
+	p.calls++
+	p.verifier = verifier
+	p.nonce = nonce
+	return p.claims, p.err
+}
+
+type fakeIdentityRepository struct {
+	repositories.IdentityRepository
+	identities []*models.Identity
+	states     []*models.OIDCState
+	touched    []uuid.UUID
+}
+
+func (r *fakeIdentityRepository) GetByIssuerAndSubject(issuer, subject string) (*models.Identity, error) {
+	for _, identity := range r.identities {
+		if identity.Issuer == issuer && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return nil, e.ErrRecordNotFound
+}
+
+func (r *fakeIdentityRepository) Insert(tx *sql.Tx, identity *models.Identity) error {
+	identity.ID = uuid.New()
+	r.identities = append(r.identities, identity)
+	return nil
+}
+
+func (r *fakeIdentityRepository) TouchLastLogin(tx *sql.Tx, id uuid.UUID) error {
+	r.touched = append(r.touched, id)
+	return nil
+}
+
+func (r *fakeIdentityRepository) InsertState(tx *sql.Tx, state *models.OIDCState) error {
+	r.states = append(r.states, state)
+	return nil
+}
+
+func (r *fakeIdentityRepository) ConsumeState(tx *sql.Tx, stateHash []byte) (*models.OIDCState, error) {
+	for i, state := range r.states {
+		if string(state.Hash) == string(stateHash) {
+			r.states = append(r.states[:i], r.states[i+1:]...)
+			return state, nil
+		}
+	}
+	return nil, e.ErrRecordNotFound
+}
+
+type fakeUserRepository struct {
+	repositories.UserRepositoryInterface
+	users   []*models.User
+	updated []*models.User
+	tokens  *fakeTokenRepository
+	// upgraded maps each user whose hash was upgraded to the hash replaced.
+	upgraded map[uuid.UUID][]byte
+}
+
+func (r *fakeUserRepository) GetByID(id uuid.UUID) (*models.User, error) {
+	for _, user := range r.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return nil, e.ErrRecordNotFound
+}
+
+func (r *fakeUserRepository) GetByEmail(email string) (*models.User, error) {
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			return user, nil
+		}
+	}
+	return nil, e.ErrRecordNotFound
+}
+
+func (r *fakeUserRepository) Update(tx *sql.Tx, user *models.User) error {
+	r.updated = append(r.updated, user)
+	return nil
+}
+
+// UpgradePasswordHash records the hash that was replaced.
+func (r *fakeUserRepository) UpgradePasswordHash(tx *sql.Tx, user *models.User, previousHash []byte) error {
+	if r.upgraded == nil {
+		r.upgraded = map[uuid.UUID][]byte{}
+	}
+	r.upgraded[user.ID] = previousHash
+	return nil
+}
+
+type fakeAuthEventRepository struct {
+	repositories.AuthEventRepository
+	events   []*models.AuthEvent
+	failures int
+	locks    map[string]time.Time
+}
+
+func (r *fakeAuthEventRepository) Insert(tx *sql.Tx, event *models.AuthEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *fakeAuthEventRepository) GetLoginAttempt(email string) (*models.LoginAttempt, error) {
+	until, ok := r.locks[email]
+	if !ok {
+		return nil, e.ErrRecordNotFound
+	}
+	return &models.LoginAttempt{Email: email, Failures: r.failures, LockedUntil: &until}, nil
+}
+
+func (r *fakeAuthEventRepository) ClearLoginFailures(tx *sql.Tx, email string) error {
+	r.failures = 0
+	delete(r.locks, email)
+	return nil
+}
+
+func (r *fakeAuthEventRepository) LockLogin(tx *sql.Tx, email string, until time.Time) error {
+	if r.locks == nil {
+		r.locks = map[string]time.Time{}
+	}
+	r.locks[email] = until
+	return nil
+}
+
+func (r *fakeAuthEventRepository) RecordLoginFailure(
+	tx *sql.Tx,
+	email string,
+	now time.Time,
+	window time.Duration,
+) (int, error) {
+	r.failures++
+	return r.failures, nil
+}
+
+type fakeUserService struct {
+	UserService
+	users []*models.User
+}
+
+func (s *fakeUserService) GetUserByEmail(email string, v *validator.Validator) (*models.User, error) {
+	for _, user := range s.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, e.ErrRecordNotFound
+}
+
+func (s *fakeUserService) GetUserByID(id uuid.UUID) (*models.User, error) {
+	for _, user := range s.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return nil, e.ErrRecordNotFound
+}
+
+func TestCompleteOIDCState(t *testing.T) {
+	provider := &fakeOIDCProvider{err: oidc.ErrInvalidIDToken}
+	identities := &fakeIdentityRepository{}
+
+	s := &AuthService{
+		identity:  identities,
+		authEvent: &fakeAuthEventRepository{},
+		oidc:      provider,
+		db:        newTestDB(t),
+	}
+
+	if _, _, err := s.StartOIDC(); err != nil {
+		t.Fatal(err)
+	}
+	pending := identities.states[0]
+
+	tests := []struct {
+		name  string
+		state string
+		err   error
+		field string
+		calls int
+	}{
+		{"forged state", "forged", e.ErrInvalidData, "state", 0},
+		{"missing state", "", e.ErrInvalidData, "state", 0},
+		{"issued state", pending.Plaintext, e.ErrInvalidCredentials, "", 1},
+		{"replayed state", pending.Plaintext, e.ErrInvalidData, "state", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+
+			_, err := s.CompleteOIDC(v, "code", tt.state, models.ClientInfo{})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("CompleteOIDC returned %v, want %v", err, tt.err)
+			}
+
+			if _, ok := v.Errors[tt.field]; tt.field != "" && !ok {
+				t.Errorf("no validation error for %q: %v", tt.field, v.Errors)
+			}
+
+			if provider.calls != tt.calls {
+				t.Errorf("provider was called %d times, want %d", provider.calls, tt.calls)
+			}
+		})
+	}
+
+	if provider.verifier != pending.CodeVerifier || provider.nonce != pending.Nonce {
+		t.Errorf("Exchange got verifier %q and nonce %q, want the ones of the issued state",
+			provider.verifier, provider.nonce)
+	}
+}
+
+func TestLinkIdentity(t *testing.T) {
+	active := &models.User{ID: uuid.New(), Email: "ana@example.com", Activated: true}
+	pending := &models.User{ID: uuid.New(), Email: "bia@example.com", Cod: 123456}
+	linked := &models.User{ID: uuid.New(), Email: "caio@example.com", Activated: true}
+	inactive := &models.User{ID: uuid.New(), Email: "davi@example.com"}
+
+	claims := func(subject, email string, verified bool) *oidc.Claims {
+		return &oidc.Claims{Subject: subject, Email: email, EmailVerified: verified}
+	}
+
+	tests := []struct {
+		name     string
+		claims   *oidc.Claims
+		user     *models.User
+		err      error
+		field    string
+		inserted bool
+		touched  bool
+		updated  bool
+	}{
+		{
+			name:     "verified email links the existing account",
+			claims:   claims("new", "ana@example.com", true),
+			user:     active,
+			inserted: true,
+		},
+		{
+			name:     "email is matched case insensitively",
+			claims:   claims("new", "ANA@example.com", true),
+			user:     active,
+			inserted: true,
+		},
+		{
+			name:   "unverified email does not link the existing account",
+			claims: claims("new", "ana@example.com", false),
+			err:    e.ErrInvalidData,
+			field:  "email",
+		},
+		{
+			name:   "missing email",
+			claims: claims("new", "", true),
+			err:    e.ErrInvalidData,
+			field:  "email",
+		},
+		{
+			name:   "no account with the email",
+			claims: claims("new", "eva@example.com", true),
+			err:    e.ErrInvalidData,
+			field:  "email",
+		},
+		{
+			name:     "verified email activates a pending account",
+			claims:   claims("new", "bia@example.com", true),
+			user:     pending,
+			inserted: true,
+			updated:  true,
+		},
+		{
+			name:    "known identity signs in without a verified email",
+			claims:  claims("linked", "", false),
+			user:    linked,
+			touched: true,
+		},
+		{
+			name:   "known identity of an inactive account",
+			claims: claims("inactive", "other@example.com", true),
+			err:    e.ErrInactiveAccount,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pending.Activated, pending.Cod = false, 123456
+
+			identities := &fakeIdentityRepository{identities: []*models.Identity{
+				{ID: uuid.New(), UserID: linked.ID, Issuer: testIssuer, Subject: "linked"},
+				{ID: uuid.New(), UserID: inactive.ID, Issuer: testIssuer, Subject: "inactive"},
+				{ID: uuid.New(), UserID: active.ID, Issuer: "https://other.example.com", Subject: "new"},
+			}}
+			users := &fakeUserRepository{users: []*models.User{active, pending, linked, inactive}}
+
+			s := &AuthService{
+				users:    users,
+				identity: identities,
+				oidc:     &fakeOIDCProvider{},
+				db:       newTestDB(t),
+			}
+
+			v := validator.New()
+			user, err := s.linkIdentity(tt.claims, v)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("linkIdentity returned %v, want %v", err, tt.err)
+			}
+
+			if _, ok := v.Errors[tt.field]; tt.field != "" && !ok {
+				t.Errorf("no validation error for %q: %v", tt.field, v.Errors)
+			}
+
+			if tt.user != nil && user != tt.user {
+				t.Errorf("linkIdentity returned user %v, want %v", user, tt.user)
+			}
+
+			inserted := len(identities.identities) == 4
+			if inserted != tt.inserted {
+				t.Errorf("identity inserted = %v, want %v", inserted, tt.inserted)
+			}
+			if inserted {
+				identity := identities.identities[3]
+				if identity.UserID != tt.user.ID || identity.Issuer != testIssuer || identity.Subject != tt.claims.Subject {
+					t.Errorf("unexpected identity %+v", identity)
+				}
+			}
+
+			if touched := len(identities.touched) == 1; touched != tt.touched {
+				t.Errorf("last login touched = %v, want %v", touched, tt.touched)
+			}
+
+			if updated := len(users.updated) == 1; updated != tt.updated {
+				t.Errorf("user updated = %v, want %v", updated, tt.updated)
+			}
+			if tt.updated && (!pending.Activated || pending.Cod != 0) {
+				t.Errorf("pending account was not activated: %+v", pending)
+			}
+		})
+	}
+}
+
+func TestLoginUnknownEmailChecksAPassword(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "ana@example.com", Activated: true}
+	if err := user.Password.Set("correct horse battery staple"); err != nil {
+		t.Fatal(err)
+	}
+
+	events := &fakeAuthEventRepository{}
+	s := &AuthService{
+		user:      &fakeUserService{users: []*models.User{user}},
+		authEvent: events,
+		db:        newTestDB(t),
+	}
+
+	login := func(email string) time.Duration {
+		start := time.Now()
+
+		_, err := s.Login(validator.New(), email, "wrong password", models.ClientInfo{})
+		if !errors.Is(err, e.ErrInvalidCredentials) {
+			t.Fatalf("Login as %s returned %v, want ErrInvalidCredentials", email, err)
+		}
+
+		return time.Since(start)
+	}
+
+	// Warm up the dummy hash, which is created on first use.
+	login("nobody@example.com")
+
+	wrongPassword := login("ana@example.com")
+	unknownEmail := login("nobody@example.com")
+
+	// Both paths run one hash check; without it the unknown email answers
+	// orders of magnitude faster.
+	if unknownEmail < wrongPassword/3 {
+		t.Errorf("unknown email took %v, a wrong password %v", unknownEmail, wrongPassword)
+	}
+
+	if events.failures != 3 || len(events.events) != 3 {
+		t.Errorf("recorded %d failures and %d events, want 3 of each", events.failures, len(events.events))
+	}
+	if events.events[2].UserID != nil {
+		t.Error("the failure for an unknown email was attributed to a user")
+	}
+}
+
+func TestLoginDelay(t *testing.T) {
+	s := &AuthService{}
+	s.config.Login.BackoffAfter = 3
+	s.config.Login.BackoffBase = time.Second
+	s.config.Login.LockoutAfter = 10
+	s.config.Login.LockoutDuration = 15 * time.Minute
+
+	tests := []struct {
+		failures int
+		delay    time.Duration
+	}{
+		{0, 0},
+		{1, 0},
+		{2, 0},
+		{3, time.Second},
+		{4, 2 * time.Second},
+		{5, 4 * time.Second},
+		{9, 64 * time.Second},
+		{10, 15 * time.Minute},
+		{100, 15 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := s.loginDelay(tt.failures); got != tt.delay {
+			t.Errorf("loginDelay(%d) = %v, want %v", tt.failures, got, tt.delay)
+		}
+	}
+
+	// The backoff never waits longer than the lockout.
+	s.config.Login.BackoffBase = 5 * time.Minute
+	if got := s.loginDelay(9); got != 15*time.Minute {
+		t.Errorf("loginDelay(9) with a long base = %v, want the lockout duration", got)
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	const correct = "correct horse battery staple"
+
+	user := &models.User{ID: uuid.New(), Email: "ana@example.com", Activated: true}
+	if err := user.Password.Set(correct); err != nil {
+		t.Fatal(err)
+	}
+
+	events := &fakeAuthEventRepository{}
+	s := &AuthService{
+		user:      &fakeUserService{users: []*models.User{user}},
+		authEvent: events,
+		db:        newTestDB(t),
+	}
+	s.config.Login.BackoffAfter = 2
+	s.config.Login.BackoffBase = time.Minute
+	s.config.Login.LockoutAfter = 5
+	s.config.Login.LockoutDuration = 15 * time.Minute
+
+	// The steps run in order against the same email.
+	steps := []struct {
+		name     string
+		password string
+		err      error
+		reason   string
+	}{
+		{"first failure", "wrong password", e.ErrInvalidCredentials, models.AuthReasonInvalidCredentials},
+		{"second failure starts the backoff", "wrong password", e.ErrInvalidCredentials, models.AuthReasonInvalidCredentials},
+		{"correct password while locked", correct, e.ErrAccountLocked, models.AuthReasonLocked},
+	}
+
+	for _, tt := range steps {
+		_, err := s.Login(validator.New(), user.Email, tt.password, models.ClientInfo{})
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("%s: Login returned %v, want %v", tt.name, err, tt.err)
+		}
+
+		last := events.events[len(events.events)-1]
+		if last.Success || last.Reason == nil || *last.Reason != tt.reason {
+			t.Errorf("%s: recorded %+v, want a failure for %s", tt.name, last, tt.reason)
+		}
+	}
+
+	if events.failures != 2 {
+		t.Errorf("recorded %d failures, want 2; a locked attempt is not a failure", events.failures)
+	}
+
+	until := events.locks[user.Email]
+	if delay := time.Until(until); delay <= 0 || delay > time.Minute {
+		t.Errorf("locked for %v, want up to a minute", delay)
+	}
+}
+
+func TestLoginUpgradesPasswordHash(t *testing.T) {
+	const password = "correct horse battery staple"
+
+	bcryptHash, err := hashing.Bcrypt{Cost: 4}.Hash(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		algorithm string
+		upgraded  bool
+	}{
+		{"bcrypt hash", hashing.AlgorithmBcrypt, true},
+		{"current hash", hashing.Default(), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The user has MFA so the login stops at the pending token and no
+			// signing keys are needed.
+			user := &models.User{ID: uuid.New(), Email: "ana@example.com", Activated: true, TOTPEnabled: true}
+			if tt.upgraded {
+				user.Password.Hash, user.Password.Algorithm = bcryptHash, hashing.AlgorithmBcrypt
+			} else if err := user.Password.Set(password); err != nil {
+				t.Fatal(err)
+			}
+			previous := user.Password.Hash
+
+			users := &fakeUserRepository{users: []*models.User{user}}
+			s := &AuthService{
+				user:      &fakeUserService{users: []*models.User{user}},
+				users:     users,
+				token:     &fakeTokenRepository{},
+				authEvent: &fakeAuthEventRepository{},
+				db:        newTestDB(t),
+			}
+
+			result, err := s.Login(validator.New(), user.Email, password, models.ClientInfo{})
+			if err != nil || result.MFAToken == nil {
+				t.Fatalf("Login returned %+v, %v", result, err)
+			}
+
+			replaced, upgraded := users.upgraded[user.ID]
+			if upgraded != tt.upgraded {
+				t.Fatalf("upgraded = %v, want %v", upgraded, tt.upgraded)
+			}
+
+			if user.Password.Algorithm != hashing.Default() {
+				t.Errorf("algorithm = %q, want %q", user.Password.Algorithm, hashing.Default())
+			}
+			if match, _ := user.Password.Matches(password); !match {
+				t.Error("the password no longer matches")
+			}
+			if upgraded && !bytes.Equal(replaced, previous) {
+				t.Error("the upgrade did not guard on the hash that was read")
+			}
+		})
+	}
+}
+
+// GetByHash follows the repository contract: only unexpired tokens of the
+// scope are found.
+func (r *fakeTokenRepository) GetByHash(scope string, tokenHash []byte) (*models.Token, error) {
+	for _, token := range r.tokens {
+		if token.Scope == scope && bytes.Equal(token.Hash, tokenHash) && token.Expiry.After(time.Now()) {
+			return token, nil
+		}
+	}
+	return nil, e.ErrRecordNotFound
+}
+
+func (r *fakeTokenRepository) Delete(tx *sql.Tx, scope string, tokenHash []byte, userID uuid.UUID) error {
+	for i, token := range r.tokens {
+		if token.Scope == scope && bytes.Equal(token.Hash, tokenHash) && token.UserID == userID {
+			r.tokens = append(r.tokens[:i], r.tokens[i+1:]...)
+			return nil
+		}
+	}
+	return e.ErrRecordNotFound
+}
+
+func TestRefresh(t *testing.T) {
+	var cfg config.Config
+	cfg.Security.SecretKey = "secret"
+	cfg.Security.AccessTokenTTL = 15 * time.Minute
+	cfg.Security.RefreshTokenTTL = 24 * time.Hour
+	cfg.Security.JWTIssuer = "moodtracker"
+	cfg.Security.JWTAudience = "moodtracker-api"
+
+	keys, err := signing.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	active := &models.User{ID: uuid.New(), Activated: true}
+	inactive := &models.User{ID: uuid.New()}
+
+	valid := models.GenerateToken(active.ID, time.Hour, models.ScopeRefresh)
+	expired := models.GenerateToken(active.ID, -time.Minute, models.ScopeRefresh)
+	reset := models.GenerateToken(active.ID, time.Hour, models.ScopePasswordReset)
+	ofInactive := models.GenerateToken(inactive.ID, time.Hour, models.ScopeRefresh)
+
+	tokens := &fakeTokenRepository{tokens: []*models.Token{valid, expired, reset, ofInactive}}
+	s := &AuthService{
+		user:   &fakeUserService{users: []*models.User{active, inactive}},
+		token:  tokens,
+		db:     newTestDB(t),
+		keys:   keys,
+		config: cfg,
+	}
+
+	refresh := func(plaintext string) (*models.AuthTokens, error) {
+		return s.Refresh(validator.New(), plaintext)
+	}
+
+	rotated, err := refresh(valid.Plaintext)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if rotated.RefreshToken == valid.Plaintext {
+		t.Error("the refresh token was not rotated")
+	}
+	if claims, err := s.ExtractClaims(rotated.AccessToken); err != nil || claims.UserID != active.ID {
+		t.Errorf("access token claims = %+v, %v", claims, err)
+	}
+
+	tests := []struct {
+		name      string
+		plaintext string
+		err       error
+	}{
+		{"used token", valid.Plaintext, e.ErrInvalidCredentials},
+		{"expired token", expired.Plaintext, e.ErrInvalidCredentials},
+		{"token of another scope", reset.Plaintext, e.ErrInvalidCredentials},
+		{"unknown token", strings.Repeat("A", 26), e.ErrInvalidCredentials},
+		{"malformed token", "short", e.ErrInvalidData},
+		{"inactive account", ofInactive.Plaintext, e.ErrInactiveAccount},
+		{"rotated token", rotated.RefreshToken, nil},
+		{"rotated token again", rotated.RefreshToken, e.ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		if _, err := refresh(tt.plaintext); !errors.Is(err, tt.err) {
+			t.Errorf("%s: Refresh returned %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	// Each rotation leaves exactly one live refresh token for the user.
+	live := 0
+	for _, token := range tokens.tokens {
+		if token.UserID == active.ID && token.Scope == models.ScopeRefresh && token.Expiry.After(time.Now()) {
+			live++
+		}
+	}
+	if live != 1 {
+		t.Errorf("%d live refresh tokens, want 1", live)
+	}
+}
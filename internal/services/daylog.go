@@ -3,102 +3,427 @@ package services
 import (
 	"database/sql"
 	"moodtracker/internal/models"
+	"moodtracker/internal/models/filters"
 	"moodtracker/internal/repositories"
 	"moodtracker/utils"
 	e "moodtracker/utils/errors"
 	"moodtracker/utils/validator"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type daylogServices struct {
-	daylog repositories.DaylogRepository
-	tag    TagService
-	db     *sql.DB
+	daylog  repositories.DaylogRepository
+	users   repositories.UserRepositoryInterface
+	tag     repositories.TagRepository
+	tracker repositories.TrackerRepository
+	db      *sql.DB
 }
 
+// maxSummaryDays bounds the range of a single daily summary request.
+const maxSummaryDays = 366
+
 func NewDaylogService(
 	daylog repositories.DaylogRepository,
+	users repositories.UserRepositoryInterface,
 	db *sql.DB,
-	tag TagService,
+	tag repositories.TagRepository,
+	tracker repositories.TrackerRepository,
 ) *daylogServices {
 	return &daylogServices{
-		daylog: daylog,
-		db:     db,
-		tag:    tag,
+		daylog:  daylog,
+		users:   users,
+		db:      db,
+		tag:     tag,
+		tracker: tracker,
 	}
 }
 
 type DaylogServices interface {
+	GetAll(
+		description string,
+		tags []string,
+		moodLabel *models.MoodLabel,
+		startDate, endDate *time.Time,
+		userID uuid.UUID,
+		f filters.Filters,
+		v *validator.Validator,
+	) ([]*models.Daylog, filters.Metadata, error)
 	GetAllByYear(
 		year int,
 		userID uuid.UUID,
 	) ([]*models.Daylog, error)
+	GetDailySummaries(
+		startDate, endDate time.Time,
+		userID uuid.UUID,
+		v *validator.Validator,
+	) ([]*models.DailySummary, error)
 	Save(model *models.Daylog, userID uuid.UUID, v *validator.Validator) error
 	FindByID(id, userID uuid.UUID) (*models.Daylog, error)
 	Update(model *models.Daylog, userID uuid.UUID, v *validator.Validator) error
 	Delete(id, userID uuid.UUID) error
+	GetRevisions(
+		id, userID uuid.UUID,
+		f filters.Filters,
+		v *validator.Validator,
+	) ([]*models.DaylogRevision, filters.Metadata, error)
+	DiffRevisions(id, userID uuid.UUID, from, to int, v *validator.Validator) (*models.RevisionDiff, error)
+	RevertToRevision(
+		current *models.Daylog,
+		version int,
+		userID uuid.UUID,
+		v *validator.Validator,
+	) (*models.Daylog, error)
+}
+
+func (s *daylogServices) GetAll(
+	description string,
+	tags []string,
+	moodLabel *models.MoodLabel,
+	startDate, endDate *time.Time,
+	userID uuid.UUID,
+	f filters.Filters,
+	v *validator.Validator,
+) ([]*models.Daylog, filters.Metadata, error) {
+	filters.ValidateFilters(v, f)
+
+	if moodLabel != nil {
+		models.ValidateMoodLabel(v, *moodLabel)
+	}
+
+	if startDate != nil && endDate != nil {
+		v.Check(!startDate.After(*endDate), "start_date", e.ErrStartDateAfterEndDate.Error())
+	}
+
+	if !v.Valid() {
+		return nil, filters.Metadata{}, e.ErrInvalidData
+	}
+
+	logs, metadata, err := s.daylog.GetAll(description, tags, moodLabel, startDate, endDate, userID, f)
+	if err != nil {
+		return nil, filters.Metadata{}, err
+	}
+
+	return logs, metadata, s.attachTrackers(logs...)
 }
 
 func (s *daylogServices) GetAllByYear(
 	year int,
 	userID uuid.UUID,
 ) ([]*models.Daylog, error) {
-	return s.daylog.GetAllByYear(year, userID)
+	logs, err := s.daylog.GetAllByYear(year, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return logs, s.attachTrackers(logs...)
+}
+
+func (s *daylogServices) GetDailySummaries(
+	startDate, endDate time.Time,
+	userID uuid.UUID,
+	v *validator.Validator,
+) ([]*models.DailySummary, error) {
+	v.Check(!startDate.After(endDate), "start_date", e.ErrStartDateAfterEndDate.Error())
+	v.Check(endDate.Sub(startDate) < maxSummaryDays*24*time.Hour,
+		"end_date", "must be less than 366 days after start_date")
+
+	if !v.Valid() {
+		return nil, e.ErrInvalidData
+	}
+
+	return s.daylog.GetDailySummaries(startDate, endDate, userID)
+}
+
+// resolveCheckIn defaults the time zone of a check-in to the user's profile
+// before deriving its date.
+func (s *daylogServices) resolveCheckIn(model *models.Daylog, userID uuid.UUID, v *validator.Validator) error {
+	if model.IsCheckIn() && model.TimeZone == nil {
+		user, err := s.users.GetByID(userID)
+		if err != nil {
+			return err
+		}
+		model.TimeZone = &user.TimeZone
+	}
+
+	model.ResolveCheckIn(v)
+	return nil
 }
 
 func (s *daylogServices) Save(model *models.Daylog, userID uuid.UUID, v *validator.Validator) error {
+	if err := s.resolveCheckIn(model, userID, v); err != nil {
+		return err
+	}
+
+	if err := s.resolveTrackers(model, userID, v); err != nil {
+		return err
+	}
+
 	return utils.RunInTx(s.db, func(tx *sql.Tx) error {
 		if model.ValidateDaylog(v); !v.Valid() {
 			return e.ErrInvalidData
 		}
 
-		err := s.daylog.InsertOrUpdate(tx, model, userID)
+		var err error
+		if model.IsCheckIn() {
+			err = s.daylog.InsertCheckIn(tx, model, userID)
+		} else {
+			if err := s.daylog.InsertDailyRevision(tx, model.Date, userID); err != nil {
+				return err
+			}
+			err = s.daylog.InsertOrUpdate(tx, model, userID)
+		}
 		if err != nil {
 			return err
 		}
 
-		tagsIDs := make([]uuid.UUID, 0, len(model.Tags))
-
-		for _, tagName := range model.Tags {
-			tagID, err := s.tag.GetIDByNameOrCreate(v, tagName, userID)
-			if err != nil {
-				return err
-			}
-			tagsIDs = append(tagsIDs, tagID)
+		if err := s.syncTags(tx, model, userID); err != nil {
+			return err
 		}
 
-		for _, tagID := range tagsIDs {
-			if err := s.daylog.InsertLogsTags(tx, model.ID, tagID); err != nil {
-				return err
-			}
+		return s.syncTrackers(tx, model)
+	})
+}
+
+// syncTags makes the tag links of the log match model.Tags, creating missing
+// tags in the caller's transaction. A nil slice leaves the links untouched,
+// an empty one removes them all.
+func (s *daylogServices) syncTags(tx *sql.Tx, model *models.Daylog, userID uuid.UUID) error {
+	if model.Tags == nil {
+		return nil
+	}
+
+	tagIDs := make([]uuid.UUID, 0, len(model.Tags))
+
+	for _, tagName := range model.Tags {
+		tagID, err := s.tag.GetIDByNameOrCreate(tx, tagName, userID)
+		if err != nil {
+			return err
 		}
+		tagIDs = append(tagIDs, tagID)
+	}
 
+	return s.daylog.SyncLogTags(tx, model.ID, tagIDs)
+}
+
+// resolveTrackers checks the tracker values of model against the user's
+// trackers. Like tags, nil Trackers leaves the recorded values untouched.
+func (s *daylogServices) resolveTrackers(model *models.Daylog, userID uuid.UUID, v *validator.Validator) error {
+	if len(model.Trackers) == 0 {
 		return nil
-	})
+	}
+
+	ids := make([]uuid.UUID, 0, len(model.Trackers))
+	for _, value := range model.Trackers {
+		ids = append(ids, value.TrackerID)
+	}
+
+	trackers, err := s.tracker.FindByIDs(ids, userID)
+	if err != nil {
+		return err
+	}
+
+	models.ResolveTrackerValues(v, model.Trackers, trackers)
+	return nil
+}
+
+func (s *daylogServices) syncTrackers(tx *sql.Tx, model *models.Daylog) error {
+	if model.Trackers == nil {
+		return nil
+	}
+
+	return s.tracker.SyncLogValues(tx, model.ID, model.Trackers)
+}
+
+// attachTrackers loads the tracker values of logs with a single query.
+func (s *daylogServices) attachTrackers(logs ...*models.Daylog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*models.Daylog, len(logs))
+	ids := make([]uuid.UUID, 0, len(logs))
+	for _, log := range logs {
+		byID[log.ID] = log
+		ids = append(ids, log.ID)
+	}
+
+	values, err := s.tracker.GetLogValues(ids)
+	if err != nil {
+		return err
+	}
+
+	for _, value := range values {
+		log := byID[value.LogID]
+		log.Trackers = append(log.Trackers, value)
+	}
+
+	return nil
 }
 
 func (s *daylogServices) FindByID(id, userID uuid.UUID) (*models.Daylog, error) {
-	return s.daylog.GetByID(id, userID)
+	log, err := s.daylog.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return log, s.attachTrackers(log)
 }
 
 func (s *daylogServices) Update(model *models.Daylog, userID uuid.UUID, v *validator.Validator) error {
+	if err := s.resolveCheckIn(model, userID, v); err != nil {
+		return err
+	}
+
+	if err := s.resolveTrackers(model, userID, v); err != nil {
+		return err
+	}
+
 	return utils.RunInTx(s.db, func(tx *sql.Tx) error {
 		if model.ValidateDaylog(v); !v.Valid() {
 			return e.ErrInvalidData
 		}
 
-		return s.daylog.Update(tx, model, userID)
+		if err := s.daylog.InsertRevision(tx, model.ID, model.Version, userID); err != nil {
+			return err
+		}
+
+		if err := s.daylog.Update(tx, model, userID); err != nil {
+			return err
+		}
+
+		if err := s.syncTags(tx, model, userID); err != nil {
+			return err
+		}
+
+		return s.syncTrackers(tx, model)
 	})
 }
 
 func (s *daylogServices) Delete(id, userID uuid.UUID) error {
 	return utils.RunInTx(s.db, func(tx *sql.Tx) error {
-		err := s.daylog.Delete(tx, id, userID)
-		if err != nil {
-			return err
-		}
+		return s.daylog.Delete(tx, id, userID)
+	})
+}
 
-		return s.daylog.DeleteLogTagByDaylogID(tx, id)
+func (s *daylogServices) GetRevisions(
+	id, userID uuid.UUID,
+	f filters.Filters,
+	v *validator.Validator,
+) ([]*models.DaylogRevision, filters.Metadata, error) {
+	if filters.ValidateFilters(v, f); !v.Valid() {
+		return nil, filters.Metadata{}, e.ErrInvalidData
+	}
+
+	if _, err := s.daylog.GetByID(id, userID); err != nil {
+		return nil, filters.Metadata{}, err
+	}
+
+	return s.daylog.GetRevisions(id, userID, f)
+}
+
+// DiffRevisions compares two versions of a log. A to of zero means the
+// current version.
+func (s *daylogServices) DiffRevisions(
+	id, userID uuid.UUID,
+	from, to int,
+	v *validator.Validator,
+) (*models.RevisionDiff, error) {
+	current, err := s.FindByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if to == 0 {
+		to = current.Version
+	}
+
+	v.Check(from > 0, "from", "must be greater than zero")
+	v.Check(to > 0, "to", "must be greater than zero")
+	v.Check(to <= current.Version, "to", "must not be greater than the current version")
+
+	if !v.Valid() {
+		return nil, e.ErrInvalidData
+	}
+
+	fromRev, err := s.revision(current, from, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	toRev, err := s.revision(current, to, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.DiffRevisions(fromRev, toRev), nil
+}
+
+// RevertToRevision saves the content of an earlier version as a new version
+// of current. The version being replaced is kept in the history as usual.
+func (s *daylogServices) RevertToRevision(
+	current *models.Daylog,
+	version int,
+	userID uuid.UUID,
+	v *validator.Validator,
+) (*models.Daylog, error) {
+	if v.Check(version != current.Version, "version", "is already the current version"); !v.Valid() {
+		return nil, e.ErrInvalidData
+	}
+
+	rev, err := s.daylog.GetRevision(current.ID, version, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	model := rev.ToDaylog()
+	model.Version = current.Version
+
+	if err := s.dropDeletedTrackers(model, userID); err != nil {
+		return nil, err
+	}
+
+	if err := s.Update(model, userID, v); err != nil {
+		return nil, err
+	}
+
+	return s.FindByID(current.ID, userID)
+}
+
+// dropDeletedTrackers removes the values of trackers deleted since the
+// revision was saved, which can't be restored.
+func (s *daylogServices) dropDeletedTrackers(model *models.Daylog, userID uuid.UUID) error {
+	if len(model.Trackers) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(model.Trackers))
+	for _, value := range model.Trackers {
+		ids = append(ids, value.TrackerID)
+	}
+
+	trackers, err := s.tracker.FindByIDs(ids, userID)
+	if err != nil {
+		return err
+	}
+
+	exists := make(map[uuid.UUID]bool, len(trackers))
+	for _, t := range trackers {
+		exists[t.ID] = true
+	}
+
+	model.Trackers = slices.DeleteFunc(model.Trackers, func(value *models.TrackerValue) bool {
+		return !exists[value.TrackerID]
 	})
+	return nil
+}
+
+func (s *daylogServices) revision(current *models.Daylog, version int, userID uuid.UUID) (*models.DaylogRevision, error) {
+	if version == current.Version {
+		return models.RevisionOf(current), nil
+	}
+
+	return s.daylog.GetRevision(current.ID, version, userID)
 }
@@ -0,0 +1,403 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"moodtracker/internal/models"
+	"moodtracker/internal/models/filters"
+	"moodtracker/internal/repositories"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type fakeDaylogRepository struct {
+	repositories.DaylogRepository
+	listed    bool
+	synced    [][]uuid.UUID
+	current   *models.Daylog
+	revisions map[int]*models.DaylogRevision
+}
+
+func (r *fakeDaylogRepository) GetAll(
+	description string,
+	tags []string,
+	moodLabel *models.MoodLabel,
+	startDate, endDate *time.Time,
+	userID uuid.UUID,
+	f filters.Filters,
+) ([]*models.Daylog, filters.Metadata, error) {
+	r.listed = true
+	return []*models.Daylog{}, filters.Metadata{}, nil
+}
+
+func TestDaylogGetAllValidation(t *testing.T) {
+	day := func(d int) *time.Time {
+		t := time.Date(2026, 2, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	mood := func(m models.MoodLabel) *models.MoodLabel { return &m }
+
+	valid := filters.Filters{Page: 1, PageSize: 20, Sort: "-date", SortSafelist: []string{"date", "-date"}}
+	withFilters := func(change func(f *filters.Filters)) filters.Filters {
+		f := valid
+		change(&f)
+		return f
+	}
+
+	tests := []struct {
+		name      string
+		mood      *models.MoodLabel
+		startDate *time.Time
+		endDate   *time.Time
+		filters   filters.Filters
+		field     string
+	}{
+		{"no filters", nil, nil, nil, valid, ""},
+		{"date range", nil, day(1), day(28), valid, ""},
+		{"single day range", nil, day(10), day(10), valid, ""},
+		{"only a start date", nil, day(10), nil, valid, ""},
+		{"mood", mood(4), nil, nil, valid, ""},
+		{"start after end", nil, day(11), day(10), valid, "start_date"},
+		{"unknown mood", mood(models.MoodUnknown), nil, nil, valid, "mood_label"},
+		{"mood out of the scale", mood(9), nil, nil, valid, "mood_label"},
+		{"page zero", nil, nil, nil, withFilters(func(f *filters.Filters) { f.Page = 0 }), "page"},
+		{"page size too big", nil, nil, nil, withFilters(func(f *filters.Filters) { f.PageSize = 101 }), "page_size"},
+		{"unsafe sort", nil, nil, nil, withFilters(func(f *filters.Filters) { f.Sort = "id; DROP TABLE" }), "sort"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeDaylogRepository{}
+			s := &daylogServices{daylog: repo}
+			v := validator.New()
+
+			_, _, err := s.GetAll("", nil, tt.mood, tt.startDate, tt.endDate, uuid.New(), tt.filters, v)
+
+			if tt.field == "" {
+				if err != nil || !repo.listed {
+					t.Errorf("GetAll returned %v with errors %v", err, v.Errors)
+				}
+				return
+			}
+
+			if !errors.Is(err, e.ErrInvalidData) {
+				t.Fatalf("GetAll returned %v, want ErrInvalidData", err)
+			}
+			if _, ok := v.Errors[tt.field]; !ok {
+				t.Errorf("errors = %v, want one for %q", v.Errors, tt.field)
+			}
+			if repo.listed {
+				t.Error("the repository was queried with invalid filters")
+			}
+		})
+	}
+}
+
+func (r *fakeDaylogRepository) GetDailySummaries(
+	startDate, endDate time.Time,
+	userID uuid.UUID,
+) ([]*models.DailySummary, error) {
+	r.listed = true
+	return []*models.DailySummary{}, nil
+}
+
+func TestDaylogGetDailySummariesValidation(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		end   time.Time
+		field string
+	}{
+		{"a single day", start, ""},
+		{"thirty days", start.AddDate(0, 0, 29), ""},
+		{"366 days", start.AddDate(0, 0, 365), ""},
+		{"367 days", start.AddDate(0, 0, 366), "end_date"},
+		{"end before start", start.AddDate(0, 0, -1), "start_date"},
+	}
+
+	for _, tt := range tests {
+		repo := &fakeDaylogRepository{}
+		s := &daylogServices{daylog: repo}
+		v := validator.New()
+
+		_, err := s.GetDailySummaries(start, tt.end, uuid.New(), v)
+
+		if tt.field == "" {
+			if err != nil || !repo.listed {
+				t.Errorf("%s: GetDailySummaries returned %v with errors %v", tt.name, err, v.Errors)
+			}
+			continue
+		}
+
+		if _, ok := v.Errors[tt.field]; !errors.Is(err, e.ErrInvalidData) || !ok {
+			t.Errorf("%s: GetDailySummaries returned %v with errors %v, want one for %q", tt.name, err, v.Errors, tt.field)
+		}
+		if repo.listed {
+			t.Errorf("%s: the repository was queried", tt.name)
+		}
+	}
+}
+
+func TestDaylogResolveCheckInDefaultsToProfileTimeZone(t *testing.T) {
+	user := &models.User{ID: uuid.New(), TimeZone: "Asia/Tokyo"}
+	s := &daylogServices{users: &fakeUserRepository{users: []*models.User{user}}}
+
+	loggedAt := time.Date(2026, 3, 10, 16, 0, 0, 0, time.UTC)
+	saoPaulo := "America/Sao_Paulo"
+
+	tests := []struct {
+		name     string
+		timeZone *string
+		zone     string
+		date     time.Time
+	}{
+		{"profile time zone", nil, "Asia/Tokyo", time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)},
+		{"time zone sent with the check-in", &saoPaulo, saoPaulo, time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		model := &models.Daylog{LoggedAt: &loggedAt, TimeZone: tt.timeZone, MoodLabel: 3}
+		v := validator.New()
+
+		if err := s.resolveCheckIn(model, user.ID, v); err != nil || !v.Valid() {
+			t.Fatalf("%s: resolveCheckIn returned %v with errors %v", tt.name, err, v.Errors)
+		}
+		if *model.TimeZone != tt.zone || !model.Date.Equal(tt.date) {
+			t.Errorf("%s: got %s on %v, want %s on %v", tt.name, *model.TimeZone, model.Date, tt.zone, tt.date)
+		}
+	}
+
+	// A daily entry never needs the profile.
+	daily := &models.Daylog{Date: time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)}
+	s.users = nil
+	if err := s.resolveCheckIn(daily, user.ID, validator.New()); err != nil || daily.TimeZone != nil {
+		t.Errorf("resolveCheckIn of a daily entry returned %v with time zone %v", err, daily.TimeZone)
+	}
+}
+
+func (r *fakeDaylogRepository) SyncLogTags(tx *sql.Tx, daylogID uuid.UUID, tagIDs []uuid.UUID) error {
+	r.synced = append(r.synced, tagIDs)
+	return nil
+}
+
+type fakeTagRepository struct {
+	repositories.TagRepository
+	ids map[string]uuid.UUID
+}
+
+// GetIDByNameOrCreate follows the repository contract: names are matched
+// case insensitively and a missing tag is created.
+func (r *fakeTagRepository) GetIDByNameOrCreate(tx *sql.Tx, name string, userID uuid.UUID) (uuid.UUID, error) {
+	key := strings.ToLower(name)
+	if id, ok := r.ids[key]; ok {
+		return id, nil
+	}
+
+	id := uuid.New()
+	r.ids[key] = id
+	return id, nil
+}
+
+func TestDaylogSyncTags(t *testing.T) {
+	work, gym := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name   string
+		tags   []string
+		synced bool
+		want   []uuid.UUID
+		ids    int
+	}{
+		{"tags left out keep the links", nil, false, nil, 2},
+		{"empty list removes every link", []string{}, true, []uuid.UUID{}, 2},
+		{"existing tags", []string{"gym", "work"}, true, []uuid.UUID{gym, work}, 2},
+		{"existing tag in another case", []string{"Work"}, true, []uuid.UUID{work}, 2},
+		{"new tag is created", []string{"work", "reading"}, true, nil, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			daylogs := &fakeDaylogRepository{}
+			tags := &fakeTagRepository{ids: map[string]uuid.UUID{"work": work, "gym": gym}}
+			s := &daylogServices{daylog: daylogs, tag: tags}
+
+			model := &models.Daylog{ID: uuid.New(), Tags: tt.tags}
+			if err := s.syncTags(nil, model, uuid.New()); err != nil {
+				t.Fatal(err)
+			}
+
+			if synced := len(daylogs.synced) == 1; synced != tt.synced {
+				t.Fatalf("synced = %v, want %v", synced, tt.synced)
+			}
+			if !tt.synced {
+				return
+			}
+
+			got := daylogs.synced[0]
+			if len(got) != len(tt.tags) || (tt.want != nil && !slices.Equal(got, tt.want)) {
+				t.Errorf("synced %v, want %v", got, tt.want)
+			}
+			if len(tags.ids) != tt.ids {
+				t.Errorf("%d tags exist, want %d", len(tags.ids), tt.ids)
+			}
+		})
+	}
+}
+
+func (r *fakeDaylogRepository) GetByID(id, userID uuid.UUID) (*models.Daylog, error) {
+	if r.current == nil || r.current.ID != id {
+		return nil, e.ErrRecordNotFound
+	}
+	log := *r.current
+	return &log, nil
+}
+
+func (r *fakeDaylogRepository) GetRevision(id uuid.UUID, version int, userID uuid.UUID) (*models.DaylogRevision, error) {
+	rev, ok := r.revisions[version]
+	if !ok {
+		return nil, e.ErrRecordNotFound
+	}
+	return rev, nil
+}
+
+func TestDaylogDiffRevisions(t *testing.T) {
+	date := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	current := &models.Daylog{
+		ID:          uuid.New(),
+		Date:        date,
+		Description: "went for a long run",
+		MoodLabel:   5,
+		BaseModel:   models.BaseModel{Version: 3, CreatedAt: date},
+	}
+
+	sleep := uuid.New()
+	value := func(hours float64) *models.TrackerValue {
+		return &models.TrackerValue{LogID: current.ID, TrackerID: sleep, Name: "sleep", Kind: models.TrackerDecimal, Value: hours}
+	}
+
+	repo := &fakeDaylogRepository{
+		current: current,
+		revisions: map[int]*models.DaylogRevision{
+			// Saved before tracker values were kept.
+			1: {LogID: current.ID, Version: 1, Date: date, Description: "went for a run", MoodLabel: 4},
+			2: {LogID: current.ID, Version: 2, Date: date, Description: "went for a run", MoodLabel: 5,
+				Trackers: models.RevisionTrackers{value(6)}},
+		},
+	}
+	trackers := &fakeTrackerRepository{values: []*models.TrackerValue{value(7.5)}}
+	s := &daylogServices{daylog: repo, tracker: trackers}
+
+	tests := []struct {
+		name    string
+		id      uuid.UUID
+		from    int
+		to      int
+		err     error
+		field   string
+		changes []string
+	}{
+		{"two stored versions", current.ID, 1, 2, nil, "", []string{"mood_label"}},
+		{"up to the current version", current.ID, 2, 3, nil, "", []string{"description", "trackers.sleep"}},
+		{"to defaults to the current version", current.ID, 1, 0, nil, "", []string{"mood_label", "description"}},
+		{"newer to older", current.ID, 3, 1, nil, "", []string{"mood_label", "description"}},
+		{"same version", current.ID, 2, 2, nil, "", []string{}},
+		{"no from", current.ID, 0, 2, e.ErrInvalidData, "from", nil},
+		{"to after the current version", current.ID, 1, 4, e.ErrInvalidData, "to", nil},
+		{"negative to", current.ID, 1, -1, e.ErrInvalidData, "to", nil},
+		{"unknown log", uuid.New(), 1, 2, e.ErrRecordNotFound, "", nil},
+	}
+
+	for _, tt := range tests {
+		v := validator.New()
+
+		diff, err := s.DiffRevisions(tt.id, uuid.New(), tt.from, tt.to, v)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if tt.field != "" {
+			if _, ok := v.Errors[tt.field]; !ok {
+				t.Errorf("%s: errors = %v, want one for %s", tt.name, v.Errors, tt.field)
+			}
+		}
+		if err != nil {
+			continue
+		}
+
+		fields := []string{}
+		for _, c := range diff.Changes {
+			fields = append(fields, c.Field)
+		}
+		if !slices.Equal(fields, tt.changes) {
+			t.Errorf("%s: changed %q, want %q", tt.name, fields, tt.changes)
+		}
+	}
+}
+
+func TestDaylogRevertToRevisionValidation(t *testing.T) {
+	current := &models.Daylog{ID: uuid.New(), BaseModel: models.BaseModel{Version: 3}}
+	s := &daylogServices{daylog: &fakeDaylogRepository{current: current}}
+
+	tests := []struct {
+		name    string
+		version int
+		err     error
+	}{
+		{"current version", 3, e.ErrInvalidData},
+		{"missing revision", 2, e.ErrRecordNotFound},
+	}
+
+	for _, tt := range tests {
+		v := validator.New()
+
+		if _, err := s.RevertToRevision(current, tt.version, uuid.New(), v); !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestDaylogDropDeletedTrackers(t *testing.T) {
+	sleep, steps := uuid.New(), uuid.New()
+	s := &daylogServices{tracker: &fakeTrackerRepository{trackers: map[uuid.UUID]*models.Tracker{
+		sleep: {ID: sleep, Name: "sleep", Kind: models.TrackerDecimal},
+	}}}
+
+	tests := []struct {
+		name     string
+		trackers []*models.TrackerValue
+		want     []uuid.UUID
+	}{
+		{"not versioned", nil, nil},
+		{"no values", []*models.TrackerValue{}, []uuid.UUID{}},
+		{"existing tracker", []*models.TrackerValue{{TrackerID: sleep}}, []uuid.UUID{sleep}},
+		{"deleted tracker", []*models.TrackerValue{{TrackerID: sleep}, {TrackerID: steps}}, []uuid.UUID{sleep}},
+		{"only deleted trackers", []*models.TrackerValue{{TrackerID: steps}}, []uuid.UUID{}},
+	}
+
+	for _, tt := range tests {
+		model := &models.Daylog{Trackers: tt.trackers}
+		if err := s.dropDeletedTrackers(model, uuid.New()); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		if (model.Trackers == nil) != (tt.want == nil) {
+			t.Errorf("%s: trackers = %v, want %v", tt.name, model.Trackers, tt.want)
+			continue
+		}
+
+		got := []uuid.UUID{}
+		for _, value := range model.Trackers {
+			got = append(got, value.TrackerID)
+		}
+		if tt.want != nil && !slices.Equal(got, tt.want) {
+			t.Errorf("%s: trackers = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
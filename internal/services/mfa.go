@@ -0,0 +1,226 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"moodtracker/internal/config"
+	"moodtracker/internal/models"
+	"moodtracker/internal/repositories"
+	"moodtracker/internal/totp"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"time"
+)
+
+type mfaService struct {
+	user   repositories.UserRepositoryInterface
+	mfa    repositories.MFARepository
+	db     *sql.DB
+	config config.Config
+	clock  func() time.Time
+}
+
+type MFAService interface {
+	EnrollTOTP(user *models.User, password string, v *validator.Validator) (*models.TOTPEnrollment, error)
+	ConfirmTOTP(user *models.User, code string, v *validator.Validator) ([]string, error)
+	RegenerateRecoveryCodes(user *models.User, code string, v *validator.Validator) ([]string, error)
+	DisableTOTP(user *models.User, password, code string, v *validator.Validator) error
+	Verify(tx *sql.Tx, user *models.User, code, recoveryCode string, v *validator.Validator) error
+}
+
+func NewMFAService(
+	userRepository repositories.UserRepositoryInterface,
+	mfaRepository repositories.MFARepository,
+	db *sql.DB,
+	config config.Config,
+	clock func() time.Time,
+) *mfaService {
+	return &mfaService{
+		user:   userRepository,
+		mfa:    mfaRepository,
+		db:     db,
+		config: config,
+		clock:  clock,
+	}
+}
+
+func (s *mfaService) EnrollTOTP(
+	user *models.User,
+	password string,
+	v *validator.Validator,
+) (*models.TOTPEnrollment, error) {
+	if v.Check(password != "", "password", "must be provided"); !v.Valid() {
+		return nil, e.ErrInvalidData
+	}
+
+	if user.TOTPEnabled {
+		v.AddError("mfa", "two-factor authentication is already enabled")
+		return nil, e.ErrInvalidData
+	}
+
+	if err := checkPassword(user, password, "password", v); err != nil {
+		return nil, err
+	}
+
+	secret := totp.GenerateSecret()
+	user.TOTPSecret = &secret
+
+	err := utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		return s.user.Update(tx, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(s.config.MFA.Issuer, user.Email, secret),
+	}, nil
+}
+
+func (s *mfaService) ConfirmTOTP(user *models.User, code string, v *validator.Validator) ([]string, error) {
+	if models.ValidateTOTPCode(v, code); !v.Valid() {
+		return nil, e.ErrInvalidData
+	}
+
+	if user.TOTPEnabled {
+		v.AddError("mfa", "two-factor authentication is already enabled")
+		return nil, e.ErrInvalidData
+	}
+
+	if user.TOTPSecret == nil {
+		v.AddError("mfa", "two-factor enrollment has not been started")
+		return nil, e.ErrInvalidData
+	}
+
+	counter, ok := totp.Validate(*user.TOTPSecret, code, s.clock())
+	if !ok {
+		v.AddError("code", "invalid authentication code")
+		return nil, e.ErrInvalidData
+	}
+
+	user.TOTPEnabled = true
+	codes := models.GenerateRecoveryCodes()
+
+	err := utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		if err := s.user.Update(tx, user); err != nil {
+			return err
+		}
+
+		if err := s.user.UseTOTPCounter(tx, user.ID, counter); err != nil {
+			return err
+		}
+
+		return s.replaceRecoveryCodes(tx, user, codes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s *mfaService) RegenerateRecoveryCodes(user *models.User, code string, v *validator.Validator) ([]string, error) {
+	if models.ValidateTOTPCode(v, code); !v.Valid() {
+		return nil, e.ErrInvalidData
+	}
+
+	codes := models.GenerateRecoveryCodes()
+
+	err := utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		if err := s.verifyCode(tx, user, code, v); err != nil {
+			return err
+		}
+
+		return s.replaceRecoveryCodes(tx, user, codes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s *mfaService) DisableTOTP(user *models.User, password, code string, v *validator.Validator) error {
+	v.Check(password != "", "password", "must be provided")
+	if models.ValidateTOTPCode(v, code); !v.Valid() {
+		return e.ErrInvalidData
+	}
+
+	if err := checkPassword(user, password, "password", v); err != nil {
+		return err
+	}
+
+	return utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		if err := s.verifyCode(tx, user, code, v); err != nil {
+			return err
+		}
+
+		user.TOTPEnabled = false
+		user.TOTPSecret = nil
+
+		if err := s.user.Update(tx, user); err != nil {
+			return err
+		}
+
+		return s.mfa.DeleteRecoveryCodes(tx, user.ID)
+	})
+}
+
+// Verify checks a TOTP code, or consumes a recovery code when one is given,
+// for a user that has two-factor authentication enabled.
+func (s *mfaService) Verify(
+	tx *sql.Tx,
+	user *models.User,
+	code,
+	recoveryCode string,
+	v *validator.Validator,
+) error {
+	if !user.TOTPEnabled || user.TOTPSecret == nil {
+		v.AddError("mfa", "two-factor authentication is not enabled")
+		return e.ErrInvalidData
+	}
+
+	if recoveryCode != "" {
+		err := s.mfa.UseRecoveryCode(tx, user.ID, models.HashRecoveryCode(recoveryCode))
+		if err != nil {
+			switch {
+			case errors.Is(err, e.ErrRecordNotFound):
+				return e.ErrInvalidCredentials
+			default:
+				return err
+			}
+		}
+		return nil
+	}
+
+	counter, ok := totp.Validate(*user.TOTPSecret, code, s.clock())
+	if !ok {
+		return e.ErrInvalidCredentials
+	}
+
+	return s.user.UseTOTPCounter(tx, user.ID, counter)
+}
+
+func (s *mfaService) verifyCode(tx *sql.Tx, user *models.User, code string, v *validator.Validator) error {
+	err := s.Verify(tx, user, code, "", v)
+	if errors.Is(err, e.ErrInvalidCredentials) {
+		v.AddError("code", "invalid authentication code")
+		return e.ErrInvalidData
+	}
+	return err
+}
+
+func (s *mfaService) replaceRecoveryCodes(tx *sql.Tx, user *models.User, codes []string) error {
+	if err := s.mfa.DeleteRecoveryCodes(tx, user.ID); err != nil {
+		return err
+	}
+
+	hashes := make([][]byte, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, models.HashRecoveryCode(code))
+	}
+
+	return s.mfa.InsertRecoveryCodes(tx, user.ID, hashes)
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"moodtracker/internal/models"
+	"moodtracker/internal/repositories"
+	"moodtracker/internal/totp"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UseTOTPCounter follows the repository contract: the step is only recorded
+// when it is later than the last one used.
+func (r *fakeUserRepository) UseTOTPCounter(tx *sql.Tx, userID uuid.UUID, counter int64) error {
+	user, err := r.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if user.TOTPLastCounter >= counter {
+		return e.ErrInvalidCredentials
+	}
+
+	user.TOTPLastCounter = counter
+	return nil
+}
+
+type fakeMFARepository struct {
+	repositories.MFARepository
+	codes map[uuid.UUID][][]byte
+}
+
+func (r *fakeMFARepository) InsertRecoveryCodes(tx *sql.Tx, userID uuid.UUID, hashes [][]byte) error {
+	r.codes[userID] = append(r.codes[userID], hashes...)
+	return nil
+}
+
+func (r *fakeMFARepository) UseRecoveryCode(tx *sql.Tx, userID uuid.UUID, hash []byte) error {
+	for i, stored := range r.codes[userID] {
+		if string(stored) == string(hash) {
+			r.codes[userID] = append(r.codes[userID][:i], r.codes[userID][i+1:]...)
+			return nil
+		}
+	}
+	return e.ErrRecordNotFound
+}
+
+func (r *fakeMFARepository) DeleteRecoveryCodes(tx *sql.Tx, userID uuid.UUID) error {
+	delete(r.codes, userID)
+	return nil
+}
+
+func newTestMFAService(t *testing.T, user *models.User, now *time.Time) *mfaService {
+	return &mfaService{
+		user:  &fakeUserRepository{users: []*models.User{user}},
+		mfa:   &fakeMFARepository{codes: map[uuid.UUID][][]byte{}},
+		db:    newTestDB(t),
+		clock: func() time.Time { return *now },
+	}
+}
+
+func TestMFAVerifyRejectsReusedSteps(t *testing.T) {
+	secret := totp.GenerateSecret()
+	user := &models.User{ID: uuid.New(), TOTPSecret: &secret, TOTPEnabled: true}
+
+	start := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	now := start
+	s := newTestMFAService(t, user, &now)
+	step := totp.Counter(start)
+
+	code := func(counter int64) string {
+		c, err := totp.Code(secret, counter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	// The steps run in order against the same user.
+	steps := []struct {
+		name string
+		now  time.Time
+		code string
+		err  error
+	}{
+		{"current step", start, code(step), nil},
+		{"same code again", start, code(step), e.ErrInvalidCredentials},
+		{"older step within the skew", start, code(step - 1), e.ErrInvalidCredentials},
+		{"next step within the skew", start, code(step + 1), nil},
+		{"that step once it is current", start.Add(totp.Period), code(step + 1), e.ErrInvalidCredentials},
+		{"following step", start.Add(2 * totp.Period), code(step + 2), nil},
+		{"wrong code", start.Add(2 * totp.Period), "000000", e.ErrInvalidCredentials},
+	}
+
+	for _, tt := range steps {
+		now = tt.now
+
+		err := s.Verify(nil, user, tt.code, "", validator.New())
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("%s: Verify returned %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	if user.TOTPLastCounter != step+2 {
+		t.Errorf("last counter = %d, want %d", user.TOTPLastCounter, step+2)
+	}
+}
+
+func TestMFARecoveryCodesAreSingleUse(t *testing.T) {
+	secret := totp.GenerateSecret()
+	user := &models.User{ID: uuid.New(), TOTPSecret: &secret}
+
+	now := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	s := newTestMFAService(t, user, &now)
+
+	code, err := totp.Code(secret, totp.Counter(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codes, err := s.ConfirmTOTP(user, code, validator.New())
+	if err != nil {
+		t.Fatalf("ConfirmTOTP: %v", err)
+	}
+	if len(codes) != models.RecoveryCodeCount {
+		t.Fatalf("got %d recovery codes, want %d", len(codes), models.RecoveryCodeCount)
+	}
+
+	// The confirmation code can't be used to sign in again.
+	if err := s.Verify(nil, user, code, "", validator.New()); !errors.Is(err, e.ErrInvalidCredentials) {
+		t.Errorf("Verify with the confirmation code returned %v, want ErrInvalidCredentials", err)
+	}
+
+	tests := []struct {
+		name string
+		code string
+		err  error
+	}{
+		{"first use", codes[0], nil},
+		{"second use", codes[0], e.ErrInvalidCredentials},
+		{"typed in lowercase without the dash", strings.ToLower(strings.ReplaceAll(codes[1], "-", "")), nil},
+		{"reused in another format", codes[1], e.ErrInvalidCredentials},
+		{"unknown code", "AAAAA-AAAAA", e.ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		err := s.Verify(nil, user, "", tt.code, validator.New())
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: Verify returned %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	// Regenerating replaces the codes that were left.
+	now = now.Add(totp.Period)
+	code, _ = totp.Code(secret, totp.Counter(now))
+
+	fresh, err := s.RegenerateRecoveryCodes(user, code, validator.New())
+	if err != nil {
+		t.Fatalf("RegenerateRecoveryCodes: %v", err)
+	}
+
+	if err := s.Verify(nil, user, "", codes[2], validator.New()); !errors.Is(err, e.ErrInvalidCredentials) {
+		t.Errorf("Verify with a replaced code returned %v, want ErrInvalidCredentials", err)
+	}
+	if err := s.Verify(nil, user, "", fresh[0], validator.New()); err != nil {
+		t.Errorf("Verify with a new code returned %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"moodtracker/internal/config"
+	"moodtracker/internal/models"
+	"moodtracker/internal/models/filters"
+	"moodtracker/internal/notify"
+	"moodtracker/internal/repositories"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// missedAfter is how late a reminder is still sent. Runs missed for longer,
+	// e.g. while no instance was up, are skipped instead of arriving hours late.
+	missedAfter = time.Hour
+
+	sendTimeout = 15 * time.Second
+)
+
+type reminderService struct {
+	reminder  repositories.ReminderRepository
+	user      repositories.UserRepositoryInterface
+	channels  notify.Channels
+	db        *sql.DB
+	batchSize int
+	now       func() time.Time
+}
+
+func NewReminderService(
+	reminder repositories.ReminderRepository,
+	user repositories.UserRepositoryInterface,
+	channels notify.Channels,
+	db *sql.DB,
+	config config.Config,
+) *reminderService {
+	return &reminderService{
+		reminder:  reminder,
+		user:      user,
+		channels:  channels,
+		db:        db,
+		batchSize: config.Reminders.BatchSize,
+		now:       time.Now,
+	}
+}
+
+type ReminderService interface {
+	GetAllByUserID(
+		userID uuid.UUID,
+		f filters.Filters,
+		v *validator.Validator,
+	) ([]*models.Reminder, filters.Metadata, error)
+	Save(model *models.Reminder, userID uuid.UUID, v *validator.Validator) error
+	FindByID(id, userID uuid.UUID) (*models.Reminder, error)
+	Update(model *models.Reminder, userID uuid.UUID, v *validator.Validator) error
+	Delete(id, userID uuid.UUID) error
+	Dispatch(ctx context.Context, now time.Time) (sent, skipped int, err error)
+}
+
+func (s *reminderService) GetAllByUserID(
+	userID uuid.UUID,
+	f filters.Filters,
+	v *validator.Validator,
+) ([]*models.Reminder, filters.Metadata, error) {
+	if filters.ValidateFilters(v, f); !v.Valid() {
+		return nil, filters.Metadata{}, e.ErrInvalidData
+	}
+
+	return s.reminder.GetAllByUserID(userID, f)
+}
+
+func (s *reminderService) Save(model *models.Reminder, userID uuid.UUID, v *validator.Validator) error {
+	if err := s.prepare(model, userID, v); err != nil {
+		return err
+	}
+
+	return utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		return s.reminder.Insert(tx, model, userID)
+	})
+}
+
+func (s *reminderService) FindByID(id, userID uuid.UUID) (*models.Reminder, error) {
+	return s.reminder.FindByID(id, userID)
+}
+
+func (s *reminderService) Update(model *models.Reminder, userID uuid.UUID, v *validator.Validator) error {
+	if err := s.prepare(model, userID, v); err != nil {
+		return err
+	}
+
+	return utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		return s.reminder.Update(tx, model, userID)
+	})
+}
+
+// prepare validates the reminder and schedules its next run. Without a time
+// zone it uses the one of the user profile.
+func (s *reminderService) prepare(model *models.Reminder, userID uuid.UUID, v *validator.Validator) error {
+	if model.TimeZone == "" {
+		user, err := s.user.GetByID(userID)
+		if err != nil {
+			return err
+		}
+		model.TimeZone = user.TimeZone
+	}
+
+	if model.ValidateReminder(v, s.channels.Names()); !v.Valid() {
+		return e.ErrInvalidData
+	}
+
+	model.NextRunAt = model.NextRun(s.now())
+	return nil
+}
+
+func (s *reminderService) Delete(id, userID uuid.UUID) error {
+	return utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		return s.reminder.Delete(tx, id, userID)
+	})
+}
+
+// Dispatch sends the reminders due at now, a batch at a time. Each batch is
+// claimed and moved to its next run in one transaction, and only sent after
+// it commits: another instance can't pick the same runs, and a failed send is
+// not retried. It stops between batches once ctx is cancelled.
+func (s *reminderService) Dispatch(ctx context.Context, now time.Time) (sent, skipped int, err error) {
+	var errs []error
+
+	for ctx.Err() == nil {
+		var due []*models.DueReminder
+
+		err := utils.RunInTx(s.db, func(tx *sql.Tx) error {
+			var err error
+			due, err = s.reminder.ClaimDue(tx, now, s.batchSize)
+			if err != nil {
+				return err
+			}
+
+			for _, reminder := range due {
+				if err := s.reminder.Reschedule(tx, reminder.ID, reminder.NextRun(now)); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return sent, skipped, errors.Join(append(errs, err)...)
+		}
+
+		for _, reminder := range due {
+			if reminder.SkipIfLogged && reminder.Logged || now.Sub(reminder.NextRunAt) > missedAfter {
+				skipped++
+				continue
+			}
+
+			if err := s.send(ctx, reminder); err != nil {
+				errs = append(errs, fmt.Errorf("reminder %s: %w", reminder.ID, err))
+				continue
+			}
+
+			sent++
+			if err := s.reminder.MarkSent(reminder.ID, now); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if len(due) == 0 || len(due) < s.batchSize {
+			break
+		}
+	}
+
+	return sent, skipped, errors.Join(errs...)
+}
+
+// send delivers a claimed run even if shutdown has begun, since the claim is
+// already committed.
+func (s *reminderService) send(ctx context.Context, reminder *models.DueReminder) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sendTimeout)
+	defer cancel()
+
+	return s.channels.Send(ctx, reminder.Channel, notify.Message{
+		Kind:   models.DailyReminder,
+		UserID: reminder.UserID,
+		Name:   reminder.Name,
+		Email:  reminder.Email,
+		Locale: reminder.Locale,
+		Target: reminder.WebhookURL,
+		Data: map[string]any{
+			"reminder_id": reminder.ID,
+			"date":        reminder.LocalDate(reminder.NextRunAt),
+		},
+	})
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"moodtracker/internal/models"
+	"moodtracker/internal/notify"
+	"moodtracker/internal/repositories"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeReminderRepository hands out the due reminders of pending in batches,
+// the way ClaimDue does, and records what the service did with them.
+type fakeReminderRepository struct {
+	repositories.ReminderRepository
+	pending     []*models.DueReminder
+	claimErr    error
+	claims      int
+	rescheduled map[uuid.UUID]time.Time
+	sent        []uuid.UUID
+	saved       *models.Reminder
+}
+
+func (r *fakeReminderRepository) ClaimDue(tx *sql.Tx, now time.Time, limit int) ([]*models.DueReminder, error) {
+	r.claims++
+	if r.claimErr != nil {
+		return nil, r.claimErr
+	}
+
+	n := min(limit, len(r.pending))
+	due := r.pending[:n]
+	r.pending = r.pending[n:]
+	return due, nil
+}
+
+func (r *fakeReminderRepository) Reschedule(tx *sql.Tx, id uuid.UUID, nextRunAt time.Time) error {
+	r.rescheduled[id] = nextRunAt
+	return nil
+}
+
+func (r *fakeReminderRepository) MarkSent(id uuid.UUID, sentAt time.Time) error {
+	r.sent = append(r.sent, id)
+	return nil
+}
+
+func (r *fakeReminderRepository) Insert(tx *sql.Tx, model *models.Reminder, userID uuid.UUID) error {
+	r.saved = model
+	return nil
+}
+
+type fakeChannel struct {
+	messages []notify.Message
+	err      error
+}
+
+func (c *fakeChannel) Send(ctx context.Context, msg notify.Message) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.messages = append(c.messages, msg)
+	return nil
+}
+
+func TestReminderDispatch(t *testing.T) {
+	now := time.Date(2026, 5, 4, 21, 0, 30, 0, time.UTC)
+
+	due := func(count int, change func(r *models.DueReminder)) []*models.DueReminder {
+		list := make([]*models.DueReminder, count)
+		for i := range list {
+			r := &models.DueReminder{
+				Reminder: models.Reminder{
+					ID:        uuid.New(),
+					TimeOfDay: "21:00",
+					TimeZone:  "UTC",
+					Channel:   "email",
+					NextRunAt: time.Date(2026, 5, 4, 21, 0, 0, 0, time.UTC),
+				},
+				UserID: uuid.New(),
+			}
+			change(r)
+			list[i] = r
+		}
+		return list
+	}
+	same := func(r *models.DueReminder) {}
+	sendErr := errors.New("connection refused")
+
+	tests := []struct {
+		name     string
+		due      []*models.DueReminder
+		batch    int
+		claimErr error
+		emailErr error
+		sent     int
+		skipped  int
+		claims   int
+		wantErr  bool
+	}{
+		{"nothing due", nil, 10, nil, nil, 0, 0, 1, false},
+		{"due reminder", due(1, same), 10, nil, nil, 1, 0, 1, false},
+		{"already logged", due(1, func(r *models.DueReminder) { r.SkipIfLogged, r.Logged = true, true }), 10, nil, nil, 0, 1, 1, false},
+		{"logged without skipping", due(1, func(r *models.DueReminder) { r.Logged = true }), 10, nil, nil, 1, 0, 1, false},
+		{"late within the hour", due(1, func(r *models.DueReminder) { r.NextRunAt = now.Add(-time.Hour) }), 10, nil, nil, 1, 0, 1, false},
+		{"missed", due(1, func(r *models.DueReminder) { r.NextRunAt = now.Add(-time.Hour - time.Second) }), 10, nil, nil, 0, 1, 1, false},
+		{"several batches", due(5, same), 2, nil, nil, 5, 0, 3, false},
+		{"full last batch", due(4, same), 2, nil, nil, 4, 0, 3, false},
+		{"send fails", due(2, same), 10, nil, sendErr, 0, 0, 1, true},
+		{"channel not enabled", due(1, func(r *models.DueReminder) { r.Channel = "sms" }), 10, nil, nil, 0, 0, 1, true},
+		{"claim fails", due(1, same), 10, sendErr, nil, 0, 0, 1, true},
+	}
+
+	for _, tt := range tests {
+		repo := &fakeReminderRepository{pending: tt.due, claimErr: tt.claimErr, rescheduled: map[uuid.UUID]time.Time{}}
+		email := &fakeChannel{err: tt.emailErr}
+		s := &reminderService{
+			reminder:  repo,
+			channels:  notify.Channels{"email": email},
+			db:        newTestDB(t),
+			batchSize: tt.batch,
+		}
+
+		sent, skipped, err := s.Dispatch(context.Background(), now)
+		if sent != tt.sent || skipped != tt.skipped || (err != nil) != tt.wantErr {
+			t.Errorf("%s: Dispatch = (%d, %d, %v), want (%d, %d, error %v)",
+				tt.name, sent, skipped, err, tt.sent, tt.skipped, tt.wantErr)
+		}
+		if repo.claims != tt.claims {
+			t.Errorf("%s: %d claims, want %d", tt.name, repo.claims, tt.claims)
+		}
+		if len(repo.sent) != tt.sent || len(email.messages) != tt.sent {
+			t.Errorf("%s: %d marked sent and %d messages, want %d", tt.name, len(repo.sent), len(email.messages), tt.sent)
+		}
+
+		// Every claimed run moves on, whether it was sent, skipped or failed.
+		if tt.claimErr == nil {
+			for _, r := range tt.due {
+				if next := repo.rescheduled[r.ID]; !next.Equal(time.Date(2026, 5, 5, 21, 0, 0, 0, time.UTC)) {
+					t.Errorf("%s: rescheduled to %v", tt.name, next)
+				}
+			}
+		}
+	}
+}
+
+func TestReminderDispatchMessage(t *testing.T) {
+	// 23:30 in New York is already the next day in UTC.
+	now := time.Date(2026, 5, 5, 3, 30, 0, 0, time.UTC)
+	reminder := &models.DueReminder{
+		Reminder: models.Reminder{
+			ID:         uuid.New(),
+			TimeOfDay:  "23:30",
+			TimeZone:   "America/New_York",
+			Channel:    "webhook",
+			WebhookURL: "https://hooks.example.com/mood",
+			NextRunAt:  now,
+		},
+		UserID: uuid.New(),
+		Name:   "Ana",
+		Email:  "ana@example.com",
+		Locale: "pt-BR",
+	}
+
+	webhook := &fakeChannel{}
+	s := &reminderService{
+		reminder:  &fakeReminderRepository{pending: []*models.DueReminder{reminder}, rescheduled: map[uuid.UUID]time.Time{}},
+		channels:  notify.Channels{"webhook": webhook},
+		db:        newTestDB(t),
+		batchSize: 10,
+	}
+
+	if _, _, err := s.Dispatch(context.Background(), now); err != nil || len(webhook.messages) != 1 {
+		t.Fatalf("Dispatch = %v, %d messages", err, len(webhook.messages))
+	}
+
+	msg := webhook.messages[0]
+	if msg.Kind != models.DailyReminder || msg.UserID != reminder.UserID || msg.Name != "Ana" ||
+		msg.Email != "ana@example.com" || msg.Locale != "pt-BR" || msg.Target != reminder.WebhookURL {
+		t.Errorf("message = %+v", msg)
+	}
+	if msg.Data["date"] != "2026-05-04" || msg.Data["reminder_id"] != reminder.ID {
+		t.Errorf("message data = %v", msg.Data)
+	}
+}
+
+func TestReminderDispatchStopsWhenCancelled(t *testing.T) {
+	repo := &fakeReminderRepository{rescheduled: map[uuid.UUID]time.Time{}}
+	s := &reminderService{reminder: repo, db: newTestDB(t), batchSize: 10}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if sent, skipped, err := s.Dispatch(ctx, time.Now()); sent != 0 || skipped != 0 || err != nil || repo.claims != 0 {
+		t.Errorf("Dispatch = (%d, %d, %v) after %d claims", sent, skipped, err, repo.claims)
+	}
+}
+
+func TestReminderSave(t *testing.T) {
+	now := time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC)
+	user := &models.User{ID: uuid.New(), TimeZone: "America/Sao_Paulo"}
+
+	tests := []struct {
+		name     string
+		reminder models.Reminder
+		userID   uuid.UUID
+		err      error
+		clock    string
+		zone     string
+		next     time.Time
+	}{
+		{"own time zone", models.Reminder{TimeOfDay: "9:05", TimeZone: "UTC", Channel: "email"}, user.ID, nil,
+			"09:05", "UTC", time.Date(2026, 5, 5, 9, 5, 0, 0, time.UTC)},
+		{"profile time zone", models.Reminder{TimeOfDay: "21:00", Channel: "email"}, user.ID, nil,
+			"21:00", "America/Sao_Paulo", time.Date(2026, 5, 5, 0, 0, 0, 0, time.UTC)},
+		{"only on weekends", models.Reminder{TimeOfDay: "10:00", TimeZone: "UTC", Weekdays: []int64{0, 6}, Channel: "email"}, user.ID, nil,
+			"10:00", "UTC", time.Date(2026, 5, 9, 10, 0, 0, 0, time.UTC)},
+		{"channel not enabled", models.Reminder{TimeOfDay: "21:00", TimeZone: "UTC", Channel: "sms"}, user.ID, e.ErrInvalidData, "", "", time.Time{}},
+		{"unknown user", models.Reminder{TimeOfDay: "21:00", Channel: "email"}, uuid.New(), e.ErrRecordNotFound, "", "", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		repo := &fakeReminderRepository{}
+		s := &reminderService{
+			reminder: repo,
+			user:     &fakeUserRepository{users: []*models.User{user}},
+			channels: notify.Channels{"email": &fakeChannel{}},
+			db:       newTestDB(t),
+			now:      func() time.Time { return now },
+		}
+		reminder := tt.reminder
+
+		err := s.Save(&reminder, tt.userID, validator.New())
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if err != nil {
+			if repo.saved != nil {
+				t.Errorf("%s: invalid reminder was saved", tt.name)
+			}
+			continue
+		}
+
+		if repo.saved != &reminder || reminder.TimeOfDay != tt.clock || reminder.TimeZone != tt.zone || !reminder.NextRunAt.Equal(tt.next) {
+			t.Errorf("%s: saved %+v, want %s %s and next run %v", tt.name, repo.saved, tt.clock, tt.zone, tt.next)
+		}
+	}
+}
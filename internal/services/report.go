@@ -3,12 +3,16 @@ package services
 import (
 	"moodtracker/internal/models"
 	"moodtracker/internal/repositories"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type reportService struct {
-	report repositories.ReportRepository
+	report  repositories.ReportRepository
+	tracker repositories.TrackerRepository
 }
 
 type ReportService interface {
@@ -27,11 +31,22 @@ type ReportService interface {
 		moodLabel models.MoodLabel,
 		userID uuid.UUID,
 	) (*models.MoodReport, error)
+
+	GetTrackerReport(
+		trackerID uuid.UUID,
+		startDate, endDate time.Time,
+		userID uuid.UUID,
+		v *validator.Validator,
+	) (*models.TrackerReport, error)
 }
 
-func NewReportService(report repositories.ReportRepository) *reportService {
+func NewReportService(
+	report repositories.ReportRepository,
+	tracker repositories.TrackerRepository,
+) *reportService {
 	return &reportService{
-		report: report,
+		report:  report,
+		tracker: tracker,
 	}
 }
 
@@ -56,3 +71,30 @@ func (s *reportService) GetMoodReport(
 ) (*models.MoodReport, error) {
 	return s.report.GetMoodReport(moodLabel, userID)
 }
+
+func (s *reportService) GetTrackerReport(
+	trackerID uuid.UUID,
+	startDate, endDate time.Time,
+	userID uuid.UUID,
+	v *validator.Validator,
+) (*models.TrackerReport, error) {
+	v.Check(!startDate.After(endDate), "start_date", e.ErrStartDateAfterEndDate.Error())
+	v.Check(endDate.Sub(startDate) < maxSummaryDays*24*time.Hour,
+		"end_date", "must be less than 366 days after start_date")
+
+	if !v.Valid() {
+		return nil, e.ErrInvalidData
+	}
+
+	tracker, err := s.tracker.FindByID(trackerID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := s.report.GetTrackerPoints(trackerID, startDate, endDate, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewTrackerReport(tracker, startDate, endDate, points), nil
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"errors"
+	"moodtracker/internal/models"
+	"moodtracker/internal/repositories"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type fakeReportRepository struct {
+	repositories.ReportRepository
+	points []*models.TrackerPoint
+}
+
+func (r *fakeReportRepository) GetTrackerPoints(
+	trackerID uuid.UUID,
+	startDate, endDate time.Time,
+	userID uuid.UUID,
+) ([]*models.TrackerPoint, error) {
+	return r.points, nil
+}
+
+func TestGetTrackerReport(t *testing.T) {
+	id := uuid.New()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		id    uuid.UUID
+		end   time.Time
+		err   error
+		field string
+	}{
+		{"one day", id, start, nil, ""},
+		{"a year", id, start.AddDate(0, 0, maxSummaryDays-1), nil, ""},
+		{"more than a year", id, start.AddDate(0, 0, maxSummaryDays), e.ErrInvalidData, "end_date"},
+		{"end before start", id, start.AddDate(0, 0, -1), e.ErrInvalidData, "start_date"},
+		{"unknown tracker", uuid.New(), start.AddDate(0, 1, 0), e.ErrRecordNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		s := &reportService{
+			report: &fakeReportRepository{points: []*models.TrackerPoint{
+				{Date: start, Raw: 7, MoodLabel: 4},
+				{Date: start, Raw: 5, MoodLabel: 2},
+			}},
+			tracker: &fakeTrackerRepository{trackers: map[uuid.UUID]*models.Tracker{
+				id: {ID: id, Name: "sleep", Kind: models.TrackerDecimal},
+			}},
+		}
+		v := validator.New()
+
+		report, err := s.GetTrackerReport(tt.id, start, tt.end, uuid.New(), v)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if _, ok := v.Errors[tt.field]; tt.field != "" && !ok {
+			t.Errorf("%s: errors = %v, want one for %s", tt.name, v.Errors, tt.field)
+		}
+		if err == nil && (report.Samples != 2 || report.Correlation == nil || *report.Correlation != 1) {
+			t.Errorf("%s: report = %+v", tt.name, report)
+		}
+	}
+}
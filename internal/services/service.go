@@ -2,11 +2,20 @@ package services
 
 import (
 	"database/sql"
+	"fmt"
+	"moodtracker/internal/blob"
 	"moodtracker/internal/config"
 	"moodtracker/internal/jsonlog"
+	"moodtracker/internal/mailer"
 	"moodtracker/internal/models"
+	"moodtracker/internal/notify"
+	"moodtracker/internal/oidc"
+	"moodtracker/internal/passwords"
 	"moodtracker/internal/repositories"
+	"moodtracker/internal/signing"
 	"moodtracker/utils/validator"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -22,22 +31,89 @@ type GenericServiceInterface[
 }
 
 type Services struct {
-	User   UserService
-	Auth   AuthServiceInterface
-	Daylog DaylogServices
-	Tag    TagService
-	Report ReportService
+	User        UserService
+	Auth        AuthServiceInterface
+	Daylog      DaylogServices
+	Tag         TagService
+	Report      ReportService
+	Admin       AdminService
+	MFA         MFAService
+	AccessToken AccessTokenService
+	Trash       TrashService
+	Tracker     TrackerService
+	Attachment  AttachmentService
+	Reminder    ReminderService
 }
 
-func NewServices(logger jsonlog.Logger, db *sql.DB, config config.Config) *Services {
+func NewServices(
+	logger jsonlog.Logger,
+	db *sql.DB,
+	config config.Config,
+	mailer mailer.Mailer,
+	store blob.Store,
+	channels notify.Channels,
+	keys *signing.KeySet,
+	policy *passwords.Policy,
+	wg *sync.WaitGroup,
+) *Services {
 	r := repositories.NewRepository(logger, db)
-	userService := NewUserService(r.User, db)
+	userService := NewUserService(r.User, r.Token, r.Permission, policy, db, config, mailer, wg, logger)
 	tagService := NewTagService(r.Tag, db)
+	mfaService := NewMFAService(r.User, r.MFA, db, config, time.Now)
+	authService := NewAuthService(
+		userService,
+		mfaService,
+		r.Token,
+		r.User,
+		r.Identity,
+		r.AuthEvent,
+		newOIDCProvider(config),
+		db,
+		keys,
+		config,
+	)
 	return &Services{
-		User:   userService,
-		Auth:   NewAuthService(userService, config),
-		Daylog: NewDaylogService(r.DayLog, db, tagService),
-		Tag:    tagService,
-		Report: NewReportService(r.Report),
+		User:        userService,
+		Auth:        authService,
+		Daylog:      NewDaylogService(r.DayLog, r.User, db, r.Tag, r.Tracker),
+		Tag:         tagService,
+		Report:      NewReportService(r.Report, r.Tracker),
+		Admin:       NewAdminService(r.User, r.Token, db),
+		MFA:         mfaService,
+		AccessToken: NewAccessTokenService(r.AccessToken, db),
+		Trash:       NewTrashService(r.Trash, r.DayLog, r.Tag, r.Attachment, store, db, config),
+		Tracker:     NewTrackerService(r.Tracker, db),
+		Attachment:  NewAttachmentService(r.Attachment, r.DayLog, store, db, config, logger),
+		Reminder:    NewReminderService(r.Reminder, r.User, channels, db, config),
 	}
 }
+
+func background(wg *sync.WaitGroup, logger jsonlog.Logger, fn func()) {
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		defer func() {
+			if err := recover(); err != nil {
+				logger.PrintError(fmt.Errorf("%s", err), nil)
+			}
+		}()
+
+		fn()
+	}()
+}
+
+func newOIDCProvider(config config.Config) OIDCProvider {
+	if config.OIDC.Issuer == "" {
+		return nil
+	}
+
+	return oidc.New(oidc.Config{
+		Issuer:       config.OIDC.Issuer,
+		ClientID:     config.OIDC.ClientID,
+		ClientSecret: config.OIDC.ClientSecret,
+		RedirectURL:  config.OIDC.RedirectURL,
+		Scopes:       config.OIDC.Scopes,
+	}, nil)
+}
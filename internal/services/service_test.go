@@ -0,0 +1,47 @@
+package services
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+// txDriver is a database/sql driver whose transactions do nothing, so that
+// services running their repository calls through utils.RunInTx can be tested
+// with fake repositories. Any query reaching it is a bug in the test.
+type txDriver struct{}
+
+type txConn struct{}
+
+type txTx struct{}
+
+func init() {
+	sql.Register("services_test", txDriver{})
+}
+
+func (txDriver) Open(string) (driver.Conn, error) { return txConn{}, nil }
+
+func (txConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("txDriver: queries are not supported")
+}
+
+func (txConn) Close() error { return nil }
+
+func (txConn) Begin() (driver.Tx, error) { return txTx{}, nil }
+
+func (txTx) Commit() error { return nil }
+
+func (txTx) Rollback() error { return nil }
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("services_test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
@@ -36,11 +36,6 @@ type TagService interface {
 	FindByID(id, userID uuid.UUID) (*models.Tag, error)
 	Update(model *models.Tag, userID uuid.UUID, v *validator.Validator) error
 	Delete(id, userID uuid.UUID) error
-	GetIDByNameOrCreate(
-		v *validator.Validator,
-		name string,
-		userID uuid.UUID,
-	) (uuid.UUID, error)
 }
 
 func (s *tagService) GetAllByUserID(
@@ -75,36 +70,6 @@ func (s *tagService) Update(model *models.Tag, userID uuid.UUID, v *validator.Va
 
 func (s *tagService) Delete(id, userID uuid.UUID) error {
 	return utils.RunInTx(s.db, func(tx *sql.Tx) error {
-		err := s.tag.Delete(tx, id, userID)
-		if err != nil {
-			return err
-		}
-
-		return s.tag.DeleteLogTagByTagID(tx, id)
+		return s.tag.Delete(tx, id, userID)
 	})
 }
-
-func (s *tagService) GetIDByNameOrCreate(
-	v *validator.Validator,
-	name string,
-	userID uuid.UUID,
-) (uuid.UUID, error) {
-	v.Check(name != "", "name", "must be provided")
-	if !v.Valid() {
-		return uuid.Nil, e.ErrInvalidData
-	}
-
-	var tagID uuid.UUID
-
-	err := utils.RunInTx(s.db, func(tx *sql.Tx) error {
-		var err error
-		tagID, err = s.tag.GetIDByNameOrCreate(tx, name, userID)
-		return err
-	})
-
-	if err != nil {
-		return uuid.Nil, err
-	}
-
-	return tagID, nil
-}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"database/sql"
+	"moodtracker/internal/models"
+	"moodtracker/internal/models/filters"
+	"moodtracker/internal/repositories"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+
+	"github.com/google/uuid"
+)
+
+type trackerService struct {
+	tracker repositories.TrackerRepository
+	db      *sql.DB
+}
+
+func NewTrackerService(
+	tracker repositories.TrackerRepository,
+	db *sql.DB,
+) *trackerService {
+	return &trackerService{
+		tracker: tracker,
+		db:      db,
+	}
+}
+
+type TrackerService interface {
+	GetAllByUserID(
+		userID uuid.UUID,
+		f filters.Filters,
+		v *validator.Validator,
+	) ([]*models.Tracker, filters.Metadata, error)
+	Save(model *models.Tracker, userID uuid.UUID, v *validator.Validator) error
+	FindByID(id, userID uuid.UUID) (*models.Tracker, error)
+	Update(model *models.Tracker, userID uuid.UUID, v *validator.Validator) error
+	Delete(id, userID uuid.UUID) error
+}
+
+func (s *trackerService) GetAllByUserID(
+	userID uuid.UUID,
+	f filters.Filters,
+	v *validator.Validator,
+) ([]*models.Tracker, filters.Metadata, error) {
+	if filters.ValidateFilters(v, f); !v.Valid() {
+		return nil, filters.Metadata{}, e.ErrInvalidData
+	}
+
+	return s.tracker.GetAllByUserID(userID, f)
+}
+
+func (s *trackerService) Save(model *models.Tracker, userID uuid.UUID, v *validator.Validator) error {
+	return utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		if model.ValidateTracker(v); !v.Valid() {
+			return e.ErrInvalidData
+		}
+
+		return s.tracker.Insert(tx, model, userID)
+	})
+}
+
+func (s *trackerService) FindByID(id, userID uuid.UUID) (*models.Tracker, error) {
+	return s.tracker.FindByID(id, userID)
+}
+
+func (s *trackerService) Update(model *models.Tracker, userID uuid.UUID, v *validator.Validator) error {
+	current, err := s.tracker.FindByID(model.ID, userID)
+	if err != nil {
+		return err
+	}
+
+	return utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		v.Check(model.Kind == current.Kind, "kind", "must not be changed")
+
+		if model.ValidateTracker(v); !v.Valid() {
+			return e.ErrInvalidData
+		}
+
+		return s.tracker.Update(tx, model, userID)
+	})
+}
+
+func (s *trackerService) Delete(id, userID uuid.UUID) error {
+	return utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		return s.tracker.Delete(tx, id, userID)
+	})
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"moodtracker/internal/models"
+	"moodtracker/internal/repositories"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type fakeTrackerRepository struct {
+	repositories.TrackerRepository
+	trackers map[uuid.UUID]*models.Tracker
+	values   []*models.TrackerValue
+	saved    *models.Tracker
+}
+
+func (r *fakeTrackerRepository) FindByIDs(ids []uuid.UUID, userID uuid.UUID) ([]*models.Tracker, error) {
+	var found []*models.Tracker
+	for _, id := range ids {
+		if tracker, ok := r.trackers[id]; ok {
+			found = append(found, tracker)
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeTrackerRepository) GetLogValues(logIDs []uuid.UUID) ([]*models.TrackerValue, error) {
+	var values []*models.TrackerValue
+	for _, value := range r.values {
+		if slices.Contains(logIDs, value.LogID) {
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
+
+func (r *fakeTrackerRepository) FindByID(id, userID uuid.UUID) (*models.Tracker, error) {
+	tracker, ok := r.trackers[id]
+	if !ok {
+		return nil, e.ErrRecordNotFound
+	}
+	return tracker, nil
+}
+
+func (r *fakeTrackerRepository) Insert(tx *sql.Tx, model *models.Tracker, userID uuid.UUID) error {
+	r.saved = model
+	return nil
+}
+
+func (r *fakeTrackerRepository) Update(tx *sql.Tx, model *models.Tracker, userID uuid.UUID) error {
+	r.saved = model
+	return nil
+}
+
+func TestTrackerSaveAndUpdate(t *testing.T) {
+	id := uuid.New()
+
+	tests := []struct {
+		name   string
+		update bool
+		model  models.Tracker
+		err    error
+		field  string
+	}{
+		{"new tracker", false, models.Tracker{Name: "exercise", Kind: models.TrackerDuration}, nil, ""},
+		{"invalid new tracker", false, models.Tracker{Name: "exercise", Kind: "text"}, e.ErrInvalidData, "kind"},
+		{"renamed", true, models.Tracker{ID: id, Name: "sleep time", Kind: models.TrackerDecimal}, nil, ""},
+		{"kind changed", true, models.Tracker{ID: id, Name: "sleep", Kind: models.TrackerInteger}, e.ErrInvalidData, "kind"},
+		{"invalid update", true, models.Tracker{ID: id, Kind: models.TrackerDecimal}, e.ErrInvalidData, "name"},
+		{"unknown tracker", true, models.Tracker{ID: uuid.New(), Name: "sleep", Kind: models.TrackerDecimal}, e.ErrRecordNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		repo := &fakeTrackerRepository{trackers: map[uuid.UUID]*models.Tracker{
+			id: {ID: id, Name: "sleep", Kind: models.TrackerDecimal},
+		}}
+		s := &trackerService{tracker: repo, db: newTestDB(t)}
+		v := validator.New()
+		model := tt.model
+
+		var err error
+		if tt.update {
+			err = s.Update(&model, uuid.New(), v)
+		} else {
+			err = s.Save(&model, uuid.New(), v)
+		}
+
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+		if _, ok := v.Errors[tt.field]; tt.field != "" && !ok {
+			t.Errorf("%s: errors = %v, want one for %s", tt.name, v.Errors, tt.field)
+		}
+		if saved := repo.saved != nil; saved != (tt.err == nil) {
+			t.Errorf("%s: saved = %v", tt.name, saved)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"moodtracker/internal/blob"
+	"moodtracker/internal/config"
+	"moodtracker/internal/models"
+	"moodtracker/internal/models/filters"
+	"moodtracker/internal/repositories"
+	"moodtracker/utils"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// purgeBatchSize bounds each purge statement so a large backlog doesn't hold
+// locks for long.
+const purgeBatchSize = 500
+
+type trashService struct {
+	trash         repositories.TrashRepository
+	daylog        repositories.DaylogRepository
+	tag           repositories.TagRepository
+	attachment    repositories.AttachmentRepository
+	store         blob.Store
+	db            *sql.DB
+	retentionDays int
+}
+
+type TrashService interface {
+	GetAll(
+		userID uuid.UUID,
+		itemType string,
+		f filters.Filters,
+		v *validator.Validator,
+	) ([]*models.TrashItem, filters.Metadata, error)
+	RestoreDaylog(id, userID uuid.UUID) (*models.Daylog, error)
+	RestoreTag(id, userID uuid.UUID) (*models.Tag, error)
+	Purge(now time.Time) (daylogs, tags, attachments int64, err error)
+}
+
+func NewTrashService(
+	trash repositories.TrashRepository,
+	daylog repositories.DaylogRepository,
+	tag repositories.TagRepository,
+	attachment repositories.AttachmentRepository,
+	store blob.Store,
+	db *sql.DB,
+	config config.Config,
+) *trashService {
+	return &trashService{
+		trash:         trash,
+		daylog:        daylog,
+		tag:           tag,
+		attachment:    attachment,
+		store:         store,
+		db:            db,
+		retentionDays: config.Trash.RetentionDays,
+	}
+}
+
+func (s *trashService) GetAll(
+	userID uuid.UUID,
+	itemType string,
+	f filters.Filters,
+	v *validator.Validator,
+) ([]*models.TrashItem, filters.Metadata, error) {
+	filters.ValidateFilters(v, f)
+	v.Check(validator.In(itemType, "", models.TrashTypeDaylog, models.TrashTypeTag),
+		"type", "must be day_log or tag")
+
+	if !v.Valid() {
+		return nil, filters.Metadata{}, e.ErrInvalidData
+	}
+
+	return s.trash.GetAll(userID, itemType, s.retentionDays, f)
+}
+
+// RestoreDaylog takes a log out of the trash along with the tags it had when
+// it was deleted.
+func (s *trashService) RestoreDaylog(id, userID uuid.UUID) (*models.Daylog, error) {
+	err := utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		if err := s.daylog.Restore(tx, id, userID); err != nil {
+			return err
+		}
+
+		return s.daylog.RestoreLogTags(tx, id, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.daylog.GetByID(id, userID)
+}
+
+func (s *trashService) RestoreTag(id, userID uuid.UUID) (*models.Tag, error) {
+	err := utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		return s.tag.Restore(tx, id, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.tag.FindByID(id, userID)
+}
+
+// Purge permanently deletes everything that has been in the trash for longer
+// than the retention period. It does nothing when retention is disabled.
+// Attachment files go first: a log is only deleted once its files are.
+func (s *trashService) Purge(now time.Time) (daylogs, tags, attachments int64, err error) {
+	if s.retentionDays <= 0 {
+		return 0, 0, 0, nil
+	}
+
+	before := now.AddDate(0, 0, -s.retentionDays)
+
+	// Logs whose files could not be removed are kept for the next run, so a
+	// failure here doesn't stop the rest of the purge.
+	attachments, attachmentsErr := purgeAll(func() (int64, error) {
+		return s.purgeAttachments(before)
+	})
+
+	daylogs, err = purgeAll(func() (int64, error) {
+		return s.trash.PurgeDaylogs(before, purgeBatchSize)
+	})
+	if err != nil {
+		return daylogs, 0, attachments, errors.Join(attachmentsErr, err)
+	}
+
+	tags, err = purgeAll(func() (int64, error) {
+		return s.trash.PurgeTags(before, purgeBatchSize)
+	})
+
+	return daylogs, tags, attachments, errors.Join(attachmentsErr, err)
+}
+
+func (s *trashService) purgeAttachments(before time.Time) (int64, error) {
+	list, err := s.attachment.GetPurgeable(before, purgeBatchSize)
+	if err != nil || len(list) == 0 {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), blobTimeout)
+	defer cancel()
+
+	var errs []error
+	ids := make([]uuid.UUID, 0, len(list))
+	for _, a := range list {
+		if err := s.store.Delete(ctx, a.StorageKey); err != nil {
+			errs = append(errs, fmt.Errorf("delete attachment %s: %w", a.ID, err))
+			continue
+		}
+		ids = append(ids, a.ID)
+	}
+
+	n, err := s.attachment.DeleteByIDs(ids)
+	return n, errors.Join(append(errs, err)...)
+}
+
+func purgeAll(batch func() (int64, error)) (int64, error) {
+	var total int64
+
+	for {
+		n, err := batch()
+		total += n
+
+		if err != nil || n < purgeBatchSize {
+			return total, err
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"moodtracker/internal/models"
+	"moodtracker/internal/models/filters"
+	"moodtracker/internal/repositories"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeTrashRepository holds a number of purgeable day logs and tags and hands
+// them out in batches of at most limit.
+type fakeTrashRepository struct {
+	repositories.TrashRepository
+	daylogs   int64
+	tags      int64
+	err       error
+	befores   []time.Time
+	retention int
+}
+
+func (r *fakeTrashRepository) GetAll(
+	userID uuid.UUID,
+	itemType string,
+	retentionDays int,
+	f filters.Filters,
+) ([]*models.TrashItem, filters.Metadata, error) {
+	r.retention = retentionDays
+	return nil, filters.Metadata{}, nil
+}
+
+func (r *fakeTrashRepository) PurgeDaylogs(before time.Time, limit int) (int64, error) {
+	r.befores = append(r.befores, before)
+	if r.err != nil {
+		return 0, r.err
+	}
+	return take(&r.daylogs, limit), nil
+}
+
+func (r *fakeTrashRepository) PurgeTags(before time.Time, limit int) (int64, error) {
+	r.befores = append(r.befores, before)
+	return take(&r.tags, limit), nil
+}
+
+func take(remaining *int64, limit int) int64 {
+	n := min(*remaining, int64(limit))
+	*remaining -= n
+	return n
+}
+
+func (r *fakeAttachmentRepository) GetPurgeable(before time.Time, limit int) ([]*models.Attachment, error) {
+	return r.purgeable[:min(len(r.purgeable), limit)], nil
+}
+
+func (r *fakeAttachmentRepository) DeleteByIDs(ids []uuid.UUID) (int64, error) {
+	r.purgeable = slices.DeleteFunc(r.purgeable, func(a *models.Attachment) bool {
+		return slices.Contains(ids, a.ID)
+	})
+	return int64(len(ids)), nil
+}
+
+func (s *fakeBlobStore) Delete(ctx context.Context, key string) error {
+	if s.failing[key] {
+		return errors.New("store unavailable")
+	}
+	delete(s.objects, key)
+	return nil
+}
+
+func TestTrashPurge(t *testing.T) {
+	now := time.Date(2026, 5, 31, 3, 0, 0, 0, time.UTC)
+	cutoff := now.AddDate(0, 0, -30)
+	purgeErr := errors.New("connection reset")
+
+	tests := []struct {
+		name          string
+		retentionDays int
+		daylogs       int64
+		tags          int64
+		attachments   []string
+		failing       string
+		purgeErr      error
+		want          [3]int64
+		calls         int
+		wantErr       bool
+	}{
+		{"retention disabled", 0, 10, 10, []string{"a"}, "", nil, [3]int64{0, 0, 0}, 0, false},
+		{"nothing to purge", 30, 0, 0, nil, "", nil, [3]int64{0, 0, 0}, 2, false},
+		{"one batch each", 30, 12, 3, nil, "", nil, [3]int64{12, 3, 0}, 2, false},
+		{"full batch", 30, purgeBatchSize, 0, nil, "", nil, [3]int64{purgeBatchSize, 0, 0}, 3, false},
+		{"several batches", 30, 2*purgeBatchSize + 7, 1, nil, "", nil, [3]int64{2*purgeBatchSize + 7, 1, 0}, 4, false},
+		{"attachments first", 30, 2, 0, []string{"a", "b"}, "", nil, [3]int64{2, 0, 2}, 2, false},
+		{"attachment that can't be removed", 30, 2, 1, []string{"a", "b"}, "b", nil, [3]int64{2, 1, 1}, 2, true},
+		{"day logs fail", 30, 2, 1, []string{"a"}, "", purgeErr, [3]int64{0, 0, 1}, 1, true},
+	}
+
+	for _, tt := range tests {
+		trash := &fakeTrashRepository{daylogs: tt.daylogs, tags: tt.tags, err: tt.purgeErr}
+		attachments := &fakeAttachmentRepository{}
+		store := &fakeBlobStore{objects: map[string]string{}, failing: map[string]bool{tt.failing: true}}
+		for _, key := range tt.attachments {
+			attachments.purgeable = append(attachments.purgeable, &models.Attachment{ID: uuid.New(), StorageKey: key})
+			store.objects[key] = "content"
+		}
+
+		s := &trashService{trash: trash, attachment: attachments, store: store, retentionDays: tt.retentionDays}
+
+		daylogs, tags, removed, err := s.Purge(now)
+		if got := [3]int64{daylogs, tags, removed}; got != tt.want {
+			t.Errorf("%s: purged (day logs, tags, attachments) = %v, want %v", tt.name, got, tt.want)
+		}
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if tt.purgeErr != nil && !errors.Is(err, tt.purgeErr) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.purgeErr)
+		}
+
+		if len(trash.befores) != tt.calls {
+			t.Errorf("%s: %d purge statements, want %d", tt.name, len(trash.befores), tt.calls)
+		}
+		for _, before := range trash.befores {
+			if !before.Equal(cutoff) {
+				t.Errorf("%s: purged before %v, want %v", tt.name, before, cutoff)
+			}
+		}
+
+		// Only the files that were removed leave the attachment list.
+		if tt.retentionDays > 0 && len(attachments.purgeable) != len(store.objects) {
+			t.Errorf("%s: %d attachments left for %d files", tt.name, len(attachments.purgeable), len(store.objects))
+		}
+	}
+}
+
+func TestTrashGetAllValidation(t *testing.T) {
+	tests := []struct {
+		itemType string
+		valid    bool
+	}{
+		{"", true},
+		{models.TrashTypeDaylog, true},
+		{models.TrashTypeTag, true},
+		{"tracker", false},
+		{"DAY_LOG", false},
+	}
+
+	f := filters.Filters{Page: 1, PageSize: 20, Sort: "-deleted_at", SortSafelist: []string{"-deleted_at"}}
+
+	for _, tt := range tests {
+		trash := &fakeTrashRepository{}
+		s := &trashService{trash: trash, retentionDays: 30}
+		v := validator.New()
+
+		_, _, err := s.GetAll(uuid.New(), tt.itemType, f, v)
+		if tt.valid {
+			if err != nil || trash.retention != 30 {
+				t.Errorf("GetAll(%q) = %v, retention %d", tt.itemType, err, trash.retention)
+			}
+			continue
+		}
+
+		if !errors.Is(err, e.ErrInvalidData) || v.Errors["type"] == "" {
+			t.Errorf("GetAll(%q) = %v, errors %v, want a type error", tt.itemType, err, v.Errors)
+		}
+	}
+}
@@ -1,26 +1,53 @@
 package services
 
 import (
+	"crypto/subtle"
 	"database/sql"
 	"errors"
+	"moodtracker/internal/config"
+	"moodtracker/internal/jsonlog"
+	"moodtracker/internal/mailer"
 	"moodtracker/internal/models"
+	"moodtracker/internal/passwords"
 	"moodtracker/internal/repositories"
 	"moodtracker/utils"
 	e "moodtracker/utils/errors"
 	"moodtracker/utils/validator"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+const activationResendInterval = time.Minute
+
 type userService struct {
-	user repositories.UserRepositoryInterface
-	db   *sql.DB
+	user       repositories.UserRepositoryInterface
+	token      repositories.TokenRepository
+	permission repositories.PermissionRepository
+	policy     *passwords.Policy
+	db         *sql.DB
+	config     config.Config
+	mailer     mailer.Mailer
+	wg         *sync.WaitGroup
+	logger     jsonlog.Logger
 }
 
 type UserService interface {
 	GetUserByEmail(email string, v *validator.Validator) (*models.User, error)
+	GetUserByID(id uuid.UUID) (*models.User, error)
+	GetPermissions(userID uuid.UUID) (models.Permissions, error)
 	ActivateUser(cod int, email string, v *validator.Validator) (*models.User, error)
+	ResendActivationCode(email string, v *validator.Validator) error
+	RequestPasswordReset(email string, v *validator.Validator) error
+	ResetPassword(tokenPlaintext, password string, v *validator.Validator) error
 	Update(user *models.User, v *validator.Validator) error
+	UpdateProfile(user *models.User, v *validator.Validator) error
+	ChangePassword(user *models.User, currentPassword, newPassword string, v *validator.Validator) error
+	DeleteAccount(user *models.User, password string, v *validator.Validator) error
+	RequestEmailChange(user *models.User, newEmail, password string, v *validator.Validator) error
+	ConfirmEmailChange(tokenPlaintext string, v *validator.Validator) (*models.User, error)
 	GetUserByCodAndEmail(cod int, email string, v *validator.Validator) (*models.User, error)
 	Save(user *models.User, v *validator.Validator) error
 	Delete(idUser uuid.UUID) error
@@ -28,11 +55,25 @@ type UserService interface {
 
 func NewUserService(
 	userRepository repositories.UserRepositoryInterface,
+	tokenRepository repositories.TokenRepository,
+	permissionRepository repositories.PermissionRepository,
+	policy *passwords.Policy,
 	db *sql.DB,
+	config config.Config,
+	mailer mailer.Mailer,
+	wg *sync.WaitGroup,
+	logger jsonlog.Logger,
 ) *userService {
 	return &userService{
-		user: userRepository,
-		db:   db,
+		user:       userRepository,
+		token:      tokenRepository,
+		permission: permissionRepository,
+		policy:     policy,
+		db:         db,
+		config:     config,
+		mailer:     mailer,
+		wg:         wg,
+		logger:     logger,
 	}
 }
 
@@ -45,19 +86,58 @@ func (s *userService) GetUserByEmail(email string, v *validator.Validator) (*mod
 	return user, nil
 }
 
+func (s *userService) GetUserByID(id uuid.UUID) (*models.User, error) {
+	return s.user.GetByID(id)
+}
+
+func (s *userService) GetPermissions(userID uuid.UUID) (models.Permissions, error) {
+	return s.permission.GetAllForUser(userID)
+}
+
 func (s *userService) ActivateUser(cod int, email string, v *validator.Validator) (*models.User, error) {
 	if models.ValidateEmail(v, email); !v.Valid() {
 		return nil, e.ErrInvalidData
 	}
 
-	user, err := s.user.GetByCodAndEmail(cod, email)
+	user, err := s.user.GetByEmail(email)
+	if err != nil {
+		switch {
+		case errors.Is(err, e.ErrRecordNotFound):
+			v.AddError("code", "invalid validation code or email")
+			return nil, e.ErrInvalidData
+		default:
+			return nil, err
+		}
+	}
+
+	if user.Activated {
+		v.AddError("code", "invalid validation code or email")
+		return nil, e.ErrInvalidData
+	}
+
+	if user.CodIssuedAt == nil || time.Since(*user.CodIssuedAt) > s.config.Activation.CodeTTL {
+		v.AddError("code", "activation code has expired, please request a new one")
+		return nil, e.ErrInvalidData
+	}
 
+	// the attempt is counted before comparing so concurrent guesses can never
+	// exceed the configured limit
+	err = utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		return s.user.IncrementCodAttempts(tx, user, s.config.Activation.MaxAttempts)
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	if subtle.ConstantTimeEq(int32(user.Cod), int32(cod)) != 1 {
+		v.AddError("code", "invalid validation code or email")
+		return nil, e.ErrInvalidData
+	}
+
 	user.Activated = true
 	user.Cod = 0
+	user.CodIssuedAt = nil
+	user.CodAttempts = 0
 
 	if err = s.Update(user, v); err != nil {
 		return nil, err
@@ -65,6 +145,138 @@ func (s *userService) ActivateUser(cod int, email string, v *validator.Validator
 	return user, nil
 }
 
+func (s *userService) ResendActivationCode(email string, v *validator.Validator) error {
+	if models.ValidateEmail(v, email); !v.Valid() {
+		return e.ErrInvalidData
+	}
+
+	user, err := s.user.GetByEmail(email)
+	if err != nil {
+		switch {
+		case errors.Is(err, e.ErrRecordNotFound):
+			return nil
+		default:
+			return err
+		}
+	}
+
+	if user.Activated {
+		return nil
+	}
+
+	if user.CodIssuedAt != nil && time.Since(*user.CodIssuedAt) < activationResendInterval {
+		return nil
+	}
+
+	now := time.Now()
+	user.Cod = utils.GenerateRandomCode()
+	user.CodIssuedAt = &now
+
+	err = utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		return s.user.UpdateCodByEmail(tx, user)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.sendMail(user, "user_activation.tmpl", map[string]any{
+		"name":  user.Name,
+		"email": user.Email,
+		"code":  user.Cod,
+		"ttl":   s.config.Activation.CodeTTL.String(),
+	})
+	return nil
+}
+
+func (s *userService) RequestPasswordReset(email string, v *validator.Validator) error {
+	if models.ValidateEmail(v, email); !v.Valid() {
+		return e.ErrInvalidData
+	}
+
+	user, err := s.user.GetByEmail(email)
+	if err != nil {
+		switch {
+		case errors.Is(err, e.ErrRecordNotFound):
+			return nil
+		default:
+			return err
+		}
+	}
+
+	if !user.Activated {
+		return nil
+	}
+
+	token := models.GenerateToken(user.ID, s.config.Security.PasswordResetTTL, models.ScopePasswordReset)
+
+	err = utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		err := s.token.DeleteAllForUser(tx, models.ScopePasswordReset, user.ID)
+		if err != nil {
+			return err
+		}
+
+		return s.token.Insert(tx, token)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.sendMail(user, "password_reset.tmpl", map[string]any{
+		"name":  user.Name,
+		"token": token.Plaintext,
+		"ttl":   s.config.Security.PasswordResetTTL.String(),
+	})
+	return nil
+}
+
+func (s *userService) ResetPassword(tokenPlaintext, password string, v *validator.Validator) error {
+	models.ValidateTokenPlaintext(v, tokenPlaintext)
+	models.ValidatePasswordPlaintext(v, "password", password)
+
+	if !v.Valid() {
+		return e.ErrInvalidData
+	}
+
+	user, err := s.user.GetForToken(models.ScopePasswordReset, models.HashToken(tokenPlaintext))
+	if err != nil {
+		switch {
+		case errors.Is(err, e.ErrRecordNotFound):
+			v.AddError("token", "invalid or expired password reset token")
+			return e.ErrInvalidData
+		default:
+			return err
+		}
+	}
+
+	if err := s.validatePasswordStrength(v, "password", user, password); err != nil {
+		return err
+	}
+
+	if !v.Valid() {
+		return e.ErrInvalidData
+	}
+
+	if err := user.Password.Set(password); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	user.PasswordChangedAt = &now
+	user.TokensRevokedAt = &now
+
+	return utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		if err := s.user.Update(tx, user); err != nil {
+			return err
+		}
+
+		if err := s.token.DeleteAllForUser(tx, models.ScopeRefresh, user.ID); err != nil {
+			return err
+		}
+
+		return s.token.DeleteAllForUser(tx, models.ScopePasswordReset, user.ID)
+	})
+}
+
 func (s *userService) Update(user *models.User, v *validator.Validator) error {
 	return utils.RunInTx(s.db, func(tx *sql.Tx) error {
 		err := s.user.Update(tx, user)
@@ -75,6 +287,191 @@ func (s *userService) Update(user *models.User, v *validator.Validator) error {
 	})
 }
 
+func (s *userService) UpdateProfile(user *models.User, v *validator.Validator) error {
+	if user.ValidateUser(v); !v.Valid() {
+		return e.ErrInvalidData
+	}
+
+	return s.Update(user, v)
+}
+
+func (s *userService) ChangePassword(
+	user *models.User,
+	currentPassword,
+	newPassword string,
+	v *validator.Validator,
+) error {
+	v.Check(currentPassword != "", "current_password", "must be provided")
+	models.ValidatePasswordPlaintext(v, "new_password", newPassword)
+
+	if !v.Valid() {
+		return e.ErrInvalidData
+	}
+
+	if err := checkPassword(user, currentPassword, "current_password", v); err != nil {
+		return err
+	}
+
+	if err := s.validatePasswordStrength(v, "new_password", user, newPassword); err != nil {
+		return err
+	}
+
+	if !v.Valid() {
+		return e.ErrInvalidData
+	}
+
+	if err := user.Password.Set(newPassword); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	user.PasswordChangedAt = &now
+	user.TokensRevokedAt = &now
+
+	return utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		if err := s.user.Update(tx, user); err != nil {
+			return err
+		}
+
+		return s.token.DeleteAllForUser(tx, models.ScopeRefresh, user.ID)
+	})
+}
+
+func (s *userService) DeleteAccount(user *models.User, password string, v *validator.Validator) error {
+	if v.Check(password != "", "password", "must be provided"); !v.Valid() {
+		return e.ErrInvalidData
+	}
+
+	if err := checkPassword(user, password, "password", v); err != nil {
+		return err
+	}
+
+	return utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		if err := s.user.Delete(tx, user.ID); err != nil {
+			return err
+		}
+
+		return s.token.DeleteAllForUser(tx, models.ScopeRefresh, user.ID)
+	})
+}
+
+func (s *userService) RequestEmailChange(
+	user *models.User,
+	newEmail,
+	password string,
+	v *validator.Validator,
+) error {
+	models.ValidateEmail(v, newEmail)
+	v.Check(password != "", "password", "must be provided")
+	v.Check(!strings.EqualFold(newEmail, user.Email), "email", "must be different from the current email")
+
+	if !v.Valid() {
+		return e.ErrInvalidData
+	}
+
+	if err := checkPassword(user, password, "password", v); err != nil {
+		return err
+	}
+
+	_, err := s.user.GetByEmail(newEmail)
+	switch {
+	case err == nil:
+		return e.ValidationAlreadyExists("email")
+	case !errors.Is(err, e.ErrRecordNotFound):
+		return err
+	}
+
+	user.PendingEmail = &newEmail
+	token := models.GenerateToken(user.ID, s.config.Security.EmailChangeTTL, models.ScopeEmailChange)
+
+	err = utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		if err := s.user.Update(tx, user); err != nil {
+			return err
+		}
+
+		if err := s.token.DeleteAllForUser(tx, models.ScopeEmailChange, user.ID); err != nil {
+			return err
+		}
+
+		return s.token.Insert(tx, token)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.sendMailTo(newEmail, user.Locale, "email_change.tmpl", map[string]any{
+		"name":  user.Name,
+		"token": token.Plaintext,
+		"ttl":   s.config.Security.EmailChangeTTL.String(),
+	})
+
+	s.sendMail(user, "email_change_notice.tmpl", map[string]any{
+		"name":     user.Name,
+		"newEmail": newEmail,
+	})
+	return nil
+}
+
+func (s *userService) ConfirmEmailChange(tokenPlaintext string, v *validator.Validator) (*models.User, error) {
+	if models.ValidateTokenPlaintext(v, tokenPlaintext); !v.Valid() {
+		return nil, e.ErrInvalidData
+	}
+
+	user, err := s.user.GetForToken(models.ScopeEmailChange, models.HashToken(tokenPlaintext))
+	if err != nil {
+		switch {
+		case errors.Is(err, e.ErrRecordNotFound):
+			v.AddError("token", "invalid or expired email change token")
+			return nil, e.ErrInvalidData
+		default:
+			return nil, err
+		}
+	}
+
+	if user.PendingEmail == nil {
+		v.AddError("token", "invalid or expired email change token")
+		return nil, e.ErrInvalidData
+	}
+
+	now := time.Now()
+	user.Email = *user.PendingEmail
+	user.PendingEmail = nil
+	user.TokensRevokedAt = &now
+
+	err = utils.RunInTx(s.db, func(tx *sql.Tx) error {
+		if err := s.user.Update(tx, user); err != nil {
+			return err
+		}
+
+		for _, scope := range []string{models.ScopeEmailChange, models.ScopePasswordReset, models.ScopeRefresh} {
+			if err := s.token.DeleteAllForUser(tx, scope, user.ID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func checkPassword(user *models.User, password, field string, v *validator.Validator) error {
+	match, err := user.Password.Matches(password)
+	if err != nil {
+		return err
+	}
+
+	if !match {
+		v.AddError(field, "is incorrect")
+		return e.ErrInvalidData
+	}
+
+	return nil
+}
+
 func (s *userService) GetUserByCodAndEmail(cod int, email string, v *validator.Validator) (*models.User, error) {
 	user, err := s.user.GetByCodAndEmail(cod, email)
 	if err != nil {
@@ -91,13 +488,56 @@ func (s *userService) GetUserByCodAndEmail(cod int, email string, v *validator.V
 }
 
 func (s *userService) Save(user *models.User, v *validator.Validator) error {
-	return utils.RunInTx(s.db, func(tx *sql.Tx) error {
+	err := utils.RunInTx(s.db, func(tx *sql.Tx) error {
 		if user.ValidateUser(v); !v.Valid() {
 			return e.ErrInvalidData
 		}
+
+		if err := s.validatePasswordStrength(v, "password", user, *user.Password.Plaintext); err != nil {
+			return err
+		}
+
+		if !v.Valid() {
+			return e.ErrInvalidData
+		}
+
+		now := time.Now()
 		user.Cod = utils.GenerateRandomCode()
+		user.CodIssuedAt = &now
 		return s.user.Insert(tx, user)
 	})
+	if err != nil {
+		return err
+	}
+
+	s.sendMail(user, "user_welcome.tmpl", map[string]any{
+		"name":  user.Name,
+		"email": user.Email,
+		"code":  user.Cod,
+		"ttl":   s.config.Activation.CodeTTL.String(),
+	})
+	return nil
+}
+
+// validatePasswordStrength runs the password policy for a password that
+// already passed ValidatePasswordPlaintext, reporting under key.
+func (s *userService) validatePasswordStrength(v *validator.Validator, key string, user *models.User, password string) error {
+	return s.policy.Validate(v, key, password, user.Name, user.Email)
+}
+
+func (s *userService) sendMail(user *models.User, templateFile string, data map[string]any) {
+	s.sendMailTo(user.Email, user.Locale, templateFile, data)
+}
+
+func (s *userService) sendMailTo(recipient, locale, templateFile string, data map[string]any) {
+	background(s.wg, s.logger, func() {
+		err := s.mailer.Send(recipient, locale, templateFile, data)
+		if err != nil {
+			s.logger.PrintError(err, map[string]string{
+				"template": templateFile,
+			})
+		}
+	})
 }
 
 func (s *userService) Delete(idUser uuid.UUID) error {
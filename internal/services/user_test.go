@@ -0,0 +1,555 @@
+package services
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"io"
+	"moodtracker/internal/jsonlog"
+	"moodtracker/internal/models"
+	"moodtracker/internal/passwords"
+	"moodtracker/internal/repositories"
+	e "moodtracker/utils/errors"
+	"moodtracker/utils/validator"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type fakeTokenRepository struct {
+	repositories.TokenRepository
+	tokens  []*models.Token
+	deleted []string
+}
+
+func (r *fakeTokenRepository) Insert(tx *sql.Tx, token *models.Token) error {
+	r.tokens = append(r.tokens, token)
+	return nil
+}
+
+func (r *fakeTokenRepository) DeleteAllForUser(tx *sql.Tx, scope string, userID uuid.UUID) error {
+	r.deleted = append(r.deleted, scope)
+	r.tokens = slices.DeleteFunc(r.tokens, func(token *models.Token) bool {
+		return token.Scope == scope && token.UserID == userID
+	})
+	return nil
+}
+
+// GetForToken follows the repository contract: only unexpired tokens of the
+// scope are found.
+func (r *fakeUserRepository) GetForToken(scope string, tokenHash []byte) (*models.User, error) {
+	for _, token := range r.tokens.tokens {
+		if token.Scope == scope && bytes.Equal(token.Hash, tokenHash) && token.Expiry.After(time.Now()) {
+			return r.GetByID(token.UserID)
+		}
+	}
+	return nil, e.ErrRecordNotFound
+}
+
+type fakeMailer struct {
+	mu         sync.Mutex
+	sent       []map[string]any
+	recipients map[string]string
+}
+
+func (m *fakeMailer) Send(recipient, locale, templateFile string, data any) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.recipients == nil {
+		m.recipients = map[string]string{}
+	}
+	m.recipients[templateFile] = recipient
+	m.sent = append(m.sent, data.(map[string]any))
+	return nil
+}
+
+func TestChangePassword(t *testing.T) {
+	const current = "correct horse battery staple"
+
+	user := &models.User{ID: uuid.New(), Name: "Ana Souza", Email: "ana.souza@example.com"}
+	if err := user.Password.Set(current); err != nil {
+		t.Fatal(err)
+	}
+	hash := user.Password.Hash
+
+	tests := []struct {
+		name            string
+		currentPassword string
+		newPassword     string
+		field           string
+	}{
+		{"missing current password", "", "V3ry-Str0ng&Unusual!", "current_password"},
+		{"wrong current password", "wrong password", "V3ry-Str0ng&Unusual!", "current_password"},
+		{"missing new password", current, "", "new_password"},
+		{"short new password", current, "Ab1!", "new_password"},
+		{"new password too long", current, strings.Repeat("x", 1025), "new_password"},
+		{"guessable new password", current, "aaaaaaaaaaaa", "new_password"},
+		{"new password with the user name", current, "souza-V3ry-Str0ng&Unusual!", "new_password"},
+		{"valid new password", current, "V3ry-Str0ng&Unusual!", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user.Password.Hash = hash
+			user.TokensRevokedAt = nil
+
+			users := &fakeUserRepository{users: []*models.User{user}}
+			tokens := &fakeTokenRepository{}
+			s := &userService{
+				user:   users,
+				token:  tokens,
+				policy: &passwords.Policy{MinEntropy: 50},
+				db:     newTestDB(t),
+			}
+
+			v := validator.New()
+			err := s.ChangePassword(user, tt.currentPassword, tt.newPassword, v)
+
+			if tt.field != "" {
+				if !errors.Is(err, e.ErrInvalidData) {
+					t.Fatalf("ChangePassword returned %v, want ErrInvalidData", err)
+				}
+				if _, ok := v.Errors[tt.field]; !ok || len(v.Errors) != 1 {
+					t.Errorf("errors = %v, want one for %q", v.Errors, tt.field)
+				}
+				if len(users.updated) != 0 {
+					t.Error("the user was updated")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ChangePassword returned %v", err)
+			}
+
+			if match, _ := user.Password.Matches(tt.newPassword); !match {
+				t.Error("the new password does not match")
+			}
+			if user.TokensRevokedAt == nil || len(users.updated) != 1 {
+				t.Error("the change was not saved with the tokens revoked")
+			}
+			if len(tokens.deleted) != 1 || tokens.deleted[0] != models.ScopeRefresh {
+				t.Errorf("deleted tokens of scopes %v, want the refresh tokens", tokens.deleted)
+			}
+		})
+	}
+}
+
+// IncrementCodAttempts follows the repository contract: the attempt is only
+// counted while the user is below the limit.
+func (r *fakeUserRepository) IncrementCodAttempts(tx *sql.Tx, user *models.User, maxAttempts int) error {
+	if user.CodAttempts >= maxAttempts {
+		return e.ErrTooManyAttempts
+	}
+
+	user.CodAttempts++
+	return nil
+}
+
+func TestActivateUser(t *testing.T) {
+	const code = 123456
+
+	issuedAt := func(ago time.Duration) *time.Time {
+		t := time.Now().Add(-ago)
+		return &t
+	}
+
+	tests := []struct {
+		name      string
+		user      models.User
+		email     string
+		code      int
+		err       error
+		field     string
+		attempts  int
+		activated bool
+	}{
+		{
+			name:      "valid code",
+			user:      models.User{Cod: code, CodIssuedAt: issuedAt(time.Minute)},
+			code:      code,
+			activated: true,
+		},
+		{
+			name:      "valid code on the last attempt",
+			user:      models.User{Cod: code, CodIssuedAt: issuedAt(time.Minute), CodAttempts: 4},
+			code:      code,
+			activated: true,
+		},
+		{
+			name:     "wrong code",
+			user:     models.User{Cod: code, CodIssuedAt: issuedAt(time.Minute), CodAttempts: 2},
+			code:     654321,
+			err:      e.ErrInvalidData,
+			field:    "code",
+			attempts: 3,
+		},
+		{
+			name:     "valid code after the attempts ran out",
+			user:     models.User{Cod: code, CodIssuedAt: issuedAt(time.Minute), CodAttempts: 5},
+			code:     code,
+			err:      e.ErrTooManyAttempts,
+			attempts: 5,
+		},
+		{
+			name:  "expired code",
+			user:  models.User{Cod: code, CodIssuedAt: issuedAt(16 * time.Minute)},
+			code:  code,
+			err:   e.ErrInvalidData,
+			field: "code",
+		},
+		{
+			name:  "code without an issue date",
+			user:  models.User{Cod: code},
+			code:  code,
+			err:   e.ErrInvalidData,
+			field: "code",
+		},
+		{
+			name:      "account already activated",
+			user:      models.User{Activated: true},
+			code:      0,
+			err:       e.ErrInvalidData,
+			field:     "code",
+			activated: true,
+		},
+		{
+			name:  "unknown email",
+			user:  models.User{Cod: code, CodIssuedAt: issuedAt(time.Minute)},
+			email: "nobody@example.com",
+			code:  code,
+			err:   e.ErrInvalidData,
+			field: "code",
+		},
+		{
+			name:  "invalid email",
+			user:  models.User{Cod: code, CodIssuedAt: issuedAt(time.Minute)},
+			email: "not an email",
+			code:  code,
+			err:   e.ErrInvalidData,
+			field: "email",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := tt.user
+			user.ID = uuid.New()
+			user.Email = "ana@example.com"
+
+			email := tt.email
+			if email == "" {
+				email = user.Email
+			}
+
+			users := &fakeUserRepository{users: []*models.User{&user}}
+			s := &userService{user: users, db: newTestDB(t)}
+			s.config.Activation.CodeTTL = 15 * time.Minute
+			s.config.Activation.MaxAttempts = 5
+
+			v := validator.New()
+			_, err := s.ActivateUser(tt.code, email, v)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ActivateUser returned %v, want %v", err, tt.err)
+			}
+
+			if _, ok := v.Errors[tt.field]; tt.field != "" && !ok {
+				t.Errorf("errors = %v, want one for %q", v.Errors, tt.field)
+			}
+
+			if user.Activated != tt.activated {
+				t.Errorf("activated = %v, want %v", user.Activated, tt.activated)
+			}
+
+			if tt.activated && !tt.user.Activated {
+				if user.Cod != 0 || user.CodIssuedAt != nil || user.CodAttempts != 0 || len(users.updated) != 1 {
+					t.Errorf("the activation code was not cleared and saved: %+v", user)
+				}
+				return
+			}
+
+			if user.CodAttempts != tt.attempts {
+				t.Errorf("attempts = %d, want %d", user.CodAttempts, tt.attempts)
+			}
+			if len(users.updated) != 0 {
+				t.Error("the user was updated")
+			}
+		})
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	const newPassword = "V3ry-Str0ng&Unusual!"
+
+	user := &models.User{ID: uuid.New(), Name: "Ana Souza", Email: "ana@example.com", Activated: true}
+	if err := user.Password.Set("correct horse battery staple"); err != nil {
+		t.Fatal(err)
+	}
+
+	tokens := &fakeTokenRepository{}
+	users := &fakeUserRepository{users: []*models.User{user}, tokens: tokens}
+	mailer := &fakeMailer{}
+	wg := &sync.WaitGroup{}
+
+	s := &userService{
+		user:   users,
+		token:  tokens,
+		policy: &passwords.Policy{MinEntropy: 50},
+		db:     newTestDB(t),
+		mailer: mailer,
+		wg:     wg,
+		logger: jsonlog.New(io.Discard, jsonlog.LevelOff),
+	}
+	s.config.Security.PasswordResetTTL = time.Hour
+
+	if err := s.RequestPasswordReset(user.Email, validator.New()); err != nil {
+		t.Fatalf("RequestPasswordReset returned %v", err)
+	}
+	wg.Wait()
+
+	if len(mailer.sent) != 1 || len(tokens.tokens) != 1 {
+		t.Fatalf("sent %d emails and stored %d tokens, want one of each", len(mailer.sent), len(tokens.tokens))
+	}
+	plaintext := mailer.sent[0]["token"].(string)
+
+	if string(tokens.tokens[0].Hash) == plaintext {
+		t.Error("the token was stored in plaintext")
+	}
+
+	expired := models.GenerateToken(user.ID, -time.Minute, models.ScopePasswordReset)
+	otherScope := models.GenerateToken(user.ID, time.Hour, models.ScopeEmailChange)
+	tokens.tokens = append(tokens.tokens, expired, otherScope)
+
+	// The steps run in order: a used token can't be used again.
+	steps := []struct {
+		name     string
+		token    string
+		password string
+		err      error
+		field    string
+	}{
+		{"malformed token", "short", newPassword, e.ErrInvalidData, "token"},
+		{"unknown token", strings.Repeat("A", 26), newPassword, e.ErrInvalidData, "token"},
+		{"expired token", expired.Plaintext, newPassword, e.ErrInvalidData, "token"},
+		{"token of another scope", otherScope.Plaintext, newPassword, e.ErrInvalidData, "token"},
+		{"guessable password", plaintext, "aaaaaaaaaaaa", e.ErrInvalidData, "password"},
+		{"valid token", plaintext, newPassword, nil, ""},
+		{"used token", plaintext, newPassword, e.ErrInvalidData, "token"},
+	}
+
+	for _, tt := range steps {
+		v := validator.New()
+
+		err := s.ResetPassword(tt.token, tt.password, v)
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("%s: ResetPassword returned %v, want %v", tt.name, err, tt.err)
+		}
+		if _, ok := v.Errors[tt.field]; tt.field != "" && !ok {
+			t.Errorf("%s: errors = %v, want one for %q", tt.name, v.Errors, tt.field)
+		}
+	}
+
+	if match, _ := user.Password.Matches(newPassword); !match {
+		t.Error("the new password does not match")
+	}
+	if user.PasswordChangedAt == nil || user.TokensRevokedAt == nil || len(users.updated) != 1 {
+		t.Error("the reset was not saved with the tokens revoked")
+	}
+	if !slices.Contains(tokens.deleted, models.ScopeRefresh) {
+		t.Errorf("deleted tokens of scopes %v, want the refresh tokens among them", tokens.deleted)
+	}
+}
+
+func TestRequestPasswordResetIsSilent(t *testing.T) {
+	inactive := &models.User{ID: uuid.New(), Email: "bia@example.com"}
+
+	tests := []struct {
+		name  string
+		email string
+	}{
+		{"unknown email", "nobody@example.com"},
+		{"inactive account", inactive.Email},
+	}
+
+	for _, tt := range tests {
+		tokens := &fakeTokenRepository{}
+		mailer := &fakeMailer{}
+		s := &userService{
+			user:   &fakeUserRepository{users: []*models.User{inactive}},
+			token:  tokens,
+			db:     newTestDB(t),
+			mailer: mailer,
+			wg:     &sync.WaitGroup{},
+		}
+
+		if err := s.RequestPasswordReset(tt.email, validator.New()); err != nil {
+			t.Errorf("%s: RequestPasswordReset returned %v", tt.name, err)
+		}
+		s.wg.Wait()
+
+		if len(tokens.tokens) != 0 || len(mailer.sent) != 0 {
+			t.Errorf("%s: a reset token was issued", tt.name)
+		}
+	}
+}
+
+func (r *fakeUserRepository) Delete(tx *sql.Tx, idUser uuid.UUID) error {
+	r.users = slices.DeleteFunc(r.users, func(user *models.User) bool { return user.ID == idUser })
+	return nil
+}
+
+func TestDeleteAccount(t *testing.T) {
+	const current = "correct horse battery staple"
+
+	tests := []struct {
+		name     string
+		password string
+		field    string
+	}{
+		{"missing password", "", "password"},
+		{"wrong password", "wrong password", "password"},
+		{"correct password", current, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &models.User{ID: uuid.New(), Email: "ana@example.com", Activated: true}
+			if err := user.Password.Set(current); err != nil {
+				t.Fatal(err)
+			}
+
+			users := &fakeUserRepository{users: []*models.User{user}}
+			tokens := &fakeTokenRepository{}
+			s := &userService{user: users, token: tokens, db: newTestDB(t)}
+
+			v := validator.New()
+			err := s.DeleteAccount(user, tt.password, v)
+
+			if tt.field != "" {
+				if !errors.Is(err, e.ErrInvalidData) {
+					t.Fatalf("DeleteAccount returned %v, want ErrInvalidData", err)
+				}
+				if _, ok := v.Errors[tt.field]; !ok {
+					t.Errorf("errors = %v, want one for %q", v.Errors, tt.field)
+				}
+				if len(users.users) != 1 || len(tokens.deleted) != 0 {
+					t.Error("the account was deleted")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("DeleteAccount returned %v", err)
+			}
+			if len(users.users) != 0 || !slices.Equal(tokens.deleted, []string{models.ScopeRefresh}) {
+				t.Errorf("the account was not deleted with its refresh tokens: %v", tokens.deleted)
+			}
+		})
+	}
+}
+
+func TestChangeEmail(t *testing.T) {
+	const current = "correct horse battery staple"
+
+	user := &models.User{ID: uuid.New(), Email: "ana@example.com", Activated: true}
+	if err := user.Password.Set(current); err != nil {
+		t.Fatal(err)
+	}
+	other := &models.User{ID: uuid.New(), Email: "bia@example.com", Activated: true}
+
+	tokens := &fakeTokenRepository{}
+	users := &fakeUserRepository{users: []*models.User{user, other}, tokens: tokens}
+	mailer := &fakeMailer{}
+
+	s := &userService{
+		user:   users,
+		token:  tokens,
+		db:     newTestDB(t),
+		mailer: mailer,
+		wg:     &sync.WaitGroup{},
+		logger: jsonlog.New(io.Discard, jsonlog.LevelOff),
+	}
+	s.config.Security.EmailChangeTTL = time.Hour
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		err      error
+		field    string
+	}{
+		{"invalid email", "ana.example.com", current, e.ErrInvalidData, "email"},
+		{"same email", "ANA@example.com", current, e.ErrInvalidData, "email"},
+		{"missing password", "ana.souza@example.com", "", e.ErrInvalidData, "password"},
+		{"wrong password", "ana.souza@example.com", "wrong password", e.ErrInvalidData, "password"},
+		{"email of another account", "bia@example.com", current, e.ValidationAlreadyExists("email"), ""},
+		{"new email", "ana.souza@example.com", current, nil, ""},
+	}
+
+	for _, tt := range tests {
+		v := validator.New()
+
+		// ValidationAlreadyExists is not a sentinel, so it is compared by message.
+		err := s.RequestEmailChange(user, tt.email, tt.password, v)
+		if !errors.Is(err, tt.err) && (err == nil || tt.err == nil || err.Error() != tt.err.Error()) {
+			t.Fatalf("%s: RequestEmailChange returned %v, want %v", tt.name, err, tt.err)
+		}
+		if _, ok := v.Errors[tt.field]; tt.field != "" && !ok {
+			t.Errorf("%s: errors = %v, want one for %q", tt.name, v.Errors, tt.field)
+		}
+	}
+	s.wg.Wait()
+
+	if user.Email != "ana@example.com" || user.PendingEmail == nil || *user.PendingEmail != "ana.souza@example.com" {
+		t.Fatalf("email = %q, pending = %v; want the change to wait for confirmation", user.Email, user.PendingEmail)
+	}
+
+	want := map[string]string{
+		"email_change.tmpl":        "ana.souza@example.com",
+		"email_change_notice.tmpl": "ana@example.com",
+	}
+	if len(mailer.recipients) != 2 || mailer.recipients["email_change.tmpl"] != want["email_change.tmpl"] ||
+		mailer.recipients["email_change_notice.tmpl"] != want["email_change_notice.tmpl"] {
+		t.Fatalf("emails sent to %v, want %v", mailer.recipients, want)
+	}
+
+	var plaintext string
+	for _, data := range mailer.sent {
+		if token, ok := data["token"].(string); ok {
+			plaintext = token
+		}
+	}
+
+	// The steps run in order: a used token can't be used again.
+	steps := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"malformed token", "short", e.ErrInvalidData},
+		{"unknown token", strings.Repeat("A", 26), e.ErrInvalidData},
+		{"valid token", plaintext, nil},
+		{"used token", plaintext, e.ErrInvalidData},
+	}
+
+	for _, tt := range steps {
+		v := validator.New()
+
+		if _, err := s.ConfirmEmailChange(tt.token, v); !errors.Is(err, tt.err) {
+			t.Fatalf("%s: ConfirmEmailChange returned %v, want %v", tt.name, err, tt.err)
+		}
+		if _, ok := v.Errors["token"]; tt.err != nil && !ok {
+			t.Errorf("%s: errors = %v, want one for token", tt.name, v.Errors)
+		}
+	}
+
+	if user.Email != "ana.souza@example.com" || user.PendingEmail != nil || user.TokensRevokedAt == nil {
+		t.Errorf("email = %q, pending = %v, revoked at %v; want the new email with the tokens revoked",
+			user.Email, user.PendingEmail, user.TokensRevokedAt)
+	}
+}
@@ -0,0 +1,212 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"moodtracker/internal/config"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrUnknownKeyID     = errors.New("unknown signing key id")
+	ErrUnsupportedKey   = errors.New("unsupported signing key type")
+	ErrNoSigningKeys    = errors.New("no signing keys configured")
+	ErrUnknownAlgorithm = errors.New("unknown signing algorithm")
+)
+
+type key struct {
+	id        string
+	signKey   any
+	verifyKey any
+}
+
+type KeySet struct {
+	method jwt.SigningMethod
+	active *key
+	keys   map[string]*key
+	order  []string
+}
+
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// New loads the signing keys from JWT_KEYS ("kid:value,kid:value"). For HS256
+// each value is a shared secret, for EdDSA and RS256 it is the path of a PEM
+// encoded private key. SECRET_KEY is used as the "default" HS256 key when no
+// keys are configured.
+func New(cfg config.Config) (*KeySet, error) {
+	ks := &KeySet{
+		keys: make(map[string]*key),
+	}
+
+	switch cfg.Security.JWTAlgorithm {
+	case "", jwt.SigningMethodHS256.Alg():
+		ks.method = jwt.SigningMethodHS256
+	case jwt.SigningMethodEdDSA.Alg():
+		ks.method = jwt.SigningMethodEdDSA
+	case jwt.SigningMethodRS256.Alg():
+		ks.method = jwt.SigningMethodRS256
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAlgorithm, cfg.Security.JWTAlgorithm)
+	}
+
+	entries := cfg.Security.JWTKeys
+	if entries == "" && ks.method == jwt.SigningMethodHS256 && cfg.Security.SecretKey != "" {
+		entries = "default:" + cfg.Security.SecretKey
+	}
+
+	for entry := range strings.SplitSeq(entries, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		id, value, ok := strings.Cut(entry, ":")
+		if !ok || id == "" || value == "" {
+			return nil, fmt.Errorf("invalid signing key entry %q, expected kid:value", entry)
+		}
+
+		k, err := ks.parseKey(id, value)
+		if err != nil {
+			return nil, err
+		}
+
+		ks.keys[id] = k
+		ks.order = append(ks.order, id)
+	}
+
+	if len(ks.order) == 0 {
+		return nil, ErrNoSigningKeys
+	}
+
+	activeID := cfg.Security.JWTActiveKeyID
+	if activeID == "" {
+		activeID = ks.order[0]
+	}
+
+	active, ok := ks.keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKeyID, activeID)
+	}
+	ks.active = active
+
+	return ks, nil
+}
+
+func (ks *KeySet) parseKey(id, value string) (*key, error) {
+	if ks.method == jwt.SigningMethodHS256 {
+		return &key{
+			id:        id,
+			signKey:   []byte(value),
+			verifyKey: []byte(value),
+		}, nil
+	}
+
+	data, err := os.ReadFile(value)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s: no PEM data found", id)
+	}
+
+	var private any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("signing key %s: %w", id, err)
+	}
+
+	switch private := private.(type) {
+	case ed25519.PrivateKey:
+		if ks.method != jwt.SigningMethodEdDSA {
+			return nil, fmt.Errorf("signing key %s: %w", id, ErrUnsupportedKey)
+		}
+		return &key{id: id, signKey: private, verifyKey: private.Public()}, nil
+	case *rsa.PrivateKey:
+		if ks.method != jwt.SigningMethodRS256 {
+			return nil, fmt.Errorf("signing key %s: %w", id, ErrUnsupportedKey)
+		}
+		return &key{id: id, signKey: private, verifyKey: &private.PublicKey}, nil
+	default:
+		return nil, fmt.Errorf("signing key %s: %w", id, ErrUnsupportedKey)
+	}
+}
+
+func (ks *KeySet) Algorithm() string {
+	return ks.method.Alg()
+}
+
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.method, claims)
+	token.Header["kid"] = ks.active.id
+	return token.SignedString(ks.active.signKey)
+}
+
+func (ks *KeySet) Keyfunc(token *jwt.Token) (any, error) {
+	id, ok := token.Header["kid"].(string)
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+
+	k, ok := ks.keys[id]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+
+	return k.verifyKey, nil
+}
+
+// JWKS returns the public verification keys. Shared HS256 secrets are never
+// published, so the set is empty in that mode.
+func (ks *KeySet) JWKS() []JWK {
+	jwks := []JWK{}
+
+	for _, id := range ks.order {
+		switch public := ks.keys[id].verifyKey.(type) {
+		case ed25519.PublicKey:
+			jwks = append(jwks, JWK{
+				KeyType:   "OKP",
+				KeyID:     id,
+				Use:       "sig",
+				Algorithm: ks.method.Alg(),
+				Curve:     "Ed25519",
+				X:         base64.RawURLEncoding.EncodeToString(public),
+			})
+		case *rsa.PublicKey:
+			jwks = append(jwks, JWK{
+				KeyType:   "RSA",
+				KeyID:     id,
+				Use:       "sig",
+				Algorithm: ks.method.Alg(),
+				N:         base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		}
+	}
+
+	return jwks
+}
@@ -0,0 +1,260 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"moodtracker/internal/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func writeKey(t *testing.T, pemType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: pemType, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func ed25519Key(t *testing.T) (string, ed25519.PublicKey) {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return writeKey(t, "PRIVATE KEY", der), public
+}
+
+func rsaKey(t *testing.T) (string, *rsa.PublicKey) {
+	t.Helper()
+
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return writeKey(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(private)), &private.PublicKey
+}
+
+func securityConfig(algorithm, keys, activeID, secret string) config.Config {
+	var cfg config.Config
+	cfg.Security.JWTAlgorithm = algorithm
+	cfg.Security.JWTKeys = keys
+	cfg.Security.JWTActiveKeyID = activeID
+	cfg.Security.SecretKey = secret
+	return cfg
+}
+
+func TestNew(t *testing.T) {
+	edPath, _ := ed25519Key(t)
+	rsaPath, _ := rsaKey(t)
+	garbage := writeKey(t, "PRIVATE KEY", []byte("not a key"))
+
+	tests := []struct {
+		name   string
+		config config.Config
+		alg    string
+		active string
+		err    error
+	}{
+		{"secret key as the default key", securityConfig("", "", "", "secret"), "HS256", "default", nil},
+		{"first key is active", securityConfig("HS256", "2026-01:one, 2026-02:two", "", "secret"), "HS256", "2026-01", nil},
+		{"chosen active key", securityConfig("HS256", "2026-01:one,2026-02:two", "2026-02", ""), "HS256", "2026-02", nil},
+		{"EdDSA key file", securityConfig("EdDSA", "ed:"+edPath, "", ""), "EdDSA", "ed", nil},
+		{"RS256 key file", securityConfig("RS256", "rsa:"+rsaPath, "", ""), "RS256", "rsa", nil},
+		{"unknown algorithm", securityConfig("none", "", "", "secret"), "", "", ErrUnknownAlgorithm},
+		{"no keys", securityConfig("HS256", "", "", ""), "", "", ErrNoSigningKeys},
+		{"secret key is not used for EdDSA", securityConfig("EdDSA", "", "", "secret"), "", "", ErrNoSigningKeys},
+		{"unknown active key", securityConfig("HS256", "one:secret", "two", ""), "", "", ErrUnknownKeyID},
+		{"EdDSA key for RS256", securityConfig("RS256", "ed:"+edPath, "", ""), "", "", ErrUnsupportedKey},
+		{"RSA key for EdDSA", securityConfig("EdDSA", "rsa:"+rsaPath, "", ""), "", "", ErrUnsupportedKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks, err := New(tt.config)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("New returned %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+
+			if ks.Algorithm() != tt.alg || ks.active.id != tt.active {
+				t.Errorf("got %s with key %q, want %s with key %q", ks.Algorithm(), ks.active.id, tt.alg, tt.active)
+			}
+		})
+	}
+
+	invalid := []struct {
+		name   string
+		config config.Config
+	}{
+		{"entry without a key id", securityConfig("HS256", ":secret", "", "")},
+		{"entry without a value", securityConfig("HS256", "one:", "", "")},
+		{"entry without a separator", securityConfig("HS256", "secret", "", "")},
+		{"missing key file", securityConfig("EdDSA", "ed:"+filepath.Join(t.TempDir(), "missing.pem"), "", "")},
+		{"file without PEM data", securityConfig("EdDSA", "ed:"+os.DevNull, "", "")},
+		{"PEM block without a key", securityConfig("EdDSA", "ed:"+garbage, "", "")},
+	}
+
+	for _, tt := range invalid {
+		if _, err := New(tt.config); err == nil {
+			t.Errorf("%s: New returned no error", tt.name)
+		}
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	edOld, _ := ed25519Key(t)
+	edNew, _ := ed25519Key(t)
+	rsaOld, _ := rsaKey(t)
+	rsaNew, _ := rsaKey(t)
+
+	tests := []struct {
+		alg      string
+		old, new string
+	}{
+		{"HS256", "old-secret", "new-secret"},
+		{"EdDSA", edOld, edNew},
+		{"RS256", rsaOld, rsaNew},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			before, err := New(securityConfig(tt.alg, "old:"+tt.old, "", ""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			during, err := New(securityConfig(tt.alg, "old:"+tt.old+",new:"+tt.new, "new", ""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			after, err := New(securityConfig(tt.alg, "new:"+tt.new, "", ""))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			claims := jwt.RegisteredClaims{
+				Subject:   "user",
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			}
+
+			oldToken, err := before.Sign(claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			newToken, err := during.Sign(claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			verify := func(ks *KeySet, token string) error {
+				_, err := jwt.Parse(token, ks.Keyfunc, jwt.WithValidMethods([]string{ks.Algorithm()}))
+				return err
+			}
+
+			checks := []struct {
+				name  string
+				ks    *KeySet
+				token string
+				err   error
+			}{
+				{"old token before the rotation", before, oldToken, nil},
+				{"old token during the rotation", during, oldToken, nil},
+				{"new token during the rotation", during, newToken, nil},
+				{"new token after the rotation", after, newToken, nil},
+				{"old token after the rotation", after, oldToken, ErrUnknownKeyID},
+				{"new token before the rotation", before, newToken, ErrUnknownKeyID},
+			}
+
+			for _, c := range checks {
+				if err := verify(c.ks, c.token); !errors.Is(err, c.err) {
+					t.Errorf("%s: verify returned %v, want %v", c.name, err, c.err)
+				}
+			}
+		})
+	}
+}
+
+func TestKeyfuncWithoutKeyID(t *testing.T) {
+	ks, err := New(securityConfig("HS256", "one:secret", "", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A token signed with the right secret but no kid header is refused.
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := jwt.Parse(token, ks.Keyfunc); !errors.Is(err, ErrUnknownKeyID) {
+		t.Errorf("Parse returned %v, want ErrUnknownKeyID", err)
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	edPath, edPublic := ed25519Key(t)
+	rsaPath, rsaPublic := rsaKey(t)
+
+	hs, err := New(securityConfig("HS256", "one:secret", "", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jwks := hs.JWKS(); len(jwks) != 0 {
+		t.Errorf("HS256 published %d keys, want none", len(jwks))
+	}
+
+	ed, err := New(securityConfig("EdDSA", "ed:"+edPath, "", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := JWK{
+		KeyType:   "OKP",
+		KeyID:     "ed",
+		Use:       "sig",
+		Algorithm: "EdDSA",
+		Curve:     "Ed25519",
+		X:         base64.RawURLEncoding.EncodeToString(edPublic),
+	}
+	if jwks := ed.JWKS(); len(jwks) != 1 || jwks[0] != want {
+		t.Errorf("EdDSA JWKS = %+v, want %+v", jwks, want)
+	}
+
+	rs, err := New(securityConfig("RS256", "rsa:"+rsaPath, "", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = JWK{
+		KeyType:   "RSA",
+		KeyID:     "rsa",
+		Use:       "sig",
+		Algorithm: "RS256",
+		N:         base64.RawURLEncoding.EncodeToString(rsaPublic.N.Bytes()),
+		E:         "AQAB",
+	}
+	if jwks := rs.JWKS(); len(jwks) != 1 || jwks[0] != want {
+		t.Errorf("RS256 JWKS = %+v, want %+v", jwks, want)
+	}
+}
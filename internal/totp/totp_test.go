@@ -0,0 +1,144 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 key of the RFC 4226 and RFC 6238 test vectors,
+// "12345678901234567890", in base32.
+var rfcSecret = encoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeRFC4226(t *testing.T) {
+	// RFC 4226, appendix D.
+	want := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+
+	for counter, code := range want {
+		got, err := Code(rfcSecret, int64(counter))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != code {
+			t.Errorf("Code(counter %d) = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestCodeRFC6238(t *testing.T) {
+	// RFC 6238, appendix B, SHA1 mode. The RFC uses 8 digits; the 6 digit
+	// codes are the last 6 digits of those.
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Counter(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.code {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestCodeLowercaseSecret(t *testing.T) {
+	upper, _ := Code("JBSWY3DPEHPK3PXP", 1)
+	lower, err := Code("jbswy3dpehpk3pxp", 1)
+	if err != nil || lower != upper {
+		t.Errorf("lowercase secret gave %q, %v, want %q", lower, err, upper)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Counter(now)
+
+	code := func(counter int64) string {
+		c, err := Code(rfcSecret, counter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		secret  string
+		code    string
+		ok      bool
+		counter int64
+	}{
+		{"current step", rfcSecret, code(current), true, current},
+		{"previous step", rfcSecret, code(current - 1), true, current - 1},
+		{"next step", rfcSecret, code(current + 1), true, current + 1},
+		{"two steps behind", rfcSecret, code(current - 2), false, 0},
+		{"two steps ahead", rfcSecret, code(current + 2), false, 0},
+		{"wrong code", rfcSecret, "000000", false, 0},
+		{"too short", rfcSecret, code(current)[:5], false, 0},
+		{"too long", rfcSecret, code(current) + "0", false, 0},
+		{"empty", rfcSecret, "", false, 0},
+		{"invalid secret", "not base32!", code(current), false, 0},
+	}
+
+	for _, tt := range tests {
+		counter, ok := Validate(tt.secret, tt.code, now)
+		if ok != tt.ok || counter != tt.counter {
+			t.Errorf("%s: Validate = (%d, %v), want (%d, %v)", tt.name, counter, ok, tt.counter, tt.ok)
+		}
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret := GenerateSecret()
+
+	key, err := encoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret %q is not base32: %v", secret, err)
+	}
+	if len(key) != secretSize {
+		t.Errorf("secret has %d bytes, want %d", len(key), secretSize)
+	}
+
+	if GenerateSecret() == secret {
+		t.Error("GenerateSecret returned the same secret twice")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	raw := ProvisioningURI("Mood Tracker", "ana@example.com", "JBSWY3DPEHPK3PXP")
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Mood Tracker:ana@example.com" {
+		t.Errorf("unexpected URI %s", raw)
+	}
+
+	want := map[string]string{
+		"secret":    "JBSWY3DPEHPK3PXP",
+		"issuer":    "Mood Tracker",
+		"algorithm": "SHA1",
+		"digits":    "6",
+		"period":    "30",
+	}
+	for name, value := range want {
+		if got := u.Query().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS cod_issued_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS cod_attempts INTEGER NOT NULL DEFAULT 0;
+
+UPDATE users SET cod_issued_at = NOW() WHERE activated = false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+    DROP COLUMN IF EXISTS cod_issued_at,
+    DROP COLUMN IF EXISTS cod_attempts;
+-- +goose StatementEnd
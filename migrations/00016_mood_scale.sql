@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- RUIM, MEDIO and BOM keep their labels on the 5-point scale
+-- (PESSIMO, RUIM, MEDIO, BOM, OTIMO). Logs saved with 0, the old value for
+-- input that matched no mood, stay at 0 and are shown as unknown instead of
+-- becoming PESSIMO.
+UPDATE day_logs SET mood_label = mood_label + 1 WHERE mood_label <> 0;
+
+ALTER TABLE day_logs
+    ADD CONSTRAINT day_logs_mood_label_check CHECK (mood_label BETWEEN 0 AND 10);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE day_logs
+    DROP CONSTRAINT IF EXISTS day_logs_mood_label_check;
+
+UPDATE day_logs SET mood_label = CASE
+    WHEN mood_label = 0 THEN 0
+    WHEN mood_label <= 2 THEN 1
+    WHEN mood_label = 3 THEN 2
+    ELSE 3
+END;
+-- +goose StatementEnd
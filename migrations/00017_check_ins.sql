@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS time_zone TEXT NOT NULL DEFAULT 'UTC';
+
+ALTER TABLE day_logs
+    ADD COLUMN IF NOT EXISTS logged_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS time_zone TEXT,
+    ADD CONSTRAINT day_logs_check_in_time_zone CHECK ((logged_at IS NULL) = (time_zone IS NULL));
+
+-- Only the daily entry (no logged_at) stays unique per user and date;
+-- check-ins can be recorded any number of times a day.
+DROP INDEX IF EXISTS uniq_day_logs_user_date;
+
+CREATE UNIQUE INDEX uniq_day_logs_user_date
+ON day_logs (user_id, date)
+WHERE deleted = false AND logged_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_day_logs_user_logged_at
+ON day_logs (user_id, logged_at)
+WHERE deleted = false AND logged_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Check-ins can't coexist with the once-per-day index, so they are
+-- soft-deleted before it is restored.
+UPDATE day_logs SET deleted = true WHERE logged_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_day_logs_user_logged_at;
+DROP INDEX IF EXISTS uniq_day_logs_user_date;
+
+CREATE UNIQUE INDEX uniq_day_logs_user_date
+ON day_logs (user_id, date)
+WHERE deleted = false;
+
+ALTER TABLE day_logs
+    DROP CONSTRAINT IF EXISTS day_logs_check_in_time_zone,
+    DROP COLUMN IF EXISTS time_zone,
+    DROP COLUMN IF EXISTS logged_at;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS time_zone;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE day_logs
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+ALTER TABLE tags
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Rows deleted before the trash existed get a full retention period from now.
+UPDATE day_logs SET deleted_at = NOW() WHERE deleted = true;
+UPDATE tags SET deleted_at = NOW() WHERE deleted = true;
+
+CREATE INDEX IF NOT EXISTS idx_day_logs_trash
+ON day_logs (user_id, deleted_at)
+WHERE deleted = true;
+
+CREATE INDEX IF NOT EXISTS idx_tags_trash
+ON tags (user_id, deleted_at)
+WHERE deleted = true;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_tags_trash;
+DROP INDEX IF EXISTS idx_day_logs_trash;
+
+ALTER TABLE tags
+    DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE day_logs
+    DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
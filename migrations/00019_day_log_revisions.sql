@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Each row is a day log as it was at version, saved right before the edit
+-- that replaced it. The current version lives in day_logs.
+CREATE TABLE day_log_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+    log_id UUID NOT NULL REFERENCES day_logs(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+
+    date DATE NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    mood_label SMALLINT NOT NULL,
+    logged_at TIMESTAMPTZ,
+    time_zone TEXT,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+
+    edited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    edited_at TIMESTAMPTZ NOT NULL,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uniq_day_log_revisions_version UNIQUE (log_id, version)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS day_log_revisions;
+-- +goose StatementEnd
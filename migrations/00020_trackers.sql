@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE trackers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('integer', 'decimal', 'boolean', 'duration')),
+    unit TEXT NOT NULL DEFAULT '',
+    min_value DOUBLE PRECISION,
+    max_value DOUBLE PRECISION,
+
+    user_id UUID NOT NULL REFERENCES users(id),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ,
+    deleted BOOLEAN NOT NULL DEFAULT false,
+
+    created_by UUID REFERENCES users(id),
+    updated_by UUID REFERENCES users(id),
+
+    version INT NOT NULL DEFAULT 1,
+
+    CONSTRAINT trackers_range CHECK (min_value IS NULL OR max_value IS NULL OR min_value <= max_value)
+);
+
+CREATE UNIQUE INDEX uniq_trackers_name_user
+ON trackers (LOWER(name), user_id)
+WHERE deleted = false;
+
+-- Durations are stored in minutes and booleans as 0 or 1, so every kind can be
+-- averaged and correlated with the mood.
+CREATE TABLE log_tracker_values (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+    log_id UUID NOT NULL REFERENCES day_logs(id) ON DELETE CASCADE,
+    tracker_id UUID NOT NULL REFERENCES trackers(id) ON DELETE CASCADE,
+    value DOUBLE PRECISION NOT NULL,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE (log_id, tracker_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_log_tracker_values_tracker
+ON log_tracker_values (tracker_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS log_tracker_values;
+DROP TABLE IF EXISTS trackers;
+-- +goose StatementEnd
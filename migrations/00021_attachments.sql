@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The content lives in the blob store under storage_key. Rows are removed by
+-- the trash purge only after their blob is gone, so the foreign key doesn't
+-- cascade: purging a log that still has files fails instead of leaking them.
+CREATE TABLE attachments (
+    id UUID PRIMARY KEY,
+
+    log_id UUID NOT NULL REFERENCES day_logs(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+
+    storage_key TEXT NOT NULL UNIQUE,
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size BIGINT NOT NULL CHECK (size > 0),
+    checksum TEXT NOT NULL,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_log
+ON attachments (log_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS attachments;
+-- +goose StatementEnd
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE reminders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+    time_of_day TEXT NOT NULL CHECK (time_of_day ~ '^([01][0-9]|2[0-3]):[0-5][0-9]$'),
+    time_zone TEXT NOT NULL,
+    -- 0 is Sunday; empty means every day.
+    weekdays SMALLINT[] NOT NULL DEFAULT '{}',
+    skip_if_logged BOOLEAN NOT NULL DEFAULT true,
+    channel TEXT NOT NULL,
+    webhook_url TEXT NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT true,
+
+    next_run_at TIMESTAMPTZ NOT NULL,
+    last_sent_at TIMESTAMPTZ,
+
+    user_id UUID NOT NULL REFERENCES users(id),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ,
+    deleted BOOLEAN NOT NULL DEFAULT false,
+
+    created_by UUID REFERENCES users(id),
+    updated_by UUID REFERENCES users(id),
+
+    version INT NOT NULL DEFAULT 1
+);
+
+CREATE INDEX IF NOT EXISTS idx_reminders_user
+ON reminders (user_id)
+WHERE deleted = false;
+
+-- The dispatcher polls this index for reminders that are due.
+CREATE INDEX IF NOT EXISTS idx_reminders_due
+ON reminders (next_run_at)
+WHERE deleted = false AND enabled = true;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS reminders;
+-- +goose StatementEnd
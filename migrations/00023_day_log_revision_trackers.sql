@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The tracker values of the log at that version, with the tracker name, kind
+-- and unit of the time. NULL on revisions saved before this column existed.
+ALTER TABLE day_log_revisions ADD COLUMN IF NOT EXISTS trackers JSONB;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE day_log_revisions DROP COLUMN IF EXISTS trackers;
+-- +goose StatementEnd
@@ -28,23 +28,34 @@ type ErrorHandlerInterface interface {
 	InvalidCredentialsResponse(w http.ResponseWriter, r *http.Request)
 	InvalidRoleResponse(w http.ResponseWriter, r *http.Request)
 	RateLimitExceededResponse(w http.ResponseWriter, r *http.Request)
+	TooManyAttemptsResponse(w http.ResponseWriter, r *http.Request)
+	AccountLockedResponse(w http.ResponseWriter, r *http.Request)
 	ServerErrorResponse(w http.ResponseWriter, r *http.Request, err error)
 	NotFoundResponse(w http.ResponseWriter, r *http.Request)
 	MethodNotAllowedResponse(w http.ResponseWriter, r *http.Request)
 	BadRequestResponse(w http.ResponseWriter, r *http.Request, err error)
 	FailedValidationResponse(w http.ResponseWriter, r *http.Request, errors map[string]string)
 	EditConflictResponse(w http.ResponseWriter, r *http.Request)
+	PreconditionRequiredResponse(w http.ResponseWriter, r *http.Request)
+	PreconditionFailedResponse(w http.ResponseWriter, r *http.Request)
+	InvalidSignatureResponse(w http.ResponseWriter, r *http.Request)
 	HandlerError(w http.ResponseWriter, r *http.Request, err error, v *validator.Validator)
+	LogError(r *http.Request, err error)
 }
 
 var (
 	ErrRecordNotFound           = errors.New("record not found")
 	ErrEditConflict             = errors.New("edit conflict")
+	ErrPreconditionRequired     = errors.New("precondition required")
+	ErrPreconditionFailed       = errors.New("precondition failed")
+	ErrInvalidSignature         = errors.New("invalid or expired signature")
 	ErrInvalidData              = errors.New("invalid data")
 	ErrInvalidCredentials       = errors.New("invalid authentication credentials")
 	ErrInactiveAccount          = errors.New("your user account must be activated to access this resource")
 	ErrStartDateAfterEndDate    = errors.New("start date must be before end date")
 	ErrInvalidRole              = errors.New("invalid role")
+	ErrTooManyAttempts          = errors.New("too many failed attempts")
+	ErrAccountLocked            = errors.New("too many failed login attempts")
 	ErrScanModel                = errors.New("dest must be a pointer")
 	ErrUnsupportedTypeScanModel = errors.New("unsupported slice type for db scan")
 )
@@ -61,6 +72,15 @@ func (e *errorHandler) HandlerError(w http.ResponseWriter, r *http.Request, err
 	case errors.Is(err, ErrEditConflict):
 		e.EditConflictResponse(w, r)
 
+	case errors.Is(err, ErrPreconditionRequired):
+		e.PreconditionRequiredResponse(w, r)
+
+	case errors.Is(err, ErrPreconditionFailed):
+		e.PreconditionFailedResponse(w, r)
+
+	case errors.Is(err, ErrInvalidSignature):
+		e.InvalidSignatureResponse(w, r)
+
 	case errors.Is(err, ErrInactiveAccount):
 		e.InactiveAccountResponse(w, r)
 
@@ -70,6 +90,12 @@ func (e *errorHandler) HandlerError(w http.ResponseWriter, r *http.Request, err
 	case errors.Is(err, ErrInvalidCredentials):
 		e.InvalidCredentialsResponse(w, r)
 
+	case errors.Is(err, ErrTooManyAttempts):
+		e.TooManyAttemptsResponse(w, r)
+
+	case errors.Is(err, ErrAccountLocked):
+		e.AccountLockedResponse(w, r)
+
 	case len(strings.Split(err.Error(), "->")) > 1:
 		parts := strings.Split(err.Error(), "->")
 		for i := 0; i+1 < len(parts); i += 2 {
@@ -122,6 +148,16 @@ func (e *errorHandler) RateLimitExceededResponse(w http.ResponseWriter, r *http.
 	e.errorHandler(w, r, http.StatusTooManyRequests, message)
 }
 
+func (e *errorHandler) TooManyAttemptsResponse(w http.ResponseWriter, r *http.Request) {
+	message := "too many failed attempts, please request a new code"
+	e.errorHandler(w, r, http.StatusTooManyRequests, message)
+}
+
+func (e *errorHandler) AccountLockedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "too many failed login attempts, please try again later"
+	e.errorHandler(w, r, http.StatusTooManyRequests, message)
+}
+
 func (e *errorHandler) ServerErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
 	e.logError(r, err)
 	message := "the server encountered a problem and could not process your request"
@@ -151,6 +187,21 @@ func (e *errorHandler) EditConflictResponse(w http.ResponseWriter, r *http.Reque
 	e.errorHandler(w, r, http.StatusConflict, message)
 }
 
+func (e *errorHandler) PreconditionRequiredResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the If-Match header with the record version is required"
+	e.errorHandler(w, r, http.StatusPreconditionRequired, message)
+}
+
+func (e *errorHandler) PreconditionFailedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the record has been modified since the version in If-Match, fetch it and try again"
+	e.errorHandler(w, r, http.StatusPreconditionFailed, message)
+}
+
+func (e *errorHandler) InvalidSignatureResponse(w http.ResponseWriter, r *http.Request) {
+	message := "this link is invalid or has expired, request a new one"
+	e.errorHandler(w, r, http.StatusForbidden, message)
+}
+
 func (e *errorHandler) errorHandler(w http.ResponseWriter, r *http.Request, status int, message any) {
 	env := utils.Envelope{"error": message}
 	err := utils.WriteJSON(w, status, env, nil)
@@ -160,6 +211,12 @@ func (e *errorHandler) errorHandler(w http.ResponseWriter, r *http.Request, stat
 	}
 }
 
+// LogError records an error that can no longer be sent to the client, such as
+// one hit after the response has started.
+func (e *errorHandler) LogError(r *http.Request, err error) {
+	e.logError(r, err)
+}
+
 func (e *errorHandler) logError(r *http.Request, err error) {
 	e.logger.PrintError(err, map[string]string{
 		"request_method": r.Method,
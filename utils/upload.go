@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+var ErrUploadTooLarge = errors.New("upload is too large")
+
+// multipartOverhead is the room left for part headers and boundaries on top
+// of the file size limit.
+const multipartOverhead = 64 << 10
+
+// Upload is a file read from a multipart request. It is spooled to a
+// temporary file so large bodies never sit in memory; Close removes it.
+type Upload struct {
+	File         *os.File
+	FileName     string
+	DeclaredType string
+	SniffedType  string
+	Size         int64
+	Checksum     string
+}
+
+func (u *Upload) Close() error {
+	u.File.Close()
+	return os.Remove(u.File.Name())
+}
+
+// ReadMultipartFile streams the first file sent in field, skipping any other
+// part. Unlike ReadJSON the body is never held in memory, and the limit is
+// maxBytes for the file itself.
+func ReadMultipartFile(
+	w http.ResponseWriter,
+	r *http.Request,
+	field string,
+	maxBytes int64,
+) (*Upload, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+multipartOverhead)
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, errors.New("body must be multipart/form-data")
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, fmt.Errorf("body must contain a file in the %q field", field)
+		}
+		if err != nil {
+			return nil, uploadError(err)
+		}
+
+		if part.FormName() != field {
+			continue
+		}
+
+		if part.FileName() == "" {
+			return nil, fmt.Errorf("the %q field must be a file", field)
+		}
+
+		return spool(part, part.FileName(), part.Header.Get("Content-Type"), maxBytes)
+	}
+}
+
+func spool(src io.Reader, fileName, declaredType string, maxBytes int64) (*Upload, error) {
+	tmp, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return nil, err
+	}
+
+	upload := &Upload{
+		File:         tmp,
+		FileName:     fileName,
+		DeclaredType: declaredType,
+	}
+
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(src, maxBytes+1))
+	if err == nil && n > maxBytes {
+		err = ErrUploadTooLarge
+	}
+	if err != nil {
+		upload.Close()
+		return nil, uploadError(err)
+	}
+
+	head := make([]byte, 512)
+	m, err := tmp.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		upload.Close()
+		return nil, err
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		upload.Close()
+		return nil, err
+	}
+
+	upload.Size = n
+	upload.Checksum = hex.EncodeToString(hash.Sum(nil))
+	upload.SniffedType = http.DetectContentType(head[:m])
+
+	return upload, nil
+}
+
+func uploadError(err error) error {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return ErrUploadTooLarge
+	}
+
+	return err
+}
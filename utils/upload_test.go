@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"mime/multipart"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReadMultipartFile(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 32)
+
+	type part struct {
+		field, fileName, contentType, content string
+	}
+
+	tests := []struct {
+		name    string
+		parts   []part
+		raw     string
+		want    string
+		sniffed string
+		err     string
+	}{
+		{"file", []part{{"file", "photo.png", "image/png", png}}, "", png, "image/png", ""},
+		{"other fields first", []part{{"note", "", "", "hi"}, {"file", "photo.png", "image/png", png}}, "", png, "image/png", ""},
+		{"text file", []part{{"file", "a.txt", "image/png", "just text"}}, "", "just text", "text/plain; charset=utf-8", ""},
+		{"at the limit", []part{{"file", "a.bin", "", strings.Repeat("a", 64)}}, "", strings.Repeat("a", 64), "text/plain; charset=utf-8", ""},
+		{"too large", []part{{"file", "a.bin", "", strings.Repeat("a", 65)}}, "", "", "", ErrUploadTooLarge.Error()},
+		{"no file", []part{{"note", "", "", "hi"}}, "", "", "", `body must contain a file in the "file" field`},
+		{"field is not a file", []part{{"file", "", "", "hi"}}, "", "", "", `the "file" field must be a file`},
+		{"not multipart", nil, `{"file":"x"}`, "", "", "body must be multipart/form-data"},
+	}
+
+	for _, tt := range tests {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		for _, p := range tt.parts {
+			if p.fileName == "" {
+				mw.WriteField(p.field, p.content)
+				continue
+			}
+			header := make(map[string][]string)
+			header["Content-Disposition"] = []string{`form-data; name="` + p.field + `"; filename="` + p.fileName + `"`}
+			if p.contentType != "" {
+				header["Content-Type"] = []string{p.contentType}
+			}
+			w, _ := mw.CreatePart(header)
+			w.Write([]byte(p.content))
+		}
+		mw.Close()
+
+		r := httptest.NewRequest("POST", "/v1/daylogs/1/attachments", &body)
+		r.Header.Set("Content-Type", mw.FormDataContentType())
+		if tt.raw != "" {
+			r = httptest.NewRequest("POST", "/v1/daylogs/1/attachments", strings.NewReader(tt.raw))
+			r.Header.Set("Content-Type", "application/json")
+		}
+
+		upload, err := ReadMultipartFile(httptest.NewRecorder(), r, "file", 64)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%s: err = %v, want %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+
+		sum := sha256.Sum256([]byte(tt.want))
+		if upload.Size != int64(len(tt.want)) || upload.Checksum != hex.EncodeToString(sum[:]) || upload.SniffedType != tt.sniffed {
+			t.Errorf("%s: upload = %+v", tt.name, upload)
+		}
+
+		content, _ := os.ReadFile(upload.File.Name())
+		if string(content) != tt.want {
+			t.Errorf("%s: spooled %q, want %q", tt.name, content, tt.want)
+		}
+
+		name := upload.File.Name()
+		upload.Close()
+		if _, err := os.Stat(name); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s: temporary file left after Close: %v", tt.name, err)
+		}
+	}
+}
@@ -1,13 +1,15 @@
 package utils
 
 import (
+	"bytes"
+	"crypto/rand"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"maps"
-	"math/rand"
+	"math/big"
 	"moodtracker/utils/validator"
 	"net/http"
 	"net/url"
@@ -90,6 +92,24 @@ func ReadStringParam(r *http.Request, key, defaultValue string) string {
 	return s
 }
 
+func ReadCSVParam(r *http.Request, key string, defaultValue []string) []string {
+	qs := r.URL.Query()
+	csv := qs.Get(key)
+
+	if csv == "" {
+		return defaultValue
+	}
+
+	values := []string{}
+	for value := range strings.SplitSeq(csv, ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func ReadDate(qs url.Values, key string, layout string) *time.Time {
 	s := qs.Get(key)
 	if s == "" {
@@ -103,6 +123,22 @@ func ReadDate(qs url.Values, key string, layout string) *time.Time {
 	return &t
 }
 
+func ReadBoolParam(r *http.Request, key string, v *validator.Validator) *bool {
+	qs := r.URL.Query()
+	s := qs.Get(key)
+
+	if s == "" {
+		return nil
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		v.AddError(key, "must be a boolean value")
+		return nil
+	}
+	return &b
+}
+
 func ReadIntParam(r *http.Request, key string, defaultValue int, v *validator.Validator) int {
 	qs := r.URL.Query()
 	s := qs.Get(key)
@@ -130,36 +166,11 @@ func ReadJSON(
 	dec := json.NewDecoder(r.Body)
 	dec.DisallowUnknownFields()
 
-	err := dec.Decode(dst)
-	if err != nil {
-		var syntaxError *json.SyntaxError
-		var unmarshalTypeError *json.UnmarshalTypeError
-		var invalidUnmarshalError *json.InvalidUnmarshalError
-
-		switch {
-		case errors.As(err, &syntaxError):
-			return fmt.Errorf("body contains badly-formed JSON (at character %d)", syntaxError.Offset)
-		case errors.As(err, &unmarshalTypeError):
-			if unmarshalTypeError.Field != "" {
-				return fmt.Errorf("body contains incorrect JSON type for field %q", unmarshalTypeError.Field)
-			}
-			return fmt.Errorf("body contains incorrect JSON type (at character %d)", unmarshalTypeError.Offset)
-
-		case errors.Is(err, io.EOF):
-			return errors.New("body must not be empty")
-		case strings.HasPrefix(err.Error(), "json: unknown field "):
-			fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
-			return fmt.Errorf("body contains unknown key %s", fieldName)
-		case err.Error() == "http: request body too large":
-			return fmt.Errorf("body must not be larger than %d bytes", maxBytes)
-		case errors.As(err, &invalidUnmarshalError):
-			panic(err)
-		default:
-			return err
-		}
+	if err := dec.Decode(dst); err != nil {
+		return jsonDecodeError(err, maxBytes)
 	}
 
-	err = dec.Decode(&struct{}{})
+	err := dec.Decode(&struct{}{})
 	if err != io.EOF {
 		return errors.New("body must only contain a single JSON value")
 	}
@@ -167,6 +178,99 @@ func ReadJSON(
 	return nil
 }
 
+// DecodeJSON decodes data into dst with the same rules and error messages as
+// ReadJSON, for bodies that were already read.
+func DecodeJSON(data []byte, dst any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		return jsonDecodeError(err, len(data))
+	}
+
+	return nil
+}
+
+func jsonDecodeError(err error, maxBytes int) error {
+	var syntaxError *json.SyntaxError
+	var unmarshalTypeError *json.UnmarshalTypeError
+	var invalidUnmarshalError *json.InvalidUnmarshalError
+
+	switch {
+	case errors.As(err, &syntaxError):
+		return fmt.Errorf("body contains badly-formed JSON (at character %d)", syntaxError.Offset)
+	case errors.As(err, &unmarshalTypeError):
+		if unmarshalTypeError.Field != "" {
+			return fmt.Errorf("body contains incorrect JSON type for field %q", unmarshalTypeError.Field)
+		}
+		return fmt.Errorf("body contains incorrect JSON type (at character %d)", unmarshalTypeError.Offset)
+
+	case errors.Is(err, io.EOF):
+		return errors.New("body must not be empty")
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		return fmt.Errorf("body contains unknown key %s", fieldName)
+	case err.Error() == "http: request body too large":
+		return fmt.Errorf("body must not be larger than %d bytes", maxBytes)
+	case errors.As(err, &invalidUnmarshalError):
+		panic(err)
+	default:
+		return err
+	}
+}
+
+// MergePatch applies an RFC 7396 merge patch to target: members of patch
+// replace the ones in target, objects are merged recursively and null removes
+// the member.
+func MergePatch(target, patch map[string]any) map[string]any {
+	if target == nil {
+		target = make(map[string]any)
+	}
+
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+
+		if object, ok := value.(map[string]any); ok {
+			current, _ := target[key].(map[string]any)
+			target[key] = MergePatch(current, object)
+			continue
+		}
+
+		target[key] = value
+	}
+
+	return target
+}
+
+// ReadIfMatchVersion reads the record version from the If-Match header. Both
+// quoted ("3", W/"3") and bare (3) entity tags are accepted.
+func ReadIfMatchVersion(r *http.Request) (int, bool, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return 0, false, nil
+	}
+
+	version, err := ETagVersion(header)
+	return version, true, err
+}
+
+// ETagVersion reads the record version at the start of an entity tag. Tags
+// that also hash related data carry it as W/"3-<hash>".
+func ETagVersion(etag string) (int, error) {
+	tag := strings.Trim(strings.TrimPrefix(etag, "W/"), `"`)
+	tag, _, _ = strings.Cut(tag, "-")
+
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		return 0, errors.New("If-Match header must contain the record version")
+	}
+
+	return version, nil
+}
+
 func GetTypeName(v any) string {
 	t := reflect.TypeOf(v)
 	if t.Kind() == reflect.Pointer {
@@ -192,7 +296,11 @@ func WriteJSON(w http.ResponseWriter, status int, data Envelope, headers http.He
 }
 
 func GenerateRandomCode() int {
-	return rand.Intn(900000) + 100000
+	n, err := rand.Int(rand.Reader, big.NewInt(900000))
+	if err != nil {
+		panic(err)
+	}
+	return int(n.Int64()) + 100000
 }
 
 func RunInTx(db *sql.DB, fn func(tx *sql.Tx) error) error {
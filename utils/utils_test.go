@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"encoding/json"
+	"moodtracker/utils/validator"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestReadCSVParam(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"default"}},
+		{"?tags=", []string{"default"}},
+		{"?tags=work", []string{"work"}},
+		{"?tags=work,gym", []string{"work", "gym"}},
+		{"?tags=%20work%20,%20gym", []string{"work", "gym"}},
+		{"?tags=work,,gym,", []string{"work", "gym"}},
+		{"?tags=,", []string{}},
+		{"?other=work", []string{"default"}},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/v1/daylogs"+tt.query, nil)
+		if got := ReadCSVParam(r, "tags", []string{"default"}); !slices.Equal(got, tt.want) {
+			t.Errorf("ReadCSVParam(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateRandomCode(t *testing.T) {
+	seen := map[int]bool{}
+
+	for range 1000 {
+		code := GenerateRandomCode()
+		if code < 100000 || code > 999999 {
+			t.Fatalf("GenerateRandomCode() = %d, want six digits", code)
+		}
+		seen[code] = true
+	}
+
+	if len(seen) < 990 {
+		t.Errorf("got %d distinct codes out of 1000", len(seen))
+	}
+}
+
+func TestReadBoolParam(t *testing.T) {
+	yes, no := true, false
+
+	tests := []struct {
+		query string
+		want  *bool
+		valid bool
+	}{
+		{"", nil, true},
+		{"?activated=", nil, true},
+		{"?activated=true", &yes, true},
+		{"?activated=1", &yes, true},
+		{"?activated=false", &no, true},
+		{"?activated=0", &no, true},
+		{"?activated=yes", nil, false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/v1/admin/users"+tt.query, nil)
+		v := validator.New()
+
+		got := ReadBoolParam(r, "activated", v)
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("ReadBoolParam(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+		if _, invalid := v.Errors["activated"]; invalid == tt.valid {
+			t.Errorf("ReadBoolParam(%q) errors = %v", tt.query, v.Errors)
+		}
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	// RFC 7396, appendix A, for the patches that are JSON objects.
+	tests := []struct {
+		target string
+		patch  string
+		want   string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`null`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{`{"a":"b"}`, `{"a":{"c":"d"}}`, `{"a":{"c":"d"}}`},
+		{`{"a":"b"}`, `{}`, `{"a":"b"}`},
+	}
+
+	decode := func(s string) map[string]any {
+		var object map[string]any
+		if err := json.Unmarshal([]byte(s), &object); err != nil {
+			t.Fatal(err)
+		}
+		return object
+	}
+
+	for _, tt := range tests {
+		got := MergePatch(decode(tt.target), decode(tt.patch))
+		if want := decode(tt.want); !reflect.DeepEqual(got, want) {
+			t.Errorf("MergePatch(%s, %s) = %v, want %v", tt.target, tt.patch, got, want)
+		}
+	}
+}
+
+func TestReadIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		header  string
+		version int
+		found   bool
+		valid   bool
+	}{
+		{"", 0, false, true},
+		{"   ", 0, false, true},
+		{`"3"`, 3, true, true},
+		{`W/"3"`, 3, true, true},
+		{"3", 3, true, true},
+		{` "12" `, 12, true, true},
+		{`W/"3-9f86d081884c7d65"`, 3, true, true},
+		{`"-3"`, 0, true, false},
+		{`"0"`, 0, true, false},
+		{`"-1"`, 0, true, false},
+		{`"abc"`, 0, true, false},
+		{"*", 0, true, false},
+		{`"3", "4"`, 0, true, false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("PATCH", "/v1/tags/1", nil)
+		r.Header.Set("If-Match", tt.header)
+
+		version, found, err := ReadIfMatchVersion(r)
+		if version != tt.version || found != tt.found || (err == nil) != tt.valid {
+			t.Errorf("ReadIfMatchVersion(%q) = (%d, %v, %v), want (%d, %v, valid %v)",
+				tt.header, version, found, err, tt.version, tt.found, tt.valid)
+		}
+	}
+}